@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/posener/complete"
+)
+
+// CacheHelpCommand exists solely to provide top level help for the cache
+// set of subcommands.
+type CacheHelpCommand struct {
+	*baseCommand
+}
+
+func (c *CacheHelpCommand) Run(args []string) int {
+	c.cmdKey = "cache"
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	c.ui.Info("The cache command requires one of the following subcommands: list, prune.")
+
+	return 0
+}
+
+func (c *CacheHelpCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *CacheHelpCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CacheHelpCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *CacheHelpCommand) Synopsis() string {
+	return "Inspect or prune the local cache of registries and packs."
+}
+
+func (c *CacheHelpCommand) Help() string {
+	return formatHelp(`
+	Usage: nomad-pack cache <subcommand> [options]
+
+	Inspect or prune the nomad-pack cache.
+	
+` + c.GetExample() + c.Flags().Help())
+}
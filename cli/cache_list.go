@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"github.com/posener/complete"
+)
+
+// CacheListCommand lists the registries, packs and refs held in the local
+// cache, along with their size on disk and when they were last fetched.
+type CacheListCommand struct {
+	*baseCommand
+	registry string
+	json     bool
+}
+
+// cacheListRegistry is a registry within the document output by the cache
+// list command when using --json.
+type cacheListRegistry struct {
+	Name  string          `json:"name"`
+	Path  string          `json:"path"`
+	Size  int64           `json:"size"`
+	Packs []cacheListPack `json:"packs"`
+}
+
+type cacheListPack struct {
+	Name        string    `json:"name"`
+	Ref         string    `json:"ref"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	LastFetched time.Time `json:"last_fetched"`
+}
+
+// newCacheList builds the --json document describing the cached registries.
+func newCacheList(registries []*cache.CachedRegistry) []cacheListRegistry {
+	out := make([]cacheListRegistry, 0, len(registries))
+	for _, r := range registries {
+		registry := cacheListRegistry{
+			Name:  r.Name,
+			Path:  r.Path,
+			Size:  r.Size,
+			Packs: make([]cacheListPack, 0, len(r.Packs)),
+		}
+		for _, p := range r.Packs {
+			registry.Packs = append(registry.Packs, cacheListPack{
+				Name:        p.Name,
+				Ref:         p.Ref,
+				Path:        p.Path,
+				Size:        p.Size,
+				LastFetched: p.LastFetched.UTC(),
+			})
+		}
+		out = append(out, registry)
+	}
+	return out
+}
+
+// cachedPackTable returns an empty table for listing cached packs.
+func cachedPackTable() *terminal.Table {
+	return terminal.NewTable("REGISTRY", "PACK NAME", "REF", "SIZE", "LAST FETCHED")
+}
+
+// cachedPackRow returns the columns of the cached pack table for the pack.
+func cachedPackRow(p *cache.CachedPack) []string {
+	return []string{
+		p.Registry,
+		p.Name,
+		p.Ref,
+		terminal.FormatBytes(p.Size),
+		p.LastFetched.Local().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// cacheListTable builds the table of cached packs. Registries without any
+// packs are shown with blank pack columns.
+func cacheListTable(registries []*cache.CachedRegistry) *terminal.Table {
+	table := cachedPackTable()
+	for _, r := range registries {
+		if len(r.Packs) == 0 {
+			table.Rich([]string{r.Name, "", "", terminal.FormatBytes(r.Size), ""}, nil)
+			continue
+		}
+		for _, p := range r.Packs {
+			table.Rich(cachedPackRow(p), nil)
+		}
+	}
+	return table
+}
+
+func (c *CacheListCommand) Run(args []string) int {
+	c.cmdKey = "cache list"
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	errorContext := errors.NewUIErrorContext()
+	errorContext.Add(errors.RegistryContextPrefixCachePath, c.cachePath())
+	if c.registry != "" {
+		errorContext.Add(errors.UIContextPrefixRegistryName, c.registry)
+	}
+
+	registries, err := cache.ListCached(c.cachePath(), c.registry)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to list cache", errorContext.GetAll()...)
+		return 1
+	}
+
+	if c.json {
+		out, err := json.MarshalIndent(newCacheList(registries), "", "  ")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to encode cache list", errorContext.GetAll()...)
+			return 1
+		}
+		c.ui.Output("%s", string(out))
+		return 0
+	}
+
+	c.ui.Table(cacheListTable(registries))
+	return 0
+}
+
+func (c *CacheListCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Cache Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.registry,
+			Default: "",
+			Usage:   `Only list the packs within the named registry.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "json",
+			Target:  &c.json,
+			Default: false,
+			Usage: `Output the cached registries and packs as a JSON document.
+Sizes are in bytes, and times are in RFC 3339 format.`,
+		})
+
+		c.cacheDirFlag(f)
+	})
+}
+
+func (c *CacheListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CacheListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *CacheListCommand) Synopsis() string {
+	return "List the registries and packs in the local cache."
+}
+
+func (c *CacheListCommand) Help() string {
+	c.Example = `
+	# List all cached registries and packs
+	nomad-pack cache list
+
+	# List the cached packs of the default registry as JSON
+	nomad-pack cache list --registry=default --json
+	`
+	return formatHelp(`
+	Usage: nomad-pack cache list [options]
+
+	List the registries, packs, and refs in the local cache, along with their
+	size on disk and when they were last fetched. These are the packs which
+	commands resolve against when using --offline.
+
+` + c.GetExample() + c.Flags().Help())
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"github.com/posener/complete"
+)
+
+// CachePruneCommand removes cached packs which are older than a duration or
+// at refs which are no longer referenced, so long-lived hosts such as CI
+// runners do not accumulate an ever growing cache.
+type CachePruneCommand struct {
+	*baseCommand
+	registry  string
+	olderThan time.Duration
+	keepRefs  []string
+	dryRun    bool
+}
+
+// validateCachePrune checks at least one of the flags selecting the packs to
+// prune is set, so the whole cache is not pruned by accident.
+func validateCachePrune(c *CachePruneCommand) error {
+	switch {
+	case c.olderThan < 0:
+		return stdErrors.New("--older-than must not be negative")
+	case c.olderThan == 0 && len(c.keepRefs) == 0:
+		return stdErrors.New("at least one of --older-than or --keep-ref is required")
+	}
+	return nil
+}
+
+func (c *CachePruneCommand) Run(args []string) int {
+	c.cmdKey = "cache prune"
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	if err := validateCachePrune(c); err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
+	errorContext := errors.NewUIErrorContext()
+	errorContext.Add(errors.RegistryContextPrefixCachePath, c.cachePath())
+	if c.registry != "" {
+		errorContext.Add(errors.UIContextPrefixRegistryName, c.registry)
+	}
+
+	registries, err := cache.ListCached(c.cachePath(), c.registry)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to list cache", errorContext.GetAll()...)
+		return 1
+	}
+
+	prunable := cache.PrunablePacks(registries, &cache.PruneOpts{
+		OlderThan: c.olderThan,
+		KeepRefs:  c.keepRefs,
+	}, time.Now())
+	if len(prunable) == 0 {
+		c.ui.Info("No cached packs to prune")
+		return 0
+	}
+
+	var size int64
+	table := cachedPackTable()
+	for _, p := range prunable {
+		size += p.Size
+		table.Rich(cachedPackRow(p), nil)
+	}
+
+	if c.dryRun {
+		c.ui.Info(fmt.Sprintf("The following %d pack(s) would be pruned, freeing %s:", len(prunable), terminal.FormatBytes(size)))
+		c.ui.Table(table)
+		c.ui.Info("Run with --dry-run=false to prune them")
+		return 0
+	}
+
+	c.ui.Warning(fmt.Sprintf("The following %d pack(s) will be pruned, freeing %s:", len(prunable), terminal.FormatBytes(size)))
+	c.ui.Table(table)
+
+	if !c.autoApproved {
+		if !c.ui.Interactive() {
+			c.ui.Warning("Skipped pruning the cache; use --auto-approve to prune")
+			return 0
+		}
+
+		prune, err := confirmPrompt(c.baseCommand, "Prune these packs? [y/n] ")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to confirm prune", errorContext.GetAll()...)
+			return 1
+		}
+		if !prune {
+			return 0
+		}
+	}
+
+	var freed int64
+	for _, p := range prunable {
+		if err := cache.RemoveCachedPack(c.cachePath(), p, c.logger()); err != nil {
+			packContext := errorContext.Copy()
+			packContext.Add(errors.UIContextPrefixPackName, cache.AppendRef(p.Name, p.Ref))
+			c.ui.ErrorWithContext(err, "failed to prune pack", packContext.GetAll()...)
+			c.ui.Info(fmt.Sprintf("Freed %s before the failure", terminal.FormatBytes(freed)))
+			return 1
+		}
+		freed += p.Size
+	}
+
+	c.ui.Success(fmt.Sprintf("Pruned %d pack(s), freeing %s", len(prunable), terminal.FormatBytes(freed)))
+	return 0
+}
+
+func (c *CachePruneCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Cache Options")
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "older-than",
+			Target: &c.olderThan,
+			Usage: `Prune the packs last fetched longer ago than the duration,
+                      such as 720h.`,
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "keep-ref",
+			Target: &c.keepRefs,
+			Usage: `Prune the packs at any ref other than those specified, such
+                      as the refs currently referenced by your jobs. Can be
+                      specified multiple times, or as a comma separated list.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.registry,
+			Default: "",
+			Usage:   `Only prune the packs within the named registry.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dry-run",
+			Target:  &c.dryRun,
+			Default: true,
+			Usage: `List the packs which would be pruned and the space which
+                      would be freed, without removing them. Set to false to
+                      prune the packs, which prompts for confirmation unless
+                      --auto-approve is set.`,
+		})
+
+		f.BoolVarP(&flag.BoolVarP{
+			BoolVar: &flag.BoolVar{
+				Name:    "auto-approve",
+				Target:  &c.autoApproved,
+				Default: false,
+				Usage:   `Automatically answer confirmation prompts in the affirmative.`,
+			},
+			Shorthand: "y",
+		})
+
+		c.cacheDirFlag(f)
+	})
+}
+
+func (c *CachePruneCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CachePruneCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *CachePruneCommand) Synopsis() string {
+	return "Remove old or unreferenced packs from the local cache."
+}
+
+func (c *CachePruneCommand) Help() string {
+	c.Example = `
+	# List the cached packs which were last fetched over 30 days ago
+	nomad-pack cache prune --older-than=720h
+
+	# Prune the cached packs at any ref other than v0.0.2 and latest
+	nomad-pack cache prune --keep-ref=v0.0.2 --keep-ref=latest --dry-run=false
+	`
+	return formatHelp(`
+	Usage: nomad-pack cache prune [options]
+
+	Remove the cached packs which were last fetched longer ago than a duration,
+	or which are at refs no longer referenced. By default this is a dry run,
+	which lists the packs which would be pruned.
+
+` + c.GetExample() + c.Flags().Help())
+}
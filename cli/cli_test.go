@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
@@ -157,6 +158,26 @@ func TestJobStop(t *testing.T) {
 	reset()
 }
 
+func TestJobStopAlreadyStopped(t *testing.T) {
+	testInit(t)
+
+	exitCode := runCmd().Run([]string{testPack})
+	require.Equal(t, 0, exitCode)
+
+	exitCode = stopCmd().Run([]string{testPack})
+	require.Equal(t, 0, exitCode)
+
+	// Stopping the pack again leaves the stopped jobs as they are, while
+	// destroying it purges them.
+	exitCode = stopCmd().Run([]string{testPack})
+	require.Equal(t, 0, exitCode)
+
+	exitCode = destroyCmd().Run([]string{testPack})
+	require.Equal(t, 0, exitCode)
+
+	reset()
+}
+
 func TestJobStopConflicts(t *testing.T) {
 	testInit(t)
 
@@ -411,3 +432,24 @@ func nomadExpectErr(t *testing.T, args ...string) {
 	err = nomadCmd.Run()
 	require.Error(t, err)
 }
+
+func Test_changeDir(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+
+	dir := t.TempDir()
+	file := path.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+
+	require.EqualError(t, changeDir(path.Join(dir, "missing")),
+		fmt.Sprintf("invalid --chdir %q: open %s: no such file or directory", path.Join(dir, "missing"), path.Join(dir, "missing")))
+	require.EqualError(t, changeDir(file), fmt.Sprintf("invalid --chdir %q: not a directory", file))
+
+	require.NoError(t, changeDir(dir))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Equal(t, resolved, cwd)
+}
@@ -8,8 +8,13 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -47,16 +52,85 @@ type baseCommand struct {
 	// flagPlain is whether the output should be in plain mode.
 	flagPlain bool
 
+	// logFormat is the --log-format flag value, which selects whether log
+	// messages are written as text or JSON. jsonLogger is set by Init when
+	// it is JSON; use logger to get the logger in effect.
+	logFormat  string
+	jsonLogger *logging.JSONLogger
+
+	// flagNoColor is whether the styling of terminal output is disabled.
+	// Commands opt in by registering the --no-color flag against it.
+	flagNoColor bool
+
+	// flagQuiet is whether all output other than errors is suppressed, with
+	// errors written to stderr. Commands opt in by registering the --quiet
+	// flag against it.
+	flagQuiet bool
+
+	// flagChdir is the directory the command is run from, set via --chdir.
+	flagChdir string
+
 	// flagLabels are set via -label if flagSetOperation is set.
 	flagLabels map[string]string
 
 	// vars sets values for defined input variables
 	vars map[string]string
 
+	// varMapValues are the raw values of the vars set using --var-map, so
+	// that varMapNames can tell which were not later replaced using --var.
+	varMapValues map[string]string
+
 	// varFiles is an HCL file(s) setting one or more values
 	// for defined input variables
 	varFiles []string
 
+	// profile is the name of the pack profile whose variable values are
+	// used, below those of varFiles and vars in precedence.
+	profile string
+
+	// metadataOverrides overrides fields of the pack metadata, keyed by the
+	// field such as pack.version.
+	metadataOverrides map[string]string
+
+	// fetchRetries and fetchRetryWait control retrying registry fetches
+	// which fail with a transient error.
+	fetchRetries   int
+	fetchRetryWait time.Duration
+
+	// registryMirrors are the --registry-mirror values, in the form
+	// registry=source. Use mirrorsFor to read the mirrors of a registry.
+	registryMirrors []string
+
+	// envPrefix is the prefix of the environment variables which set pack
+	// variables, unless noEnvVars is set or it is empty.
+	envPrefix string
+	noEnvVars bool
+
+	// strictVars makes environment variables which set undeclared pack
+	// variables an error, rather than a warning.
+	strictVars bool
+
+	// varFileMerge is the strategy used to merge the values set by variable
+	// files, which is one of variable.VarFileMerges.
+	varFileMerge string
+
+	// cacheDir overrides the directory of the cache containing registries.
+	// Use cachePath to read the directory in effect.
+	cacheDir string
+
+	// offline disables network access, so registries are only read from
+	// the local cache and never fetched.
+	offline bool
+
+	// nomadVariables enables the template functions which read Nomad
+	// variables from the cluster.
+	nomadVariables bool
+
+	// flagDelims is the --delims flag value, which is parsed into the
+	// leftDelim and rightDelim template action delimiters.
+	flagDelims            string
+	leftDelim, rightDelim string
+
 	// autoApproved is true when the user supplies the --auto-approve or -y flag
 	autoApproved bool
 
@@ -161,6 +235,14 @@ func (c *baseCommand) Init(opts ...Option) error {
 	}
 	c.args = baseCfg.Flags.Args()
 
+	// Change directory before anything else, so that the arguments and
+	// flag values which are paths resolve against the new directory.
+	if c.flagChdir != "" {
+		if err := changeDir(c.flagChdir); err != nil {
+			return err
+		}
+	}
+
 	// Do any validation after parsing
 	if baseCfg.Validation != nil {
 		err := baseCfg.Validation(c, c.args)
@@ -169,41 +251,108 @@ func (c *baseCommand) Init(opts ...Option) error {
 		}
 	}
 
-	// Reset the UI to plain if that was set
-	if c.flagPlain {
+	if c.flagDelims != "" {
+		if c.leftDelim, c.rightDelim, err = parseDelims(c.flagDelims); err != nil {
+			return err
+		}
+	}
+
+	// Reset the UI to plain if that was set. Reading variables from standard
+	// input consumes it, so the UI can no longer prompt the user either.
+	if c.flagPlain || c.varsFromStdin() {
 		c.ui = terminal.NonInteractiveUI(c.Ctx)
 	}
 
+	// The quiet UI is also non-interactive, so any prompts are declined.
+	if c.flagQuiet {
+		c.ui = terminal.QuietUI(c.Ctx)
+	}
+
+	// Styling is disabled when stdout is not a terminal, as the escape
+	// sequences would otherwise corrupt redirected output.
+	if c.flagNoColor || !terminal.IsTerminal(os.Stdout) {
+		terminal.DisableColor()
+	}
+
+	if err := c.initLogger(); err != nil {
+		return err
+	}
+
 	// Perform the cache ensure, but skip if we are running the version
-	// command.
-	if c.cmdKey != "version" {
+	// command, or a cache command which only inspects the cache as it is.
+	if c.cmdKey != "version" && c.cmdKey != "cache" && !strings.HasPrefix(c.cmdKey, "cache ") {
 		return c.ensureCache()
 	}
 
 	return nil
 }
 
+// changeDir changes the working directory to dir, which must be an existing
+// directory which can be read.
+func changeDir(dir string) error {
+	expanded, err := filesystem.ExpandPath(dir)
+	if err != nil {
+		return fmt.Errorf("invalid --chdir %q: %v", dir, err)
+	}
+
+	f, err := os.Open(expanded)
+	if err != nil {
+		return fmt.Errorf("invalid --chdir %q: %v", dir, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("invalid --chdir %q: %v", dir, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("invalid --chdir %q: not a directory", dir)
+	}
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid --chdir %q: %v", dir, err)
+	}
+
+	if err := os.Chdir(expanded); err != nil {
+		return fmt.Errorf("failed to change directory to %q: %v", dir, err)
+	}
+	return nil
+}
+
 func (c *baseCommand) ensureCache() error {
 	// Creates global cache
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
-		Logger: c.ui,
+		Path:   c.cachePath(),
+		Logger: c.logger(),
 	})
 	if err != nil {
 		return err
 	}
 
 	// Check if default registry exists
-	_, err = os.Stat(path.Join(cache.DefaultCachePath(), cache.DefaultRegistryName))
+	_, err = os.Stat(path.Join(c.cachePath(), cache.DefaultRegistryName))
 	// If it does not error, then the registry already exists
 	if err == nil {
 		return nil
 	}
 
+	// The default registry cannot be fetched when offline. Packs within it
+	// then fail verification as not being in the cache.
+	if c.offline {
+		return nil
+	}
+
+	mirrors, err := c.mirrorsFor(cache.DefaultRegistryName)
+	if err != nil {
+		return err
+	}
+
 	// Add the registry or registry target to the global cache
 	_, err = globalCache.Add(&cache.AddOpts{
-		RegistryName: cache.DefaultRegistryName,
-		Source:       cache.DefaultRegistrySource,
+		RegistryName:   cache.DefaultRegistryName,
+		Source:         cache.DefaultRegistrySource,
+		FetchRetries:   c.fetchRetries,
+		FetchRetryWait: c.fetchRetryWait,
+		Mirrors:        mirrors,
 	})
 	if err != nil {
 		return err
@@ -216,7 +365,30 @@ func (c *baseCommand) ensureCache() error {
 func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 	set := flag.NewSets()
 	{
-		// f := set.NewSet("Global Options")
+		f := set.NewSet("Global Options")
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "log-format",
+			Target:  &c.logFormat,
+			Values:  []string{logFormatText, logFormatJSON},
+			Default: logFormatText,
+			EnvVar:  EnvLogFormat,
+			Usage: `The format of log messages, such as those written when
+                      tracing a render or fetching a registry. The json format
+                      writes each message to stderr as a JSON object with its
+                      level, message and fields, such as the paths of the
+                      files being copied, for use with log aggregation tools.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "chdir",
+			Target: &c.flagChdir,
+			Usage: `Change to the directory before running the command, so
+                      relative paths, such as a local pack path of ".", the
+                      paths passed to --var-file and other flags, and the
+                      automatically loaded variable files, resolve against it.`,
+			Completion: complete.PredictDirs("*"),
+		})
 
 		// f.BoolVar(&flag.BoolVar{
 		// 	Name:    "plain",
@@ -249,18 +421,107 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 				Target:  &c.varFiles,
 				Default: make([]string, 0),
 				Usage: `Specifies the path to a variable override file. This can be provided 
-				multiple times on a single command to result in a list of files.`,
+				multiple times on a single command to result in a list of files, which
+				are applied from left to right so later files take precedence. Use "-"
+				to read HCL or JSON variable overrides from standard input.`,
 				Completion: complete.PredictOr(complete.PredictFiles("*.var"), complete.PredictFiles("*.hcl")),
 			},
 			Shorthand: "f",
 		})
 
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "var-file-merge",
+			Target:  &c.varFileMerge,
+			Values:  variable.VarFileMerges,
+			Default: variable.VarFileMergeReplace,
+			Usage: `How the values set by variable files are merged into the
+                      values set before them. The replace strategy replaces the
+                      whole value. The deep strategy merges object and map
+                      values, recursing into nested objects and maps, while
+                      lists and other values are replaced.`,
+		})
+
 		f.StringMapVar(&flag.StringMapVar{
 			Name:    "var",
 			Target:  &c.vars,
 			Default: make(map[string]string),
 			Usage: `Specifies single override variables in the form of HCL syntax and
-				can be specified multiple times per command.`,
+				can be specified multiple times per command. A value of @path
+				reads the value from the file at path, and @@ escapes a
+				literal leading @.`,
+		})
+
+		f.VarFlag(&flag.VarFlag{
+			Name:  "var-map",
+			Value: &varMapValue{c: c},
+			Usage: `Specifies override variables as a JSON object, such as
+                      '{"replicas":3,"image":"redis"}'. Each key is set in the
+                      same way, and at the same precedence, as --var, with
+                      whichever flag sets a variable last winning. Keys which
+                      the pack does not declare result in a warning, or an
+                      error with --strict-vars.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "profile",
+			Target:  &c.profile,
+			Default: "",
+			Usage: `The name of a profile defined by the pack, whose variable
+                      values are used. Profiles are variable files within the
+                      profiles directory of the pack, such as profiles/prod.hcl.
+                      Profile values take precedence over variable defaults, but
+                      not over environment variables, variable files, or --var.`,
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:    "override-meta",
+			Target:  &c.metadataOverrides,
+			Default: make(map[string]string),
+			Usage: `Overrides a field of the pack metadata in the form of
+                      key=value, such as pack.version=2.0.0, without editing the
+                      pack. Templates referencing the field render with the new
+                      value. Can be specified multiple times. Valid keys are
+                      app.author, app.url, pack.description, pack.url and
+                      pack.version.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "env-prefix",
+			Target:  &c.envPrefix,
+			Default: variable.DefaultEnvPrefix,
+			Usage: `The prefix of the environment variables which set pack
+                      variables, such as NOMAD_PACK_VAR_redis_image=latest. The
+                      variable name can be prefixed by the pack name and a
+                      period. Environment variables take precedence over
+                      variable defaults, but not over variable files.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "no-env-vars",
+			Target:  &c.noEnvVars,
+			Default: false,
+			Usage:   `Do not set pack variables from environment variables.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "strict-vars",
+			Target:  &c.strictVars,
+			Default: false,
+			Usage: `Fail if an environment variable or --var-map key sets a
+                      variable which is not declared by the pack, rather than
+                      warning. Variables set using --var, --var-file, or a
+                      profile must always be declared. Undeclared variables
+                      are reported along with any similarly named declared
+                      variable.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "delims",
+			Target: &c.flagDelims,
+			Usage: `The left and right template action delimiters used to parse
+                      the pack templates, separated by a space, such as "<< >>".
+                      Defaults to "[[ ]]". Useful when the rendered files
+                      themselves contain the default delimiters.`,
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -280,6 +541,9 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
                       should be passed to the plan or destroy commands.
                       `,
 		})
+		c.fetchFlags(f)
+		c.cacheDirFlag(f)
+
 		f.BoolVarP(&flag.BoolVarP{
 			BoolVar: &flag.BoolVar{
 				Name:    "auto-approve",
@@ -299,6 +563,97 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 	return set
 }
 
+// fetchFlags adds the flags controlling registry fetch retries to the set.
+func (c *baseCommand) fetchFlags(f *flag.Set) {
+	f.IntVar(&flag.IntVar{
+		Name:    "fetch-retries",
+		Target:  &c.fetchRetries,
+		Default: defaultFetchRetries,
+		Usage: `The number of times to retry fetching a registry after a
+                      transient error, such as the remote being unreachable.
+                      Errors such as the registry not being found are not
+                      retried.`,
+	})
+
+	f.DurationVar(&flag.DurationVar{
+		Name:    "fetch-retry-wait",
+		Target:  &c.fetchRetryWait,
+		Default: defaultFetchRetryWait,
+		Usage: `The wait before the first retry of a registry fetch, which
+                      doubles for each subsequent retry.`,
+	})
+
+	f.StringSliceVar(&flag.StringSliceVar{
+		Name:   "registry-mirror",
+		Target: &c.registryMirrors,
+		EnvVar: EnvRegistryMirrors,
+		Usage: `A mirror of a registry in the form registry=source, such as
+                      default=https://git.example.com/mirrors/packs. If the
+                      registry source cannot be fetched, its mirrors are tried
+                      in the order specified. Can be specified multiple times,
+                      or set as a comma separated list using the
+                      NOMAD_PACK_REGISTRY_MIRRORS environment variable, which
+                      is ignored when the flag is set.`,
+	})
+}
+
+// cacheDirFlag adds the flag overriding the cache directory to the set.
+func (c *baseCommand) cacheDirFlag(f *flag.Set) {
+	f.StringVar(&flag.StringVar{
+		Name:   "cache-dir",
+		Target: &c.cacheDir,
+		Usage: `The directory of the cache containing registries, such as a
+                      shared or ephemeral directory in CI. Can also be set
+                      using the NOMAD_PACK_CACHE environment variable. The
+                      directory is created if it does not exist, and must be
+                      writable.`,
+	})
+}
+
+// cachePath returns the directory of the cache containing registries, which
+// is the --cache-dir flag value if set, otherwise the default.
+func (c *baseCommand) cachePath() string {
+	if c.cacheDir != "" {
+		return c.cacheDir
+	}
+	return cache.DefaultCachePath()
+}
+
+// offlineFlag adds the flag disabling network access to the set.
+func (c *baseCommand) offlineFlag(f *flag.Set) {
+	f.BoolVar(&flag.BoolVar{
+		Name:    "offline",
+		Target:  &c.offline,
+		Default: false,
+		Usage: `Disable network access. Registries are not fetched, so packs
+                      must already be in the local cache, and the Nomad API
+                      template functions are not available.`,
+	})
+}
+
+// nomadVariablesFlag adds the flag enabling the Nomad variable template
+// functions to the set.
+func (c *baseCommand) nomadVariablesFlag(f *flag.Set) {
+	f.BoolVar(&flag.BoolVar{
+		Name:    "nomad-variables",
+		Target:  &c.nomadVariables,
+		Default: false,
+		Usage: `Enable the nomadVariable and nomadVariableItems template
+                      functions, which read Nomad variables from the cluster
+                      using NOMAD_ADDR and NOMAD_TOKEN. The values read are
+                      rendered in plain text, including into any files written.`,
+	})
+}
+
+// variableEnvPrefix returns the prefix of the environment variables which set
+// pack variables, which is empty if they are disabled.
+func (c *baseCommand) variableEnvPrefix() string {
+	if c.noEnvVars {
+		return ""
+	}
+	return c.envPrefix
+}
+
 // Returns minimal help usage message
 // Used on flag/arg parse error in c.Init method
 func (c *baseCommand) helpUsageMessage() string {
@@ -308,6 +663,13 @@ func (c *baseCommand) helpUsageMessage() string {
 	return fmt.Sprintf(`See "nomad-pack %s --help"`, c.cmdKey)
 }
 
+// defaultFetchRetries and defaultFetchRetryWait are the defaults of the
+// --fetch-retries and --fetch-retry-wait flags.
+const (
+	defaultFetchRetries   = 3
+	defaultFetchRetryWait = time.Second
+)
+
 // flagSetBit is used with baseCommand.flagSet
 type flagSetBit uint
 
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/posener/complete"
+)
+
+const (
+	depsGraphFormatDOT  = "dot"
+	depsGraphFormatJSON = "json"
+)
+
+// depsGraphFormats are the formats the dependency graph can be output in.
+var depsGraphFormats = []string{depsGraphFormatDOT, depsGraphFormatJSON}
+
+// DepsGraphCommand outputs the dependency relationships of a pack, for
+// auditing packs composed of several dependencies.
+type DepsGraphCommand struct {
+	*baseCommand
+	packConfig *cache.PackConfig
+	format     string
+}
+
+func (c *DepsGraphCommand) Run(args []string) int {
+	c.cmdKey = "deps graph" // Add cmdKey here to print out helpUsageMessage on Init error
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithExactArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	c.packConfig.Name = c.args[0]
+
+	// Set the packConfig defaults if necessary and generate our UI error context.
+	errorContext := c.initPackCommand(c.packConfig)
+
+	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+		return 1
+	}
+
+	// The dependencies are read from the pack metadata, so no client is
+	// needed as nothing is rendered.
+	packManager := generatePackManager(c.baseCommand, nil, c.packConfig)
+
+	graph, err := packManager.DependencyGraph()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to resolve pack dependencies", errorContext.GetAll()...)
+		return 1
+	}
+	graph.Registry = c.packConfig.Registry
+	graph.Ref = c.packConfig.Ref
+
+	switch c.format {
+	case depsGraphFormatJSON:
+		out, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to encode dependency graph", errorContext.GetAll()...)
+			return 1
+		}
+		c.ui.Output("%s", string(out))
+	default:
+		c.ui.Output("%s", strings.TrimSuffix(graph.DOT(), "\n"))
+	}
+	return 0
+}
+
+func (c *DepsGraphCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		c.packConfig = &cache.PackConfig{}
+
+		f := set.NewSet("Graph Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.packConfig.Registry,
+			Default: "",
+			Usage: `Specific registry name containing the pack to graph the
+                      dependencies of. If not specified, the default registry
+                      will be used.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "ref",
+			Target:  &c.packConfig.Ref,
+			Default: "",
+			Usage: `Specific git ref of the pack to graph the dependencies of.
+                      Supports tags, SHA, and latest. If no ref is specified,
+                      defaults to latest.
+
+                      Using ref with a file path is not supported.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.format,
+			Values:  depsGraphFormats,
+			Default: depsGraphFormatDOT,
+			Usage: `Format used to output the dependency graph. The dot format
+                      can be rendered using Graphviz, while the json format
+                      is sorted so that graphs can be compared.`,
+		})
+
+		c.cacheDirFlag(f)
+		c.offlineFlag(f)
+	})
+}
+
+func (c *DepsGraphCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *DepsGraphCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+// Help satisfies the Help function of the cli.Command interface.
+func (c *DepsGraphCommand) Help() string {
+	c.Example = `
+	# Render the dependency graph of the "hello-world" pack as an image
+	nomad-pack deps graph hello-world | dot -Tpng -o deps.png
+
+	# Output the dependency graph of a pack under development as JSON
+	nomad-pack deps graph . --format=json
+	`
+
+	return formatHelp(`
+	Usage: nomad-pack deps graph <pack-name> [options]
+
+	Output the dependency relationships of a pack, including the version of
+	each pack and the source of each dependency, as Graphviz DOT or JSON.
+	Dependency cycles are marked within the graph rather than followed.
+
+` + c.GetExample() + c.Flags().Help())
+}
+
+// Synopsis satisfies the Synopsis function of the cli.Command interface.
+func (c *DepsGraphCommand) Synopsis() string {
+	return "Output the dependency graph of a pack"
+}
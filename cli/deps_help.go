@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/posener/complete"
+)
+
+// DepsHelpCommand exists solely to provide top level help for the deps set
+// of subcommands.
+type DepsHelpCommand struct {
+	*baseCommand
+}
+
+func (c *DepsHelpCommand) Run(args []string) int {
+	c.cmdKey = "deps"
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	c.ui.Info("The deps command requires one of the following subcommands: graph.")
+
+	return 0
+}
+
+func (c *DepsHelpCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *DepsHelpCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *DepsHelpCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *DepsHelpCommand) Synopsis() string {
+	return "Inspect the dependencies of packs."
+}
+
+func (c *DepsHelpCommand) Help() string {
+	return formatHelp(`
+	Usage: nomad-pack deps <subcommand> [options]
+
+	Inspect the dependencies of packs.
+	
+` + c.GetExample() + c.Flags().Help())
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/posener/complete"
+)
+
+// FmtCommand is a command that rewrites the HCL files of a pack to the
+// canonical HCL format.
+type FmtCommand struct {
+	*baseCommand
+	// check reports the unformatted files without writing them.
+	check bool
+	// diff outputs a diff of the formatting changes.
+	diff bool
+}
+
+// errFmtInvalidTemplate indicates a template file is not valid HCL, and so
+// cannot be formatted.
+var errFmtInvalidTemplate = stdErrors.New("template is not valid HCL")
+
+func (c *FmtCommand) Run(args []string) int {
+	c.cmdKey = "fmt" // Add cmdKey here to print out helpUsageMessage on Init error
+
+	if err := c.Init(
+		WithMaximumNArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig()); err != nil {
+
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+
+		return 1
+	}
+
+	target := "."
+	if len(c.args) == 1 {
+		target = c.args[0]
+	}
+
+	files, err := fmtFiles(target)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to find files to format", "Path: "+target)
+		return 1
+	}
+
+	var failed, unformatted bool
+
+	for _, file := range files {
+		changed, err := c.formatFile(file)
+		switch {
+		case stdErrors.Is(err, errFmtInvalidTemplate):
+			continue
+		case err != nil:
+			c.ui.ErrorWithContext(err, "failed to format file", "File: "+file)
+			failed = true
+		case changed:
+			unformatted = true
+		}
+	}
+
+	if failed || (c.check && unformatted) {
+		return 1
+	}
+	return 0
+}
+
+// formatFile formats the file at path, outputting its path if it changed
+// along with the diff when requested. The file is only written when not
+// running with --check.
+func (c *FmtCommand) formatFile(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := formatHCL(path, src)
+	if err != nil {
+		return false, err
+	}
+	if string(out) == string(src) {
+		return false, nil
+	}
+
+	c.ui.Output("%s", path)
+
+	if c.diff {
+		diff, err := renderDiff(filepath.ToSlash(path), string(src), string(out))
+		if err != nil {
+			return true, err
+		}
+		outputDiff(c.ui, path, diff)
+	}
+
+	if c.check {
+		return true, nil
+	}
+	return true, filesystem.WriteFileMode(path, string(out), true, info.Mode().Perm())
+}
+
+// formatHCL returns the canonically formatted content of the named HCL file.
+// Template files which are not valid HCL, such as those using template
+// actions outside of strings, return errFmtInvalidTemplate, while other
+// invalid files return the parse diagnostics.
+func formatHCL(name string, src []byte) ([]byte, error) {
+	if _, diags := hclsyntax.ParseConfig(src, name, hcl.InitialPos); diags.HasErrors() {
+		if isFmtTemplate(name) {
+			return nil, errFmtInvalidTemplate
+		}
+		return nil, diags
+	}
+	return hclwrite.Format(src), nil
+}
+
+// fmtFiles returns the paths of the files to format. When target is a
+// directory, it is walked to find the .hcl and .tpl files within it.
+// Otherwise, target must itself be one of these files.
+func fmtFiles(target string) ([]string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if !isFmtFile(target) {
+			return nil, fmt.Errorf("only .hcl and .tpl files can be formatted")
+		}
+		return []string{target}, nil
+	}
+
+	var files []string
+	err = filesystem.WalkFiles(target, func(path string) error {
+		if isFmtFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// isFmtFile returns whether the file at path is formatted by the fmt command.
+func isFmtFile(path string) bool {
+	return strings.HasSuffix(path, ".hcl") || isFmtTemplate(path)
+}
+
+// isFmtTemplate returns whether the file at path is a pack template.
+func isFmtTemplate(path string) bool {
+	return strings.HasSuffix(path, ".tpl")
+}
+
+func (c *FmtCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Format Options")
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "check",
+			Target:  &c.check,
+			Default: false,
+			Usage: `Check whether the files are formatted without writing them.
+                      The command exits non-zero if any file is not formatted.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "diff",
+			Target:  &c.diff,
+			Default: false,
+			Usage:   `Output a diff of the formatting changes to each file.`,
+		})
+	})
+}
+
+func (c *FmtCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("")
+}
+
+func (c *FmtCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+// Help satisfies the Help function of the cli.Command interface.
+func (c *FmtCommand) Help() string {
+
+	c.Example = `
+	# Format the pack in the current directory.
+	nomad-pack fmt
+
+	# Format the example pack, showing the changes made.
+	nomad-pack fmt ./example --diff
+
+	# Check the example pack is formatted, as in a CI pipeline.
+	nomad-pack fmt ./example --check
+	`
+
+	return formatHelp(`
+	Usage: nomad-pack fmt [path] [options]
+
+	Rewrite the HCL files of a pack to the canonical format. The path can be a
+	pack directory, which is searched for .hcl and .tpl files, or a single file,
+	and defaults to the current directory. Templates which are not valid HCL,
+	such as those using template actions outside of strings, are skipped. The
+	path of each file which is changed is output.
+
+` + c.GetExample() + c.Flags().Help())
+}
+
+// Synopsis satisfies the Synopsis function of the cli.Command interface.
+func (c *FmtCommand) Synopsis() string {
+	return "Format the HCL files of a pack"
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatHCL(t *testing.T) {
+	out, err := formatHCL("variables.hcl", []byte("variable \"x\" {\ntype=string\n}\n"))
+	require.NoError(t, err)
+	require.Equal(t, "variable \"x\" {\n  type = string\n}\n", string(out))
+
+	// Templates using template actions outside of strings are skipped.
+	_, err = formatHCL("example.nomad.tpl", []byte("job \"x\" {\n[[ template \"region\" . ]]\n}\n"))
+	require.ErrorIs(t, err, errFmtInvalidTemplate)
+
+	// Templates which are valid HCL are formatted.
+	out, err = formatHCL("example.nomad.tpl", []byte("job \"[[ .my.name ]]\" {\ncount=1\n}\n"))
+	require.NoError(t, err)
+	require.Equal(t, "job \"[[ .my.name ]]\" {\n  count = 1\n}\n", string(out))
+
+	// Invalid HCL files are an error.
+	_, err = formatHCL("variables.hcl", []byte("variable \"x\" {\n"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errFmtInvalidTemplate)
+}
+
+func TestFmtFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"metadata.hcl", "README.md", "templates/example.nomad.tpl", "templates/notes.txt"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	files, err := fmtFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "metadata.hcl"),
+		filepath.Join(dir, "templates/example.nomad.tpl"),
+	}, files)
+
+	files, err = fmtFiles(filepath.Join(dir, "metadata.hcl"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "metadata.hcl")}, files)
+
+	_, err = fmtFiles(filepath.Join(dir, "README.md"))
+	require.EqualError(t, err, "only .hcl and .tpl files can be formatted")
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/posener/complete"
+)
+
+// GenerateVarFileCommand outputs a variable file setting each of the variables
+// declared by a pack, for use with --var-file.
+type GenerateVarFileCommand struct {
+	*baseCommand
+	packConfig *cache.PackConfig
+	toFile     string
+}
+
+func (c *GenerateVarFileCommand) Run(args []string) int {
+	c.cmdKey = "generate var-file" // Add cmdKey here to print out helpUsageMessage on Init error
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithExactArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	c.packConfig.Name = c.args[0]
+
+	// Set the packConfig defaults if necessary and generate our UI error context.
+	errorContext := c.initPackCommand(c.packConfig)
+
+	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+		return 1
+	}
+
+	// The variables are read from their declarations, so no client is
+	// needed as nothing is rendered.
+	packManager := generatePackManager(c.baseCommand, nil, c.packConfig)
+
+	declared, errs := packManager.ProcessRootVariables()
+	if errs != nil {
+		for _, err := range errs {
+			err.Context.Append(errorContext)
+			c.ui.ErrorWithContext(err.Err, err.Subject, err.Context.GetAll()...)
+		}
+		return 1
+	}
+
+	content := declared.VarFile(packManager.ParentName())
+
+	if c.toFile == "" {
+		c.ui.Output("%s", strings.TrimSuffix(string(content), "\n"))
+		return 0
+	}
+
+	if err := c.writeVarFile(content, errorContext); err != nil {
+		if stdErrors.Is(err, os.ErrExist) {
+			c.ui.Warning(fmt.Sprintf("Skipped writing %s: %s", c.toFile, err))
+			return 0
+		}
+		c.ui.ErrorWithContext(err, "failed to write variable file", errorContext.GetAll()...)
+		return 1
+	}
+
+	c.ui.Success(fmt.Sprintf("Wrote variable file to %s", c.toFile))
+	return 0
+}
+
+// writeVarFile writes the variable file to the --to-file path, prompting to
+// confirm overwriting an existing file in the same manner as render. When
+// the file is not overwritten, the error wraps os.ErrExist.
+func (c *GenerateVarFileCommand) writeVarFile(content []byte, ec *errors.UIErrorContext) error {
+	path, err := filesystem.ExpandPath(c.toFile)
+	if err != nil {
+		return err
+	}
+	c.toFile = path
+
+	exists, err := filesystem.Exists(path)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestFile, path)
+		return err
+	}
+
+	overwrite := c.autoApproved
+	if exists && !overwrite && c.ui.Interactive() {
+		if overwrite, err = confirmOverwrite(c.baseCommand); err != nil {
+			return err
+		}
+	}
+
+	if err := filesystem.WriteFileAll(path, string(content), overwrite, 0644, defaultDirMode); err != nil {
+		ec.Add(errors.FilesystemContextDestFile, path)
+		return err
+	}
+	return nil
+}
+
+func (c *GenerateVarFileCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
+		c.packConfig = &cache.PackConfig{}
+
+		f := set.NewSet("Generate Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.packConfig.Registry,
+			Default: "",
+			Usage: `Specific registry name containing the pack to generate the
+variable file for. If not specified, the default registry will be used.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "ref",
+			Target:  &c.packConfig.Ref,
+			Default: "",
+			Usage: `Specific git ref of the pack to generate the variable file
+for. Supports tags, SHA, and latest. If no ref is specified, defaults to
+latest.
+
+Using ref with a file path is not supported.`,
+		})
+
+		c.offlineFlag(f)
+
+		f.StringVar(&flag.StringVar{
+			Name:    "to-file",
+			Target:  &c.toFile,
+			Default: "",
+			Usage: `Path to write the variable file to, rather than outputting
+it to the terminal. If the file exists, confirmation is
+required to overwrite it, unless --auto-approve is set.`,
+		})
+	})
+}
+
+func (c *GenerateVarFileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *GenerateVarFileCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+// Help satisfies the Help function of the cli.Command interface.
+func (c *GenerateVarFileCommand) Help() string {
+	c.Example = `
+	# Output a variable file for the "hello-world" pack
+	nomad-pack generate var-file hello-world
+
+	# Write a variable file for a pack under development, then render with it
+	nomad-pack generate var-file . --to-file=./overrides.hcl
+	nomad-pack render . --var-file=./overrides.hcl
+	`
+
+	return formatHelp(`
+	Usage: nomad-pack generate var-file <pack-name> [options]
+
+	Generate an HCL variable file setting each variable declared by the pack,
+	for use with --var-file. Each variable is preceded by comments containing
+	its description and type, and is set to its default value. Variables
+	without a default are required, and are marked as such and commented out
+	with a placeholder value.
+
+` + c.GetExample() + c.Flags().Help())
+}
+
+// Synopsis satisfies the Synopsis function of the cli.Command interface.
+func (c *GenerateVarFileCommand) Synopsis() string {
+	return "Generate a variable file for a pack"
+}
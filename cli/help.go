@@ -247,6 +247,20 @@ pack name is specified, it will return a list of all deployed packs. If pack nam
 is provided, it will return a list of the jobs in that pack, along with their status, 
 and the pack deployment they belong to. The --name flag can be used with pack name
 to limit the list of jobs to a specific deployment of the pack.`,
+	},
+	"cache list": {
+		"Lists the registries and packs in the local cache",
+		`
+Cache list can be used to list the registries, packs, and refs held in the local
+cache, along with their size on disk and when they were last fetched.
+`,
+	},
+	"cache prune": {
+		"Removes old or unreferenced packs from the local cache",
+		`
+Cache prune can be used to remove the cached packs which were last fetched longer
+ago than a duration, or which are at refs no longer referenced.
+`,
 	},
 	"registry add": {
 		"Adds a pack registry or a specific pack from a registry",
@@ -4,6 +4,7 @@ import (
 	stdErrors "errors"
 	"fmt"
 	"os"
+	"strings"
 
 	v1client "github.com/hashicorp/nomad-openapi/clients/go/v1"
 	v1 "github.com/hashicorp/nomad-openapi/v1"
@@ -11,15 +12,29 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
 	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
 	"github.com/hashicorp/nomad-pack/internal/runner"
 	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
 // get an initialized error context for a command that accepts pack args.
-func initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext) {
+// Registry packs are read from the cache. If the registry is an OCI source,
+// the pack is pulled into the cache if it is not already held there.
+func (c *baseCommand) initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext) {
+	var ociSource string
+	if cache.IsOCISource(cfg.Registry) {
+		ociSource = cfg.Registry
+		cfg.Registry = cache.OCIRegistryName(ociSource)
+	}
+
+	cfg.CachePath = c.cachePath()
 	cfg.Init()
 
+	if ociSource != "" && cfg.Registry != cache.DevRegistryName {
+		c.ensureOCIPack(cfg, ociSource)
+	}
+
 	// Generate our UI error context.
 	errorContext = errors.NewUIErrorContext()
 	errorContext.Add(errors.UIContextPrefixRegistryName, cfg.Registry)
@@ -29,13 +44,58 @@ func initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext
 	return
 }
 
+// ensureOCIPack pulls the pack from the OCI registry source into the cache,
+// unless it is already held there or network access is disabled. Failures
+// are output by the cache, after which the pack fails verification as not
+// being cached.
+func (c *baseCommand) ensureOCIPack(cfg *cache.PackConfig, source string) {
+	if _, err := os.Stat(cfg.Path); err == nil || c.offline {
+		return
+	}
+
+	globalCache, err := cache.NewCache(&cache.CacheConfig{
+		Path:   c.cachePath(),
+		Logger: c.logger(),
+	})
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to initialize cache")
+		return
+	}
+
+	mirrors, err := c.mirrorsFor(cfg.Registry)
+	if err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		return
+	}
+
+	_, _ = globalCache.Add(&cache.AddOpts{
+		RegistryName:   cfg.Registry,
+		Source:         source,
+		PackName:       cfg.Name,
+		Ref:            cfg.Ref,
+		FetchRetries:   c.fetchRetries,
+		FetchRetryWait: c.fetchRetryWait,
+		Mirrors:        mirrors,
+	})
+}
+
 // generatePackManager is used to generate the pack manager for this Nomad Pack run.
 func generatePackManager(c *baseCommand, client *v1.Client, packCfg *cache.PackConfig) *manager.PackManager {
 	// TODO: Refactor to have manager use cache.
 	cfg := manager.Config{
-		Path:            packCfg.Path,
-		VariableFiles:   c.varFiles,
-		VariableCLIArgs: c.vars,
+		Path:              packCfg.Path,
+		VariableFiles:     c.varFiles,
+		VariableCLIArgs:   c.vars,
+		VariableMapNames:  c.varMapNames(),
+		Profile:           c.profile,
+		MetadataOverrides: c.metadataOverrides,
+		EnvPrefix:         c.variableEnvPrefix(),
+		StrictVars:        c.strictVars,
+		VariableFileMerge: c.varFileMerge,
+		LeftDelim:         c.leftDelim,
+		RightDelim:        c.rightDelim,
+		Offline:           c.offline,
+		NomadVariables:    c.nomadVariables,
 	}
 	return manager.NewPackManager(&cfg, client)
 }
@@ -116,6 +176,9 @@ func renderPack(manager *manager.PackManager, ui terminal.UI, errCtx *errors.UIE
 		}
 		return nil, stdErrors.New("failed to render")
 	}
+	for _, warning := range manager.Warnings() {
+		ui.Warning(warning)
+	}
 	return r, nil
 }
 
@@ -238,6 +301,30 @@ func hasVarOverrides(c *baseCommand) bool {
 	return len(c.varFiles) > 0 || len(c.vars) > 0
 }
 
+// parseDelims parses the value of the --delims flag, which holds the left and
+// right template action delimiters separated by whitespace, such as "<< >>".
+func parseDelims(delims string) (string, string, error) {
+	fields := strings.Fields(delims)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("invalid --delims %q: must be the left and right delimiters separated by a space, such as \"<< >>\"", delims)
+	}
+	if fields[0] == fields[1] {
+		return "", "", fmt.Errorf("invalid --delims %q: the left and right delimiters must be distinct", delims)
+	}
+	return fields[0], fields[1], nil
+}
+
+// varsFromStdin returns whether the variable overrides are read from standard
+// input.
+func (c *baseCommand) varsFromStdin() bool {
+	for _, file := range c.varFiles {
+		if file == variable.StdinFileOverride {
+			return true
+		}
+	}
+	return false
+}
+
 // TODO: Move to a domain specific package.
 func getDeployedPacks(jobsApi *v1.Jobs) (map[string]map[string]struct{}, error) {
 	opts := newQueryOpts()
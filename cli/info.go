@@ -1,19 +1,76 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
+	"strings"
 
 	"github.com/hashicorp/nomad-pack/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/mitchellh/go-glint"
 )
 
 type InfoCommand struct {
 	*baseCommand
 	packConfig *cache.PackConfig
+	json       bool
+}
+
+// packInfo is the document output by the info command when using --json.
+type packInfo struct {
+	Name         string                  `json:"name"`
+	Version      string                  `json:"version"`
+	Description  string                  `json:"description"`
+	URL          string                  `json:"url"`
+	App          packInfoApp             `json:"app"`
+	Variables    []*variable.Description `json:"variables"`
+	Dependencies []packInfoDependency    `json:"dependencies"`
+	Profiles     []string                `json:"profiles"`
+}
+
+type packInfoApp struct {
+	URL    string `json:"url"`
+	Author string `json:"author"`
+}
+
+type packInfoDependency struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Enabled bool   `json:"enabled"`
+}
+
+// newPackInfo builds the --json document describing the pack and the
+// variables it declares.
+func newPackInfo(p *pack.Pack, parsedVars *variable.ParsedVariables, parentName string) (*packInfo, error) {
+	vars, err := parsedVars.Describe(parentName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &packInfo{
+		Name:         p.Metadata.Pack.Name,
+		Version:      p.Metadata.Pack.Version,
+		Description:  p.Metadata.Pack.Description,
+		URL:          p.Metadata.Pack.URL,
+		Variables:    vars,
+		Dependencies: []packInfoDependency{},
+		Profiles:     p.Profiles(),
+	}
+	if p.Metadata.App != nil {
+		info.App = packInfoApp{URL: p.Metadata.App.URL, Author: p.Metadata.App.Author}
+	}
+	for _, d := range p.Metadata.Dependencies {
+		info.Dependencies = append(info.Dependencies, packInfoDependency{
+			Name:    d.Name,
+			Source:  d.Source,
+			Enabled: d.Enabled == nil || *d.Enabled,
+		})
+	}
+	return info, nil
 }
 
 func (c *InfoCommand) Run(args []string) int {
@@ -34,7 +91,7 @@ func (c *InfoCommand) Run(args []string) int {
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
 
 	// verify packs exist before running jobs
 	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
@@ -49,8 +106,10 @@ func (c *InfoCommand) Run(args []string) int {
 		return 1
 	}
 
+	parentName := path.Base(packPath)
+
 	variableParser, err := variable.NewParser(&variable.ParserConfig{
-		ParentName:        path.Base(packPath),
+		ParentName:        parentName,
 		RootVariableFiles: pack.RootVariableFiles(),
 	})
 	if err != nil {
@@ -63,6 +122,21 @@ func (c *InfoCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.json {
+		info, err := newPackInfo(pack, parsedVars, parentName)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to describe pack", errorContext.GetAll()...)
+			return 1
+		}
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to encode pack info", errorContext.GetAll()...)
+			return 1
+		}
+		c.ui.Output("%s", string(out))
+		return 0
+	}
+
 	// Create a new glint document to handle the outputting of information.
 	doc := glint.New()
 
@@ -84,6 +158,15 @@ func (c *InfoCommand) Run(args []string) int {
 	doc.Append(glint.Layout(
 		glint.Style(glint.Text("Application Author "), glint.Bold()),
 		glint.Text(pack.Metadata.App.Author),
+	).Row())
+
+	profiles := "none"
+	if names := pack.Profiles(); len(names) > 0 {
+		profiles = strings.Join(names, ", ")
+	}
+	doc.Append(glint.Layout(
+		glint.Style(glint.Text("Profiles           "), glint.Bold()),
+		glint.Text(profiles),
 		glint.Text("\n"),
 	).Row())
 
@@ -130,6 +213,17 @@ latest.
 
 Using ref with a file path is not supported.`,
 		})
+
+		c.offlineFlag(f)
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "json",
+			Target:  &c.json,
+			Default: false,
+			Usage: `Output the pack information as a JSON document, including
+the declared variables with their types and defaults, and
+the pack dependencies and profiles.`,
+		})
 	})
 }
 
@@ -137,6 +231,9 @@ func (c *InfoCommand) Help() string {
 	c.Example = `
 	# Get information on the "hello-world" pack
 	nomad-pack info hello-world
+
+	# Get information on the "hello-world" pack as JSON
+	nomad-pack info hello-world --json
 	`
 
 	return formatHelp(`
@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"github.com/posener/complete"
+)
+
+// LintCommand is a command that allows users to check a pack for common
+// problems. The pack is rendered in memory, so no files are written and no
+// Nomad cluster is required.
+type LintCommand struct {
+	*baseCommand
+	packConfig *cache.PackConfig
+	// disabledChecks is the list of lint checks which are not run.
+	disabledChecks []string
+}
+
+// lintCheck* are the names of the checks performed by the lint command.
+const (
+	lintCheckUnusedVariables     = "unused-variables"
+	lintCheckUndeclaredVariables = "undeclared-variables"
+	lintCheckEmptyRenders        = "empty-renders"
+	lintCheckInvalidJobspecs     = "invalid-jobspecs"
+)
+
+// lintChecks lists all the checks performed by the lint command.
+var lintChecks = []string{
+	lintCheckUnusedVariables,
+	lintCheckUndeclaredVariables,
+	lintCheckEmptyRenders,
+	lintCheckInvalidJobspecs,
+}
+
+// lintSeverity* are the severity levels of lint findings. Only error findings
+// cause the lint command to exit non-zero.
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+)
+
+// lintFinding is a single problem found by a lint check.
+type lintFinding struct {
+	Severity string
+	Check    string
+	File     string
+	Message  string
+}
+
+func (c *LintCommand) Run(args []string) int {
+	c.cmdKey = "lint" // Add cmdKey here to print out helpUsageMessage on Init error
+
+	if err := c.Init(
+		WithExactArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig()); err != nil {
+
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+
+		return 1
+	}
+
+	c.packConfig.Name = c.args[0]
+
+	// Set the packConfig defaults if necessary and generate our UI error context.
+	errorContext := c.initPackCommand(c.packConfig)
+
+	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+		return 1
+	}
+
+	if err := validatePackMetadata(c.packConfig); err != nil {
+		c.reportMetadataError(err, errorContext)
+		return 1
+	}
+
+	client, err := v1.NewClient()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+		return 1
+	}
+
+	packManager := generatePackManager(c.baseCommand, client, c.packConfig)
+
+	declared, errs := packManager.ProcessRootVariables()
+	if errs != nil {
+		for _, err := range errs {
+			err.Context.Append(errorContext)
+			c.ui.ErrorWithContext(err.Err, err.Subject, err.Context.GetAll()...)
+		}
+		return 1
+	}
+
+	renderOutput, err := renderPack(packManager, c.ui, errorContext)
+	if err != nil {
+		return 1
+	}
+
+	findings := c.lint(declared, packManager.VariableReferences(), newRenders(renderOutput, false))
+	if len(findings) == 0 {
+		c.ui.Success("No problems found")
+		return 0
+	}
+
+	c.ui.Table(formatLintFindings(findings))
+
+	var numErrors, numWarnings int
+	for _, finding := range findings {
+		if finding.Severity == lintSeverityError {
+			numErrors++
+		} else {
+			numWarnings++
+		}
+	}
+	c.ui.Output(fmt.Sprintf("%d error(s), %d warning(s)", numErrors, numWarnings))
+
+	if numErrors > 0 {
+		return 1
+	}
+	return 0
+}
+
+// lint runs the enabled checks, returning the findings sorted by file.
+func (c *LintCommand) lint(declared *variable.ParsedVariables, refs []renderer.VariableReference, renders []Render) []lintFinding {
+	disabled := make(map[string]bool, len(c.disabledChecks))
+	for _, check := range c.disabledChecks {
+		disabled[check] = true
+	}
+
+	var findings []lintFinding
+
+	if !disabled[lintCheckUnusedVariables] {
+		findings = append(findings, lintUnusedVariables(declared, refs)...)
+	}
+	if !disabled[lintCheckUndeclaredVariables] {
+		findings = append(findings, lintUndeclaredVariables(declared, refs)...)
+	}
+	if !disabled[lintCheckEmptyRenders] {
+		findings = append(findings, lintEmptyRenders(renders)...)
+	}
+	if !disabled[lintCheckInvalidJobspecs] {
+		findings = append(findings, lintInvalidJobspecs(renders)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].File < findings[j].File })
+	return findings
+}
+
+// lintUnusedVariables finds the variables declared by each pack which are
+// not referenced by any template.
+func lintUnusedVariables(declared *variable.ParsedVariables, refs []renderer.VariableReference) []lintFinding {
+	used := make(map[string]map[string]bool)
+	for _, ref := range refs {
+		if used[ref.Pack] == nil {
+			used[ref.Pack] = make(map[string]bool)
+		}
+		used[ref.Pack][ref.Variable] = true
+	}
+
+	var findings []lintFinding
+
+	for packName, vars := range declared.Vars {
+		for name := range vars {
+			if used[packName][name] {
+				continue
+			}
+			findings = append(findings, lintFinding{
+				Severity: lintSeverityWarning,
+				Check:    lintCheckUnusedVariables,
+				File:     path.Join(packName, "variables.hcl"),
+				Message:  fmt.Sprintf("variable %q is declared but not used by any template", name),
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Message < findings[j].Message })
+	return findings
+}
+
+// lintUndeclaredVariables finds the variables referenced by templates which
+// are not declared by the pack. References to packs which are not part of
+// the render, such as the nomad_pack metadata, are ignored.
+func lintUndeclaredVariables(declared *variable.ParsedVariables, refs []renderer.VariableReference) []lintFinding {
+	var findings []lintFinding
+
+	for _, ref := range refs {
+		vars, ok := declared.Vars[ref.Pack]
+		if !ok {
+			continue
+		}
+		if _, ok := vars[ref.Variable]; ok {
+			continue
+		}
+		findings = append(findings, lintFinding{
+			Severity: lintSeverityError,
+			Check:    lintCheckUndeclaredVariables,
+			File:     ref.Template,
+			Message:  fmt.Sprintf("variable %q is not declared by pack %q", ref.Variable, ref.Pack),
+		})
+	}
+
+	return findings
+}
+
+// lintEmptyRenders finds the templates which render empty content.
+func lintEmptyRenders(renders []Render) []lintFinding {
+	var findings []lintFinding
+
+	for _, r := range renders {
+		if !r.isEmpty() {
+			continue
+		}
+		findings = append(findings, lintFinding{
+			Severity: lintSeverityWarning,
+			Check:    lintCheckEmptyRenders,
+			File:     r.Name,
+			Message:  "template rendered empty content",
+		})
+	}
+
+	return findings
+}
+
+// lintInvalidJobspecs finds the rendered job specifications of the parent
+// pack which cannot be parsed.
+func lintInvalidJobspecs(renders []Render) []lintFinding {
+	var findings []lintFinding
+
+	for _, r := range renders {
+		if !r.shouldValidate() || r.isEmpty() {
+			continue
+		}
+		if err := r.validate(); err != nil {
+			findings = append(findings, lintFinding{
+				Severity: lintSeverityError,
+				Check:    lintCheckInvalidJobspecs,
+				File:     r.Name,
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	return findings
+}
+
+// formatLintFindings builds the table used to output the lint findings.
+func formatLintFindings(findings []lintFinding) *terminal.Table {
+	tbl := terminal.NewTable("SEVERITY", "CHECK", "FILE", "MESSAGE")
+
+	for _, finding := range findings {
+		color := terminal.Yellow
+		if finding.Severity == lintSeverityError {
+			color = terminal.Red
+		}
+		tbl.Rich([]string{finding.Severity, finding.Check, finding.File, finding.Message},
+			[]string{color, "", "", ""})
+	}
+
+	return tbl
+}
+
+func (c *LintCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
+		c.packConfig = &cache.PackConfig{}
+
+		f := set.NewSet("Lint Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.packConfig.Registry,
+			Default: "",
+			Usage: `Specific registry name containing the pack to be linted.
+If not specified, the default registry will be used.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "ref",
+			Target:  &c.packConfig.Ref,
+			Default: "",
+			Usage: `Specific git ref of the pack to be linted.
+Supports tags, SHA, and latest. If no ref is specified, defaults to latest.
+
+Using ref with a file path is not supported.`,
+		})
+
+		c.offlineFlag(f)
+
+		f.EnumVar(&flag.EnumVar{
+			Name:   "disable",
+			Target: &c.disabledChecks,
+			Values: lintChecks,
+			Usage: `Disable a lint check. Can be specified multiple times. The
+                      available checks are unused-variables,
+                      undeclared-variables, empty-renders, and
+                      invalid-jobspecs.`,
+		})
+	})
+}
+
+func (c *LintCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LintCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+// Help satisfies the Help function of the cli.Command interface.
+func (c *LintCommand) Help() string {
+
+	c.Example = `
+	# Lint an example pack.
+	nomad-pack lint example
+
+	# Lint an example pack with override variables in a variable file.
+	nomad-pack lint example --var-file="./overrides.hcl"
+
+	# Lint a pack under development, ignoring unused variables.
+	nomad-pack lint . --disable=unused-variables
+	`
+
+	return formatHelp(`
+	Usage: nomad-pack lint <pack-name> [options]
+
+	Check the specified Nomad Pack for common problems. The pack is rendered in
+	memory, so no files are written, and the findings are reported along with
+	their severity. The command exits non-zero if any error is found.
+
+` + c.GetExample() + c.Flags().Help())
+}
+
+// Synopsis satisfies the Synopsis function of the cli.Command interface.
+func (c *LintCommand) Synopsis() string {
+	return "Check a pack for common problems"
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	declared := &variable.ParsedVariables{
+		Vars: map[string]map[string]*variable.Variable{
+			"example": {"job_name": {}, "count": {}},
+		},
+	}
+	refs := []renderer.VariableReference{
+		{Template: "example/templates/example.nomad.tpl", Pack: "example", Variable: "job_name"},
+		{Template: "example/templates/example.nomad.tpl", Pack: "example", Variable: "region"},
+		{Template: "example/templates/example.nomad.tpl", Pack: "nomad_pack", Variable: "pack"},
+	}
+	renders := []Render{
+		{Name: "example/example.nomad", Content: `job "example" {}`, Parent: true},
+		{Name: "example/empty.nomad", Content: "\n", Parent: true},
+		{Name: "example/invalid.nomad", Content: `job "example" {`, Parent: true},
+	}
+
+	c := &LintCommand{}
+	findings := c.lint(declared, refs, renders)
+
+	var checks []string
+	for _, finding := range findings {
+		checks = append(checks, finding.Severity+" "+finding.Check+" "+finding.File)
+	}
+	require.Equal(t, []string{
+		"warning empty-renders example/empty.nomad",
+		"error invalid-jobspecs example/invalid.nomad",
+		"error undeclared-variables example/templates/example.nomad.tpl",
+		"warning unused-variables example/variables.hcl",
+	}, checks)
+
+	c.disabledChecks = []string{lintCheckEmptyRenders, lintCheckInvalidJobspecs, lintCheckUnusedVariables}
+	findings = c.lint(declared, refs, renders)
+	require.Len(t, findings, 1)
+	require.Equal(t, `variable "region" is not declared by pack "example"`, findings[0].Message)
+}
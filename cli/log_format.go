@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+const (
+	// logFormatText writes log messages to the UI as formatted text, which
+	// is the default.
+	logFormatText = "text"
+
+	// logFormatJSON writes log messages to stderr as JSON objects, one per
+	// line, for consumption by log aggregation tools.
+	logFormatJSON = "json"
+)
+
+// initLogger sets up the logger selected by the --log-format flag. It is
+// called by Init once the UI has been set up.
+func (c *baseCommand) initLogger() error {
+	switch c.logFormat {
+	case "", logFormatText:
+		c.jsonLogger = nil
+	case logFormatJSON:
+		c.jsonLogger = logging.NewJSONLogger(os.Stderr)
+	default:
+		return fmt.Errorf("invalid log format %q: must be one of %s, %s", c.logFormat, logFormatText, logFormatJSON)
+	}
+	return nil
+}
+
+// logger returns the logger passed to the lower layers of the stack, such as
+// the cache and renderer. This is the UI, unless --log-format selects JSON
+// output.
+func (c *baseCommand) logger() logging.Logger {
+	if c.jsonLogger != nil {
+		return c.jsonLogger
+	}
+	return c.ui
+}
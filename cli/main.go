@@ -17,6 +17,13 @@ const (
 	// EnvLogLevel is the env var to set with the log level.
 	EnvLogLevel = "NOMAD_PACK_LOG_LEVEL"
 
+	// EnvLogFormat is the env var to set with the log format.
+	EnvLogFormat = "NOMAD_PACK_LOG_FORMAT"
+
+	// EnvRegistryMirrors is the env var to set with the registry mirrors,
+	// as a comma separated list of registry=source values.
+	EnvRegistryMirrors = "NOMAD_PACK_REGISTRY_MIRRORS"
+
 	// EnvPlain is the env var that can be set to force plain output mode.
 	EnvPlain = "NOMAD_PACK_PLAIN"
 )
@@ -116,6 +123,16 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"lint": func() (cli.Command, error) {
+			return &LintCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"fmt": func() (cli.Command, error) {
+			return &FmtCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"run": func() (cli.Command, error) {
 			return &RunCommand{
 				baseCommand: baseCommand,
@@ -153,6 +170,41 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"generate": func() (cli.Command, error) {
+			return &GenerateHelpCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"generate var-file": func() (cli.Command, error) {
+			return &GenerateVarFileCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"deps": func() (cli.Command, error) {
+			return &DepsHelpCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"deps graph": func() (cli.Command, error) {
+			return &DepsGraphCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"cache": func() (cli.Command, error) {
+			return &CacheHelpCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"cache list": func() (cli.Command, error) {
+			return &CacheListCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"cache prune": func() (cli.Command, error) {
+			return &CachePruneCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"registry": func() (cli.Command, error) {
 			return &RegistryHelpCommand{
 				baseCommand: baseCommand,
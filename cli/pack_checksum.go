@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+
+	flag "github.com/hashicorp/nomad-pack/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+)
+
+// checksumFlag adds the flag setting the expected checksum of the pack to the
+// set.
+func (c *baseCommand) checksumFlag(f *flag.Set, cfg *cache.PackConfig) {
+	f.StringVar(&flag.StringVar{
+		Name:    "checksum",
+		Target:  &cfg.Checksum,
+		Default: "",
+		Usage: `Expected checksum of the pack, in the form algo:hex, such as
+                      sha256:3b1f... The checksum of the pack is computed
+                      before it is used, and the command fails if it does not
+                      match. Supported algorithms are sha256 and sha512. If not
+                      specified, the checksum of a registry pack is output so
+                      it can be captured.`,
+	})
+}
+
+// validateChecksum checks the --checksum flag, if set, is in the algo:hex form
+// and uses a supported algorithm.
+func validateChecksum(cfg *cache.PackConfig) error {
+	if cfg.Checksum == "" {
+		return nil
+	}
+	_, _, err := cache.ParseChecksum(cfg.Checksum)
+	return err
+}
+
+// reportPackChecksum outputs the checksum of a registry pack when --checksum
+// was not specified, so it can be used to verify the pack in future. Local
+// packs are expected to change, so their checksum is not output. Failing to
+// compute the checksum is not fatal, as it is only informational.
+func (c *baseCommand) reportPackChecksum(cfg *cache.PackConfig) {
+	if cfg.Checksum != "" || cfg.Registry == cache.DevRegistryName {
+		return
+	}
+
+	sum, err := cache.PackChecksum(cfg.Path, cache.DefaultChecksumAlgo)
+	if err != nil {
+		c.ui.Warning(fmt.Sprintf("Failed to compute checksum of pack %s: %s", cache.AppendRef(cfg.Name, cfg.Ref), err))
+		return
+	}
+	c.ui.Info(fmt.Sprintf("Pack %s has checksum %s, pass it to --checksum to verify the pack has not changed", cache.AppendRef(cfg.Name, cfg.Ref), sum))
+}
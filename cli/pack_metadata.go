@@ -0,0 +1,46 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+)
+
+// validatePackMetadata validates the metadata file of the pack against the
+// metadata schema before it is loaded, so every problem with it can be
+// reported at once. A pack without a metadata file is left for the loader to
+// reject.
+func validatePackMetadata(cfg *cache.PackConfig) error {
+	filename := filepath.Join(cfg.Path, "metadata.hcl")
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return pack.ValidateMetadataFile(filename, src)
+}
+
+// reportMetadataError outputs an error returned by validatePackMetadata. Each
+// problem with the metadata is output as a line of context.
+func (c *baseCommand) reportMetadataError(err error, errorContext *errors.UIErrorContext) {
+	var metaErr *pack.MetadataError
+	if !stdErrors.As(err, &metaErr) {
+		c.ui.ErrorWithContext(err, "failed to read pack metadata", errorContext.GetAll()...)
+		return
+	}
+
+	metaContext := errorContext.Copy()
+	for _, v := range metaErr.Violations {
+		metaContext.Add(errors.UIContextPrefixMetadataProblem, v.String())
+	}
+	c.ui.ErrorWithContext(
+		fmt.Errorf("%w: %d problem(s) found in %s", pack.ErrInvalidMetadata, len(metaErr.Violations), metaErr.File),
+		"failed to validate pack metadata", metaContext.GetAll()...)
+}
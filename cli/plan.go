@@ -32,13 +32,19 @@ func (c *PlanCommand) Run(args []string) int {
 
 	c.packConfig.Name = c.args[0]
 
+	if err = validateChecksum(c.packConfig); err != nil {
+		c.ui.Error(err.Error())
+		return 255
+	}
+
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
 
 	// verify packs exist before planning jobs
 	if err = cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
 		return 255
 	}
+	c.reportPackChecksum(c.packConfig)
 
 	// If no deploymentName set default to pack@ref
 	c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
@@ -143,6 +149,9 @@ latest.
 Using ref with a file path is not supported.`,
 		})
 
+		c.checksumFlag(f, c.packConfig)
+		c.nomadVariablesFlag(f)
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "diff",
 			Target:  &c.jobConfig.PlanConfig.Diff,
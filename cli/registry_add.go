@@ -51,18 +51,27 @@ func (c *RegistryAddCommand) Run(args []string) int {
 
 	// Add the registry or registry target to the global cache
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
-		Logger: c.ui,
+		Path:   c.cachePath(),
+		Logger: c.logger(),
 	})
 	if err != nil {
 		return 1
 	}
 
+	mirrors, err := c.mirrorsFor(c.name)
+	if err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags, errorContext.GetAll()...)
+		return 1
+	}
+
 	newRegistry, err := globalCache.Add(&cache.AddOpts{
-		RegistryName: c.name,
-		Source:       c.source,
-		PackName:     c.target,
-		Ref:          c.ref,
+		RegistryName:   c.name,
+		Source:         c.source,
+		PackName:       c.target,
+		Ref:            c.ref,
+		FetchRetries:   c.fetchRetries,
+		FetchRetryWait: c.fetchRetryWait,
+		Mirrors:        mirrors,
 	})
 	if err != nil {
 		return 1
@@ -131,8 +140,12 @@ Running "nomad registry add" multiple times for the same ref is idempotent,
 however running "nomad-pack registry add" without specifying a ref, or when 
 specifying @latest, is destructive, and will overwrite current @latest in the global cache.
 
-Using ref with a file path is not supported.`,
+Using ref with a file path is not supported. For OCI registries, the ref is 
+the tag of each pack.`,
 		})
+
+		c.fetchFlags(f)
+		c.cacheDirFlag(f)
 	})
 }
 
@@ -158,11 +171,16 @@ func (c *RegistryAddCommand) Help() string {
 
 	# Download packs from a registry at a specific tag/release/SHA.
 	nomad-pack registry add community github.com/hashicorp/nomad-pack-community-registry  --ref=v0.1.0
+
+	# Pull a pack from an OCI registry, using the docker credentials of the registry.
+	nomad-pack registry add internal oci://registry.example.com/packs --target=hello_world --ref=v0.1.0
 	`
 	return formatHelp(`
 	Usage: nomad-pack registry add <name> <source> [options]
 
-	Add nomad pack registries.
+	Add nomad pack registries. The source is either a git repository, or an OCI
+	registry prefixed with oci://, where each pack is a repository beneath the
+	source.
 	
 ` + c.GetExample() + c.Flags().Help())
 }
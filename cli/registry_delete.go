@@ -40,11 +40,10 @@ func (c *RegistryDeleteCommand) Run(args []string) int {
 	errorContext.Add(errors.UIContextPrefixRegistryName, c.name)
 	errorContext.Add(errors.UIContextPrefixRegistryTarget, c.target)
 
-	// Get the global cache dir - may be configurable in the future, so using this
-	// helper function rather than a direct reference to the CONST.
+	// Get the global cache dir, which may be overridden by the user.
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
-		Logger: c.ui,
+		Path:   c.cachePath(),
+		Logger: c.logger(),
 	})
 	if err != nil {
 		return 1
@@ -106,6 +105,8 @@ latest.
 
 Using ref with a file path is not supported.`,
 		})
+
+		c.cacheDirFlag(f)
 	})
 }
 
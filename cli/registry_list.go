@@ -18,17 +18,17 @@ func (c *RegistryListCommand) Run(args []string) int {
 	// Initialize. If we fail, we just exit since Init handles the UI.
 	if err := c.Init(
 		WithNoArgs(args),
+		WithFlags(c.Flags()),
 		WithNoConfig(),
 		WithClient(false),
 	); err != nil {
 		return 1
 	}
 
-	// Get the global cache dir - may be configurable in the future, so using this
-	// helper function rather than a direct reference to the CONST.
+	// Get the global cache dir, which may be overridden by the user.
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
-		Logger: c.ui,
+		Path:   c.cachePath(),
+		Logger: c.logger(),
 	})
 	if err != nil {
 		return 1
@@ -69,7 +69,11 @@ func (c *RegistryListCommand) Run(args []string) int {
 }
 
 func (c *RegistryListCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Registry Options")
+
+		c.cacheDirFlag(f)
+	})
 }
 
 func (c *RegistryListCommand) AutocompleteArgs() complete.Predictor {
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// registryMirrors parses the --registry-mirror values, each in the form
+// registry=source, into the mirrors of each registry. The mirrors of a
+// registry keep the order they were specified in, which is the order they
+// are tried.
+func registryMirrors(values []string) (map[string][]string, error) {
+	mirrors := make(map[string][]string)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		segments := strings.SplitN(value, "=", 2)
+		if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+			return nil, fmt.Errorf("invalid registry mirror %q: must be in the form registry=source", value)
+		}
+		mirrors[segments[0]] = append(mirrors[segments[0]], segments[1])
+	}
+	return mirrors, nil
+}
+
+// mirrorsFor returns the mirrors configured for the named registry.
+func (c *baseCommand) mirrorsFor(registry string) ([]string, error) {
+	mirrors, err := registryMirrors(c.registryMirrors)
+	if err != nil {
+		return nil, err
+	}
+	return mirrors[registry], nil
+}
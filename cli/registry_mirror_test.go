@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryMirrors(t *testing.T) {
+	mirrors, err := registryMirrors([]string{
+		"default=https://git.example.com/a",
+		"internal=https://git.example.com/internal",
+		" default=https://git.example.com/b ",
+		"",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"default":  {"https://git.example.com/a", "https://git.example.com/b"},
+		"internal": {"https://git.example.com/internal"},
+	}, mirrors)
+
+	for _, value := range []string{"https://git.example.com/a", "=https://git.example.com/a", "default="} {
+		_, err = registryMirrors([]string{value})
+		require.Error(t, err, value)
+	}
+}
@@ -4,16 +4,27 @@ import (
 	"context"
 	stdErrors "errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	v1 "github.com/hashicorp/nomad-openapi/v1"
 	"github.com/hashicorp/nomad-pack/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/upload"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/terminal"
 	"github.com/posener/complete"
 )
@@ -27,69 +38,360 @@ type RenderCommand struct {
 	// renderOutputTemplate is a boolean flag to control whether the output
 	// template is rendered.
 	renderOutputTemplate bool
+	// renderOutputTemplateFile is the name of the output template file within
+	// the pack to render, allowing packs with multiple output templates to
+	// target a specific one. Setting this implies renderOutputTemplate.
+	renderOutputTemplateFile string
+	// renderOutputTemplateDest is the name the output template of the parent
+	// pack is output as, and written to within renderToDir. When empty, the
+	// name is derived from the output template file name.
+	renderOutputTemplateDest string
+	// renderDependencyOutputs is a boolean flag to control whether the output
+	// templates of the dependency packs are also rendered and displayed.
+	renderDependencyOutputs bool
+	// renderVarSchema is a boolean flag to control whether a JSON Schema of
+	// the pack variables is output instead of the rendered templates.
+	renderVarSchema bool
+	// renderKeepTplExt is a boolean flag to control whether the rendered
+	// template names retain the .tpl extension of their source template.
+	renderKeepTplExt bool
+	// renderErrorOnEmpty is a boolean flag to control whether templates which
+	// render empty content cause the command to fail, rather than warn.
+	renderErrorOnEmpty bool
+	// renderKeepGoing is a boolean flag to control whether the remaining
+	// templates are rendered when a template fails, with the failures output
+	// once the successful renders have been.
+	renderKeepGoing bool
+	// renderSeed seeds the random template functions when renderSeedSet is
+	// true, so renders are reproducible.
+	renderSeed    int64
+	renderSeedSet bool
+	// renderTemplateFuncList lists the template functions available to pack
+	// templates rather than rendering a pack.
+	renderTemplateFuncList bool
+	// renderParallelism is the number of templates rendered concurrently.
+	renderParallelism int
 	// renderToDir is the path to write rendered job files to in addition to
 	// standard output.
 	renderToDir string
+	// renderToArchive is the path to write a gzip compressed tarball
+	// containing the rendered job files to in addition to standard output.
+	renderToArchive string
+	// renderFormat is the format used to output the renders. The text format
+	// outputs each render individually, whereas the structured formats
+	// serialize all renders into a single document.
+	renderFormat string
+	// renderDiff is a boolean flag to control whether renders are diffed
+	// against the existing files in renderToDir, rather than written.
+	renderDiff bool
+	// renderWriteConcurrency is the number of renders written to renderToDir
+	// at once.
+	renderWriteConcurrency int
+	// writeProgress displays the progress of writing large renders to
+	// renderToDir. It is only set while writeRenders is writing to an
+	// interactive terminal.
+	writeProgress terminal.StepGroup
+	// renderToURL is the URL of a remote destination, such as an S3 bucket
+	// or HTTP endpoint, to upload rendered job files to in addition to
+	// standard output.
+	renderToURL string
+	// renderForce is a boolean flag to control whether renders are written
+	// to renderToDir even when the existing file content is unchanged.
+	renderForce bool
+	// renderDedup is a boolean flag to control whether renders with the same
+	// content are hardlinked within renderToDir rather than written again.
+	renderDedup bool
+	// renderCompress is a boolean flag to control whether renders whose
+	// content exceeds renderCompressThreshold bytes are gzip compressed when
+	// written to renderToDir.
+	renderCompress          bool
+	renderCompressThreshold int
+	// renderGitCommit is the message used to commit the files written to
+	// renderToDir to the git repository containing it. When empty, no
+	// commit is made.
+	renderGitCommit string
+	// renderGitCommitAllowDirty is a boolean flag to control whether the
+	// commit is made even when the git working tree has uncommitted changes.
+	renderGitCommitAllowDirty bool
+	// renderPostRenderHook is the command run for each file written to
+	// renderToDir, which is passed the path of the file. When empty, no
+	// hook is run.
+	renderPostRenderHook string
+	// renderIgnoreHookErrors is a boolean flag to control whether a failed
+	// renderPostRenderHook is reported as a warning, rather than failing
+	// the render.
+	renderIgnoreHookErrors bool
+	// renderPrune is a boolean flag to control whether files within
+	// renderToDir which are not part of the render are removed.
+	renderPrune bool
+	// renderEmitChecksums is a boolean flag to control whether a checksum
+	// manifest of the rendered files is written to renderToDir.
+	renderEmitChecksums bool
+	// renderChecksumAlgo is the hash algorithm used for the checksum
+	// manifest.
+	renderChecksumAlgo string
+	// renderDiffBaseRef and renderDiffTargetRef are the git refs of the pack
+	// to render and diff against one another, rather than rendering the pack
+	// at a single ref.
+	renderDiffBaseRef   string
+	renderDiffTargetRef string
+	// renderOnly is a list of glob patterns used to filter the renders by
+	// their formatted name. When empty, all renders are output.
+	renderOnly []string
+	// renderSummary is a boolean flag to control whether a summary of the
+	// rendered files is output.
+	renderSummary bool
+	// renderValidate is a boolean flag to control whether the rendered job
+	// specifications are parsed to check they are valid.
+	renderValidate bool
+	// renderNoHeaders is a boolean flag to control whether a single render is
+	// output without any decoration, so that it can be piped to other tools.
+	renderNoHeaders bool
+	// renderStream is a boolean flag to control whether the renders are
+	// output as a single stream of documents separated by --- lines.
+	renderStream bool
+	// renderReport is the format of the report categorizing the disposition
+	// of each file written to renderToDir. When empty, no report is output.
+	renderReport string
+	// renderStdinPack is a boolean flag to control whether the pack is read
+	// from standard input as a gzip compressed tarball.
+	renderStdinPack bool
+	// renderWatch is a boolean flag to control whether the pack is
+	// re-rendered each time its templates or variable files change.
+	renderWatch bool
+	// renderShowEnabled outputs the jobs and groups of the rendered packs,
+	// whether each is enabled, and the variables controlling this.
+	renderShowEnabled bool
+	// renderTemplateTrace logs the parsing and execution of the pack
+	// templates, for debugging packs which render incorrectly.
+	renderTemplateTrace bool
+	// renderAgainstCluster outputs a diff of each rendered job against the
+	// job of the same name deployed to the cluster, rather than the render.
+	renderAgainstCluster bool
+	// renderPlan submits a plan of each rendered job to the Nomad cluster,
+	// outputting the scheduler's diff and predicted allocation changes.
+	renderPlan bool
+	// renderNamespace and renderRegion are the Nomad namespace and region
+	// used when querying the cluster, overriding NOMAD_NAMESPACE and
+	// NOMAD_REGION.
+	renderNamespace string
+	renderRegion    string
+	// renderUnmask disables masking the values of sensitive variables when
+	// outputting renders to the terminal.
+	renderUnmask bool
+
+	// sensitiveValues are the values of the sensitive variables of the
+	// rendered packs, which are masked when output to the terminal.
+	sensitiveValues []string
+
+	// verifyCache memoizes the packs verified during the run, so that the
+	// same pack is not verified more than once. It is shared with the pack
+	// managers of each rendered pack.
+	verifyCache *cache.VerifyCache
+
+	// renderExplainVars outputs the final value of each variable along with
+	// the source it was set from.
+	renderExplainVars bool
+
+	// renderDirMode is the octal permission used when creating directories
+	// for rendered files, which is parsed into renderDirModeValue.
+	renderDirMode      string
+	renderDirModeValue os.FileMode
+
+	// renderMaxTerminalBytes is the limit on the size of each render output
+	// to the terminal, beyond which it is truncated. Zero disables the
+	// limit.
+	renderMaxTerminalBytes int
+	// renderNoAutoVars is a boolean flag to control whether the variable
+	// files matching variable.AutoFileOverridePatterns in the working
+	// directory are loaded when rendering a local pack.
+	renderNoAutoVars bool
+	// renderAutoVarFiles are the variable files discovered in the working
+	// directory, which are loaded when rendering a local pack.
+	renderAutoVarFiles []string
 }
 
 type Render struct {
 	Name    string
 	Content string
+	// Template is the name of the pack template the render was produced
+	// from, such as example/templates/example.nomad.tpl. It is empty for
+	// renders which are not of a pack template, such as output templates.
+	Template string
+	// Mode is the permission used when writing the render to disk.
+	Mode os.FileMode
+	// Pack is the name of the pack passed to the render command which the
+	// render belongs to. This includes renders of its dependencies.
+	Pack string
+	// Parent is true when the render belongs to the parent pack, rather than
+	// one of its dependencies.
+	Parent bool
 }
 
 func (r Render) toTerminal(c *RenderCommand) {
 	c.ui.Output(r.Name+":", terminal.WithStyle(terminal.BoldStyle))
 	c.ui.Output("")
-	c.ui.Output(r.Content)
+
+	masked := c.maskSensitive(r.Content)
+	content, truncated := truncateContent(masked, c.renderMaxTerminalBytes)
+	c.ui.Output("%s", content)
+
+	if truncated {
+		notice := fmt.Sprintf("Output of %s truncated to %d of %d bytes", r.Name, len(content), len(masked))
+		if c.renderToDir != "" {
+			outFile := r.outFile(c)
+			if r.compresses(c) {
+				outFile += compressedExt
+			}
+			c.ui.Warning(fmt.Sprintf("%s; the full content is written to %s", notice, outFile))
+		} else {
+			c.ui.Warning(notice + "; use --to-dir to write the full content")
+		}
+	}
+}
+
+// sensitiveMask replaces the values of sensitive variables when renders are
+// output to the terminal.
+const sensitiveMask = "***"
+
+// sortSensitiveValues sorts the sensitive values longest first, so a value
+// containing another is masked whole when they were collected from multiple
+// packs or refs.
+func (c *RenderCommand) sortSensitiveValues() {
+	sort.SliceStable(c.sensitiveValues, func(i, j int) bool {
+		return len(c.sensitiveValues[i]) > len(c.sensitiveValues[j])
+	})
+}
+
+// maskSensitive replaces each occurrence of a sensitive variable value within
+// the content with sensitiveMask, unless --unmask is set.
+func (c *RenderCommand) maskSensitive(content string) string {
+	if c.renderUnmask || len(c.sensitiveValues) == 0 {
+		return content
+	}
+	pairs := make([]string, 0, 2*len(c.sensitiveValues))
+	for _, value := range c.sensitiveValues {
+		pairs = append(pairs, value, sensitiveMask)
+	}
+	return strings.NewReplacer(pairs...).Replace(content)
 }
 
-func (r Render) toFile(c *RenderCommand, ec *errors.UIErrorContext) error {
-	renderToDir := path.Clean(c.renderToDir)
-	err := validateOutDir(renderToDir)
+// defaultMaxTerminalBytes is the default limit on the size of each render
+// output to the terminal.
+const defaultMaxTerminalBytes = 1 << 20
+
+// truncateContent truncates the content to at most max bytes, without
+// splitting a UTF-8 encoded character, and reports whether it was truncated.
+// A max of zero disables the limit.
+func truncateContent(content string, max int) (string, bool) {
+	if max <= 0 || len(content) <= max {
+		return content, false
+	}
+	end := max
+	for end > 0 && !utf8.RuneStart(content[end]) {
+		end--
+	}
+	return content[:end], true
+}
+
+// toRaw outputs only the content of the render, without the name header, so
+// the output can be piped directly to other tools.
+func (r Render) toRaw(c *RenderCommand) {
+	c.ui.Output("%s", strings.TrimSuffix(r.Content, "\n"))
+}
+
+// toFile writes the render to its file within the --to-dir path, prompting
+// to confirm overwriting any existing file, and returns the disposition of the
+// write. Files whose content is unchanged are skipped, returning
+// errRenderUnchanged.
+func (r Render) toFile(c *RenderCommand, ec *errors.UIErrorContext) (renderDisposition, error) {
+	if r.unchanged(c) {
+		return renderSkippedUnchanged, errRenderUnchanged
+	}
+	overwrite, err := r.confirmFileOverwrite(c)
 	if err != nil {
-		ec.Add("Destination Dir: ", renderToDir)
-		return err
+		return "", err
 	}
+	return r.writeFile(c, overwrite, ec)
+}
+
+// outFile returns the path the render is written to within the --to-dir
+// directory.
+func (r Render) outFile(c *RenderCommand) string {
+	return path.Join(path.Clean(c.renderToDir), r.Name)
+}
 
-	filePath, fileName := path.Split(r.Name)
-	outDir := path.Join(renderToDir, filePath)
-	outFile := path.Join(outDir, fileName)
+// rendersToArchive writes all the passed renders into a gzip compressed
+// tarball at the configured archive path. The directory structure of the
+// render names is preserved within the archive. An existing archive is only
+// replaced once the new one has been fully written.
+func rendersToArchive(c *RenderCommand, renders []Render, ec *errors.UIErrorContext) error {
+	archivePath := path.Clean(c.renderToArchive)
 
-	maybeCreateDestinationDir(outDir)
+	exists, err := filesystem.Exists(archivePath)
+	if err != nil {
+		ec.Add("Destination Archive: ", archivePath)
+		return err
+	}
 
 	var overwrite bool
 
-	if !c.autoApproved && c.ui.Interactive() {
-		overwrite, err = confirmOverwrite(c)
+	if exists && !c.autoApproved && c.ui.Interactive() {
+		overwrite, err = confirmOverwrite(c.baseCommand)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = writeFile(outFile, r.Content, c.autoApproved || overwrite)
-	if err != nil {
-		ec.Add("Destination File: ", outFile)
+	if exists && !(c.autoApproved || overwrite) {
+		ec.Add("Destination Archive: ", archivePath)
+		return fmt.Errorf("destination archive exists and overwrite is unset: %w", os.ErrExist)
+	}
+
+	if err := maybeCreateDestinationDir(c, path.Dir(archivePath)); err != nil {
+		ec.Add("Destination Archive: ", archivePath)
 		return err
 	}
 
+	files := make([]*filesystem.ArchiveFile, len(renders))
+	for i, render := range renders {
+		files[i] = &filesystem.ArchiveFile{Name: render.Name, Content: []byte(render.Content), Mode: render.fileMode()}
+	}
+
+	// The tarball is streamed into a temporary file which is renamed into
+	// place, so an interrupted write never leaves a truncated archive over
+	// an existing one.
+	err = filesystem.WriteFileFuncContext(c.Ctx, archivePath, true, 0644, func(w io.Writer) error {
+		return filesystem.WriteTarGz(w, files)
+	})
+	if err != nil {
+		ec.Add("Destination Archive: ", archivePath)
+		return fmt.Errorf("failed to write archive: %v", err)
+	}
+
 	return nil
 }
 
-func confirmOverwrite(c *RenderCommand) (bool, error) {
+func confirmOverwrite(c *baseCommand) (bool, error) {
+	return confirmPrompt(c, "Output file exists, overwrite? [y/n] ")
+}
+
+// confirmPrompt asks the user the yes or no question, repeating the prompt
+// until a valid answer is given.
+func confirmPrompt(c *baseCommand, prompt string) (bool, error) {
 	for {
-		overwrite, err := c.ui.Input(&terminal.Input{
-			Prompt: "Output file exists, overwrite? [y/n] ",
+		answer, err := c.ui.Input(&terminal.Input{
+			Prompt: prompt,
 			Style:  terminal.WarningBoldStyle,
 		})
 		if err != nil {
 			return false, err
 		}
-		overwrite = strings.ToLower(overwrite)
-		if overwrite == "y" || overwrite == "n" {
-			return overwrite == "y", nil
+		answer = strings.ToLower(answer)
+		if answer == "y" || answer == "n" {
+			return answer == "y", nil
 		}
 	}
-
 }
 
 func validateOutDir(path string) error {
@@ -107,59 +409,413 @@ func validateOutDir(path string) error {
 	}
 
 	if !info.IsDir() {
-		return stdErrors.New("--to-dir must be a directory")
+		return &errors.ErrNotDirectory{Path: path}
+	}
+
+	return nil
+}
+
+// newRenders builds the list of renders from the rendered templates of the
+// pack and its dependencies, formatting each name for output.
+func newRenders(renderOutput *renderer.Rendered, keepTplExt bool) []Render {
+	var renders = []Render{}
+
+	for name, renderedFile := range renderOutput.DependentRenders() {
+		renders = append(renders, Render{
+			Name:     renderName(renderOutput, name, keepTplExt),
+			Content:  renderedFile,
+			Template: name,
+			Mode:     renderFileMode(renderOutput.FileMode(name), renderOutput.Executable(name), renderedFile),
+		})
+	}
+	for name, renderedFile := range renderOutput.ParentRenders() {
+		renders = append(renders, Render{
+			Name:     renderName(renderOutput, name, keepTplExt),
+			Content:  renderedFile,
+			Template: name,
+			Mode:     renderFileMode(renderOutput.FileMode(name), renderOutput.Executable(name), renderedFile),
+			Parent:   true,
+		})
+	}
+	return renders
+}
+
+// templateFailure is a template which failed to render when using
+// --keep-going, along with the error context of its pack.
+type templateFailure struct {
+	*renderer.TemplateFailure
+	// renderName is the name the template would have been rendered to.
+	renderName   string
+	errorContext *errors.UIErrorContext
+}
+
+// templateFailuresToTerminal outputs each of the templates which failed to
+// render, once the successful renders have been output.
+func templateFailuresToTerminal(c *RenderCommand, failures []*templateFailure) {
+	c.ui.Error(fmt.Sprintf("%d template(s) failed to render:", len(failures)))
+	for _, failure := range failures {
+		errorContext := manager.TemplateErrorContext(failure.Err)
+		errorContext.Append(failure.errorContext)
+		c.ui.ErrorWithContext(failure.Err, "failed to render template", errorContext.GetAll()...)
+	}
+}
+
+// validateDiff checks the --diff flag is used alongside the flags it
+// depends on.
+func validateDiff(c *RenderCommand) error {
+	if !c.renderDiff {
+		return nil
 	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--diff requires --to-dir")
+	}
+	if c.renderFormat != renderFormatText {
+		return stdErrors.New("--diff can only be used with the text format")
+	}
+	return nil
+}
+
+// validateDependencyOutputs checks the --include-dependency-outputs flag is
+// used alongside a flag rendering the output template of the parent pack.
+func validateDependencyOutputs(c *RenderCommand) error {
+	if c.renderDependencyOutputs && !c.renderOutputTemplate && c.renderOutputTemplateFile == "" {
+		return stdErrors.New("--include-dependency-outputs requires --render-output-template or --output-template-file")
+	}
+	return nil
+}
+
+// validateOutputTemplateDest checks the --output-template-dest flag is used
+// alongside a flag rendering the output template, and is a relative path
+// which stays within the --to-dir path.
+func validateOutputTemplateDest(c *RenderCommand) error {
+	dest := c.renderOutputTemplateDest
+	if dest == "" {
+		return nil
+	}
+	if !c.renderOutputTemplate && c.renderOutputTemplateFile == "" {
+		return stdErrors.New("--output-template-dest requires --render-output-template or --output-template-file")
+	}
+	clean := path.Clean(filepath.ToSlash(dest))
+	if path.IsAbs(clean) || filepath.IsAbs(dest) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("--output-template-dest must be a file path relative to --to-dir, got %q", dest)
+	}
+	return nil
+}
+
+// outputRenderName returns the name of the render of an output template
+// file, which is dest when set. Otherwise the .tpl extension is replaced by
+// .txt, so the file is not mistaken for a template when written to disk,
+// unless the name has another extension or keepTplExt is set.
+func outputRenderName(name, dest string, keepTplExt bool) string {
+	if dest != "" {
+		return path.Clean(filepath.ToSlash(dest))
+	}
+	if keepTplExt || !strings.HasSuffix(name, ".tpl") {
+		return name
+	}
+	name = strings.TrimSuffix(name, ".tpl")
+	if path.Ext(name) == "" {
+		name += ".txt"
+	}
+	return name
+}
+
+// validateNoHeaders checks the --no-headers flag is not combined with a
+// structured output format.
+func validateNoHeaders(c *RenderCommand) error {
+	if c.renderNoHeaders && c.renderFormat != renderFormatText {
+		return stdErrors.New("--no-headers can only be used with the text format")
+	}
+	return nil
+}
 
+// validateQuiet checks the --quiet flag is not combined with flags whose
+// only purpose is to output to the terminal.
+func validateQuiet(c *RenderCommand) error {
+	if !c.flagQuiet {
+		return nil
+	}
+	switch {
+	case c.renderNoHeaders:
+		return stdErrors.New("--quiet cannot be used with --no-headers")
+	case c.renderDiff:
+		return stdErrors.New("--quiet cannot be used with --diff")
+	}
 	return nil
 }
 
-func maybeCreateDestinationDir(path string) error {
-	_, err := os.Stat(path)
+// selectRawRender returns the single render to output when using
+// --no-headers. The outputs template is only selected when there are no
+// other renders. An error is returned if more than one render remains.
+func selectRawRender(renders []Render, outputRender *Render) (Render, error) {
+	switch {
+	case len(renders) == 1:
+		return renders[0], nil
+	case len(renders) == 0 && outputRender != nil:
+		return *outputRender, nil
+	case len(renders) == 0:
+		return Render{}, stdErrors.New("--no-headers requires a render, but none were selected")
+	}
+
+	names := make([]string, len(renders))
+	for i, render := range renders {
+		names[i] = render.Name
+	}
+	sort.Strings(names)
+
+	return Render{}, fmt.Errorf("--no-headers requires exactly one render, but %d were selected: %s; use --only to select a single render",
+		len(renders), strings.Join(names, ", "))
+}
+
+// validateOutArchive checks the --to-archive path is usable and does not
+// collide with the --to-dir path.
+func validateOutArchive(archivePath, dirPath string) error {
+	if archivePath == "" {
+		return nil
+	}
 
-	// If the directory doesn't exist, create it.
-	if stdErrors.Is(err, fs.ErrNotExist) {
-		err := os.MkdirAll(path, 0755)
+	if dirPath != "" {
+		absArchive, err := filepath.Abs(archivePath)
 		if err != nil {
-			return err
+			return fmt.Errorf("unexpected error validating --to-archive path: %w", err)
+		}
+		absDir, err := filepath.Abs(dirPath)
+		if err != nil {
+			return fmt.Errorf("unexpected error validating --to-dir path: %w", err)
+		}
+		if absArchive == absDir {
+			return stdErrors.New("--to-archive and --to-dir must not be the same path")
+		}
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		if stdErrors.Is(err, fs.ErrNotExist) {
+			return nil
 		}
+		return fmt.Errorf("unexpected error validating --to-archive path: %w", err)
+	}
+
+	if info.IsDir() {
+		return stdErrors.New("--to-archive must not be a directory")
 	}
 
 	return nil
 }
 
-func writeFile(path string, content string, overwrite bool) error {
-	// Check to see if the file already exists and validate against the value
-	// of overwrite.
+// maybeCreateDestinationDir creates the directory at path, along with any
+// missing parents, using the --dir-mode permissions.
+func maybeCreateDestinationDir(c *RenderCommand, path string) error {
+	return filesystem.CreatePath(path, c.dirMode())
+}
 
-	_, err := os.Stat(path)
+// defaultDirMode is the default permission used when creating directories
+// for rendered files.
+const defaultDirMode os.FileMode = 0755
 
-	if err == nil && !overwrite {
-		return fmt.Errorf("destination file exists and overwrite is unset")
+// dirMode returns the permission used when creating directories for rendered
+// files, defaulting to 0755 when --dir-mode is unset.
+func (c *RenderCommand) dirMode() os.FileMode {
+	if c.renderDirModeValue == 0 {
+		return defaultDirMode
 	}
+	return c.renderDirModeValue
+}
 
-	err = ioutil.WriteFile(path, []byte(content), 0644)
+// parseDirMode parses the octal --dir-mode value. The mode must only contain
+// permission bits, and must allow the owner to write to and traverse the
+// directory, so that the rendered files can be written within it.
+func parseDirMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
 	if err != nil {
-		return fmt.Errorf("failed to write rendered template to file: %s", err)
+		return 0, fmt.Errorf("invalid --dir-mode %q: must be an octal permission such as 0755", s)
+	}
+	if mode&^uint64(os.ModePerm) != 0 {
+		return 0, fmt.Errorf("invalid --dir-mode %q: must only contain permission bits", s)
 	}
+	if mode&0300 != 0300 {
+		return 0, fmt.Errorf("invalid --dir-mode %q: must allow the owner to write and execute", s)
+	}
+	return os.FileMode(mode), nil
+}
 
-	return nil
+// isEmpty returns whether the render content is empty or only whitespace.
+func (r Render) isEmpty() bool { return strings.TrimSpace(r.Content) == "" }
+
+// fileMode returns the permission used when writing the render, defaulting
+// to 0644 when no mode has been set.
+func (r Render) fileMode() os.FileMode {
+	if r.Mode == 0 {
+		return 0644
+	}
+	return r.Mode
+}
+
+// renderFileMode determines the permission a render should be written with.
+// Renders are executable if their source template is executable, the pack
+// metadata annotates the template as executable, or the rendered content
+// starts with a shebang, otherwise the default 0644 is used.
+func renderFileMode(templateMode os.FileMode, executable bool, content string) os.FileMode {
+	if templateMode&0111 != 0 || executable || strings.HasPrefix(content, "#!") {
+		return 0755
+	}
+	return 0644
+}
+
+// renderName returns the name of the render of the named template, which is
+// the output path annotated by the pack metadata if there is one, otherwise
+// the name formatted from the template name.
+func renderName(renderOutput *renderer.Rendered, name string, keepTplExt bool) string {
+	if outputPath := renderOutput.OutputPath(name); outputPath != "" {
+		return outputPath
+	}
+	return formatRenderName(name, keepTplExt)
+}
+
+// checkRenderCollisions returns an error if more than one render has the same
+// name, such as when the pack metadata annotates templates with the same
+// output path.
+func checkRenderCollisions(renders []Render) error {
+	sources := make(map[string][]string, len(renders))
+	for _, render := range renders {
+		source := render.Template
+		if source == "" {
+			source = render.Name
+		}
+		sources[render.Name] = append(sources[render.Name], source)
+	}
+
+	names := make([]string, 0, len(sources))
+	for name, srcs := range sources {
+		if len(srcs) > 1 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	srcs := sources[names[0]]
+	sort.Strings(srcs)
+	return fmt.Errorf("templates %s render to the same path %s", strings.Join(srcs, ", "), names[0])
 }
 
 // formatRenderName trims the low-value elements from the rendered template
-// name.
-func formatRenderName(name string) string {
+// name, leaving the name of the pack the template belongs to followed by its
+// path, such as example/job.nomad. Dependency renders, including those of
+// transitive dependencies, are therefore labeled by their origin pack. The
+// .tpl extension is removed unless keepTplExt is true.
+func formatRenderName(name string, keepTplExt bool) string {
 	outName := strings.Replace(name, "/templates/", "/", 1)
-	outName = strings.TrimRight(outName, ".tpl")
+	if !keepTplExt {
+		outName = strings.TrimSuffix(outName, ".tpl")
+	}
 
 	return outName
 }
 
+// varSchemaFileName is the file name used when writing the variable schema to
+// the --to-dir directory.
+const varSchemaFileName = "variables.schema.json"
+
+// emitVarSchema outputs a JSON Schema describing the pack variables, writing
+// it to the --to-dir directory when set, otherwise to the terminal.
+func (c *RenderCommand) emitVarSchema(packManager *manager.PackManager, errorContext *errors.UIErrorContext) int {
+	parsedVars, errs := packManager.ProcessRootVariables()
+	if errs != nil {
+		for _, err := range errs {
+			err.Context.Append(errorContext)
+			c.ui.ErrorWithContext(err.Err, "failed to process pack variables", err.Context.GetAll()...)
+		}
+		return 1
+	}
+
+	schema, err := parsedVars.JSONSchema(packManager.ParentName())
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to generate variable schema", errorContext.GetAll()...)
+		return 1
+	}
+
+	if c.renderToDir == "" {
+		c.ui.Output("%s", string(schema))
+		return 0
+	}
+
+	render := Render{Name: varSchemaFileName, Content: string(schema) + "\n"}
+	disposition, err := render.toFile(c, errorContext)
+	if err != nil {
+		switch {
+		case stdErrors.Is(err, errRenderUnchanged):
+			c.ui.Info(fmt.Sprintf("Skipped writing %s: %s", render.Name, err))
+		case stdErrors.Is(err, os.ErrExist):
+			c.ui.Warning(fmt.Sprintf("Skipped writing %s: %s", render.Name, err))
+		case stdErrors.Is(err, context.Canceled):
+			return renderExitError
+		default:
+			c.ui.ErrorWithContext(err, "failed to render to file", errorContext.GetAll()...)
+			return renderExitWriteFailed
+		}
+	}
+
+	report := newRenderReport()
+	report.add(render.outFile(c), disposition)
+	if err := report.toTerminal(c); err != nil {
+		c.ui.ErrorWithContext(err, "failed to output report", errorContext.GetAll()...)
+		return 1
+	}
+	return 0
+}
+
+// filterRenders returns the renders whose name matches at least one of the
+// passed glob patterns. An error listing the available render names is
+// returned if no render matches.
+func filterRenders(renders []Render, patterns []string) ([]Render, error) {
+	var filtered []Render
+
+	for _, render := range renders {
+		for _, pattern := range patterns {
+			match, err := path.Match(pattern, render.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --only pattern %q: %w", pattern, err)
+			}
+			if match {
+				filtered = append(filtered, render)
+				break
+			}
+		}
+	}
+
+	if len(filtered) == 0 {
+		names := make([]string, len(renders))
+		for i, render := range renders {
+			names[i] = render.Name
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("no renders match the --only patterns %s, available renders are: %s",
+			strings.Join(patterns, ", "), strings.Join(names, ", "))
+	}
+
+	return filtered, nil
+}
+
+// The exit codes of the render command, which allow scripts to distinguish
+// a pack which rendered nothing, and renders which could not be written, from
+// other failures.
+const (
+	renderExitSuccess     = 0
+	renderExitError       = 1
+	renderExitNoTemplates = 2
+	renderExitWriteFailed = 3
+)
+
 // Run satisfies the Run function of the cli.Command interface.
 func (c *RenderCommand) Run(args []string) int {
 	c.cmdKey = "render" // Add cmdKey here to print out helpUsageMessage on Init error
 
 	if err := c.Init(
-		WithExactArgs(1, args),
+		WithCustomArgs(args, renderArgs(c)),
 		WithFlags(c.Flags()),
 		WithNoConfig()); err != nil {
 
@@ -169,97 +825,767 @@ func (c *RenderCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.renderTemplateFuncList {
+		return c.runTemplateFuncList()
+	}
+
+	if err := validateStdinPack(c); err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
+	// A pack read from standard input is extracted to a temporary directory,
+	// which is then rendered as a local pack.
+	if c.renderStdinPack {
+		packPath, cleanup, err := extractStdinPack(os.Stdin)
+		defer cleanup()
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to read pack from standard input")
+			return 1
+		}
+		c.args[0] = packPath
+
+		// Standard input has been consumed, so the user can no longer be
+		// prompted.
+		c.ui = terminal.NonInteractiveUI(c.Ctx)
+	}
+
 	c.packConfig.Name = c.args[0]
 
-	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	if c.verifyCache == nil {
+		c.verifyCache = cache.NewVerifyCache(cache.DefaultVerifyCacheSize)
+	}
+
+	// Rendering multiple packs is only supported when each render is output
+	// as part of the whole.
+	multiPack := len(c.args) > 1
+	if multiPack {
+		if err := validateMultiPack(c); err != nil {
+			c.ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if err := validateChecksum(c.packConfig); err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
+	if c.renderDiffBaseRef != "" || c.renderDiffTargetRef != "" {
+		return c.runRefDiff()
+	}
+
+	// Expand the output paths so that home directory and environment variable
+	// references work even when not expanded by the shell, such as when
+	// passed as --to-dir=~/out.
+	var err error
+	if c.renderToDir, err = filesystem.ExpandPath(c.renderToDir); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		return 1
+	}
+	if c.renderToArchive, err = filesystem.ExpandPath(c.renderToArchive); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		return 1
+	}
 
-	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+	targets, ok := c.initPackTargets()
+	if !ok {
 		return 1
 	}
 
+	// The error context identifies the pack when rendering a single pack.
+	// Otherwise, errors which do not relate to a specific pack have no
+	// pack context.
+	errorContext := targets[0].errorContext
+	if multiPack {
+		errorContext = errors.NewUIErrorContext()
+	}
+
 	client, err := v1.NewClient()
 	if err != nil {
 		c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
 		return 1
 	}
 	err = validateOutDir(c.renderToDir)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "invalid --to-dir path", errors.FilesystemContextDestDir+c.renderToDir)
+		return 1
+	}
+	err = validateOutArchive(c.renderToArchive, c.renderToDir)
 	if err != nil {
 		c.ui.Error(err.Error())
 		return 1
 	}
-	packManager := generatePackManager(c.baseCommand, client, c.packConfig)
-
-	renderOutput, err := renderPack(packManager, c.baseCommand.ui, errorContext)
+	err = validateDiff(c)
 	if err != nil {
+		c.ui.Error(err.Error())
 		return 1
 	}
-
-	// The render command should at least render one parent, or one dependant
-	// pack template.
-	if renderOutput.LenParentRenders() < 1 && renderOutput.LenDependentRenders() < 1 {
-		c.ui.ErrorWithContext(errors.ErrNoTemplatesRendered, "no templates rendered", errorContext.GetAll()...)
+	err = validateNoHeaders(c)
+	if err != nil {
+		c.ui.Error(err.Error())
 		return 1
 	}
-
-	var renders = []Render{}
-
-	// Iterate the rendered files and add these to the list of renders to
-	// output. This allows errors to surface and end things without emitting
-	// partial output and then erroring out.
-
-	for name, renderedFile := range renderOutput.DependentRenders() {
-		renders = append(renders, Render{Name: formatRenderName(name), Content: renderedFile})
+	err = validateQuiet(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
 	}
-	for name, renderedFile := range renderOutput.ParentRenders() {
-		renders = append(renders, Render{Name: formatRenderName(name), Content: renderedFile})
+	err = validateStream(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
 	}
-
-	// If the user wants to render and display the outputs template file then
-	// render this. In the event the render returns an error, print this but do
-	// not exit. The render can fail due to template function errors, but we
-	// can still display the pack templates from above. The error will be
-	// displayed before the template renders, so the UI looks OK.
-	if c.renderOutputTemplate {
-		outputRender, err := packManager.ProcessOutputTemplate()
-		if err != nil {
-			c.ui.ErrorWithContext(err, "failed to render output template", errorContext.GetAll()...)
-		} else {
-			renders = append(renders, Render{Name: "outputs.tpl", Content: outputRender})
-		}
+	err = validatePlan(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateToURL(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
 	}
 
-	// Output the renders. Output the files first if enabled so that any renders
-	// that display will also have been written to disk.
-	for _, render := range renders {
-		if c.renderToDir != "" {
-			err = render.toFile(c, errorContext)
-			if err != nil {
-				if stdErrors.Is(err, context.Canceled) {
-					return 1
-				}
-				c.ui.ErrorWithContext(err, "failed to render to file", errorContext.GetAll()...)
-				return 1
-			}
+	var uploader upload.Uploader
+	if c.renderToURL != "" {
+		if uploader, err = upload.New(c.renderToURL); err != nil {
+			c.ui.ErrorWithContext(err, "failed to initialize uploader", errorContext.GetAll()...)
+			return 1
 		}
-		render.toTerminal(c)
 	}
 
-	return 0
-}
-
-func (c *RenderCommand) Flags() *flag.Sets {
-	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
-		c.packConfig = &cache.PackConfig{}
-
-		f := set.NewSet("Render Options")
-
-		f.StringVar(&flag.StringVar{
-			Name:    "registry",
-			Target:  &c.packConfig.Registry,
-			Default: "",
-			Usage: `Specific registry name containing the pack to be rendered.
-If not specified, the default registry will be used.`,
+	err = validatePrune(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateEmitChecksums(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	if c.renderDedup && c.renderToDir == "" {
+		c.ui.Error("--dedup requires --to-dir")
+		return 1
+	}
+	if c.renderWriteConcurrency < 1 {
+		c.ui.Error("--write-concurrency must be at least 1")
+		return 1
+	}
+	if c.renderParallelism < 1 {
+		c.ui.Error("--parallelism must be at least 1")
+		return 1
+	}
+	if c.renderMaxTerminalBytes < 0 {
+		c.ui.Error("--max-terminal-bytes must not be negative")
+		return 1
+	}
+	if c.renderDirMode != "" {
+		mode, err := parseDirMode(c.renderDirMode)
+		if err != nil {
+			c.ui.Error(err.Error())
+			return 1
+		}
+		c.renderDirModeValue = mode
+	}
+	err = validateGitCommit(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validatePostRenderHook(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateReport(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateExplainVars(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateWatch(c, targets)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateShowEnabled(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateAgainstCluster(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateNomadVariables(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateCompress(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateDependencyOutputs(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	err = validateOutputTemplateDest(c)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+	if !c.offline {
+		if err := c.checkNamespace(client); err != nil {
+			c.ui.ErrorWithContext(err, "invalid namespace", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// The git working tree is checked before anything is written, as the
+	// writes themselves would make it dirty.
+	if c.renderGitCommit != "" {
+		if err := c.checkGitClean(errorContext); err != nil {
+			c.ui.ErrorWithContext(err, "failed to check git working tree", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	if !c.renderNoAutoVars {
+		if c.renderAutoVarFiles, err = variable.FindAutoFileOverrides("."); err != nil {
+			c.ui.ErrorWithContext(err, "failed to find variable files", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// Emitting the variable schema replaces rendering the templates, since
+	// variables without defaults may not have been provided.
+	if c.renderVarSchema {
+		return c.emitVarSchema(c.packManager(client, targets[0], targets), errorContext)
+	}
+
+	if c.renderWatch {
+		return c.watch(client, targets, uploader, errorContext)
+	}
+	return c.renderTargets(client, targets, uploader, errorContext)
+}
+
+// renderTargets renders each of the pack targets and outputs the renders,
+// returning the exit code.
+func (c *RenderCommand) renderTargets(client *v1.Client, targets []*renderPackTarget, uploader upload.Uploader,
+	errorContext *errors.UIErrorContext) int {
+
+	multiPack := len(targets) > 1
+
+	// The sensitive values are collected afresh on each render, as they may
+	// have changed when watching the pack.
+	c.sensitiveValues = nil
+
+	var (
+		renders                         []Render
+		outputRender                    *Render
+		numParentRenders, numDepRenders int
+		explanations                    []*variable.Explanation
+		enabledBlocks                   []*enabledBlock
+		failures                        []*templateFailure
+	)
+
+	for _, target := range targets {
+		packManager := c.packManager(client, target, targets)
+
+		renderOutput, err := renderPack(packManager, c.baseCommand.ui, target.errorContext)
+		if err != nil {
+			return 1
+		}
+
+		for _, failure := range renderOutput.Failures() {
+			failures = append(failures, &templateFailure{
+				TemplateFailure: failure,
+				renderName:      renderName(renderOutput, failure.Name, c.renderKeepTplExt),
+				errorContext:    target.errorContext,
+			})
+		}
+
+		// The render command should at least render one parent, or one
+		// dependant pack template, unless the templates failed and are
+		// reported below.
+		if renderOutput.LenParentRenders() < 1 && renderOutput.LenDependentRenders() < 1 && len(renderOutput.Failures()) == 0 {
+			c.ui.ErrorWithContext(errors.ErrNoTemplatesRendered, "no templates rendered", target.errorContext.GetAll()...)
+			return renderExitNoTemplates
+		}
+		numParentRenders += renderOutput.LenParentRenders()
+		numDepRenders += renderOutput.LenDependentRenders()
+
+		if processed := packManager.ProcessedVariables(); processed != nil {
+			c.sensitiveValues = append(c.sensitiveValues, processed.SensitiveValues()...)
+		}
+
+		if c.renderExplainVars {
+			packExplanations, err := packManager.ProcessedVariables().Explain()
+			if err != nil {
+				c.ui.ErrorWithContext(err, "failed to explain variables", target.errorContext.GetAll()...)
+				return 1
+			}
+			explanations = append(explanations, packExplanations...)
+		}
+
+		// Iterate the rendered files and add these to the list of renders to
+		// output. This allows errors to surface and end things without
+		// emitting partial output and then erroring out.
+		packRenders := newRenders(renderOutput, c.renderKeepTplExt)
+
+		// If the user wants to render and display the outputs template file
+		// then render this. In the event the render returns an error, print
+		// this but do not exit. The render can fail due to template function
+		// errors, but we can still display the pack templates from above. The
+		// error will be displayed before the template renders, so the UI
+		// looks OK. A named output template which does not exist is a user
+		// error, so we exit.
+		if c.renderOutputTemplate || c.renderOutputTemplateFile != "" {
+			outputContent, err := packManager.ProcessOutputTemplate(c.renderOutputTemplateFile)
+			if err != nil {
+				outputErrorContext := manager.TemplateErrorContext(err)
+				outputErrorContext.Append(target.errorContext)
+				c.ui.ErrorWithContext(err, "failed to render output template", outputErrorContext.GetAll()...)
+				if stdErrors.Is(err, pack.ErrOutputTemplateNotFound) {
+					return 1
+				}
+			} else {
+				outputFile := c.renderOutputTemplateFile
+				if outputFile == "" {
+					outputFile = "outputs.tpl"
+				}
+				outputName := outputRenderName(outputFile, c.renderOutputTemplateDest, c.renderKeepTplExt)
+
+				// When rendering multiple packs, each output template is
+				// named for its pack and output along with the renders.
+				if multiPack {
+					packRenders = append(packRenders, Render{
+						Name:    path.Join(target.cfg.Name, outputName),
+						Content: outputContent,
+					})
+				} else {
+					outputRender = &Render{Name: outputName, Content: outputContent}
+				}
+			}
+
+			// The output templates of the dependency packs are named for
+			// their pack and output along with the renders. As with the
+			// parent, failures are reported without aborting.
+			if c.renderDependencyOutputs {
+				for _, output := range packManager.ProcessDependencyOutputTemplates() {
+					if output.Err != nil {
+						outputErrorContext := manager.TemplateErrorContext(output.Err)
+						outputErrorContext.Append(target.errorContext)
+						c.ui.ErrorWithContext(output.Err, "failed to render dependency output template", outputErrorContext.GetAll()...)
+						continue
+					}
+					packRenders = append(packRenders, Render{
+						Name:    outputRenderName(output.Name, "", c.renderKeepTplExt),
+						Content: output.Content,
+					})
+				}
+			}
+		}
+
+		for i := range packRenders {
+			packRenders[i].Pack = target.cfg.Name
+		}
+
+		if c.renderShowEnabled {
+			blocks, err := findEnabledBlocks(target.cfg.Name, packRenders, packManager.TemplateBlocks())
+			if err != nil {
+				c.ui.ErrorWithContext(err, "failed to find enabled jobs and groups", target.errorContext.GetAll()...)
+				return 1
+			}
+			enabledBlocks = append(enabledBlocks, blocks...)
+		}
+		renders = append(renders, packRenders...)
+	}
+
+	c.sortSensitiveValues()
+
+	if len(c.renderOnly) > 0 {
+		var err error
+		renders, err = filterRenders(renders, c.renderOnly)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to filter renders", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// Validate the job specifications before they are output, so the errors
+	// are displayed first. Failures are reported for all renders, rather than
+	// the first, and still allow the renders to be output for debugging.
+	var validateFailed bool
+
+	if c.renderValidate {
+		for _, render := range renders {
+			if !render.shouldValidate() {
+				continue
+			}
+			if err := render.validate(); err != nil {
+				c.ui.ErrorWithContext(err, "failed to validate render", errorContext.GetAll()...)
+				validateFailed = true
+			}
+		}
+	}
+
+	allRenders := renders
+	if outputRender != nil {
+		allRenders = append(allRenders, *outputRender)
+	}
+
+	// Renders with the same name would overwrite each other, so this is
+	// checked before any are written.
+	if err := checkRenderCollisions(allRenders); err != nil {
+		c.ui.ErrorWithContext(err, "conflicting render names", errorContext.GetAll()...)
+		return 1
+	}
+
+	// Templates which render only whitespace usually indicate a conditional
+	// that produced nothing, which run will silently ignore. Warn about these,
+	// or fail before outputting anything if requested.
+	var emptyFailed bool
+
+	for _, render := range allRenders {
+		if !render.isEmpty() {
+			continue
+		}
+		if c.renderErrorOnEmpty {
+			c.ui.ErrorWithContext(fmt.Errorf("template %s rendered empty content", render.Name),
+				"empty render", errorContext.GetAll()...)
+			emptyFailed = true
+		} else {
+			c.ui.Warning(fmt.Sprintf("Template %s rendered empty content", render.Name))
+		}
+	}
+
+	if emptyFailed {
+		return 1
+	}
+
+	// Raw output only makes sense for a single render, so make sure we have
+	// exactly one to output.
+	if c.renderNoHeaders {
+		selected, err := selectRawRender(renders, outputRender)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to select render", errorContext.GetAll()...)
+			return 1
+		}
+		allRenders = []Render{selected}
+	}
+
+	// The renders written to --to-dir differ from those output when they
+	// are compressed, in which case the compressed names must not collide
+	// either.
+	fileRenders := allRenders
+	if c.renderCompress {
+		var err error
+		if fileRenders, err = compressRenders(c, allRenders); err != nil {
+			c.ui.ErrorWithContext(err, "failed to compress renders", errorContext.GetAll()...)
+			return 1
+		}
+		if err := checkRenderCollisions(fileRenders); err != nil {
+			c.ui.ErrorWithContext(err, "conflicting render names", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	var summary *renderSummary
+	if c.renderSummary {
+		summary = newRenderSummary(numParentRenders, numDepRenders, allRenders)
+	}
+
+	// Track whether any write failed so that we can exit non-zero once all
+	// the renders have been output. Declining to overwrite an existing file
+	// is not considered a failure.
+	var writeFailed bool
+
+	// Write the files first if enabled so that any renders that display will
+	// also have been written to disk. The writes are performed concurrently,
+	// but the results are reported in render order below.
+	var writes []renderWrite
+	report := newRenderReport()
+	if c.renderToDir != "" && !c.renderDiff {
+		writes = c.writeRenders(fileRenders, errorContext)
+		for i, render := range fileRenders {
+			report.add(render.outFile(c), writes[i].disposition)
+		}
+
+		// Hooks are run once the files are written, and before any
+		// checksums of them are computed, as hooks such as formatters may
+		// rewrite the files.
+		if c.renderPostRenderHook != "" {
+			hooks := c.runPostRenderHooks(fileRenders, writes)
+			if postRenderHooksToTerminal(c, hooks, errorContext) {
+				if err := c.Ctx.Err(); err != nil {
+					return 1
+				}
+				writeFailed = true
+			}
+		}
+	}
+
+	// Uploads are performed sequentially as each may need to prompt.
+	var uploads []renderWrite
+	if uploader != nil {
+		uploads = c.uploadRenders(uploader, allRenders, errorContext)
+	}
+
+	// The renders are compared against the deployed jobs before any are
+	// output. When the cluster cannot be reached, the renders are output as
+	// usual instead.
+	var clusterDiffs []*clusterJobDiff
+	if c.renderAgainstCluster {
+		var err error
+		clusterDiffs, err = diffAgainstCluster(client, allRenders, c.namespace(), c.region())
+		if err != nil {
+			if !stdErrors.Is(err, errClusterUnreachable) {
+				c.ui.ErrorWithContext(err, "failed to compare against cluster", errorContext.GetAll()...)
+				return 1
+			}
+			c.ui.Warning(fmt.Sprintf("Rendering without comparing against the cluster: %s", err))
+		}
+	}
+
+	// Output the renders.
+	for i, render := range allRenders {
+		// In diff mode the renders are compared against the existing files
+		// rather than being written or displayed.
+		if c.renderDiff {
+			if err := render.toDiff(c, errorContext); err != nil {
+				c.ui.ErrorWithContext(err, "failed to diff render", errorContext.GetAll()...)
+				return 1
+			}
+			continue
+		}
+		if writes != nil {
+			if err := writes[i].err; err != nil {
+				if stdErrors.Is(err, context.Canceled) {
+					return 1
+				}
+				if stdErrors.Is(err, errRenderUnchanged) {
+					c.ui.Info(fmt.Sprintf("Skipped writing %s: %s", fileRenders[i].Name, err))
+				} else if stdErrors.Is(err, os.ErrExist) {
+					c.ui.Warning(fmt.Sprintf("Skipped writing %s: %s", fileRenders[i].Name, err))
+				} else {
+					c.ui.ErrorWithContext(err, "failed to render to file", writes[i].errorContext.GetAll()...)
+					writeFailed = true
+				}
+			}
+		}
+		if uploads != nil {
+			if err := uploads[i].err; err != nil {
+				if stdErrors.Is(err, context.Canceled) {
+					return 1
+				}
+				if stdErrors.Is(err, os.ErrExist) {
+					c.ui.Warning(fmt.Sprintf("Skipped uploading %s: %s", render.Name, err))
+				} else {
+					c.ui.ErrorWithContext(err, "failed to upload render", uploads[i].errorContext.GetAll()...)
+					writeFailed = true
+				}
+			}
+		}
+		if c.renderFormat == renderFormatText {
+			if c.renderNoHeaders {
+				render.toRaw(c)
+			} else if c.renderStream {
+				render.toStream(c)
+			} else {
+				// Separate the renders of each pack when rendering
+				// multiple packs.
+				if multiPack && (i == 0 || allRenders[i-1].Pack != render.Pack) {
+					c.ui.Output(fmt.Sprintf("Pack %s", render.Pack), terminal.WithHeaderStyle())
+				}
+				if clusterDiffs != nil && clusterDiffs[i] != nil {
+					clusterDiffs[i].toTerminal(c, render.Name)
+				} else {
+					render.toTerminal(c)
+				}
+			}
+		}
+	}
+
+	if c.renderDedup && writes != nil {
+		dedupToTerminal(c, writes)
+	}
+
+	if c.renderToArchive != "" {
+		if err := rendersToArchive(c, allRenders, errorContext); err != nil {
+			if stdErrors.Is(err, context.Canceled) {
+				return 1
+			}
+			if stdErrors.Is(err, os.ErrExist) {
+				c.ui.Warning(fmt.Sprintf("Skipped writing archive: %s", err))
+			} else {
+				c.ui.ErrorWithContext(err, "failed to render to archive", errorContext.GetAll()...)
+				writeFailed = true
+			}
+		}
+	}
+
+	// The checksum manifest is only written once all the renders have been,
+	// as otherwise it would not match the contents of the directory.
+	if c.renderEmitChecksums {
+		if renderWritesSucceeded(writes) && len(failures) == 0 {
+			disposition, err := c.writeChecksumManifest(fileRenders, errorContext)
+			report.add(Render{Name: checksumManifestName(c.renderChecksumAlgo)}.outFile(c), disposition)
+			if err != nil {
+				if stdErrors.Is(err, context.Canceled) {
+					return 1
+				}
+				if stdErrors.Is(err, errRenderUnchanged) {
+					c.ui.Info(fmt.Sprintf("Skipped writing %s: %s", checksumManifestName(c.renderChecksumAlgo), err))
+				} else if stdErrors.Is(err, os.ErrExist) {
+					c.ui.Warning(fmt.Sprintf("Skipped writing %s: %s", checksumManifestName(c.renderChecksumAlgo), err))
+				} else {
+					c.ui.ErrorWithContext(err, "failed to write checksums", errorContext.GetAll()...)
+					writeFailed = true
+				}
+			}
+		} else {
+			c.ui.Warning(fmt.Sprintf("Skipped writing %s as not all renders were written",
+				checksumManifestName(c.renderChecksumAlgo)))
+		}
+	}
+
+	// Structured formats are output as a single document, rather than per
+	// render, and skip the header styling so the output can be parsed.
+	if c.renderFormat != renderFormatText {
+		doc, err := newRenderDocument(renders, outputRender, summary).marshal(c.renderFormat)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to format renders", errorContext.GetAll()...)
+			return 1
+		}
+
+		if c.renderToDir != "" {
+			manifest := Render{Name: renderManifestName(c.renderFormat), Content: string(doc)}
+			disposition, err := manifest.toFile(c, errorContext)
+			report.add(manifest.outFile(c), disposition)
+			if err != nil {
+				if stdErrors.Is(err, context.Canceled) {
+					return 1
+				}
+				if stdErrors.Is(err, errRenderUnchanged) {
+					c.ui.Info(fmt.Sprintf("Skipped writing %s: %s", manifest.Name, err))
+				} else if stdErrors.Is(err, os.ErrExist) {
+					c.ui.Warning(fmt.Sprintf("Skipped writing %s: %s", manifest.Name, err))
+				} else {
+					c.ui.ErrorWithContext(err, "failed to render to file", errorContext.GetAll()...)
+					writeFailed = true
+				}
+			}
+		}
+		c.ui.Output("%s", string(doc))
+	} else if summary != nil {
+		summary.toTerminal(c)
+	}
+
+	// The jobs are planned once the renders have been output, so the plans
+	// follow them. When the cluster cannot be reached, the renders are left
+	// as the output.
+	var planFailed bool
+	if c.renderPlan {
+		plans, err := planAgainstCluster(client, allRenders, c.namespace(), c.region())
+		switch {
+		case err == nil:
+			plansToTerminal(c, plans)
+		case stdErrors.Is(err, errClusterUnreachable):
+			c.ui.Warning(fmt.Sprintf("Rendered without planning against the cluster: %s", err))
+		default:
+			c.ui.ErrorWithContext(err, "failed to plan against cluster", errorContext.GetAll()...)
+			planFailed = true
+		}
+	}
+
+	if c.renderExplainVars {
+		explainVarsToTerminal(c, explanations)
+	}
+
+	if c.renderShowEnabled {
+		showEnabledToTerminal(c, enabledBlocks)
+	}
+
+	// The files written to --to-dir are those kept when pruning, and are
+	// those committed to git along with any pruned files.
+	written := make([]string, 0, len(fileRenders)+2)
+	for _, render := range fileRenders {
+		written = append(written, render.Name)
+	}
+
+	// The files of templates which failed to render are kept, as they could
+	// not be replaced.
+	for _, failure := range failures {
+		written = append(written, failure.renderName)
+	}
+	if c.renderFormat != renderFormatText {
+		written = append(written, renderManifestName(c.renderFormat))
+	}
+	if c.renderEmitChecksums {
+		written = append(written, checksumManifestName(c.renderChecksumAlgo))
+	}
+
+	var pruned []string
+	if c.renderPrune {
+		var err error
+		if pruned, err = c.pruneRenders(written, errorContext); err != nil {
+			c.ui.ErrorWithContext(err, "failed to prune files", errorContext.GetAll()...)
+			return renderExitWriteFailed
+		}
+	}
+
+	if c.renderGitCommit != "" {
+		if writeFailed || len(failures) > 0 {
+			c.ui.Warning("Skipped git commit as not all renders were written")
+		} else if err := c.gitCommitRenders(append(written, pruned...), errorContext); err != nil {
+			c.ui.ErrorWithContext(err, "failed to commit to git", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	if err := report.toTerminal(c); err != nil {
+		c.ui.ErrorWithContext(err, "failed to output report", errorContext.GetAll()...)
+		return 1
+	}
+
+	if len(failures) > 0 {
+		templateFailuresToTerminal(c, failures)
+		return renderExitError
+	}
+
+	switch {
+	case validateFailed || planFailed:
+		return renderExitError
+	case writeFailed:
+		return renderExitWriteFailed
+	}
+
+	return renderExitSuccess
+}
+
+func (c *RenderCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
+		c.packConfig = &cache.PackConfig{}
+
+		f := set.NewSet("Render Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry",
+			Target:  &c.packConfig.Registry,
+			Default: "",
+			Usage: `Specific registry name containing the pack to be rendered.
+If not specified, the default registry will be used.`,
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -272,6 +1598,11 @@ Supports tags, SHA, and latest. If no ref is specified, defaults to latest.
 Using ref with a file path is not supported.`,
 		})
 
+		c.checksumFlag(f, c.packConfig)
+		c.nomadVariablesFlag(f)
+
+		c.offlineFlag(f)
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "render-output-template",
 			Target:  &c.renderOutputTemplate,
@@ -280,6 +1611,118 @@ Using ref with a file path is not supported.`,
                       pack is rendered and displayed.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:   "output-template-file",
+			Target: &c.renderOutputTemplateFile,
+			Usage: `Name of the output template file within the pack to render and
+                      display, such as "outputs-dev.tpl". Useful for packs which
+                      define multiple output templates. When unset, the
+                      --render-output-template flag renders the default
+                      outputs.tpl file.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "output-template-dest",
+			Target: &c.renderOutputTemplateDest,
+			Usage: `The file path, relative to --to-dir, the rendered output
+                      template is written to, such as "docs/outputs.md". When
+                      unset, the output template file name is used with its
+                      .tpl extension replaced by .txt, such as outputs.txt,
+                      unless --keep-tpl-ext is set. When rendering multiple
+                      packs, the path is within the directory of each pack.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "include-dependency-outputs",
+			Target:  &c.renderDependencyOutputs,
+			Default: false,
+			Usage: `Also render and display the default outputs.tpl file of each
+                      dependency pack, named for the dependency, such as
+                      redis/outputs.tpl. Requires --render-output-template or
+                      --output-template-file.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "emit-var-schema",
+			Target:  &c.renderVarSchema,
+			Default: false,
+			Usage: `Output a JSON Schema describing the variables declared by the
+                      pack and its dependencies, instead of rendering the
+                      templates. When used with --to-dir, the schema is written
+                      to the directory as variables.schema.json.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "keep-tpl-ext",
+			Target:  &c.renderKeepTplExt,
+			Default: false,
+			Usage: `Retain the .tpl extension of the source template in the rendered
+                      template names, making it easier to compare renders against
+                      their source templates.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "error-on-empty",
+			Target:  &c.renderErrorOnEmpty,
+			Default: false,
+			Usage: `Fail if any template renders empty or whitespace only content,
+                      rather than outputting a warning. The output template is
+                      only checked when it is rendered.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "keep-going",
+			Target:  &c.renderKeepGoing,
+			Default: false,
+			Usage: `Continue rendering the remaining templates when a template
+                      fails to render, rather than stopping at the first failure.
+                      The templates which rendered are output as usual, followed
+                      by each failure, and the command still exits with an
+                      error.`,
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:    "seed",
+			Target:  &c.renderSeed,
+			SetHook: func(int64) { c.renderSeedSet = true },
+			Usage: `Seed the source of randomness used by the random template
+                      functions, such as randAlphaNum, shuffle and uuidv4, so
+                      repeated renders are identical. When unset, the values
+                      are random on each render.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "template-func-list",
+			Target:  &c.renderTemplateFuncList,
+			Default: false,
+			Usage: `List the template functions available to pack templates,
+                      along with their signatures and descriptions, rather than
+                      rendering a pack. Use --format to output the list as json
+                      or yaml.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "parallelism",
+			Target:  &c.renderParallelism,
+			Default: runtime.GOMAXPROCS(0),
+			Usage: `The number of templates rendered concurrently, which speeds
+                      up rendering packs with many templates. The output is the
+                      same whatever the parallelism. Templates are rendered one
+                      at a time when --seed or --template-trace is set. Defaults
+                      to the number of CPUs available.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.renderFormat,
+			Values:  renderFormats,
+			Default: renderFormatText,
+			Usage: `Format used to output the rendered templates. The json and yaml
+                      formats output a single document keyed by template name and,
+                      when used with --to-dir, also write it to the directory as
+                      renders.json or renders.yaml.`,
+		})
+
 		f.StringVarP(&flag.StringVarP{
 			StringVar: &flag.StringVar{
 				Name:   "to-dir",
@@ -291,6 +1734,387 @@ Using ref with a file path is not supported.`,
 			Shorthand: "o",
 		})
 
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "only",
+			Target: &c.renderOnly,
+			Usage: `Glob pattern used to filter the rendered templates by name, such
+                      as "*/web.nomad". Can be specified multiple times, in which
+                      case templates matching any pattern are rendered.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "summary",
+			Target:  &c.renderSummary,
+			Default: false,
+			Usage: `Output a summary of the rendered files and their sizes, along
+                      with the number of parent and dependent templates rendered.
+                      When using a structured format, the summary is included in
+                      the document.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "validate",
+			Target:  &c.renderValidate,
+			Default: false,
+			Usage: `Parse the rendered job specifications of the pack to check they
+                      are valid HCL containing a single job. Parsing is performed
+                      locally, and the command exits non-zero if any job is invalid.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "no-auto-vars",
+			Target:  &c.renderNoAutoVars,
+			Default: false,
+			Usage: `Do not automatically load the *.auto.nomadpack.hcl and
+                      *.auto.pkrvars.hcl variable files found in the working
+                      directory when rendering a local pack.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "no-headers",
+			Aliases: []string{"raw"},
+			Target:  &c.renderNoHeaders,
+			Default: false,
+			Usage: `Output only the content of a single rendered template, without
+                      the name header, so that it can be piped to other tools.
+                      Requires exactly one template to be rendered, which can be
+                      selected using --only.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "stream",
+			Target:  &c.renderStream,
+			Default: false,
+			Usage: `Output the rendered templates as a single stream of documents,
+                      each preceded by a --- line and a comment naming the file,
+                      so the output can be piped to tools expecting multiple
+                      documents. The outputs template, if rendered, is last.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "diff",
+			Target:  &c.renderDiff,
+			Default: false,
+			Usage: `Show a unified diff between each rendered template and the
+                      file previously written to --to-dir, rather than writing
+                      the renders. Identical files are reported as unchanged.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "diff-base-ref",
+			Target: &c.renderDiffBaseRef,
+			Usage: `Git ref of the pack to use as the base when showing how the
+                      rendered templates differ between two refs. Supports tags,
+                      SHA, and latest. Requires --diff-target-ref.
+
+                      Using ref with a file path is not supported.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "diff-target-ref",
+			Target: &c.renderDiffTargetRef,
+			Usage: `Git ref of the pack to compare against --diff-base-ref. The
+                      pack is rendered at both refs and a unified diff is shown
+                      for each rendered template. Supports tags, SHA, and latest.
+
+                      Using ref with a file path is not supported.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "max-terminal-bytes",
+			Target:  &c.renderMaxTerminalBytes,
+			Default: defaultMaxTerminalBytes,
+			Usage: `The maximum number of bytes of each rendered template output
+                      to the terminal, beyond which the output is truncated.
+                      Files written using --to-dir are never truncated. Set to 0
+                      to disable the limit.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "unmask",
+			Target:  &c.renderUnmask,
+			Default: false,
+			Usage: `Output the values of variables declared as sensitive when
+                      rendering to the terminal, rather than masking them with
+                      ***. Files written using --to-dir always contain the
+                      values.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "explain-vars",
+			Target:  &c.renderExplainVars,
+			Default: false,
+			Usage: `Output a table of each variable's final value along with the
+                      source it was set from, such as a default, variable file,
+                      or --var flag, and where. The values of variables
+                      declared as sensitive are redacted.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "show-enabled",
+			Target:  &c.renderShowEnabled,
+			Default: false,
+			Usage: `Output a table after the renders listing the jobs and groups
+                      of the pack, whether each is enabled or disabled, and the
+                      variables controlling this where they can be found. Jobs
+                      and groups found within the templates but not rendered
+                      are disabled.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "template-trace",
+			Target:  &c.renderTemplateTrace,
+			Default: false,
+			Usage: `Log each template as it is parsed and executed, along with
+                      the names of the variables in its scope, the chain of
+                      templates it includes, and each template function it
+                      calls with the arguments. This is useful when debugging
+                      packs which render incorrectly.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "against-cluster",
+			Target:  &c.renderAgainstCluster,
+			Default: false,
+			Usage: `Output a diff of each rendered job against the job of the
+                      same name deployed to the Nomad cluster, in place of the
+                      render, showing what would change if the pack were run.
+                      Jobs which are not deployed are shown as entirely new. The
+                      namespace and region are those set by the job, or
+                      otherwise by --namespace and --region. If the
+                      cluster cannot be reached, the renders are output as
+                      usual.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "plan",
+			Target:  &c.renderPlan,
+			Default: false,
+			Usage: `Plan each rendered job of the pack against the Nomad cluster
+                      once rendered, outputting the diff, the allocation changes
+                      predicted by the scheduler, and any warnings, in the same
+                      way as the plan command. Planning does not modify the
+                      cluster. The namespace and region are those set by the
+                      job, or otherwise by --namespace and --region. If the
+                      cluster cannot be reached, the renders are output with a
+                      warning.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "namespace",
+			Target:  &c.renderNamespace,
+			Default: "",
+			Usage: `The Nomad namespace used when querying the cluster, such as
+                      when using --against-cluster or --plan. Overrides the
+                      NOMAD_NAMESPACE environment variable. The namespace is
+                      checked to exist when the cluster can be reached.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "region",
+			Target:  &c.renderRegion,
+			Default: "",
+			Usage: `The Nomad region used when querying the cluster, such as
+                      when using --against-cluster or --plan. Overrides the NOMAD_REGION
+                      environment variable.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "dir-mode",
+			Target:  &c.renderDirMode,
+			Default: "0755",
+			Usage: `The octal permission used when creating directories for the
+                      rendered files within --to-dir or --to-archive, such as
+                      0700. Existing directories are not modified.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "write-concurrency",
+			Target:  &c.renderWriteConcurrency,
+			Default: defaultWriteConcurrency,
+			Usage: `The number of rendered files written to --to-dir at once.
+                      Increasing this can speed up writing packs with many
+                      templates, particularly to network filesystems.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "no-color",
+			Target:  &c.flagNoColor,
+			Default: false,
+			Usage: `Disable the color and text styling of output, including the
+                      template name headers. Styling is always disabled when
+                      the output is not a terminal.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "quiet",
+			Target:  &c.flagQuiet,
+			Default: false,
+			Usage: `Suppress all output other than errors, which are written
+                      to stderr. This includes the rendered templates, so is
+                      useful alongside --to-dir when scripting. Existing files
+                      are not overwritten unless --auto-approve is set, as
+                      confirmation cannot be prompted for. The --report output
+                      is still written.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "stdin-pack",
+			Target:  &c.renderStdinPack,
+			Default: false,
+			Usage: `Read the pack from standard input as a gzip compressed
+                      tarball, passing - as the pack name. The pack files can
+                      be at the root of the tarball or within a single top
+                      level directory. The pack is extracted to a temporary
+                      directory, which is removed once rendering completes.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:   "report",
+			Target: &c.renderReport,
+			Values: renderReports,
+			Usage: `Output a final report in the given format, categorizing each
+                      file written to --to-dir as written, overwritten,
+                      skipped-unchanged, or skipped-declined. The only
+                      supported format is json.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "force",
+			Target:  &c.renderForce,
+			Default: false,
+			Usage: `Rewrite files within --to-dir even when their content is
+                      unchanged. By default, files whose content matches the
+                      render are skipped to avoid modifying them needlessly.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dedup",
+			Target:  &c.renderDedup,
+			Default: false,
+			Usage: `Hardlink renders within --to-dir whose content and
+                      permissions are the same as an earlier render, rather
+                      than writing the content again. Where the filesystem
+                      does not support hardlinks, copies are written instead.
+                      The number of renders written each way is output.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "compress",
+			Target:  &c.renderCompress,
+			Default: false,
+			Usage: `Gzip compress renders whose content is larger than
+                      --compress-threshold when writing them to --to-dir. The
+                      compressed files are named with a .gz extension in place
+                      of the uncompressed file, and must be decompressed before
+                      use. Renders output to the terminal are not compressed.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "compress-threshold",
+			Target:  &c.renderCompressThreshold,
+			Default: defaultCompressThreshold,
+			Usage: `The size in bytes above which renders are compressed when
+                      using --compress. Set to 0 to compress all non-empty
+                      renders.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "prune",
+			Target:  &c.renderPrune,
+			Default: false,
+			Usage: `Remove files within --to-dir which are not part of the
+                      render, such as those from templates since removed from
+                      the pack. Prompts for confirmation unless --auto-approve
+                      is set.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "git-commit",
+			Target: &c.renderGitCommit,
+			Usage: `Commit the files written to --to-dir to the git repository
+                      containing it, using the passed commit message. Only the
+                      rendered files are committed, and no commit is made if
+                      none changed. Fails if the working tree is dirty.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "git-commit-allow-dirty",
+			Target:  &c.renderGitCommitAllowDirty,
+			Default: false,
+			Usage: `Allow --git-commit to commit even when the git working tree
+                      has uncommitted changes. Those changes are not included
+                      in the commit.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "post-render-hook",
+			Target: &c.renderPostRenderHook,
+			Usage: `A command run for each file written to --to-dir, such as
+                      "nomad fmt", which is passed the path of the file as its
+                      final argument. The command is run using the shell once
+                      the file is fully written, with the NOMAD_PACK_HOOK_PACK,
+                      NOMAD_PACK_HOOK_FILE and NOMAD_PACK_HOOK_DIR environment
+                      variables set to the pack name, the file name within
+                      --to-dir, and --to-dir. The render fails if the command
+                      exits non-zero. Requires --to-dir.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "ignore-hook-errors",
+			Target:  &c.renderIgnoreHookErrors,
+			Default: false,
+			Usage: `Report a failed --post-render-hook as a warning, rather than
+                      failing the render.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "emit-checksums",
+			Target:  &c.renderEmitChecksums,
+			Default: false,
+			Usage: `Write a checksum manifest of the rendered files to --to-dir,
+                      named after the algorithm such as SHA256SUMS. The manifest
+                      can be verified using sha256sum -c from within the
+                      directory, and is only written if all renders are.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "checksum-algo",
+			Target:  &c.renderChecksumAlgo,
+			Values:  checksumAlgos,
+			Default: checksumAlgoSHA256,
+			Usage:   `Hash algorithm used by --emit-checksums.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "to-url",
+			Target: &c.renderToURL,
+			Usage: `URL to upload the rendered job files to in addition to
+                      standard output. Supports http and https endpoints which
+                      accept PUT requests, and S3 buckets in the form
+                      s3://bucket/prefix. Existing files are not replaced
+                      unless confirmed or --auto-approve is set.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "to-archive",
+			Target: &c.renderToArchive,
+			Usage: `Path to write a gzip compressed tarball containing the rendered
+                      job files to in addition to standard output. The directory
+                      structure of the rendered files is preserved.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "watch",
+			Target:  &c.renderWatch,
+			Default: false,
+			Usage: `Watch the directory of a local pack, and re-render the pack
+                      each time a template or variable file changes, until
+                      interrupted. When used with --to-dir, the files are
+                      rewritten on each change, skipping those which are
+                      unchanged, and --auto-approve is required.`,
+		})
+
 	})
 }
 
@@ -311,25 +2135,99 @@ func (c *RenderCommand) Help() string {
 
 	# Render an example pack with cli variable overrides.
 	nomad-pack render example --var="redis_image_version=latest" \
-		--var="redis_resources={"cpu": "1000", "memory": "512"}"
+		--var='redis_resources={"cpu": "1000", "memory": "512"}'
+
+	# Render multiple packs into a directory, with the renders of each pack
+	# written to a subdirectory named for the pack. Variables can be scoped
+	# to a single pack by prefixing them with the pack name.
+	nomad-pack render web api --to-dir ~/out --var="web.count=3"
+
+	# Render a pack read from standard input as a gzip compressed tarball.
+	tar -czf - ./example | nomad-pack render - --stdin-pack
+
+	# Render multiple packs as a single stream of documents.
+	nomad-pack render web api --stream | tee combined.txt
+
+	# Render an example pack and plan its jobs against the Nomad cluster.
+	nomad-pack render example --plan --namespace=dev
 
 	# Render an example pack including the outputs template file.
 	nomad-pack render example --render-output-template
 
+	# List the template functions available to pack templates as JSON.
+	nomad-pack render --template-func-list --format=json
+
+	# Output a JSON Schema of the example pack variables, for use by editors
+	# when writing variable files.
+	nomad-pack render example --emit-var-schema
+
+	# Render an example pack including a specific output template file.
+	nomad-pack render example --output-template-file="outputs-dev.tpl"
+
 	# Render an example pack, outputting the rendered templates to file in
 	# addition to the terminal. Setting auto-approve allows the command to
 	# overwrite existing files.
 	nomad-pack render example --to-dir ~/out --auto-approve
 
+	# Render an example pack to a directory, removing any files left over
+	# from previous renders which are no longer part of the pack.
+	nomad-pack render example --to-dir ~/out --prune --auto-approve
+
+	# Render an example pack followed by a summary of the rendered files.
+	nomad-pack render example --summary
+
+	# Render an example pack, explaining where each variable value came from.
+	nomad-pack render example --var-file=./overrides.hcl --explain-vars
+
+	# Render the web job template of an example pack without decoration and
+	# pipe it directly to Nomad.
+	nomad-pack render example --only="*/web.nomad" --no-headers | nomad job run -
+
+	# Render an example pack, checking the rendered job specifications are
+	# valid. This does not require access to a Nomad cluster.
+	nomad-pack render example --validate
+
+	# Render only the web job template of an example pack.
+	nomad-pack render example --only="*/web.nomad"
+
+	# Render an example pack, showing how the renders differ from those
+	# previously written to the output directory.
+	nomad-pack render example --to-dir ~/out --diff
+
+	# Show how the rendered templates of an example pack differ between two
+	# versions of the pack.
+	nomad-pack render example --diff-base-ref=v0.0.1 --diff-target-ref=latest
+
+	# Render an example pack to a directory along with a SHA256SUMS file,
+	# which can be verified using "sha256sum -c SHA256SUMS".
+	nomad-pack render example --to-dir ~/out --emit-checksums
+
+	# Render an example pack, uploading the rendered templates to an S3
+	# bucket in addition to the terminal.
+	nomad-pack render example --to-url s3://my-bucket/example --auto-approve
+
+	# Render an example pack, writing the rendered templates into a gzip
+	# compressed tarball in addition to the terminal.
+	nomad-pack render example --to-archive ./example.tar.gz
+
+	# Render an example pack as a JSON document keyed by template name.
+	nomad-pack render example --format=json
+
     # Render a pack under development from the filesystem - supports current working 
     # directory or relative path
 	nomad-pack render . 
 	`
 
 	return formatHelp(`
-	Usage: nomad-pack render <pack-name> [options]
+	Usage: nomad-pack render <pack-name> [<pack-name>...] [options]
+
+	Render the specified Nomad Packs and view the results.
 
-	Render the specified Nomad Pack and view the results.
+	Render will return one of the following exit codes:
+		* code 0: The templates were rendered and output.
+		* code 1: An error occurred, such as a template failing to render.
+		* code 2: The pack rendered no templates.
+		* code 3: A render could not be written to a file, archive or URL.
 
 ` + c.GetExample() + c.Flags().Help())
 }
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	stdErrors "errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// checksumAlgo* are the supported values of the render command
+// --checksum-algo flag.
+const (
+	checksumAlgoSHA256 = "sha256"
+	checksumAlgoSHA512 = "sha512"
+)
+
+// checksumAlgos lists all the supported checksum algorithms.
+var checksumAlgos = []string{checksumAlgoSHA256, checksumAlgoSHA512}
+
+// newChecksumHash returns a new hash for the checksum algorithm.
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case checksumAlgoSHA256:
+		return sha256.New(), nil
+	case checksumAlgoSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+}
+
+// checksumManifestName returns the file name of the checksum manifest written
+// to --to-dir, following the naming used by the standard tooling, such as
+// SHA256SUMS.
+func checksumManifestName(algo string) string {
+	return strings.ToUpper(algo) + "SUMS"
+}
+
+// newChecksumManifest builds a checksum manifest for the renders in the format
+// used by sha256sum and sha512sum, so it can be verified using their -c flag
+// from within the --to-dir directory. Entries are sorted by render name.
+func newChecksumManifest(renders []Render, algo string) (string, error) {
+	sorted := make([]Render, len(renders))
+	copy(sorted, renders)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	for _, r := range sorted {
+		h, err := newChecksumHash(algo)
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(r.Content))
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(h.Sum(nil)), r.Name)
+	}
+	return b.String(), nil
+}
+
+// validateEmitChecksums checks the --emit-checksums flag is used alongside
+// the flags it depends on.
+func validateEmitChecksums(c *RenderCommand) error {
+	if !c.renderEmitChecksums {
+		return nil
+	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--emit-checksums requires --to-dir")
+	}
+	if c.renderDiff {
+		return stdErrors.New("--emit-checksums cannot be used with --diff")
+	}
+	return nil
+}
+
+// writeChecksumManifest writes the checksum manifest of the renders to the
+// --to-dir path, prompting to confirm overwriting any existing manifest, and
+// returns the disposition of the write.
+func (c *RenderCommand) writeChecksumManifest(renders []Render, ec *errors.UIErrorContext) (renderDisposition, error) {
+	content, err := newChecksumManifest(renders, c.renderChecksumAlgo)
+	if err != nil {
+		return "", err
+	}
+	manifest := Render{Name: checksumManifestName(c.renderChecksumAlgo), Content: content}
+	return manifest.toFile(c, ec)
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"strings"
+
+	v1client "github.com/hashicorp/nomad-openapi/clients/go/v1"
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
+)
+
+// errClusterUnreachable is returned when the Nomad cluster cannot be reached
+// to compare the renders against.
+var errClusterUnreachable = stdErrors.New("unable to reach the Nomad cluster")
+
+// packMetaKeys are the job meta keys added by Nomad Pack when deploying a
+// job, which are ignored when comparing against the deployed job as they are
+// not part of the render.
+var packMetaKeys = []string{
+	job.PackPathKey,
+	job.PackNameKey,
+	job.PackRegistryKey,
+	job.PackDeploymentNameKey,
+	job.PackJobKey,
+	job.PackRefKey,
+}
+
+// clusterJobDiff is the comparison of a rendered job specification with the
+// job of the same name deployed to the cluster.
+type clusterJobDiff struct {
+	job string
+	// deployed is false when no job of the same name is deployed, in which
+	// case the diff shows the rendered job as entirely new.
+	deployed bool
+	diff     string
+}
+
+// validateAgainstCluster checks the --against-cluster flag is only used when
+// the renders are output to the terminal as text, in place of the renders,
+// and that the cluster may be contacted.
+func validateAgainstCluster(c *RenderCommand) error {
+	if !c.renderAgainstCluster {
+		return nil
+	}
+	switch {
+	case c.renderFormat != renderFormatText || c.renderNoHeaders:
+		return stdErrors.New("--against-cluster can only be used with the text format and without --no-headers")
+	case c.renderDiff:
+		return stdErrors.New("--against-cluster cannot be used with --diff")
+	case c.offline:
+		return stdErrors.New("--against-cluster cannot be used with --offline")
+	}
+	return nil
+}
+
+// validateNomadVariables checks the Nomad variable template functions are
+// not enabled when network access is disabled, as they read from the cluster.
+func validateNomadVariables(c *RenderCommand) error {
+	if c.nomadVariables && c.offline {
+		return stdErrors.New("--nomad-variables cannot be used with --offline")
+	}
+	return nil
+}
+
+// namespace returns the Nomad namespace used when querying the cluster, from
+// --namespace or otherwise NOMAD_NAMESPACE. It is empty when neither is set,
+// in which case the cluster's default is used.
+func (c *RenderCommand) namespace() string {
+	if c.renderNamespace != "" {
+		return c.renderNamespace
+	}
+	return os.Getenv("NOMAD_NAMESPACE")
+}
+
+// region returns the Nomad region used when querying the cluster, from
+// --region or otherwise NOMAD_REGION.
+func (c *RenderCommand) region() string {
+	if c.renderRegion != "" {
+		return c.renderRegion
+	}
+	return os.Getenv("NOMAD_REGION")
+}
+
+// checkNamespace checks the namespace, if set, exists within the cluster. As
+// rendering does not otherwise need the cluster, a warning is output rather
+// than failing when it cannot be checked.
+func (c *RenderCommand) checkNamespace(client *v1.Client) error {
+	namespace := c.namespace()
+	if namespace == "" {
+		return nil
+	}
+
+	opts := newQueryOpts().WithRegion(c.region())
+	_, _, err := client.Namespaces().GetNamespace(opts.Ctx(), namespace)
+	switch {
+	case err == nil:
+		return nil
+	case isNotFoundError(err):
+		return fmt.Errorf("namespace %q not found", namespace)
+	default:
+		c.ui.Warning(fmt.Sprintf("Unable to check namespace %q exists: %s", namespace, err))
+		return nil
+	}
+}
+
+// isNotFoundError returns whether err is a not found response from the Nomad
+// API.
+func isNotFoundError(err error) bool {
+	var openAPIErr v1client.GenericOpenAPIError
+	return stdErrors.As(err, &openAPIErr) && strings.HasPrefix(openAPIErr.Error(), "404")
+}
+
+// diffAgainstCluster compares each job specification render with the job of
+// the same name deployed to the cluster, in the namespace and region set by
+// the job or otherwise those passed. The diffs are returned in render order, with nil for renders
+// which are not job specifications. errClusterUnreachable is returned when
+// the cluster cannot be contacted.
+func diffAgainstCluster(client *v1.Client, renders []Render, namespace, region string) ([]*clusterJobDiff, error) {
+	diffs := make([]*clusterJobDiff, len(renders))
+	for i, render := range renders {
+		if !isJobSpecRender(render) || render.isEmpty() {
+			continue
+		}
+		diff, err := diffRenderAgainstCluster(client, render, namespace, region)
+		if err != nil {
+			return nil, err
+		}
+		diffs[i] = diff
+	}
+	return diffs, nil
+}
+
+// parseRenderedJob parses the job specification render using the cluster.
+// The namespace and region of the job are those set by the job, or otherwise
+// those passed, which are returned along with the job.
+func parseRenderedJob(client *v1.Client, render Render, namespace, region string) (*v1client.Job, string, string, error) {
+	opts := newQueryOpts()
+
+	// The job is parsed without canonicalization first to find whether it
+	// sets its namespace and region, as otherwise these are defaulted.
+	specified, err := client.Jobs().Parse(opts.Ctx(), render.Content, false, false)
+	if err != nil {
+		return nil, "", "", clusterError(fmt.Errorf("failed to parse %s: %w", render.Name, err))
+	}
+	rendered, err := client.Jobs().Parse(opts.Ctx(), render.Content, true, false)
+	if err != nil {
+		return nil, "", "", clusterError(fmt.Errorf("failed to parse %s: %w", render.Name, err))
+	}
+
+	if specified.Namespace != nil && *specified.Namespace != "" {
+		namespace = *specified.Namespace
+	}
+	if specified.Region != nil && *specified.Region != "" {
+		region = *specified.Region
+	}
+	if namespace != "" {
+		rendered.Namespace = &namespace
+	}
+	if region != "" {
+		rendered.Region = &region
+	}
+	return rendered, namespace, region, nil
+}
+
+// diffRenderAgainstCluster compares a single job specification render with
+// the deployed job.
+func diffRenderAgainstCluster(client *v1.Client, render Render, namespace, region string) (*clusterJobDiff, error) {
+	rendered, namespace, region, err := parseRenderedJob(client, render, namespace, region)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &clusterJobDiff{job: rendered.GetID()}
+
+	opts := newQueryOpts().WithNamespace(namespace).WithRegion(region)
+	deployed, _, err := client.Jobs().GetJob(opts.Ctx(), result.job)
+	if err != nil {
+		openAPIErr, ok := err.(v1client.GenericOpenAPIError)
+		if !ok || string(openAPIErr.Body()) != "job not found" {
+			return nil, clusterError(fmt.Errorf("failed to get job %q: %w", result.job, err))
+		}
+	}
+
+	var existing string
+	if deployed != nil {
+		result.deployed = true
+		if existing, err = clusterJobJSON(deployed); err != nil {
+			return nil, err
+		}
+	}
+	content, err := clusterJobJSON(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.diff, err = renderDiff(render.Name, existing, content); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// clusterError wraps err with errClusterUnreachable when it was not returned
+// by the Nomad API, such as when the connection is refused.
+func clusterError(err error) error {
+	var openAPIErr v1client.GenericOpenAPIError
+	if stdErrors.As(err, &openAPIErr) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errClusterUnreachable, err)
+}
+
+// clusterJobJSON returns the job formatted as indented JSON for comparison,
+// without the fields set by Nomad when the job is registered and the meta
+// added by Nomad Pack.
+func clusterJobJSON(j *v1client.Job) (string, error) {
+	normalized := *j
+	normalized.CreateIndex = nil
+	normalized.ModifyIndex = nil
+	normalized.JobModifyIndex = nil
+	normalized.SubmitTime = nil
+	normalized.Version = nil
+	normalized.Stable = nil
+	normalized.Status = nil
+	normalized.StatusDescription = nil
+
+	if j.Meta != nil {
+		meta := make(map[string]string, len(*j.Meta))
+		for k, v := range *j.Meta {
+			meta[k] = v
+		}
+		for _, key := range packMetaKeys {
+			delete(meta, key)
+		}
+		normalized.Meta = &meta
+		if len(meta) == 0 {
+			normalized.Meta = nil
+		}
+	}
+
+	out, err := json.MarshalIndent(normalized, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format job: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+// toTerminal outputs the diff of the render against the cluster, noting when
+// the job is not deployed.
+func (d *clusterJobDiff) toTerminal(c *RenderCommand, name string) {
+	if !d.deployed {
+		c.ui.Info(fmt.Sprintf("Job %q is not deployed, showing it as new", d.job))
+	}
+	outputDiff(c.ui, name, c.maskSensitive(d.diff))
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAgainstCluster(t *testing.T) {
+	c := &RenderCommand{baseCommand: &baseCommand{}, renderFormat: renderFormatText}
+	require.NoError(t, validateAgainstCluster(c))
+
+	c.renderAgainstCluster = true
+	require.NoError(t, validateAgainstCluster(c))
+
+	c.offline = true
+	require.EqualError(t, validateAgainstCluster(c), "--against-cluster cannot be used with --offline")
+
+	c.renderDiff = true
+	require.EqualError(t, validateAgainstCluster(c), "--against-cluster cannot be used with --diff")
+
+	c.renderFormat = renderFormatJSON
+	require.EqualError(t, validateAgainstCluster(c),
+		"--against-cluster can only be used with the text format and without --no-headers")
+}
+
+func TestDiffAgainstCluster(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/jobs/parse":
+			var req struct {
+				JobHCL       string
+				Canonicalize bool
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			id := strings.TrimPrefix(strings.TrimSpace(req.JobHCL), "job ")
+			job := map[string]interface{}{"ID": id, "Name": id}
+			if req.Canonicalize {
+				job["Namespace"] = "default"
+				job["Priority"] = 50
+			}
+			_ = json.NewEncoder(w).Encode(job)
+		case r.URL.Path == "/v1/job/deployed":
+			require.Equal(t, "prod", r.URL.Query().Get("namespace"))
+			w.Header().Set("X-Nomad-Index", "42")
+			w.Header().Set("X-Nomad-LastContact", "0")
+			w.Header().Set("X-Nomad-KnownLeader", "true")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"ID": "deployed", "Name": "deployed", "Namespace": "prod", "Priority": 40,
+				"Version": 3, "ModifyIndex": 42, "Meta": map[string]string{"pack.name": "example"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("job not found"))
+		}
+	}))
+	defer srv.Close()
+
+	defer os.Setenv("NOMAD_ADDR", os.Getenv("NOMAD_ADDR"))
+	require.NoError(t, os.Setenv("NOMAD_ADDR", srv.URL))
+
+	client, err := v1.NewClient()
+	require.NoError(t, err)
+
+	diffs, err := diffAgainstCluster(client, []Render{
+		{Name: "example/deployed.nomad", Content: "job deployed"},
+		{Name: "example/README.md", Content: "readme"},
+		{Name: "example/new.nomad", Content: "job new"},
+	}, "prod", "")
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+
+	require.True(t, diffs[0].deployed)
+	require.Equal(t, "deployed", diffs[0].job)
+	require.Contains(t, diffs[0].diff, `-  "Priority": 40`)
+	require.Contains(t, diffs[0].diff, `+  "Priority": 50`)
+	require.NotContains(t, diffs[0].diff, "Version")
+	require.NotContains(t, diffs[0].diff, "pack.name")
+
+	require.Nil(t, diffs[1])
+
+	require.False(t, diffs[2].deployed)
+	require.Contains(t, diffs[2].diff, `+  "ID": "new",`)
+	require.NotContains(t, diffs[2].diff, "\n-")
+
+	srv.Close()
+	_, err = diffAgainstCluster(client, []Render{{Name: "example/new.nomad", Content: "job new"}}, "", "")
+	require.ErrorIs(t, err, errClusterUnreachable)
+}
+
+func TestClusterNamespaceRegion(t *testing.T) {
+	defer os.Setenv("NOMAD_NAMESPACE", os.Getenv("NOMAD_NAMESPACE"))
+	defer os.Setenv("NOMAD_REGION", os.Getenv("NOMAD_REGION"))
+	require.NoError(t, os.Setenv("NOMAD_NAMESPACE", "env-ns"))
+	require.NoError(t, os.Setenv("NOMAD_REGION", "env-region"))
+
+	c := &RenderCommand{baseCommand: &baseCommand{}}
+	require.Equal(t, "env-ns", c.namespace())
+	require.Equal(t, "env-region", c.region())
+
+	c.renderNamespace, c.renderRegion = "flag-ns", "flag-region"
+	require.Equal(t, "flag-ns", c.namespace())
+	require.Equal(t, "flag-region", c.region())
+}
+
+func TestCheckNamespace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/v1/namespace/prod" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("Namespace not found"))
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Name": "prod"})
+	}))
+	defer srv.Close()
+
+	defer os.Setenv("NOMAD_ADDR", os.Getenv("NOMAD_ADDR"))
+	defer os.Setenv("NOMAD_NAMESPACE", os.Getenv("NOMAD_NAMESPACE"))
+	require.NoError(t, os.Setenv("NOMAD_ADDR", srv.URL))
+	require.NoError(t, os.Unsetenv("NOMAD_NAMESPACE"))
+
+	client, err := v1.NewClient()
+	require.NoError(t, err)
+
+	c := &RenderCommand{baseCommand: &baseCommand{}}
+	require.NoError(t, c.checkNamespace(client))
+
+	c.renderNamespace = "prod"
+	require.NoError(t, c.checkNamespace(client))
+
+	c.renderNamespace = "missing"
+	require.EqualError(t, c.checkNamespace(client), `namespace "missing" not found`)
+}
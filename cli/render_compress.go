@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	stdErrors "errors"
+	"fmt"
+)
+
+// defaultCompressThreshold is the default size in bytes of the content above
+// which renders are compressed when using --compress.
+const defaultCompressThreshold = 1 << 20
+
+// compressedExt is the extension added to the name of compressed renders.
+const compressedExt = ".gz"
+
+// validateCompress checks the --compress flag is used alongside --to-dir, as
+// only the files written are compressed, and that the threshold is valid.
+func validateCompress(c *RenderCommand) error {
+	if !c.renderCompress {
+		return nil
+	}
+	switch {
+	case c.renderToDir == "":
+		return stdErrors.New("--compress requires --to-dir")
+	case c.renderDiff:
+		return stdErrors.New("--compress cannot be used with --diff")
+	case c.renderCompressThreshold < 0:
+		return stdErrors.New("--compress-threshold must not be negative")
+	}
+	return nil
+}
+
+// compressRenders returns the renders as they are written to --to-dir, in
+// the same order, compressing those whose content exceeds the threshold.
+func compressRenders(c *RenderCommand, renders []Render) ([]Render, error) {
+	compressed := make([]Render, len(renders))
+	for i, render := range renders {
+		var err error
+		if compressed[i], err = render.compressed(c); err != nil {
+			return nil, err
+		}
+	}
+	return compressed, nil
+}
+
+// compressed returns the render as it is written to --to-dir. When using
+// --compress and the content exceeds the threshold, the content is gzip
+// compressed and the name has the .gz extension added. Otherwise, the render
+// is returned as is.
+func (r Render) compressed(c *RenderCommand) (Render, error) {
+	if !r.compresses(c) {
+		return r, nil
+	}
+
+	// The gzip header is left without a name or modification time, so the
+	// same content always compresses identically and unchanged files are
+	// detected.
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(r.Content)); err != nil {
+		return Render{}, fmt.Errorf("failed to compress %s: %w", r.Name, err)
+	}
+	if err := zw.Close(); err != nil {
+		return Render{}, fmt.Errorf("failed to compress %s: %w", r.Name, err)
+	}
+
+	r.Name += compressedExt
+	r.Content = buf.String()
+	return r, nil
+}
+
+// compresses reports whether the render is compressed when written to
+// --to-dir.
+func (r Render) compresses(c *RenderCommand) bool {
+	return c.renderCompress && len(r.Content) > c.renderCompressThreshold
+}
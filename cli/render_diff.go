@@ -0,0 +1,226 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// toDiff outputs a unified diff between the render and the file previously
+// written to the --to-dir path. Files which do not yet exist are diffed
+// against empty content, while identical files are reported as unchanged.
+// Sensitive variable values are masked within the diff.
+func (r Render) toDiff(c *RenderCommand, ec *errors.UIErrorContext) error {
+	outFile := r.outFile(c)
+
+	existing, err := os.ReadFile(outFile)
+	if err != nil && !stdErrors.Is(err, fs.ErrNotExist) {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		return fmt.Errorf("failed to read existing file: %w", err)
+	}
+
+	diff, err := renderDiff(r.Name, string(existing), r.Content)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		return err
+	}
+
+	outputDiff(c.ui, r.Name, c.maskSensitive(diff))
+	return nil
+}
+
+// outputDiff writes the unified diff of the named render to the terminal,
+// styling each line. An empty diff is reported as unchanged.
+func outputDiff(ui terminal.UI, name, diff string) {
+	if diff == "" {
+		ui.Output("%s: unchanged", name, terminal.WithStyle(terminal.BoldStyle))
+		return
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		ui.Output("%s", strings.TrimSuffix(line, "\n"), terminal.WithStyle(diffLineStyle(line)))
+	}
+}
+
+// runRefDiff renders the pack at both the --diff-base-ref and
+// --diff-target-ref refs and outputs a unified diff for each rendered
+// template. Templates only rendered at one of the refs are diffed against
+// empty content. Sensitive variable values from either ref are masked within
+// the diffs.
+func (c *RenderCommand) runRefDiff() int {
+	if err := validateRefDiff(c); err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
+	client, err := v1.NewClient()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to initialize client")
+		return 1
+	}
+
+	c.sensitiveValues = nil
+
+	base, ok := c.renderRef(client, c.renderDiffBaseRef)
+	if !ok {
+		return 1
+	}
+	target, ok := c.renderRef(client, c.renderDiffTargetRef)
+	if !ok {
+		return 1
+	}
+	c.sortSensitiveValues()
+
+	for _, name := range refDiffNames(base, target) {
+		diff, err := renderDiff(name, base[name], target[name])
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to diff render", errors.UIContextPrefixTemplateName+name)
+			return 1
+		}
+		outputDiff(c.ui, name, c.maskSensitive(diff))
+	}
+
+	return 0
+}
+
+// renderRef renders the pack at the passed ref, returning the content of each
+// render keyed by its formatted name. The pack config is copied so that only
+// the ref differs between calls. The values of the sensitive variables are
+// added to those to mask. Any errors are output before returning.
+func (c *RenderCommand) renderRef(client *v1.Client, ref string) (map[string]string, bool) {
+	cfg := *c.packConfig
+	cfg.Ref = ref
+
+	errorContext := c.initPackCommand(&cfg)
+
+	if cfg.Registry == cache.DevRegistryName {
+		c.ui.ErrorWithContext(stdErrors.New("ref with a file path is not supported"),
+			"failed to diff refs", errorContext.GetAll()...)
+		return nil, false
+	}
+
+	if err := c.verifyCache.Verify(&cfg, errorContext, c.ui); err != nil {
+		return nil, false
+	}
+
+	packManager := generatePackManager(c.baseCommand, client, &cfg)
+
+	renderOutput, err := renderPack(packManager, c.ui, errorContext)
+	if err != nil {
+		return nil, false
+	}
+
+	if processed := packManager.ProcessedVariables(); processed != nil {
+		c.sensitiveValues = append(c.sensitiveValues, processed.SensitiveValues()...)
+	}
+
+	renders := newRenders(renderOutput, c.renderKeepTplExt)
+	if len(c.renderOnly) > 0 {
+		if renders, err = filterRenders(renders, c.renderOnly); err != nil {
+			c.ui.ErrorWithContext(err, "failed to filter renders", errorContext.GetAll()...)
+			return nil, false
+		}
+	}
+
+	out := make(map[string]string, len(renders))
+	for _, r := range renders {
+		out[r.Name] = r.Content
+	}
+	return out, true
+}
+
+// refDiffNames returns the sorted, de-duplicated names of the renders from
+// both the base and target refs.
+func refDiffNames(base, target map[string]string) []string {
+	names := make([]string, 0, len(base)+len(target))
+	for name := range base {
+		names = append(names, name)
+	}
+	for name := range target {
+		if _, ok := base[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateRefDiff checks the --diff-base-ref and --diff-target-ref flags are
+// used together, and not alongside flags which conflict with them.
+func validateRefDiff(c *RenderCommand) error {
+	switch {
+	case c.renderDiffBaseRef == "" || c.renderDiffTargetRef == "":
+		return stdErrors.New("--diff-base-ref and --diff-target-ref must be used together")
+	case c.packConfig.Ref != "":
+		return stdErrors.New("--ref cannot be used with --diff-base-ref and --diff-target-ref")
+	case c.packConfig.Checksum != "":
+		return stdErrors.New("--checksum cannot be used with --diff-base-ref and --diff-target-ref")
+	case c.renderDiff, c.renderToDir != "", c.renderToArchive != "":
+		return stdErrors.New("--diff-base-ref and --diff-target-ref cannot be used with --diff, --to-dir, or --to-archive")
+	case c.renderFormat != renderFormatText:
+		return stdErrors.New("--diff-base-ref and --diff-target-ref can only be used with the text format")
+	}
+	return nil
+}
+
+// renderDiff returns the unified diff between the existing and new content
+// of the named render. An empty string is returned if they are identical.
+func renderDiff(name, existing, content string) (string, error) {
+	if existing == content {
+		return "", nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitDiffLines(existing),
+		B:        splitDiffLines(content),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff render: %w", err)
+	}
+
+	return diff, nil
+}
+
+// splitDiffLines splits the content into newline terminated lines for use in
+// a diff. Unlike difflib.SplitLines, no empty trailing line is added for
+// content ending in a newline, and empty content has no lines.
+func splitDiffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// diffLineStyle returns the terminal style used to display a single line of
+// unified diff output.
+func diffLineStyle(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return terminal.BoldStyle
+	case strings.HasPrefix(line, "@@"):
+		return terminal.CyanStyle
+	case strings.HasPrefix(line, "+"):
+		return terminal.GreenStyle
+	case strings.HasPrefix(line, "-"):
+		return terminal.RedStyle
+	default:
+		return terminal.DefaultStyle
+	}
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+const (
+	showEnabledEnabled  = "enabled"
+	showEnabledDisabled = "disabled"
+)
+
+// jobBodySchema is the schema of the blocks within a job used to find its
+// groups.
+var jobBodySchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "group", LabelNames: []string{"name"}},
+	},
+}
+
+// enabledBlock is a job, or a group within a job, of a rendered pack along
+// with whether it was rendered and the variables controlling this.
+type enabledBlock struct {
+	Pack string
+	Job  string
+	// Group is empty for the job itself.
+	Group      string
+	Enabled    bool
+	Conditions []string
+}
+
+// validateShowEnabled checks the --show-enabled flag is only used when the
+// renders are output as text, so the table does not corrupt the output.
+func validateShowEnabled(c *RenderCommand) error {
+	if !c.renderShowEnabled {
+		return nil
+	}
+	if c.renderFormat != renderFormatText || c.renderNoHeaders {
+		return stdErrors.New("--show-enabled can only be used with the text format and without --no-headers")
+	}
+	return nil
+}
+
+// findEnabledBlocks returns the jobs and groups of the pack. Those within the
+// job specification renders are enabled, while those found within the
+// templates but not rendered are disabled. Each is correlated with the
+// variables referenced by the conditions enclosing it in the templates,
+// where the block has a literal name.
+func findEnabledBlocks(packName string, renders []Render, tplBlocks []renderer.TemplateBlock) ([]*enabledBlock, error) {
+	var blocks []*enabledBlock
+	seen := make(map[string]bool)
+	renderJobs := make(map[string][]renderedJob)
+
+	for _, render := range renders {
+		if !isJobSpecRender(render) {
+			continue
+		}
+		jobs, err := renderedJobGroups(render)
+		if err != nil {
+			return nil, err
+		}
+		renderJobs[render.Template] = jobs
+		for _, job := range jobs {
+			blocks = append(blocks, &enabledBlock{Pack: packName, Job: job.name, Enabled: true})
+			seen[blockKey("job", "", job.name)] = true
+			for _, group := range job.groups {
+				blocks = append(blocks, &enabledBlock{Pack: packName, Job: job.name, Group: group, Enabled: true})
+				seen[blockKey("group", job.name, group)] = true
+				seen[blockKey("group", "", group)] = true
+			}
+		}
+	}
+
+	// A group within a job with a templated name belongs to the job
+	// rendered by its template, when there is only one.
+	tplBlocks = append([]renderer.TemplateBlock{}, tplBlocks...)
+	for i, tplBlock := range tplBlocks {
+		if tplBlock.Kind != "group" || tplBlock.Job != "" {
+			continue
+		}
+		if jobs := renderJobs[tplBlock.Template]; len(jobs) == 1 {
+			tplBlocks[i].Job = jobs[0].name
+		}
+	}
+
+	for _, tplBlock := range tplBlocks {
+		key := blockKey(tplBlock.Kind, tplBlock.Job, tplBlock.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		block := &enabledBlock{Pack: packName, Job: tplBlock.Name}
+		if tplBlock.Kind == "group" {
+			block.Job, block.Group = tplBlock.Job, tplBlock.Name
+		}
+		blocks = append(blocks, block)
+	}
+
+	for _, block := range blocks {
+		block.Conditions = blockConditions(block, tplBlocks)
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Job != blocks[j].Job {
+			return blocks[i].Job < blocks[j].Job
+		}
+		return blocks[i].Group < blocks[j].Group
+	})
+	return blocks, nil
+}
+
+// blockKey identifies a job or group block. The job of a group is empty when
+// it is not known.
+func blockKey(kind, job, name string) string {
+	return kind + "\x00" + job + "\x00" + name
+}
+
+// blockConditions returns the variables controlling the block, from the
+// first template block matching it, formatted as pack.variable.
+func blockConditions(block *enabledBlock, tplBlocks []renderer.TemplateBlock) []string {
+	for _, tplBlock := range tplBlocks {
+		var match bool
+		switch tplBlock.Kind {
+		case "job":
+			match = block.Group == "" && tplBlock.Name == block.Job
+		case "group":
+			match = block.Group != "" && tplBlock.Name == block.Group &&
+				(tplBlock.Job == "" || tplBlock.Job == block.Job)
+		}
+		if !match {
+			continue
+		}
+		var conds []string
+		for _, ref := range tplBlock.Conditions {
+			conds = append(conds, ref.Pack+"."+ref.Variable)
+		}
+		return conds
+	}
+	return nil
+}
+
+// isJobSpecRender returns whether the render is a job specification, by its
+// .nomad or .hcl extension, ignoring any retained .tpl extension.
+func isJobSpecRender(r Render) bool {
+	switch path.Ext(strings.TrimSuffix(r.Name, ".tpl")) {
+	case ".nomad", ".hcl":
+		return true
+	default:
+		return false
+	}
+}
+
+// renderedJob is a job within a render, along with the names of its groups.
+type renderedJob struct {
+	name   string
+	groups []string
+}
+
+// renderedJobGroups parses the render as an HCL job specification, returning
+// its jobs and their groups.
+func renderedJobGroups(r Render) ([]renderedJob, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(r.Content), r.Name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, _, diags := file.Body.PartialContent(jobSpecSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	jobs := make([]renderedJob, 0, len(content.Blocks))
+	for _, jobBlock := range content.Blocks {
+		jobContent, _, diags := jobBlock.Body.PartialContent(jobBodySchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		job := renderedJob{name: jobBlock.Labels[0]}
+		for _, groupBlock := range jobContent.Blocks {
+			job.groups = append(job.groups, groupBlock.Labels[0])
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// showEnabledToTerminal outputs a table listing the jobs and groups of the
+// rendered packs, whether each is enabled, and the variables controlling it.
+func showEnabledToTerminal(c *RenderCommand, blocks []*enabledBlock) {
+	tbl := terminal.NewTable("Pack", "Job", "Group", "Status", "Controlled By")
+	for _, b := range blocks {
+		status := showEnabledDisabled
+		if b.Enabled {
+			status = showEnabledEnabled
+		}
+		tbl.Rows = append(tbl.Rows, []terminal.TableEntry{
+			{Value: b.Pack},
+			{Value: b.Job},
+			{Value: b.Group},
+			{Value: status},
+			{Value: strings.Join(b.Conditions, ", ")},
+		})
+	}
+	c.ui.Table(tbl)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindEnabledBlocks(t *testing.T) {
+	tpl := "example/templates/example.nomad.tpl"
+	renders := []Render{
+		{Name: "example/example.nomad", Template: tpl, Content: `job "example" {
+  group "app" {}
+}`},
+		{Name: "example/README.md", Content: "not a job"},
+	}
+
+	cond := func(variable string) []renderer.VariableReference {
+		return []renderer.VariableReference{{Template: tpl, Pack: "example", Variable: variable}}
+	}
+	tplBlocks := []renderer.TemplateBlock{
+		{Template: tpl, Kind: "group", Name: "app"},
+		{Template: tpl, Kind: "group", Name: "cache", Conditions: cond("enable_cache")},
+		{Template: "example/templates/batch.nomad.tpl", Kind: "job", Name: "batch", Conditions: cond("enable_batch")},
+		{Template: "example/templates/batch.nomad.tpl", Kind: "group", Name: "run", Job: "batch", Conditions: cond("enable_batch")},
+	}
+
+	blocks, err := findEnabledBlocks("example", renders, tplBlocks)
+	require.NoError(t, err)
+
+	// The cache group belongs to the job rendered by its template, as the
+	// job name is not known from the template.
+	require.Equal(t, []*enabledBlock{
+		{Pack: "example", Job: "batch", Conditions: []string{"example.enable_batch"}},
+		{Pack: "example", Job: "batch", Group: "run", Conditions: []string{"example.enable_batch"}},
+		{Pack: "example", Job: "example", Enabled: true},
+		{Pack: "example", Job: "example", Group: "app", Enabled: true},
+		{Pack: "example", Job: "example", Group: "cache", Conditions: []string{"example.enable_cache"}},
+	}, blocks)
+
+	// Renders which are not valid job specifications are an error.
+	renders[0].Content = "job {"
+	_, err = findEnabledBlocks("example", renders, tplBlocks)
+	require.Error(t, err)
+}
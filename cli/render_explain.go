@@ -0,0 +1,46 @@
+package cli
+
+import (
+	stdErrors "errors"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// explainVarsUnset is displayed as the source of variables without a value.
+const explainVarsUnset = "unset"
+
+// validateExplainVars checks the --explain-vars flag is only used when the
+// renders are output as text, so the table does not corrupt the output.
+func validateExplainVars(c *RenderCommand) error {
+	if !c.renderExplainVars {
+		return nil
+	}
+	if c.renderFormat != renderFormatText || c.renderNoHeaders {
+		return stdErrors.New("--explain-vars can only be used with the text format and without --no-headers")
+	}
+	return nil
+}
+
+// explainVarsToTerminal outputs a table listing the final value of each
+// variable along with the source it was set from. Sensitive values are
+// redacted.
+func explainVarsToTerminal(c *RenderCommand, explanations []*variable.Explanation) {
+	tbl := terminal.NewTable("Pack", "Variable", "Value", "Source", "Origin")
+	for _, e := range explanations {
+		source := string(e.Source.Kind)
+		if source == "" {
+			source = explainVarsUnset
+		}
+		tbl.Rows = append(tbl.Rows, []terminal.TableEntry{
+			{Value: e.Pack},
+			{Value: e.Name},
+			{Value: e.Value},
+			{Value: source},
+			{Value: e.Source.Origin},
+		})
+	}
+
+	c.ui.Output("")
+	c.ui.Table(tbl)
+}
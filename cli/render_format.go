@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderFormat* are the supported values of the render command --format flag.
+const (
+	renderFormatText = "text"
+	renderFormatJSON = "json"
+	renderFormatYAML = "yaml"
+)
+
+// renderFormats lists all the supported render output formats.
+var renderFormats = []string{renderFormatText, renderFormatJSON, renderFormatYAML}
+
+// renderDocument is the structured representation of a render run used when
+// the output format is not text. Template renders are keyed by their formatted
+// name, while the outputs template is stored under its own key so consumers
+// can tell them apart.
+type renderDocument struct {
+	Renders map[string]string `json:"renders" yaml:"renders"`
+	Outputs *string           `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	Summary *renderSummary    `json:"summary,omitempty" yaml:"summary,omitempty"`
+}
+
+// newRenderDocument builds a renderDocument from the template renders, the
+// optional outputs template render, and the optional render summary.
+func newRenderDocument(renders []Render, outputRender *Render, summary *renderSummary) *renderDocument {
+	doc := renderDocument{
+		Renders: make(map[string]string, len(renders)),
+		Summary: summary,
+	}
+
+	for _, render := range renders {
+		doc.Renders[render.Name] = render.Content
+	}
+
+	if outputRender != nil {
+		doc.Outputs = &outputRender.Content
+	}
+
+	return &doc
+}
+
+// marshal serializes the document into the requested format.
+func (d *renderDocument) marshal(format string) ([]byte, error) {
+	switch format {
+	case renderFormatJSON:
+		return json.MarshalIndent(d, "", "  ")
+	case renderFormatYAML:
+		return yaml.Marshal(d)
+	default:
+		return nil, fmt.Errorf("unsupported render format %q", format)
+	}
+}
+
+// renderManifestName returns the file name used when writing the document
+// into the render output directory.
+func renderManifestName(format string) string { return "renders." + format }
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"gopkg.in/yaml.v3"
+)
+
+// renderArgs returns the validation of the render command arguments. A pack
+// is required unless --template-func-list is set, in which case no pack can
+// be passed.
+func renderArgs(c *RenderCommand) ValidationFn {
+	return func(b *baseCommand, args []string) error {
+		if !c.renderTemplateFuncList {
+			return MinimumNArgs(1)(b, args)
+		}
+		if len(args) != 0 {
+			return fmt.Errorf("--template-func-list takes no args, received %d", len(args))
+		}
+		return nil
+	}
+}
+
+// templateFuncTable builds the table of template functions.
+func templateFuncTable(funcs []*renderer.FuncInfo) *terminal.Table {
+	table := terminal.NewTable("NAME", "SIGNATURE", "DESCRIPTION")
+	for _, fn := range funcs {
+		table.Rich([]string{fn.Name, fn.Signature, fn.Description}, nil)
+	}
+	return table
+}
+
+// runTemplateFuncList outputs the template functions available to pack
+// templates, as a table or, when using a structured format, a document.
+func (c *RenderCommand) runTemplateFuncList() int {
+	funcs := renderer.FuncList()
+
+	var (
+		out []byte
+		err error
+	)
+	switch c.renderFormat {
+	case renderFormatJSON:
+		out, err = json.MarshalIndent(funcs, "", "  ")
+	case renderFormatYAML:
+		out, err = yaml.Marshal(funcs)
+	default:
+		c.ui.Table(templateFuncTable(funcs))
+		return 0
+	}
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to encode template function list")
+		return 1
+	}
+	c.ui.Output("%s", string(out))
+	return 0
+}
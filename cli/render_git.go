@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+// validateGitCommit checks the --git-commit flags are used alongside the flags
+// they depend on.
+func validateGitCommit(c *RenderCommand) error {
+	if c.renderGitCommitAllowDirty && c.renderGitCommit == "" {
+		return stdErrors.New("--git-commit-allow-dirty requires --git-commit")
+	}
+	if c.renderGitCommit == "" {
+		return nil
+	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--git-commit requires --to-dir")
+	}
+	if c.renderDiff {
+		return stdErrors.New("--git-commit cannot be used with --diff")
+	}
+	return nil
+}
+
+// runGit runs the git command within the directory, returning its standard
+// output. On failure, the standard error output is included in the error.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return stdout.String(), nil
+}
+
+// checkGitClean checks the git working tree containing the --to-dir path has
+// no uncommitted changes, unless allowed by --git-commit-allow-dirty. As the
+// --to-dir path may not have been created yet, the check is made from its
+// closest existing parent directory.
+func (c *RenderCommand) checkGitClean(ec *errors.UIErrorContext) error {
+	dir := path.Clean(c.renderToDir)
+	for {
+		exists, err := filesystem.Exists(dir)
+		if err != nil {
+			return err
+		}
+		if exists || dir == path.Dir(dir) {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+
+	status, err := runGit(c.Ctx, dir, "status", "--porcelain")
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestDir, c.renderToDir)
+		return err
+	}
+
+	if status != "" && !c.renderGitCommitAllowDirty {
+		ec.Add(errors.FilesystemContextDestDir, c.renderToDir)
+		return stdErrors.New("git working tree has uncommitted changes; use --git-commit-allow-dirty to commit anyway")
+	}
+	return nil
+}
+
+// gitCommitRenders stages the named files within the --to-dir path and
+// commits them using the --git-commit message. Removed files are staged as
+// deletions. Only the named files are committed, so other changes allowed by
+// --git-commit-allow-dirty are left as they are, and no commit is made when
+// none of the files changed.
+func (c *RenderCommand) gitCommitRenders(names []string, ec *errors.UIErrorContext) error {
+	renderToDir := path.Clean(c.renderToDir)
+
+	var existing, removed []string
+	for _, name := range names {
+		exists, err := filesystem.Exists(path.Join(renderToDir, name))
+		if err != nil {
+			return err
+		}
+		if exists {
+			existing = append(existing, name)
+		} else {
+			removed = append(removed, name)
+		}
+	}
+
+	if len(existing) > 0 {
+		if _, err := runGit(c.Ctx, renderToDir, append([]string{"add", "--all", "--"}, existing...)...); err != nil {
+			ec.Add(errors.FilesystemContextDestDir, renderToDir)
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		args := append([]string{"rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, removed...)
+		if _, err := runGit(c.Ctx, renderToDir, args...); err != nil {
+			ec.Add(errors.FilesystemContextDestDir, renderToDir)
+			return err
+		}
+	}
+
+	// Only the files with staged changes can be committed, since passing any
+	// others to git commit results in an error.
+	diff, err := runGit(c.Ctx, renderToDir, append([]string{"diff", "--cached", "--name-only", "--relative", "-z", "--"}, names...)...)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestDir, renderToDir)
+		return err
+	}
+
+	changed := strings.FieldsFunc(diff, func(r rune) bool { return r == 0 })
+	if len(changed) == 0 {
+		c.ui.Info("Skipped git commit as no rendered files changed")
+		return nil
+	}
+
+	if _, err := runGit(c.Ctx, renderToDir, append([]string{"commit", "--quiet", "-m", c.renderGitCommit, "--"}, changed...)...); err != nil {
+		ec.Add(errors.FilesystemContextDestDir, renderToDir)
+		return err
+	}
+
+	c.ui.Success(fmt.Sprintf("Committed %d changed file(s) to git", len(changed)))
+	return nil
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+// renderHookEnv* are the environment variables passed to the
+// --post-render-hook command, in addition to the environment of nomad-pack.
+const (
+	renderHookEnvPack = "NOMAD_PACK_HOOK_PACK"
+	renderHookEnvFile = "NOMAD_PACK_HOOK_FILE"
+	renderHookEnvDir  = "NOMAD_PACK_HOOK_DIR"
+)
+
+// validatePostRenderHook checks the --post-render-hook flags are used
+// alongside the flags they depend on.
+func validatePostRenderHook(c *RenderCommand) error {
+	if c.renderIgnoreHookErrors && c.renderPostRenderHook == "" {
+		return stdErrors.New("--ignore-hook-errors requires --post-render-hook")
+	}
+	if c.renderPostRenderHook == "" {
+		return nil
+	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--post-render-hook requires --to-dir")
+	}
+	if c.renderDiff {
+		return stdErrors.New("--post-render-hook cannot be used with --diff")
+	}
+	return nil
+}
+
+// renderHookResult is the result of running the --post-render-hook command
+// for a single written file.
+type renderHookResult struct {
+	// file is the path of the written file passed to the hook.
+	file string
+	// output is the combined standard output and error of the hook.
+	output string
+	// exitCode is the exit code of the hook, or -1 if it could not be run.
+	exitCode int
+	// err is the error running the hook, or nil on success.
+	err error
+}
+
+// runPostRenderHooks runs the --post-render-hook command for each render
+// which was written to --to-dir, in render order. Renders whose write was
+// skipped or failed are not passed to the hook. As the writes are complete,
+// each file is fully present when its hook runs.
+func (c *RenderCommand) runPostRenderHooks(renders []Render, writes []renderWrite) []renderHookResult {
+	var results []renderHookResult
+	for i, render := range renders {
+		if writes[i].err != nil {
+			continue
+		}
+		if d := writes[i].disposition; d != renderWritten && d != renderOverwritten {
+			continue
+		}
+
+		result := renderHookResult{file: render.outFile(c), exitCode: -1}
+		if err := c.Ctx.Err(); err != nil {
+			result.err = err
+			results = append(results, result)
+			continue
+		}
+
+		result.output, result.exitCode, result.err = runRenderHook(c.Ctx, c.renderPostRenderHook, result.file, []string{
+			renderHookEnvPack + "=" + render.Pack,
+			renderHookEnvFile + "=" + render.Name,
+			renderHookEnvDir + "=" + c.renderToDir,
+		})
+		results = append(results, result)
+	}
+	return results
+}
+
+// runRenderHook runs the hook command using the shell, passing it the file
+// as its final argument, so the command can include its own arguments and
+// shell syntax. The combined standard output and error of the command are
+// returned along with its exit code.
+func runRenderHook(ctx context.Context, hook, file string, env []string) (string, int, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook+` "`+file+`"`)
+	} else {
+		// The file is passed as a positional parameter rather than being
+		// interpolated into the command, so it is never interpreted by the
+		// shell.
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", hook+` "$@"`, "nomad-pack-hook", file)
+	}
+	cmd.Env = append(os.Environ(), env...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		return output.String(), exitCode, fmt.Errorf("post-render hook failed: %w", err)
+	}
+	return output.String(), exitCode, nil
+}
+
+// postRenderHooksToTerminal outputs the result of each hook. The output of
+// successful hooks is only included in the debug log, while failed hooks are
+// reported as errors, or warnings with --ignore-hook-errors, along with their
+// output. It returns whether any hook failed.
+func postRenderHooksToTerminal(c *RenderCommand, results []renderHookResult, ec *errors.UIErrorContext) bool {
+	var failed bool
+	for _, result := range results {
+		output := strings.TrimSpace(result.output)
+		if result.err == nil {
+			logging.Debug(c.logger(), "post-render hook succeeded",
+				logging.F("file", result.file), logging.F("output", output))
+			continue
+		}
+		if stdErrors.Is(result.err, context.Canceled) {
+			failed = true
+			continue
+		}
+
+		if c.renderIgnoreHookErrors {
+			msg := fmt.Sprintf("Ignoring failed post-render hook for %s: %s", result.file, result.err)
+			if output != "" {
+				msg += "\n" + output
+			}
+			c.ui.Warning(msg)
+			continue
+		}
+
+		failed = true
+		hookContext := errors.NewUIErrorContext()
+		hookContext.Append(ec)
+		hookContext.Add(errors.FilesystemContextDestFile, result.file)
+		hookContext.Add("Hook Command: ", c.renderPostRenderHook)
+		hookContext.Add("Hook Exit Code: ", fmt.Sprint(result.exitCode))
+		if output != "" {
+			hookContext.Add("Hook Output: ", output)
+		}
+		c.ui.ErrorWithContext(result.err, "post-render hook failed", hookContext.GetAll()...)
+	}
+	return failed
+}
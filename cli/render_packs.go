@@ -0,0 +1,183 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"strings"
+
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
+)
+
+// renderPackTarget is a single pack passed to the render command, along with
+// the configuration used to render it.
+type renderPackTarget struct {
+	cfg          *cache.PackConfig
+	errorContext *errors.UIErrorContext
+}
+
+// initPackTargets builds the pack config of each pack argument, sharing the
+// registry and ref flags, and verifies each pack exists. When rendering
+// multiple packs, they are verified concurrently and every pack which fails
+// is reported together. Any errors are output before returning.
+func (c *RenderCommand) initPackTargets() ([]*renderPackTarget, bool) {
+	targets := make([]*renderPackTarget, 0, len(c.args))
+	seen := make(map[string]bool, len(c.args))
+
+	for _, name := range c.args {
+		cfg := *c.packConfig
+		cfg.Name = name
+
+		// Set the packConfig defaults if necessary and generate our UI error
+		// context.
+		errorContext := c.initPackCommand(&cfg)
+
+		// The renders of each pack are named for the pack, so the same pack
+		// cannot be rendered more than once.
+		if seen[cfg.Name] {
+			c.ui.ErrorWithContext(fmt.Errorf("pack %q was specified more than once", cfg.Name),
+				ErrParsingArgsOrFlags, errorContext.GetAll()...)
+			return nil, false
+		}
+		seen[cfg.Name] = true
+
+		targets = append(targets, &renderPackTarget{cfg: &cfg, errorContext: errorContext})
+	}
+
+	if !c.verifyPackTargets(targets) {
+		return nil, false
+	}
+
+	for _, target := range targets {
+		if err := validatePackMetadata(target.cfg); err != nil {
+			c.reportMetadataError(err, target.errorContext)
+			return nil, false
+		}
+
+		// Without headers, or as a stream, the output is intended to be
+		// consumed as is, so the checksum is not output alongside it.
+		if !c.renderNoHeaders && !c.renderStream {
+			c.reportPackChecksum(target.cfg)
+		}
+	}
+
+	return targets, true
+}
+
+// verifyPackTargets verifies the packs of the targets exist. A single pack is
+// verified directly, while multiple packs are verified concurrently, with the
+// packs which failed reported once all have been verified.
+func (c *RenderCommand) verifyPackTargets(targets []*renderPackTarget) bool {
+	if len(targets) == 1 {
+		return c.verifyCache.Verify(targets[0].cfg, targets[0].errorContext, c.ui) == nil
+	}
+
+	cfgs := make([]*cache.PackConfig, 0, len(targets))
+	errCtxs := make([]*errors.UIErrorContext, 0, len(targets))
+	for _, target := range targets {
+		cfgs = append(cfgs, target.cfg)
+		errCtxs = append(errCtxs, target.errorContext)
+	}
+
+	failures := c.verifyCache.VerifyPacks(cfgs, errCtxs, cache.DefaultVerifyWorkers)
+	if len(failures) == 0 {
+		return true
+	}
+
+	names := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		names = append(names, failure.Config.Name)
+	}
+	c.ui.Error(fmt.Sprintf("%d of %d pack(s) failed verification: %s",
+		len(failures), len(targets), strings.Join(names, ", ")))
+	for _, failure := range failures {
+		c.ui.ErrorWithContext(failure.Err, failure.Subject, failure.Context...)
+	}
+	return false
+}
+
+// packManager generates the pack manager used to render the target. When
+// rendering multiple packs, only the --var overrides which apply to the
+// target are passed, while the variable files are shared by all packs. The
+// automatically discovered variable files are only passed for local packs.
+func (c *RenderCommand) packManager(client *v1.Client, target *renderPackTarget, targets []*renderPackTarget) *manager.PackManager {
+	cfg := manager.Config{
+		Path:              target.cfg.Path,
+		VariableFiles:     c.varFiles,
+		VariableCLIArgs:   c.vars,
+		VariableMapNames:  c.varMapNames(),
+		Profile:           c.profile,
+		MetadataOverrides: c.metadataOverrides,
+		EnvPrefix:         c.variableEnvPrefix(),
+		StrictVars:        c.strictVars,
+		VariableFileMerge: c.varFileMerge,
+		LeftDelim:         c.leftDelim,
+		RightDelim:        c.rightDelim,
+		VerifyCache:       c.verifyCache,
+		Offline:           c.offline,
+		NomadVariables:    c.nomadVariables,
+		KeepGoing:         c.renderKeepGoing,
+		Parallelism:       c.renderParallelism,
+	}
+
+	if c.renderTemplateTrace {
+		cfg.Trace = c.logger()
+	}
+
+	if c.renderSeedSet {
+		seed := c.renderSeed
+		cfg.Seed = &seed
+	}
+
+	if len(targets) > 1 {
+		packNames := make([]string, 0, len(targets))
+		for _, t := range targets {
+			packNames = append(packNames, t.cfg.Name)
+		}
+		cfg.VariableCLIArgs = scopedVars(c.vars, target.cfg.Name, packNames)
+	}
+
+	if target.cfg.Registry == cache.DevRegistryName {
+		cfg.AutoVariableFiles = c.renderAutoVarFiles
+	}
+
+	return manager.NewPackManager(&cfg, client)
+}
+
+// scopedVars returns the --var overrides which apply to the named pack. An
+// override in the form pack.var=value, where pack is another of the rendered
+// packs, is scoped to that pack, while all other overrides, including those
+// for dependencies, are passed to every pack.
+func scopedVars(vars map[string]string, packName string, packNames []string) map[string]string {
+	others := make(map[string]bool, len(packNames))
+	for _, name := range packNames {
+		if name != packName {
+			others[name] = true
+		}
+	}
+
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if i := strings.Index(k, "."); i != -1 && others[k[:i]] {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// validateMultiPack checks the flags used when rendering multiple packs are
+// supported, as some only apply to a single pack.
+func validateMultiPack(c *RenderCommand) error {
+	switch {
+	case c.renderDiffBaseRef != "" || c.renderDiffTargetRef != "":
+		return stdErrors.New("--diff-base-ref and --diff-target-ref can only be used with a single pack")
+	case c.renderVarSchema:
+		return stdErrors.New("--emit-var-schema can only be used with a single pack")
+	case c.packConfig.Checksum != "":
+		return stdErrors.New("--checksum can only be used with a single pack")
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+
+	v1client "github.com/hashicorp/nomad-openapi/clients/go/v1"
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// validatePlan checks the --plan flag is only used when the renders are
+// output to the terminal as text, and that the cluster may be contacted.
+func validatePlan(c *RenderCommand) error {
+	if !c.renderPlan {
+		return nil
+	}
+	switch {
+	case c.renderFormat != renderFormatText || c.renderNoHeaders:
+		return stdErrors.New("--plan can only be used with the text format and without --no-headers")
+	case c.renderStream:
+		return stdErrors.New("--plan cannot be used with --stream")
+	case c.renderDiff:
+		return stdErrors.New("--plan cannot be used with --diff")
+	case c.flagQuiet:
+		return stdErrors.New("--plan cannot be used with --quiet")
+	case c.offline:
+		return stdErrors.New("--plan cannot be used with --offline")
+	}
+	return nil
+}
+
+// renderJobPlan is the plan of the job within a job specification render.
+type renderJobPlan struct {
+	render string
+	job    *v1client.Job
+	// region is the region the job was planned in when it is a multiregion
+	// job, which is planned in each of its regions.
+	region string
+	resp   *v1client.JobPlanResponse
+}
+
+// planAgainstCluster submits a plan of each job specification render of the
+// parent packs, which are those that would be run, in the namespace and
+// region set by the job or otherwise those passed. Planning does not modify
+// the cluster. The plans are returned in render order, so none are output
+// when a later plan fails. errClusterUnreachable is returned when the cluster
+// cannot be contacted.
+func planAgainstCluster(client *v1.Client, renders []Render, namespace, region string) ([]*renderJobPlan, error) {
+	var plans []*renderJobPlan
+	for _, render := range renders {
+		if !render.Parent || !isJobSpecRender(render) || render.isEmpty() {
+			continue
+		}
+		renderPlans, err := planRenderAgainstCluster(client, render, namespace, region)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, renderPlans...)
+	}
+	return plans, nil
+}
+
+// planRenderAgainstCluster plans the job within a single job specification
+// render. Multiregion jobs are planned in each of their regions.
+func planRenderAgainstCluster(client *v1.Client, render Render, namespace, region string) ([]*renderJobPlan, error) {
+	rendered, namespace, region, err := parseRenderedJob(client, render, namespace, region)
+	if err != nil {
+		return nil, err
+	}
+
+	planOpts := v1.PlanOpts{Diff: true}
+	opts := newWriteOpts().WithNamespace(namespace).WithRegion(region)
+
+	if !client.Jobs().IsMultiRegion(rendered) {
+		resp, _, err := client.Jobs().PlanOpts(opts.Ctx(), rendered, &planOpts)
+		if err != nil {
+			return nil, clusterError(fmt.Errorf("failed to plan job %q: %w", rendered.GetID(), err))
+		}
+		return []*renderJobPlan{{render: render.Name, job: rendered, resp: resp}}, nil
+	}
+
+	var plans []*renderJobPlan
+	for _, r := range *rendered.Multiregion.Regions {
+		regional := *rendered
+		regional.SetRegion(r.GetName())
+
+		resp, _, err := client.Jobs().PlanOpts(opts.WithRegion(r.GetName()).Ctx(), &regional, &planOpts)
+		if err != nil {
+			return nil, clusterError(fmt.Errorf("failed to plan job %q in region %q: %w", rendered.GetID(), r.GetName(), err))
+		}
+		plans = append(plans, &renderJobPlan{render: render.Name, job: &regional, region: r.GetName(), resp: resp})
+	}
+	return plans, nil
+}
+
+// plansToTerminal outputs each plan in the same way as the plan command,
+// including the job diff, the allocation changes predicted by the scheduler,
+// and any warnings. Sensitive variable values are masked within the diff and
+// warnings.
+func plansToTerminal(c *RenderCommand, plans []*renderJobPlan) {
+	if len(plans) == 0 {
+		c.ui.Info("No jobs were rendered to plan")
+		return
+	}
+	for _, plan := range plans {
+		header := fmt.Sprintf("Plan for job %q from %s", plan.job.GetID(), plan.render)
+		if plan.region != "" {
+			header += fmt.Sprintf(" in region %q", plan.region)
+		}
+		c.ui.Output(header, terminal.WithHeaderStyle())
+		c.maskPlan(plan.resp)
+		job.OutputPlan(c.ui, plan.job, plan.resp, true, false)
+	}
+}
+
+// maskPlan masks the sensitive variable values within the plan response, in
+// place, so they are not output within the field values of its diff or its
+// warnings.
+func (c *RenderCommand) maskPlan(resp *v1client.JobPlanResponse) {
+	if resp.Warnings != nil {
+		resp.SetWarnings(c.maskSensitive(*resp.Warnings))
+	}
+	if resp.Diff == nil {
+		return
+	}
+
+	diff := resp.Diff
+	c.maskFieldDiffs(diff.Fields)
+	c.maskObjectDiffs(diff.Objects)
+	if diff.TaskGroups == nil {
+		return
+	}
+	for i := range *diff.TaskGroups {
+		group := &(*diff.TaskGroups)[i]
+		c.maskFieldDiffs(group.Fields)
+		c.maskObjectDiffs(group.Objects)
+		if group.Tasks == nil {
+			continue
+		}
+		for j := range *group.Tasks {
+			task := &(*group.Tasks)[j]
+			c.maskFieldDiffs(task.Fields)
+			c.maskObjectDiffs(task.Objects)
+		}
+	}
+}
+
+// maskObjectDiffs masks the sensitive variable values within the fields of
+// the object diffs and their nested objects.
+func (c *RenderCommand) maskObjectDiffs(objects *[]v1client.ObjectDiff) {
+	if objects == nil {
+		return
+	}
+	for i := range *objects {
+		object := &(*objects)[i]
+		c.maskFieldDiffs(object.Fields)
+		c.maskObjectDiffs(object.Objects)
+	}
+}
+
+// maskFieldDiffs masks the sensitive variable values within the names and
+// the old and new values of the field diffs.
+func (c *RenderCommand) maskFieldDiffs(fields *[]v1client.FieldDiff) {
+	if fields == nil {
+		return
+	}
+	for i := range *fields {
+		field := &(*fields)[i]
+		for _, value := range []*string{field.Name, field.Old, field.New} {
+			if value != nil {
+				*value = c.maskSensitive(*value)
+			}
+		}
+	}
+}
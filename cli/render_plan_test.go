@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	v1client "github.com/hashicorp/nomad-openapi/clients/go/v1"
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePlan(t *testing.T) {
+	c := &RenderCommand{baseCommand: &baseCommand{}, renderFormat: renderFormatText}
+	require.NoError(t, validatePlan(c))
+
+	c.renderPlan = true
+	require.NoError(t, validatePlan(c))
+
+	c.offline = true
+	require.EqualError(t, validatePlan(c), "--plan cannot be used with --offline")
+
+	c.flagQuiet = true
+	require.EqualError(t, validatePlan(c), "--plan cannot be used with --quiet")
+
+	c.renderDiff = true
+	require.EqualError(t, validatePlan(c), "--plan cannot be used with --diff")
+
+	c.renderStream = true
+	require.EqualError(t, validatePlan(c), "--plan cannot be used with --stream")
+
+	c.renderNoHeaders = true
+	require.EqualError(t, validatePlan(c),
+		"--plan can only be used with the text format and without --no-headers")
+}
+
+func TestPlanAgainstCluster(t *testing.T) {
+	var planned []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/jobs/parse":
+			var req struct {
+				JobHCL       string
+				Canonicalize bool
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			id := strings.TrimPrefix(strings.TrimSpace(req.JobHCL), "job ")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ID": id, "Name": id})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/plan"):
+			require.Equal(t, "prod", r.URL.Query().Get("namespace"))
+			require.Equal(t, "east", r.URL.Query().Get("region"))
+			planned = append(planned, r.URL.Path)
+			w.Header().Set("X-Nomad-Index", "7")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"Warnings": "group count is deprecated",
+				"Annotations": map[string]interface{}{
+					"DesiredTGUpdates": map[string]interface{}{
+						"web": map[string]interface{}{"Place": 2},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+	defer srv.Close()
+
+	defer os.Setenv("NOMAD_ADDR", os.Getenv("NOMAD_ADDR"))
+	require.NoError(t, os.Setenv("NOMAD_ADDR", srv.URL))
+
+	client, err := v1.NewClient()
+	require.NoError(t, err)
+
+	plans, err := planAgainstCluster(client, []Render{
+		{Name: "example/web.nomad", Content: "job web", Parent: true},
+		{Name: "example/README.md", Content: "readme", Parent: true},
+		{Name: "dep/db.nomad", Content: "job db"},
+	}, "prod", "east")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/v1/job/web/plan"}, planned)
+	require.Len(t, plans, 1)
+
+	require.Equal(t, "example/web.nomad", plans[0].render)
+	require.Equal(t, "web", plans[0].job.GetID())
+	require.Empty(t, plans[0].region)
+	require.Equal(t, "group count is deprecated", plans[0].resp.GetWarnings())
+	web := plans[0].resp.Annotations.GetDesiredTGUpdates()["web"]
+	require.Equal(t, int32(2), web.GetPlace())
+
+	srv.Close()
+	_, err = planAgainstCluster(client, []Render{{Name: "example/web.nomad", Content: "job web", Parent: true}}, "", "")
+	require.ErrorIs(t, err, errClusterUnreachable)
+}
+
+func TestPlansToTerminalMasksSensitive(t *testing.T) {
+	sensitivePlan := func() *renderJobPlan {
+		j := v1client.NewJob()
+		j.SetID("web")
+		j.SetName("web")
+
+		resp := v1client.NewJobPlanResponse()
+		resp.SetWarnings("token hunter2 is set in plain text")
+		resp.SetDiff(v1client.JobDiff{
+			Type: v1client.PtrString("Edited"),
+			ID:   v1client.PtrString("web"),
+			TaskGroups: &[]v1client.TaskGroupDiff{{
+				Type:    v1client.PtrString("Edited"),
+				Name:    v1client.PtrString("web"),
+				Updates: &map[string]int32{"in-place update": 1},
+				Tasks: &[]v1client.TaskDiff{{
+					Type: v1client.PtrString("Edited"),
+					Name: v1client.PtrString("server"),
+					Objects: &[]v1client.ObjectDiff{{
+						Type: v1client.PtrString("Edited"),
+						Name: v1client.PtrString("Env"),
+						Fields: &[]v1client.FieldDiff{{
+							Type: v1client.PtrString("Edited"),
+							Name: v1client.PtrString("TOKEN"),
+							Old:  v1client.PtrString("letmein"),
+							New:  v1client.PtrString("hunter2"),
+						}},
+					}},
+				}},
+			}},
+		})
+		resp.SetAnnotations(v1client.PlanAnnotations{
+			DesiredTGUpdates: &map[string]v1client.DesiredUpdates{},
+		})
+		return &renderJobPlan{render: "example/web.nomad", job: j, resp: resp}
+	}
+
+	ui := &recordingUI{}
+	c := &RenderCommand{
+		baseCommand:     &baseCommand{ui: ui},
+		sensitiveValues: []string{"hunter2", "letmein"},
+	}
+
+	plansToTerminal(c, []*renderJobPlan{sensitivePlan()})
+	output := strings.Join(ui.output, "\n")
+	require.Contains(t, output, `"***" => "***"`)
+	require.Contains(t, output, "token *** is set in plain text")
+	require.NotContains(t, output, "hunter2")
+	require.NotContains(t, output, "letmein")
+
+	ui.output = nil
+	c.renderUnmask = true
+	plansToTerminal(c, []*renderJobPlan{sensitivePlan()})
+	require.Contains(t, strings.Join(ui.output, "\n"), `"letmein" => "hunter2"`)
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+// staleFiles returns the sorted paths, relative to dir and using forward
+// slashes, of the files within dir which are not in the keep list.
+func staleFiles(dir string, keep []string) ([]string, error) {
+	keepSet := make(map[string]struct{}, len(keep))
+	for _, name := range keep {
+		keepSet[name] = struct{}{}
+	}
+
+	var stale []string
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := keepSet[filepath.ToSlash(rel)]; !ok {
+			stale = append(stale, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// pruneRenders removes the files within the --to-dir path which are not in
+// the keep list, such as renders of templates since removed from the pack,
+// returning the names of the removed files. The user is prompted to confirm
+// the removal unless auto-approved; when the UI is not interactive, nothing
+// is removed.
+func (c *RenderCommand) pruneRenders(keep []string, ec *errors.UIErrorContext) ([]string, error) {
+	renderToDir := path.Clean(c.renderToDir)
+
+	stale, err := staleFiles(renderToDir, keep)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestDir, renderToDir)
+		return nil, fmt.Errorf("failed to find files to prune: %w", err)
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	if !c.autoApproved {
+		if !c.ui.Interactive() {
+			c.ui.Warning(fmt.Sprintf("Skipped pruning %d file(s) not in the render; use --auto-approve to prune", len(stale)))
+			return nil, nil
+		}
+
+		c.ui.Warning("The following files are not in the render and will be removed:")
+		for _, name := range stale {
+			c.ui.Output("  %s", name)
+		}
+
+		prune, err := confirmPrompt(c.baseCommand, "Remove these files? [y/n] ")
+		if err != nil {
+			return nil, err
+		}
+		if !prune {
+			return nil, nil
+		}
+	}
+
+	for _, name := range stale {
+		if err := filesystem.RemovePath(renderToDir, path.Join(renderToDir, name), c.logger()); err != nil {
+			ec.Add(errors.FilesystemContextDestFile, path.Join(renderToDir, name))
+			return nil, err
+		}
+		c.ui.Info(fmt.Sprintf("Pruned %s", name))
+	}
+
+	return stale, nil
+}
+
+// validatePrune checks the --prune flag is used alongside the flags it
+// depends on.
+func validatePrune(c *RenderCommand) error {
+	if !c.renderPrune {
+		return nil
+	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--prune requires --to-dir")
+	}
+	if c.renderDiff {
+		return stdErrors.New("--prune cannot be used with --diff")
+	}
+	return nil
+}
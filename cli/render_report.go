@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+)
+
+// renderReport* are the supported values of the render command --report
+// flag.
+const (
+	renderReportJSON = "json"
+)
+
+// renderReports lists all the supported render report formats.
+var renderReports = []string{renderReportJSON}
+
+// renderDisposition describes what happened when writing a file to --to-dir.
+type renderDisposition string
+
+// renderDisposition* are the possible outcomes of writing a file to --to-dir.
+// A failed write has no disposition.
+const (
+	renderWritten          renderDisposition = "written"
+	renderOverwritten      renderDisposition = "overwritten"
+	renderSkippedUnchanged renderDisposition = "skipped-unchanged"
+	renderSkippedDeclined  renderDisposition = "skipped-declined"
+)
+
+// renderReport categorizes the destination path of each file written to
+// --to-dir by its disposition. Paths are listed in the order they were
+// written.
+type renderReport struct {
+	Written          []string `json:"written"`
+	Overwritten      []string `json:"overwritten"`
+	SkippedUnchanged []string `json:"skipped-unchanged"`
+	SkippedDeclined  []string `json:"skipped-declined"`
+}
+
+// newRenderReport returns an empty report, which marshals each category as an
+// empty list rather than null.
+func newRenderReport() *renderReport {
+	return &renderReport{
+		Written:          []string{},
+		Overwritten:      []string{},
+		SkippedUnchanged: []string{},
+		SkippedDeclined:  []string{},
+	}
+}
+
+// add records the disposition of the file at path. Failed writes, which have
+// no disposition, are ignored.
+func (r *renderReport) add(path string, disposition renderDisposition) {
+	switch disposition {
+	case renderWritten:
+		r.Written = append(r.Written, path)
+	case renderOverwritten:
+		r.Overwritten = append(r.Overwritten, path)
+	case renderSkippedUnchanged:
+		r.SkippedUnchanged = append(r.SkippedUnchanged, path)
+	case renderSkippedDeclined:
+		r.SkippedDeclined = append(r.SkippedDeclined, path)
+	}
+}
+
+// toTerminal outputs the report in the --report format, if set.
+func (r *renderReport) toTerminal(c *RenderCommand) error {
+	if c.renderReport != renderReportJSON {
+		return nil
+	}
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The report is written directly when using --quiet, as the UI discards
+	// all output other than errors.
+	if c.flagQuiet {
+		stdout, _, err := c.ui.OutputWriters()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, string(out))
+		return err
+	}
+	c.ui.Output("%s", string(out))
+	return nil
+}
+
+// validateReport checks the --report flag is used alongside the flags it
+// depends on.
+func validateReport(c *RenderCommand) error {
+	if c.renderReport == "" {
+		return nil
+	}
+	if c.renderToDir == "" {
+		return stdErrors.New("--report requires --to-dir")
+	}
+	if c.renderDiff {
+		return stdErrors.New("--report cannot be used with --diff")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
+)
+
+// stdinPackArg is the pack argument used alongside --stdin-pack to indicate
+// the pack is read from standard input.
+const stdinPackArg = "-"
+
+// validateStdinPack checks the --stdin-pack flag is used alongside the
+// arguments and flags it depends on.
+func validateStdinPack(c *RenderCommand) error {
+	if !c.renderStdinPack {
+		if len(c.args) == 1 && c.args[0] == stdinPackArg {
+			return stdErrors.New("reading the pack from standard input requires --stdin-pack")
+		}
+		return nil
+	}
+	if len(c.args) != 1 || c.args[0] != stdinPackArg {
+		return fmt.Errorf("--stdin-pack requires the pack argument to be %q", stdinPackArg)
+	}
+	if c.packConfig.Registry != "" || c.packConfig.Ref != "" {
+		return stdErrors.New("--stdin-pack cannot be used with --registry or --ref")
+	}
+	if c.renderDiffBaseRef != "" || c.renderDiffTargetRef != "" {
+		return stdErrors.New("--stdin-pack cannot be used with --diff-base-ref or --diff-target-ref")
+	}
+	if c.varsFromStdin() {
+		return stdErrors.New("--stdin-pack cannot be used with variables read from standard input")
+	}
+	return nil
+}
+
+// extractStdinPack extracts the gzip compressed tarball of a pack read from r
+// into a new temporary directory, returning the path of the pack. The
+// returned cleanup function removes the temporary directory, and must be
+// called even when an error is returned.
+func extractStdinPack(r io.Reader) (string, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "nomad-pack-stdin-")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+
+	extractDir := filepath.Join(tmpDir, "extract")
+	if err := os.Mkdir(extractDir, 0755); err != nil {
+		return "", cleanup, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	if err := filesystem.ExtractTarGz(r, extractDir); err != nil {
+		return "", cleanup, fmt.Errorf("failed to extract pack: %v", err)
+	}
+
+	root, err := stdinPackRoot(extractDir)
+	if err != nil {
+		return "", cleanup, err
+	}
+
+	p, err := loader.Load(root)
+	if err != nil {
+		return "", cleanup, fmt.Errorf("invalid pack: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return "", cleanup, fmt.Errorf("invalid pack: %v", err)
+	}
+	if len(p.TemplateFiles) == 0 {
+		return "", cleanup, stdErrors.New("invalid pack: no templates found in the templates directory")
+	}
+
+	// The pack is identified by the name of its directory, so this must
+	// match the name within the pack metadata. As the metadata is untrusted,
+	// the name must be a single path element so the pack stays within the
+	// temporary directory removed by cleanup.
+	name := p.Name()
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", cleanup, fmt.Errorf("invalid pack: name %q must not be empty or contain a path separator", name)
+	}
+	packPath := filepath.Join(tmpDir, name)
+	if err := os.Rename(root, packPath); err != nil {
+		return "", cleanup, fmt.Errorf("failed to move extracted pack: %v", err)
+	}
+
+	return packPath, cleanup, nil
+}
+
+// stdinPackRoot returns the root directory of the pack extracted to dir. The
+// pack files can either be at the root of the tarball, or within a single top
+// level directory, as created by archiving the pack directory itself.
+func stdinPackRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "metadata.hcl")); err == nil {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read extracted pack: %v", err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		root := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(root, "metadata.hcl")); err == nil {
+			return root, nil
+		}
+	}
+
+	return "", stdErrors.New("invalid pack: metadata.hcl not found at the root of the archive or within a single top level directory")
+}
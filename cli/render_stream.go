@@ -0,0 +1,51 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// renderStreamSeparator separates the documents of the --stream output.
+const renderStreamSeparator = "---"
+
+// validateStream checks the --stream flag is only used when the renders are
+// output as text, and not alongside flags which output anything other than
+// the renders, as that would break up the stream.
+func validateStream(c *RenderCommand) error {
+	if !c.renderStream {
+		return nil
+	}
+	switch {
+	case c.renderFormat != renderFormatText:
+		return stdErrors.New("--stream can only be used with the text format")
+	case c.renderNoHeaders:
+		return stdErrors.New("--stream cannot be used with --no-headers")
+	case c.renderDiff:
+		return stdErrors.New("--stream cannot be used with --diff")
+	case c.renderAgainstCluster:
+		return stdErrors.New("--stream cannot be used with --against-cluster")
+	case c.renderSummary:
+		return stdErrors.New("--stream cannot be used with --summary")
+	case c.renderShowEnabled:
+		return stdErrors.New("--stream cannot be used with --show-enabled")
+	case c.renderExplainVars:
+		return stdErrors.New("--stream cannot be used with --explain-vars")
+	case c.flagQuiet:
+		return stdErrors.New("--stream cannot be used with --quiet")
+	}
+	return nil
+}
+
+// toStream outputs the render as a document of the --stream output, preceded
+// by the separator and a comment naming the render. The separator lines are
+// styled unless color is disabled, such as by --no-color or when the output
+// is redirected, so the stream is left as plain text. As with toRaw, the
+// content is neither truncated nor masked, as the stream is intended to be
+// consumed by other tools.
+func (r Render) toStream(c *RenderCommand) {
+	c.ui.Output(renderStreamSeparator, terminal.WithStyle(terminal.BoldStyle))
+	c.ui.Output("# Source: "+r.Name, terminal.WithStyle(terminal.BoldStyle))
+	c.ui.Output("%s", strings.TrimSuffix(r.Content, "\n"))
+}
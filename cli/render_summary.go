@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// renderSummary describes the result of a render run, detailing the number
+// of parent and dependent templates rendered along with the size of each
+// output file.
+type renderSummary struct {
+	ParentRenders    int                  `json:"parent_renders" yaml:"parent_renders"`
+	DependentRenders int                  `json:"dependent_renders" yaml:"dependent_renders"`
+	Files            []*renderSummaryFile `json:"files" yaml:"files"`
+}
+
+// renderSummaryFile is a single output file within the renderSummary.
+type renderSummaryFile struct {
+	Name string `json:"name" yaml:"name"`
+	Size int    `json:"size" yaml:"size"`
+}
+
+// newRenderSummary builds a renderSummary from the template counts and the
+// renders being output.
+func newRenderSummary(parentRenders, dependentRenders int, renders []Render) *renderSummary {
+	summary := renderSummary{
+		ParentRenders:    parentRenders,
+		DependentRenders: dependentRenders,
+		Files:            make([]*renderSummaryFile, len(renders)),
+	}
+
+	for i, render := range renders {
+		summary.Files[i] = &renderSummaryFile{Name: render.Name, Size: len(render.Content)}
+	}
+
+	return &summary
+}
+
+// toTerminal outputs the summary as a table of files followed by a count of
+// the rendered templates. Empty files are highlighted as they usually signal
+// a template problem.
+func (s *renderSummary) toTerminal(c *RenderCommand) {
+	tbl := terminal.NewTable("File", "Size")
+	for _, file := range s.Files {
+		var color string
+		if file.Size == 0 {
+			color = terminal.Yellow
+		}
+		tbl.Rows = append(tbl.Rows, []terminal.TableEntry{
+			{Value: file.Name, Color: color},
+			{Value: strconv.Itoa(file.Size) + " B", Color: color},
+		})
+	}
+
+	c.ui.Output("")
+	c.ui.Table(tbl)
+	c.ui.Output(fmt.Sprintf("Rendered %d parent and %d dependent templates",
+		s.ParentRenders, s.DependentRenders), terminal.WithStyle(terminal.BoldStyle))
+}
@@ -0,0 +1,1004 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/terminal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing string
+		content  string
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			existing: "job \"example\" {}\n",
+			content:  "job \"example\" {}\n",
+			expected: "",
+		},
+		{
+			name:     "new file",
+			existing: "",
+			content:  "job \"example\" {}\n",
+			expected: "--- a/example.nomad\n+++ b/example.nomad\n@@ -0,0 +1 @@\n+job \"example\" {}\n",
+		},
+		{
+			name:     "changed line",
+			existing: "count = 1\n",
+			content:  "count = 3\n",
+			expected: "--- a/example.nomad\n+++ b/example.nomad\n@@ -1 +1 @@\n-count = 1\n+count = 3\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff, err := renderDiff("example.nomad", tc.existing, tc.content)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, diff)
+		})
+	}
+}
+
+func TestFilterRenders(t *testing.T) {
+	renders := []Render{
+		{Name: "example/web.nomad"},
+		{Name: "example/api.nomad"},
+		{Name: "dep/web.nomad"},
+	}
+
+	testCases := []struct {
+		name        string
+		patterns    []string
+		expected    []string
+		expectedErr string
+	}{
+		{
+			name:     "single pattern",
+			patterns: []string{"*/web.nomad"},
+			expected: []string{"example/web.nomad", "dep/web.nomad"},
+		},
+		{
+			name:     "multiple patterns",
+			patterns: []string{"example/api.nomad", "dep/*"},
+			expected: []string{"example/api.nomad", "dep/web.nomad"},
+		},
+		{
+			name:        "no match",
+			patterns:    []string{"*/db.nomad"},
+			expectedErr: "no renders match the --only patterns */db.nomad, available renders are: dep/web.nomad, example/api.nomad, example/web.nomad",
+		},
+		{
+			name:        "invalid pattern",
+			patterns:    []string{"["},
+			expectedErr: `invalid --only pattern "["`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := filterRenders(renders, tc.patterns)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, render := range filtered {
+				names = append(names, render.Name)
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestRenderValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		render      Render
+		expectedErr string
+	}{
+		{
+			name:   "valid job",
+			render: Render{Name: "example/example.nomad", Content: "job \"example\" {\n  type = \"service\"\n}\n"},
+		},
+		{
+			name:   "valid job with variables",
+			render: Render{Name: "example/example.nomad", Content: "variable \"image\" {}\n\njob \"example\" {}\n"},
+		},
+		{
+			name:        "syntax error",
+			render:      Render{Name: "example/example.nomad", Content: "job \"example\" {\n  type = \n}\n"},
+			expectedErr: "example/example.nomad:2,",
+		},
+		{
+			name:        "missing job",
+			render:      Render{Name: "example/example.nomad", Content: "variable \"image\" {}\n"},
+			expectedErr: "example/example.nomad: expected a single job block, found 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.render.validate()
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRenderShouldValidate(t *testing.T) {
+	require.True(t, Render{Name: "example/example.nomad", Parent: true}.shouldValidate())
+	require.True(t, Render{Name: "example/example.hcl", Parent: true}.shouldValidate())
+	require.True(t, Render{Name: "example/example.nomad.tpl", Parent: true}.shouldValidate())
+	require.False(t, Render{Name: "example/example.nomad"}.shouldValidate())
+	require.False(t, Render{Name: "example/README.md", Parent: true}.shouldValidate())
+}
+
+func TestSelectRawRender(t *testing.T) {
+	web := Render{Name: "example/web.nomad"}
+	api := Render{Name: "example/api.nomad"}
+	outputs := &Render{Name: "outputs.txt"}
+
+	selected, err := selectRawRender([]Render{web}, outputs)
+	require.NoError(t, err)
+	require.Equal(t, web, selected)
+
+	selected, err = selectRawRender(nil, outputs)
+	require.NoError(t, err)
+	require.Equal(t, *outputs, selected)
+
+	_, err = selectRawRender(nil, nil)
+	require.Error(t, err)
+
+	_, err = selectRawRender([]Render{web, api}, nil)
+	require.EqualError(t, err, "--no-headers requires exactly one render, but 2 were selected: example/api.nomad, example/web.nomad; use --only to select a single render")
+}
+
+func TestFormatRenderName(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		keepTplExt bool
+		expected   string
+	}{
+		{
+			name:     "nomad template",
+			input:    "example/templates/example.nomad.tpl",
+			expected: "example/example.nomad",
+		},
+		{
+			name:     "name ending in cutset characters",
+			input:    "example/templates/webapp.lt.tpl",
+			expected: "example/webapp.lt",
+		},
+		{
+			name:     "name without extension",
+			input:    "example/templates/script.pl",
+			expected: "example/script.pl",
+		},
+		{
+			name:       "keep extension",
+			input:      "example/templates/example.nomad.tpl",
+			keepTplExt: true,
+			expected:   "example/example.nomad.tpl",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, formatRenderName(tc.input, tc.keepTplExt))
+		})
+	}
+}
+
+func TestCheckRenderCollisions(t *testing.T) {
+	renders := []Render{
+		{Name: "example/jobs/web.nomad", Template: "example/templates/web.nomad.tpl"},
+		{Name: "example/api.nomad", Template: "example/templates/api.nomad.tpl"},
+		{Name: "outputs.txt"},
+	}
+	require.NoError(t, checkRenderCollisions(renders))
+
+	renders = append(renders, Render{Name: "example/jobs/web.nomad", Template: "example/templates/app.nomad.tpl"})
+	require.EqualError(t, checkRenderCollisions(renders),
+		"templates example/templates/app.nomad.tpl, example/templates/web.nomad.tpl render to the same path example/jobs/web.nomad")
+}
+
+func TestValidateOutDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, validateOutDir(""))
+	require.NoError(t, validateOutDir(dir))
+	require.NoError(t, validateOutDir(filepath.Join(dir, "missing")))
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+
+	var notDirErr *errors.ErrNotDirectory
+	err := validateOutDir(file)
+	require.True(t, stdErrors.As(err, &notDirErr))
+	require.Equal(t, file, notDirErr.Path)
+}
+
+func TestRefDiffNames(t *testing.T) {
+	base := map[string]string{"example/web.nomad": "", "example/api.nomad": ""}
+	target := map[string]string{"example/web.nomad": "", "example/worker.nomad": ""}
+
+	require.Equal(t,
+		[]string{"example/api.nomad", "example/web.nomad", "example/worker.nomad"},
+		refDiffNames(base, target))
+}
+
+func TestWriteRenders(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c := &RenderCommand{
+		baseCommand:            &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:            dir,
+		renderWriteConcurrency: 3,
+	}
+
+	var renders []Render
+	for i := 0; i < 10; i++ {
+		renders = append(renders, Render{Name: fmt.Sprintf("example/job-%d.nomad", i), Content: fmt.Sprint(i)})
+	}
+
+	// Create one of the files up front, which should not be overwritten.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "example"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example", "job-4.nomad"), []byte("existing"), 0644))
+
+	writes := c.writeRenders(renders, errors.NewUIErrorContext())
+	require.Len(t, writes, len(renders))
+
+	for i, r := range renders {
+		content, err := os.ReadFile(filepath.Join(dir, r.Name))
+		require.NoError(t, err)
+
+		if i == 4 {
+			require.ErrorIs(t, writes[i].err, os.ErrExist)
+			require.Equal(t, "existing", string(content))
+			continue
+		}
+		require.NoError(t, writes[i].err)
+		require.Equal(t, r.Content, string(content))
+	}
+}
+
+func TestWriteRendersUnchanged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c := &RenderCommand{
+		baseCommand:            &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:            dir,
+		renderWriteConcurrency: 1,
+	}
+
+	renders := []Render{{Name: "same.nomad", Content: "same"}, {Name: "changed.nomad", Content: "new"}}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "same.nomad"), []byte("same"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "changed.nomad"), []byte("old"), 0644))
+
+	// Unchanged files are skipped, whereas changed files require approval
+	// to be overwritten.
+	writes := c.writeRenders(renders, errors.NewUIErrorContext())
+	require.ErrorIs(t, writes[0].err, errRenderUnchanged)
+	require.Equal(t, renderSkippedUnchanged, writes[0].disposition)
+	require.ErrorIs(t, writes[1].err, os.ErrExist)
+	require.Equal(t, renderSkippedDeclined, writes[1].disposition)
+	require.True(t, renderWritesSucceeded(writes[:1]))
+
+	// Forcing the write means no file is considered unchanged.
+	c.renderForce = true
+	c.autoApproved = true
+	writes = c.writeRenders(renders, errors.NewUIErrorContext())
+	require.NoError(t, writes[0].err)
+	require.Equal(t, renderOverwritten, writes[0].disposition)
+	require.NoError(t, writes[1].err)
+	require.Equal(t, renderOverwritten, writes[1].disposition)
+
+	// Files which did not exist are reported as written.
+	disposition, err := Render{Name: "new.nomad", Content: "new"}.toFile(c, errors.NewUIErrorContext())
+	require.NoError(t, err)
+	require.Equal(t, renderWritten, disposition)
+
+	content, err := os.ReadFile(filepath.Join(dir, "changed.nomad"))
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+}
+
+func TestWriteRendersDedup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c := &RenderCommand{
+		baseCommand:            &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:            dir,
+		renderWriteConcurrency: 2,
+		renderDedup:            true,
+	}
+
+	renders := []Render{
+		{Name: "web/sidecar.hcl", Content: "sidecar"},
+		{Name: "api/sidecar.hcl", Content: "sidecar"},
+		{Name: "api/job.nomad", Content: "job"},
+		{Name: "db/sidecar.hcl", Content: "sidecar"},
+		{Name: "db/run.sh", Content: "sidecar", Mode: 0755},
+	}
+
+	writes := c.writeRenders(renders, errors.NewUIErrorContext())
+	for i, w := range writes {
+		require.NoError(t, w.err, renders[i].Name)
+		require.Equal(t, renderWritten, w.disposition, renders[i].Name)
+	}
+
+	// Only renders duplicating the content and permissions of an earlier
+	// render are linked to its file.
+	require.Equal(t, []renderDedupMode{"", renderDedupLinked, "", renderDedupLinked, ""},
+		[]renderDedupMode{writes[0].dedup, writes[1].dedup, writes[2].dedup, writes[3].dedup, writes[4].dedup})
+
+	original, err := os.Stat(filepath.Join(dir, "web", "sidecar.hcl"))
+	require.NoError(t, err)
+	for _, name := range []string{"api/sidecar.hcl", "db/sidecar.hcl"} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.True(t, os.SameFile(original, fi), name)
+	}
+	fi, err := os.Stat(filepath.Join(dir, "db", "run.sh"))
+	require.NoError(t, err)
+	require.False(t, os.SameFile(original, fi))
+
+	// Existing files are only replaced by a link when approved.
+	renders[1].Content, renders[3].Content = "changed", "changed"
+	renders[0].Content = "changed"
+	writes = c.writeRenders(renders, errors.NewUIErrorContext())
+	require.ErrorIs(t, writes[0].err, os.ErrExist)
+	require.ErrorIs(t, writes[1].err, os.ErrExist)
+
+	c.autoApproved = true
+	writes = c.writeRenders(renders, errors.NewUIErrorContext())
+	require.NoError(t, writes[1].err)
+	require.Equal(t, renderOverwritten, writes[1].disposition)
+	require.Equal(t, renderDedupLinked, writes[1].dedup)
+
+	content, err := os.ReadFile(filepath.Join(dir, "db", "sidecar.hcl"))
+	require.NoError(t, err)
+	require.Equal(t, "changed", string(content))
+}
+
+func TestRunPostRenderHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook uses a POSIX shell")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	log := filepath.Join(t.TempDir(), "hook.log")
+
+	c := &RenderCommand{
+		baseCommand:            &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:            dir,
+		renderWriteConcurrency: 2,
+		renderPostRenderHook: fmt.Sprintf(`f() { echo "$NOMAD_PACK_HOOK_PACK $NOMAD_PACK_HOOK_FILE $(cat "$1")" >> %s; `+
+			`grep -qv fail "$1" || { echo "bad content"; exit 3; }; }; f`, log),
+	}
+
+	renders := []Render{
+		{Name: "example/a.nomad", Content: "a", Pack: "example"},
+		{Name: "example/b.nomad", Content: "fail", Pack: "example"},
+		{Name: "example/c.nomad", Content: "c", Pack: "example"},
+	}
+
+	// The hook is not run for files which are not written.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "example"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example", "c.nomad"), []byte("existing"), 0644))
+
+	writes := c.writeRenders(renders, errors.NewUIErrorContext())
+	results := c.runPostRenderHooks(renders, writes)
+	require.Len(t, results, 2)
+
+	require.NoError(t, results[0].err)
+	require.Equal(t, filepath.Join(dir, "example", "a.nomad"), results[0].file)
+	require.Equal(t, 0, results[0].exitCode)
+
+	require.Error(t, results[1].err)
+	require.Equal(t, 3, results[1].exitCode)
+	require.Equal(t, "bad content\n", results[1].output)
+
+	content, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, "example example/a.nomad a\nexample example/b.nomad fail\n", string(content))
+
+	require.True(t, postRenderHooksToTerminal(c, results, errors.NewUIErrorContext()))
+	c.renderIgnoreHookErrors = true
+	require.False(t, postRenderHooksToTerminal(c, results, errors.NewUIErrorContext()))
+}
+
+func TestCompressRenders(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c := &RenderCommand{
+		baseCommand:             &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:             dir,
+		renderWriteConcurrency:  1,
+		renderCompress:          true,
+		renderCompressThreshold: 8,
+	}
+
+	renders := []Render{
+		{Name: "example/small.nomad", Content: "small"},
+		{Name: "example/large.json", Content: strings.Repeat("large", 10)},
+	}
+
+	compressed, err := compressRenders(c, renders)
+	require.NoError(t, err)
+	require.Equal(t, renders[0], compressed[0])
+	require.Equal(t, "example/large.json.gz", compressed[1].Name)
+
+	// The compressed content is written in place of the uncompressed file,
+	// and is identical each time so unchanged files are skipped.
+	writes := c.writeRenders(compressed, errors.NewUIErrorContext())
+	for i, w := range writes {
+		require.NoError(t, w.err, compressed[i].Name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "example", "large.json"))
+	require.True(t, os.IsNotExist(err))
+
+	f, err := os.Open(filepath.Join(dir, "example", "large.json.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, renders[1].Content, string(content))
+
+	again, err := compressRenders(c, renders)
+	require.NoError(t, err)
+	writes = c.writeRenders(again, errors.NewUIErrorContext())
+	require.ErrorIs(t, writes[1].err, errRenderUnchanged)
+}
+
+func TestValidateCompress(t *testing.T) {
+	c := &RenderCommand{baseCommand: &baseCommand{}}
+	require.NoError(t, validateCompress(c))
+
+	c.renderCompress = true
+	require.EqualError(t, validateCompress(c), "--compress requires --to-dir")
+
+	c.renderToDir = "out"
+	require.NoError(t, validateCompress(c))
+
+	c.renderCompressThreshold = -1
+	require.EqualError(t, validateCompress(c), "--compress-threshold must not be negative")
+
+	c.renderDiff = true
+	require.EqualError(t, validateCompress(c), "--compress cannot be used with --diff")
+}
+
+func TestValidateQuiet(t *testing.T) {
+	c := &RenderCommand{baseCommand: &baseCommand{}, renderNoHeaders: true, renderDiff: true}
+	require.NoError(t, validateQuiet(c))
+
+	c.flagQuiet = true
+	require.EqualError(t, validateQuiet(c), "--quiet cannot be used with --no-headers")
+
+	c.renderNoHeaders = false
+	require.EqualError(t, validateQuiet(c), "--quiet cannot be used with --diff")
+
+	c.renderDiff = false
+	require.NoError(t, validateQuiet(c))
+}
+
+func TestRenderReport(t *testing.T) {
+	report := newRenderReport()
+	report.add("out/a.nomad", renderWritten)
+	report.add("out/b.nomad", renderOverwritten)
+	report.add("out/c.nomad", renderSkippedUnchanged)
+	report.add("out/d.nomad", renderSkippedDeclined)
+	report.add("out/e.nomad", "")
+
+	out, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "written": ["out/a.nomad"],
+  "overwritten": ["out/b.nomad"],
+  "skipped-unchanged": ["out/c.nomad"],
+  "skipped-declined": ["out/d.nomad"]
+}`, string(out))
+
+	// Empty categories are output as empty lists.
+	out, err = json.Marshal(newRenderReport())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"written": [], "overwritten": [], "skipped-unchanged": [], "skipped-declined": []}`, string(out))
+}
+
+func TestGitCommitRenders(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	ctx := context.Background()
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "deploy")
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		_, err := runGit(ctx, repo, args...)
+		require.NoError(t, err)
+	}
+
+	c := &RenderCommand{
+		baseCommand:     &baseCommand{Ctx: ctx, ui: terminal.NonInteractiveUI(ctx)},
+		renderToDir:     dir,
+		renderGitCommit: "render",
+	}
+	require.NoError(t, c.checkGitClean(errors.NewUIErrorContext()))
+
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "job.nomad"), []byte("job"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "other"), []byte("other"), 0644))
+
+	// The unrelated file makes the working tree dirty.
+	require.Error(t, c.checkGitClean(errors.NewUIErrorContext()))
+	c.renderGitCommitAllowDirty = true
+	require.NoError(t, c.checkGitClean(errors.NewUIErrorContext()))
+
+	// Only the rendered files are committed.
+	require.NoError(t, c.gitCommitRenders([]string{"job.nomad"}, errors.NewUIErrorContext()))
+
+	files, err := runGit(ctx, repo, "ls-files")
+	require.NoError(t, err)
+	require.Equal(t, "deploy/job.nomad\n", files)
+
+	// Without changes, no commit is made.
+	require.NoError(t, c.gitCommitRenders([]string{"job.nomad"}, errors.NewUIErrorContext()))
+
+	count, err := runGit(ctx, repo, "rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	require.Equal(t, "1\n", count)
+}
+
+func TestExtractStdinPack(t *testing.T) {
+	metadata := `app {
+  url    = "https://example.com"
+  author = "test"
+}
+
+pack {
+  name        = "example"
+  description = "example pack"
+  url         = "https://example.com/example"
+  version     = "0.0.1"
+}
+`
+
+	testCases := []struct {
+		name        string
+		files       []*filesystem.ArchiveFile
+		expectedErr string
+	}{
+		{
+			name: "root",
+			files: []*filesystem.ArchiveFile{
+				{Name: "metadata.hcl", Content: []byte(metadata)},
+				{Name: "variables.hcl", Content: nil},
+				{Name: "templates/example.nomad.tpl", Content: []byte("job")},
+			},
+		},
+		{
+			name: "top level directory",
+			files: []*filesystem.ArchiveFile{
+				{Name: "packs/metadata.hcl", Content: []byte(metadata)},
+				{Name: "packs/variables.hcl", Content: nil},
+				{Name: "packs/templates/example.nomad.tpl", Content: []byte("job")},
+			},
+		},
+		{
+			name: "missing metadata",
+			files: []*filesystem.ArchiveFile{
+				{Name: "templates/example.nomad.tpl", Content: []byte("job")},
+			},
+			expectedErr: "metadata.hcl not found",
+		},
+		{
+			name: "missing templates",
+			files: []*filesystem.ArchiveFile{
+				{Name: "metadata.hcl", Content: []byte(metadata)},
+				{Name: "variables.hcl", Content: nil},
+			},
+			expectedErr: "no templates found",
+		},
+		{
+			name: "name outside temporary directory",
+			files: []*filesystem.ArchiveFile{
+				{Name: "metadata.hcl", Content: []byte(strings.Replace(metadata, `"example"`, `"../nomad-pack-stdin-escape"`, 1))},
+				{Name: "variables.hcl", Content: nil},
+				{Name: "templates/example.nomad.tpl", Content: []byte("job")},
+			},
+			expectedErr: `name "../nomad-pack-stdin-escape" must not be empty or contain a path separator`,
+		},
+		{
+			name: "parent directory name",
+			files: []*filesystem.ArchiveFile{
+				{Name: "metadata.hcl", Content: []byte(strings.Replace(metadata, `"example"`, `".."`, 1))},
+				{Name: "variables.hcl", Content: nil},
+				{Name: "templates/example.nomad.tpl", Content: []byte("job")},
+			},
+			expectedErr: `name ".." must not be empty or contain a path separator`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, filesystem.WriteTarGz(&buf, tc.files))
+
+			packPath, cleanup, err := extractStdinPack(&buf)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "example", filepath.Base(packPath))
+				require.FileExists(t, filepath.Join(packPath, "templates", "example.nomad.tpl"))
+			}
+
+			// The temporary directory is removed, even on error, and nothing
+			// is moved outside of it.
+			cleanup()
+			if packPath != "" {
+				require.NoDirExists(t, filepath.Dir(packPath))
+			}
+			require.NoDirExists(t, filepath.Join(os.TempDir(), "nomad-pack-stdin-escape"))
+		})
+	}
+}
+
+func TestOutputRenderName(t *testing.T) {
+	testCases := []struct {
+		name, dest string
+		keepTplExt bool
+		expected   string
+	}{
+		{name: "outputs.tpl", expected: "outputs.txt"},
+		{name: "redis/outputs.tpl", expected: "redis/outputs.txt"},
+		{name: "outputs.md.tpl", expected: "outputs.md"},
+		{name: "outputs.tpl", keepTplExt: true, expected: "outputs.tpl"},
+		{name: "outputs.tpl", dest: "./docs/outputs.md", expected: "docs/outputs.md"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, outputRenderName(tc.name, tc.dest, tc.keepTplExt))
+	}
+}
+
+func TestValidateOutputTemplateDest(t *testing.T) {
+	c := &RenderCommand{renderOutputTemplateDest: "outputs.md"}
+	require.EqualError(t, validateOutputTemplateDest(c),
+		"--output-template-dest requires --render-output-template or --output-template-file")
+
+	c.renderOutputTemplate = true
+	require.NoError(t, validateOutputTemplateDest(c))
+
+	for _, dest := range []string{"/tmp/outputs.md", "../outputs.md", "docs/../../outputs.md", "."} {
+		c.renderOutputTemplateDest = dest
+		require.Error(t, validateOutputTemplateDest(c), dest)
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	c := &RenderCommand{baseCommand: &baseCommand{}, renderFormat: renderFormatText}
+	require.NoError(t, validateStream(c))
+
+	c.renderStream = true
+	require.NoError(t, validateStream(c))
+
+	c.renderSummary = true
+	require.EqualError(t, validateStream(c), "--stream cannot be used with --summary")
+
+	c.renderSummary = false
+	c.renderFormat = renderFormatJSON
+	require.EqualError(t, validateStream(c), "--stream can only be used with the text format")
+}
+
+func TestRenderArgs(t *testing.T) {
+	c := &RenderCommand{}
+	validate := renderArgs(c)
+	require.Error(t, validate(nil, nil))
+	require.NoError(t, validate(nil, []string{"example"}))
+
+	c.renderTemplateFuncList = true
+	require.NoError(t, validate(nil, nil))
+	require.EqualError(t, validate(nil, []string{"example"}), "--template-func-list takes no args, received 1")
+}
+
+func TestParseDelims(t *testing.T) {
+	left, right, err := parseDelims(" << >> ")
+	require.NoError(t, err)
+	require.Equal(t, "<<", left)
+	require.Equal(t, ">>", right)
+
+	for _, delims := range []string{"", "<<", "<< >> ||", "<< <<"} {
+		_, _, err := parseDelims(delims)
+		require.Error(t, err, delims)
+	}
+}
+
+func TestNewChecksumManifest(t *testing.T) {
+	renders := []Render{
+		{Name: "example/web.nomad", Content: "web"},
+		{Name: "example/api.nomad", Content: "api"},
+	}
+
+	manifest, err := newChecksumManifest(renders, checksumAlgoSHA256)
+	require.NoError(t, err)
+	require.Equal(t,
+		"14c2529eb4498c5d1ffd6915d05bf58a91bdda796af59f41d480d11c099d0479  example/api.nomad\n"+
+			"4b5e57f6eb2f42b9039b3d1e13929295f231749c510cbe341cd68036d9af97e2  example/web.nomad\n",
+		manifest)
+
+	manifest, err = newChecksumManifest(renders[:1], checksumAlgoSHA512)
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(manifest)[0], 128)
+
+	_, err = newChecksumManifest(renders, "md5")
+	require.EqualError(t, err, `unsupported checksum algorithm "md5"`)
+	require.Equal(t, "SHA512SUMS", checksumManifestName(checksumAlgoSHA512))
+}
+
+func TestStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"example/web.nomad", "example/old.nomad", "SHA256SUMS", "other/file"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	stale, err := staleFiles(dir, []string{"example/web.nomad", "SHA256SUMS"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"example/old.nomad", "other/file"}, stale)
+}
+
+func TestScopedVars(t *testing.T) {
+	vars := map[string]string{
+		"count":         "3",
+		"web.image":     "nginx",
+		"api.image":     "api",
+		"redis.version": "6",
+	}
+
+	require.Equal(t, map[string]string{
+		"count":         "3",
+		"web.image":     "nginx",
+		"redis.version": "6",
+	}, scopedVars(vars, "web", []string{"web", "api"}))
+}
+
+func TestParseVarMap(t *testing.T) {
+	vars, err := parseVarMap(`{"replicas":3,"image":"redis","enabled":true,"cert":"@cert.pem",
+		"datacenters":["dc1","dc2"],"labels":{"team":"${x}"},"empty":null}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"replicas":    "3",
+		"image":       "redis",
+		"enabled":     "true",
+		"cert":        "@@cert.pem",
+		"datacenters": `["dc1","dc2"]`,
+		"labels":      `{"team":"$${x}"}`,
+		"empty":       "null",
+	}, vars)
+
+	_, err = parseVarMap("{\n  \"replicas\": 3,\n  \"image\" redis\n}")
+	require.EqualError(t, err, "invalid JSON at line 3, column 11: invalid character 'r' after object key")
+
+	_, err = parseVarMap(`["replicas"]`)
+	require.EqualError(t, err, "value must be a JSON object, not array")
+
+	_, err = parseVarMap(`{"replicas":3} {}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected content after the object")
+}
+
+func TestVarMapNames(t *testing.T) {
+	c := &baseCommand{}
+	c.vars = map[string]string{"image": "nginx"}
+
+	v := &varMapValue{c: c}
+	require.NoError(t, v.Set(`{"replicas":3,"image":"redis"}`))
+	require.Equal(t, map[string]string{"replicas": "3", "image": "redis"}, c.vars)
+
+	// A later --var replaces the --var-map value.
+	c.vars["replicas"] = "5"
+	require.Equal(t, map[string]bool{"image": true}, c.varMapNames())
+}
+
+func TestTruncateContent(t *testing.T) {
+	out, truncated := truncateContent("hello world", 5)
+	require.True(t, truncated)
+	require.Equal(t, "hello", out)
+
+	out, truncated = truncateContent("hello", 5)
+	require.False(t, truncated)
+	require.Equal(t, "hello", out)
+
+	out, truncated = truncateContent("hello world", 0)
+	require.False(t, truncated)
+	require.Equal(t, "hello world", out)
+
+	// Multi-byte characters are not split.
+	out, truncated = truncateContent("héllo", 2)
+	require.True(t, truncated)
+	require.Equal(t, "h", out)
+}
+
+func TestRenderFileMode(t *testing.T) {
+	require.Equal(t, os.FileMode(0644), renderFileMode(0644, false, "echo hello"))
+	require.Equal(t, os.FileMode(0755), renderFileMode(0755, false, "echo hello"))
+	require.Equal(t, os.FileMode(0755), renderFileMode(0644, false, "#!/bin/sh\necho hello"))
+	require.Equal(t, os.FileMode(0755), renderFileMode(0644, true, "echo hello"))
+}
+
+func TestParseDirMode(t *testing.T) {
+	mode, err := parseDirMode("0700")
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), mode)
+
+	mode, err = parseDirMode("775")
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0775), mode)
+
+	_, err = parseDirMode("0788")
+	require.EqualError(t, err, `invalid --dir-mode "0788": must be an octal permission such as 0755`)
+	_, err = parseDirMode("01755")
+	require.EqualError(t, err, `invalid --dir-mode "01755": must only contain permission bits`)
+	_, err = parseDirMode("0500")
+	require.EqualError(t, err, `invalid --dir-mode "0500": must allow the owner to write and execute`)
+}
+
+func TestMaskSensitive(t *testing.T) {
+	c := &RenderCommand{sensitiveValues: []string{"hunter2", "hunter"}}
+	require.Equal(t, "password = \"***\"\nuser = \"***\"\n",
+		c.maskSensitive("password = \"hunter2\"\nuser = \"hunter\"\n"))
+
+	c.renderUnmask = true
+	require.Equal(t, "password = \"hunter2\"\n", c.maskSensitive("password = \"hunter2\"\n"))
+
+	c = &RenderCommand{}
+	require.Equal(t, "password = \"hunter2\"\n", c.maskSensitive("password = \"hunter2\"\n"))
+}
+
+// recordingUI records the messages output to the terminal.
+type recordingUI struct {
+	terminal.UI
+	output []string
+}
+
+func (ui *recordingUI) Output(msg string, raw ...interface{}) {
+	msg, _, _ = terminal.Interpret(msg, raw...)
+	ui.output = append(ui.output, msg)
+}
+
+func (ui *recordingUI) AppendToRow(msg string, raw ...interface{}) { ui.Output(msg, raw...) }
+func (ui *recordingUI) Header(msg string)                          { ui.Output(msg) }
+func (ui *recordingUI) Info(msg string)                            { ui.Output(msg) }
+func (ui *recordingUI) Success(msg string)                         { ui.Output(msg) }
+func (ui *recordingUI) Warning(msg string)                         { ui.Output(msg) }
+func (ui *recordingUI) WarningBold(msg string)                     { ui.Output(msg) }
+
+func TestRenderToDiffMasksSensitive(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example.nomad"), []byte("password = \"old\"\n"), 0644))
+
+	ui := &recordingUI{}
+	c := &RenderCommand{
+		baseCommand:     &baseCommand{ui: ui},
+		renderToDir:     dir,
+		sensitiveValues: []string{"hunter2"},
+	}
+	r := Render{Name: "example.nomad", Content: "password = \"hunter2\"\n"}
+
+	require.NoError(t, r.toDiff(c, errors.NewUIErrorContext()))
+	require.Contains(t, ui.output, `+password = "***"`)
+	require.NotContains(t, strings.Join(ui.output, "\n"), "hunter2")
+
+	ui.output = nil
+	c.renderUnmask = true
+	require.NoError(t, r.toDiff(c, errors.NewUIErrorContext()))
+	require.Contains(t, ui.output, `+password = "hunter2"`)
+}
+
+func TestRenderExitCodes(t *testing.T) {
+	writePack := func(t *testing.T, templates map[string]string) string {
+		packPath := filepath.Join(t.TempDir(), "example")
+		require.NoError(t, os.MkdirAll(filepath.Join(packPath, "templates"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(packPath, "metadata.hcl"), []byte(`app {
+  url    = ""
+  author = ""
+}
+
+pack {
+  name    = "example"
+  url     = ""
+  version = "0.1.0"
+}
+`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(packPath, "variables.hcl"), nil, 0644))
+		for name, content := range templates {
+			require.NoError(t, os.WriteFile(filepath.Join(packPath, "templates", name), []byte(content), 0644))
+		}
+		return packPath
+	}
+
+	run := func(args ...string) int {
+		c := &RenderCommand{baseCommand: &baseCommand{Ctx: context.Background()}}
+		return c.Run(append(args, "--offline", "--no-auto-vars", "--cache-dir", t.TempDir()))
+	}
+
+	t.Run("success", func(t *testing.T) {
+		packPath := writePack(t, map[string]string{"example.nomad.tpl": "job"})
+		require.Equal(t, renderExitSuccess, run(packPath))
+	})
+
+	t.Run("render failure", func(t *testing.T) {
+		packPath := writePack(t, map[string]string{"example.nomad.tpl": `[[ fail "boom" ]]`})
+		require.Equal(t, renderExitError, run(packPath))
+	})
+
+	t.Run("nothing rendered", func(t *testing.T) {
+		packPath := writePack(t, map[string]string{"_helpers.tpl": `[[ define "name" ]][[ end ]]`})
+		require.Equal(t, renderExitNoTemplates, run(packPath))
+	})
+
+	t.Run("write failure", func(t *testing.T) {
+		packPath := writePack(t, map[string]string{"example.nomad.tpl": "job"})
+
+		// A file in place of the pack's directory prevents writing.
+		toDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(toDir, "example"), nil, 0644))
+		require.Equal(t, renderExitWriteFailed, run(packPath, "--to-dir", toDir, "--auto-approve"))
+	})
+
+	t.Run("output template", func(t *testing.T) {
+		packPath := writePack(t, map[string]string{"example.nomad.tpl": "job"})
+		require.NoError(t, os.WriteFile(filepath.Join(packPath, "outputs.tpl"), []byte("outputs"), 0644))
+
+		toDir := t.TempDir()
+		require.Equal(t, renderExitSuccess, run(packPath, "--to-dir", toDir, "--render-output-template"))
+		content, err := os.ReadFile(filepath.Join(toDir, "outputs.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "outputs", string(content))
+
+		// The destination is written like the other renders, so a file in
+		// place of its directory fails the write.
+		require.NoError(t, os.WriteFile(filepath.Join(toDir, "docs"), nil, 0644))
+		require.Equal(t, renderExitWriteFailed, run(packPath, "--to-dir", toDir, "--render-output-template",
+			"--output-template-dest", "docs/outputs.md", "--auto-approve"))
+	})
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/upload"
+)
+
+// uploadRenders uploads the renders to the --to-url destination, returning
+// the result of each upload in the same order as the passed renders. Existing
+// files are only replaced when auto-approved or confirmed by the user. As
+// with writing to --to-dir, the upload of an existing file which is not to be
+// replaced fails with an error wrapping os.ErrExist.
+func (c *RenderCommand) uploadRenders(uploader upload.Uploader, renders []Render, ec *errors.UIErrorContext) []renderWrite {
+	results := make([]renderWrite, len(renders))
+
+	for i, r := range renders {
+		results[i].errorContext = errors.NewUIErrorContext()
+		results[i].errorContext.Append(ec)
+
+		if err := c.Ctx.Err(); err != nil {
+			results[i].err = err
+			continue
+		}
+
+		if err := r.toURL(c, uploader); err != nil {
+			results[i].errorContext.Add("Destination URL: ", c.renderToURL)
+			results[i].err = err
+		}
+	}
+
+	return results
+}
+
+// toURL uploads the render using the uploader, checking whether it already
+// exists unless auto-approved.
+func (r Render) toURL(c *RenderCommand, uploader upload.Uploader) error {
+	if !c.autoApproved {
+		exists, err := uploader.Exists(c.Ctx, r.Name)
+		if err != nil {
+			if stdErrors.Is(err, upload.ErrExistsUnsupported) {
+				return fmt.Errorf("%w; use --auto-approve to upload without checking", err)
+			}
+			return err
+		}
+
+		if exists {
+			var overwrite bool
+			if c.ui.Interactive() {
+				if overwrite, err = confirmOverwrite(c.baseCommand); err != nil {
+					return err
+				}
+			}
+			if !overwrite {
+				return fmt.Errorf("destination file exists and overwrite is unset: %w", os.ErrExist)
+			}
+		}
+	}
+
+	return uploader.Upload(c.Ctx, r.Name, []byte(r.Content))
+}
+
+// validateToURL checks the --to-url flag is a supported destination URL and
+// is not used alongside flags it conflicts with.
+func validateToURL(c *RenderCommand) error {
+	if c.renderToURL == "" {
+		return nil
+	}
+	if !upload.IsURL(c.renderToURL) {
+		return fmt.Errorf("--to-url must be an http, https, or s3 URL, got %q", c.renderToURL)
+	}
+	if c.renderDiff {
+		return stdErrors.New("--to-url cannot be used with --diff")
+	}
+	return nil
+}
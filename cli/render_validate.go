@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// jobSpecSchema is the top level schema of a job specification. Only the job
+// block is required; other top level blocks, such as variables, are ignored.
+var jobSpecSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "job", LabelNames: []string{"name"}},
+	},
+}
+
+// shouldValidate returns whether the render is a job specification which can
+// be validated. Only parent pack renders with a .nomad or .hcl extension are
+// validated, ignoring any retained .tpl extension.
+func (r Render) shouldValidate() bool {
+	if !r.Parent {
+		return false
+	}
+	switch path.Ext(strings.TrimSuffix(r.Name, ".tpl")) {
+	case ".nomad", ".hcl":
+		return true
+	default:
+		return false
+	}
+}
+
+// validate parses the render as an HCL job specification, returning an error
+// detailing the location of any syntax error, or if the render does not
+// contain exactly one job block. The parsing is performed locally and does
+// not require access to a Nomad cluster.
+func (r Render) validate() error {
+	file, diags := hclsyntax.ParseConfig([]byte(r.Content), r.Name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	content, _, diags := file.Body.PartialContent(jobSpecSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	if len(content.Blocks) != 1 {
+		return fmt.Errorf("%s: expected a single job block, found %d", r.Name, len(content.Blocks))
+	}
+
+	return nil
+}
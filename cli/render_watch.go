@@ -0,0 +1,219 @@
+package cli
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/upload"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// watchDebounce is the wait after the last change to the watched files
+// before re-rendering, so that rapid successive writes, such as those made
+// by editors when saving, result in a single render.
+const watchDebounce = 250 * time.Millisecond
+
+// clearScreen is the escape sequence which clears the terminal and moves the
+// cursor to the top left.
+const clearScreen = "\033[H\033[2J"
+
+// watchExtensions are the extensions of the files within the pack directory
+// which trigger a re-render when changed.
+var watchExtensions = []string{".tpl", ".hcl", ".json"}
+
+// validateWatch checks the --watch flag is only used with local packs, and
+// not with the flags which cannot be repeated on each change.
+func validateWatch(c *RenderCommand, targets []*renderPackTarget) error {
+	if !c.renderWatch {
+		return nil
+	}
+	for _, target := range targets {
+		if target.cfg.Registry != cache.DevRegistryName {
+			return fmt.Errorf("--watch can only be used with local packs, but %q is in the %q registry",
+				target.cfg.Name, target.cfg.Registry)
+		}
+	}
+	switch {
+	case c.renderStdinPack:
+		return stdErrors.New("--watch cannot be used with --stdin-pack")
+	case c.renderToURL != "":
+		return stdErrors.New("--watch cannot be used with --to-url")
+	case c.renderGitCommit != "":
+		return stdErrors.New("--watch cannot be used with --git-commit")
+	case (c.renderToDir != "" || c.renderToArchive != "") && !c.autoApproved:
+		return stdErrors.New("--watch with --to-dir or --to-archive requires --auto-approve, as the files are rewritten on each change")
+	}
+	return nil
+}
+
+// watch renders the pack targets, then re-renders them each time a template
+// or variable file changes until interrupted. The exit code is that of the
+// last render.
+func (c *RenderCommand) watch(client *v1.Client, targets []*renderPackTarget, uploader upload.Uploader,
+	errorContext *errors.UIErrorContext) int {
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to start watcher", errorContext.GetAll()...)
+		return 1
+	}
+	defer watcher.Close()
+
+	// The directories containing the variable files are watched, rather
+	// than the files themselves, as editors often save by replacing the
+	// file. The pack directories are watched recursively, as fsnotify does
+	// not.
+	scope := &watchScope{varFiles: make(map[string]bool)}
+	for _, file := range append(c.renderAutoVarFiles, c.varFiles...) {
+		abs, err := filepath.Abs(file)
+		if err == nil {
+			err = watcher.Add(filepath.Dir(abs))
+		}
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to watch variable file", errorContext.GetAll()...)
+			return 1
+		}
+		scope.varFiles[abs] = true
+	}
+	for _, target := range targets {
+		if err := watchDir(watcher, target.cfg.Path); err != nil {
+			c.ui.ErrorWithContext(err, "failed to watch pack", target.errorContext.GetAll()...)
+			return 1
+		}
+		scope.packDirs = append(scope.packDirs, target.cfg.Path)
+	}
+
+	// Renders written within a pack directory must not trigger a re-render,
+	// as this would loop forever.
+	if c.renderToDir != "" {
+		if abs, err := filepath.Abs(c.renderToDir); err == nil {
+			scope.ignoreDir = abs
+		}
+	}
+
+	code := c.watchCycle(client, targets, uploader, errorContext)
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-c.Ctx.Done():
+			c.ui.Info("Stopped watching")
+			return code
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return code
+			}
+
+			// New directories are watched so that templates added within
+			// them are picked up.
+			if event.Op&fsnotify.Create != 0 {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					_ = watchDir(watcher, event.Name)
+				}
+			}
+			if !scope.relevant(event) {
+				continue
+			}
+			debounce.Reset(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return code
+			}
+			c.ui.Warning(fmt.Sprintf("Watcher error: %s", err))
+
+		case <-debounce.C:
+			code = c.watchCycle(client, targets, uploader, errorContext)
+		}
+	}
+}
+
+// watchCycle clears the terminal and renders the pack targets, preceded by
+// the time of the render.
+func (c *RenderCommand) watchCycle(client *v1.Client, targets []*renderPackTarget, uploader upload.Uploader,
+	errorContext *errors.UIErrorContext) int {
+
+	if terminal.IsTerminal(os.Stdout) && c.renderFormat == renderFormatText {
+		fmt.Fprint(os.Stdout, clearScreen)
+	}
+	c.ui.Output(fmt.Sprintf("Rendered at %s", time.Now().Format("15:04:05")), terminal.WithHeaderStyle())
+
+	code := c.renderTargets(client, targets, uploader, errorContext)
+	c.ui.Info("Watching for changes, press Ctrl-C to stop")
+	return code
+}
+
+// watchDir adds dir and all the directories within it to the watcher.
+func watchDir(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// watchScope describes the files which trigger a re-render when changed.
+type watchScope struct {
+	// varFiles are the absolute paths of the variable files.
+	varFiles map[string]bool
+	// packDirs are the directories of the packs, within which the
+	// templates, variable and metadata files are relevant.
+	packDirs []string
+	// ignoreDir is the directory the renders are written to, within which
+	// changes are ignored.
+	ignoreDir string
+}
+
+// relevant returns whether the event should trigger a re-render.
+func (s *watchScope) relevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if s.varFiles[event.Name] {
+		return true
+	}
+	if s.ignoreDir != "" && pathWithin(event.Name, s.ignoreDir) {
+		return false
+	}
+
+	var inPack bool
+	for _, dir := range s.packDirs {
+		if pathWithin(event.Name, dir) {
+			inPack = true
+			break
+		}
+	}
+	if !inPack {
+		return false
+	}
+	for _, ext := range watchExtensions {
+		if strings.HasSuffix(event.Name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathWithin returns whether path is dir or within it.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
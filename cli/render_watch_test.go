@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWatch(t *testing.T) {
+	local := []*renderPackTarget{{cfg: &cache.PackConfig{Name: "example", Registry: cache.DevRegistryName}}}
+	registry := []*renderPackTarget{{cfg: &cache.PackConfig{Name: "example", Registry: cache.DefaultRegistryName}}}
+
+	c := &RenderCommand{baseCommand: &baseCommand{Ctx: context.Background()}}
+	require.NoError(t, validateWatch(c, registry))
+
+	c.renderWatch = true
+	require.NoError(t, validateWatch(c, local))
+	require.EqualError(t, validateWatch(c, registry),
+		`--watch can only be used with local packs, but "example" is in the "default" registry`)
+
+	c.renderToDir = "out"
+	require.EqualError(t, validateWatch(c, local),
+		"--watch with --to-dir or --to-archive requires --auto-approve, as the files are rewritten on each change")
+
+	c.autoApproved = true
+	require.NoError(t, validateWatch(c, local))
+
+	c.renderGitCommit = "render"
+	require.EqualError(t, validateWatch(c, local), "--watch cannot be used with --git-commit")
+}
+
+func TestWatchScopeRelevant(t *testing.T) {
+	scope := &watchScope{
+		varFiles:  map[string]bool{"/work/overrides.hcl": true},
+		packDirs:  []string{"/work/example"},
+		ignoreDir: "/work/example/out",
+	}
+
+	testCases := []struct {
+		name     string
+		op       fsnotify.Op
+		expected bool
+	}{
+		{name: "/work/example/templates/example.nomad.tpl", op: fsnotify.Write, expected: true},
+		{name: "/work/example/variables.hcl", op: fsnotify.Rename, expected: true},
+		{name: "/work/example/metadata.hcl", op: fsnotify.Chmod, expected: false},
+		{name: "/work/example/README.md", op: fsnotify.Write, expected: false},
+		{name: "/work/example/out/example.nomad.tpl", op: fsnotify.Create, expected: false},
+		{name: "/work/overrides.hcl", op: fsnotify.Create, expected: true},
+		{name: "/work/other.hcl", op: fsnotify.Write, expected: false},
+		{name: "/work/example-other/variables.hcl", op: fsnotify.Write, expected: false},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, scope.relevant(fsnotify.Event{Name: tc.name, Op: tc.op}), tc.name)
+	}
+}
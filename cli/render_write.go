@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"crypto/sha256"
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// defaultWriteConcurrency is the default number of renders written to
+// --to-dir at once.
+const defaultWriteConcurrency = 4
+
+// writeProgressThreshold is the size in bytes of the content of renders whose
+// write progress is displayed.
+const writeProgressThreshold = 1 << 20
+
+// errRenderUnchanged is returned when writing a render is skipped as the
+// existing file already has the same content.
+var errRenderUnchanged = stdErrors.New("content unchanged")
+
+// renderWrite is the result of writing a single render to --to-dir.
+type renderWrite struct {
+	// err is any error encountered writing the render, or nil on success.
+	err error
+	// disposition describes the outcome of the write, and is empty when the
+	// write failed.
+	disposition renderDisposition
+	// errorContext is the UI error context for the write, which includes the
+	// destination file when err is set.
+	errorContext *errors.UIErrorContext
+	// dedup describes how a render duplicating the content of an earlier
+	// one was written when using --dedup, and is empty otherwise.
+	dedup renderDedupMode
+}
+
+// renderDedupMode describes how a duplicate render was written when using
+// --dedup.
+type renderDedupMode string
+
+// renderDedup* are the ways a duplicate render can be written. Renders are
+// copied when the filesystem does not support hardlinks.
+const (
+	renderDedupLinked renderDedupMode = "linked"
+	renderDedupCopied renderDedupMode = "copied"
+)
+
+// writeRenders writes the renders to the --to-dir path using a pool of
+// workers, returning the result of each write in the same order as the passed
+// renders. Overwrite confirmation prompts cannot be interleaved, so any are
+// performed up front before writing starts.
+func (c *RenderCommand) writeRenders(renders []Render, ec *errors.UIErrorContext) []renderWrite {
+	results := make([]renderWrite, len(renders))
+	overwrite := make([]bool, len(renders))
+
+	for i, r := range renders {
+		results[i].errorContext = errors.NewUIErrorContext()
+		results[i].errorContext.Append(ec)
+
+		// Unchanged files are skipped before confirming the overwrite, so
+		// the user isn't prompted for files which won't change.
+		if r.unchanged(c) {
+			results[i].err = errRenderUnchanged
+			results[i].disposition = renderSkippedUnchanged
+			continue
+		}
+		overwrite[i], results[i].err = r.confirmFileOverwrite(c)
+	}
+
+	// Duplicate renders are linked to the file of the render they duplicate
+	// once all the others have been written, rather than being written.
+	var duplicates map[int]int
+	if c.renderDedup {
+		duplicates = findDuplicateRenders(renders, results)
+	}
+
+	// Progress is only displayed on an interactive terminal, so that it does
+	// not pollute redirected output.
+	if c.ui.Interactive() && terminal.IsTerminal(os.Stdout) && hasLargeRender(renders) {
+		c.writeProgress = c.ui.StepGroup()
+		defer func() {
+			c.writeProgress.Wait()
+			c.writeProgress = nil
+		}()
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < c.renderWriteConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i].disposition, results[i].err = renders[i].writeFile(c, overwrite[i], results[i].errorContext)
+			}
+		}()
+	}
+
+	for i := range renders {
+		if _, ok := duplicates[i]; ok || results[i].err != nil {
+			continue
+		}
+		// Once cancelled, mark the remaining writes as such rather than
+		// dispatching them.
+		if err := c.Ctx.Err(); err != nil {
+			results[i].err = err
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := range renders {
+		original, ok := duplicates[i]
+		if !ok {
+			continue
+		}
+		if err := c.Ctx.Err(); err != nil {
+			results[i].err = err
+			continue
+		}
+		results[i].disposition, results[i].dedup, results[i].err = renders[i].writeDuplicate(
+			c, renders[original], results[original], overwrite[i], results[i].errorContext)
+	}
+
+	return results
+}
+
+// renderContentKey identifies renders with the same content and permissions,
+// which can share a file.
+type renderContentKey struct {
+	sum  [sha256.Size]byte
+	mode os.FileMode
+}
+
+// findDuplicateRenders returns the index of each render to be written whose
+// content and permissions are the same as an earlier render, mapped to the
+// index of that render. Renders whose file is unchanged are kept as the
+// original, as their file already has the content.
+func findDuplicateRenders(renders []Render, results []renderWrite) map[int]int {
+	originals := make(map[renderContentKey]int)
+	duplicates := make(map[int]int)
+
+	for i, r := range renders {
+		if results[i].err != nil && !stdErrors.Is(results[i].err, errRenderUnchanged) {
+			continue
+		}
+		key := renderContentKey{sum: sha256.Sum256([]byte(r.Content)), mode: r.fileMode()}
+		original, ok := originals[key]
+		if !ok {
+			originals[key] = i
+			continue
+		}
+		if results[i].err == nil {
+			duplicates[i] = original
+		}
+	}
+	return duplicates
+}
+
+// writeDuplicate writes a render duplicating the original render by
+// hardlinking its file. When the original was not written, or the
+// filesystem does not support hardlinks, the render is written as a copy.
+func (r Render) writeDuplicate(c *RenderCommand, original Render, originalWrite renderWrite, overwrite bool,
+	ec *errors.UIErrorContext) (renderDisposition, renderDedupMode, error) {
+
+	if originalWrite.err == nil || stdErrors.Is(originalWrite.err, errRenderUnchanged) {
+		disposition, err := r.linkFile(c, original.outFile(c), overwrite, ec)
+		var linkErr *os.LinkError
+		if !stdErrors.As(err, &linkErr) {
+			return disposition, renderDedupLinked, err
+		}
+	}
+
+	disposition, err := r.writeFile(c, overwrite, ec)
+	return disposition, renderDedupCopied, err
+}
+
+// linkFile creates the file for the render within the --to-dir path as a
+// hardlink to the target file, returning the disposition of the write in the
+// same manner as writeFile. An *os.LinkError is returned when the link
+// cannot be created.
+func (r Render) linkFile(c *RenderCommand, target string, overwrite bool, ec *errors.UIErrorContext) (renderDisposition, error) {
+	outFile := r.outFile(c)
+
+	existed, err := filesystem.Exists(outFile)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		return "", err
+	}
+
+	if err := maybeCreateDestinationDir(c, path.Dir(outFile)); err != nil {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		return "", err
+	}
+
+	if err := filesystem.LinkFile(target, outFile, overwrite); err != nil {
+		var linkErr *os.LinkError
+		if stdErrors.As(err, &linkErr) {
+			return "", err
+		}
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		if stdErrors.Is(err, os.ErrExist) {
+			return renderSkippedDeclined, err
+		}
+		return "", err
+	}
+
+	if existed {
+		return renderOverwritten, nil
+	}
+	return renderWritten, nil
+}
+
+// dedupToTerminal outputs the number of duplicate renders written as
+// hardlinks and as copies when using --dedup.
+func dedupToTerminal(c *RenderCommand, writes []renderWrite) {
+	var linked, copied int
+	for _, w := range writes {
+		if w.err != nil {
+			continue
+		}
+		switch w.dedup {
+		case renderDedupLinked:
+			linked++
+		case renderDedupCopied:
+			copied++
+		}
+	}
+	if linked > 0 {
+		c.ui.Info(fmt.Sprintf("Deduplicated %d render(s) using hardlinks", linked))
+	}
+	if copied > 0 {
+		c.ui.Info(fmt.Sprintf("Wrote %d duplicate render(s) as copies, as they could not be hardlinked", copied))
+	}
+}
+
+// unchanged reports whether the file for the render already exists with the
+// same content, in which case writing it can be skipped. Files are always
+// considered changed when --force is set.
+func (r Render) unchanged(c *RenderCommand) bool {
+	if c.renderForce {
+		return false
+	}
+	existing, err := os.ReadFile(r.outFile(c))
+	if err != nil {
+		return false
+	}
+	return sha256.Sum256(existing) == sha256.Sum256([]byte(r.Content))
+}
+
+// confirmFileOverwrite prompts the user to confirm overwriting the existing
+// file for the render, returning whether the file may be overwritten. No
+// prompt is made when auto-approved, the UI is not interactive, or the file
+// does not exist.
+func (r Render) confirmFileOverwrite(c *RenderCommand) (bool, error) {
+	if c.autoApproved {
+		return true, nil
+	}
+	if !c.ui.Interactive() {
+		return false, nil
+	}
+	// Any error determining whether the file exists is surfaced rather than
+	// assuming either way, as this would risk overwriting without consent.
+	exists, err := filesystem.Exists(r.outFile(c))
+	if err != nil || !exists {
+		return false, err
+	}
+	return confirmOverwrite(c.baseCommand)
+}
+
+// writeFile writes the render to its file within the --to-dir path, creating
+// the parent directories as needed, and returns the disposition of the write.
+// When an existing file is not overwritten, the error wraps os.ErrExist and
+// the disposition is renderSkippedDeclined.
+func (r Render) writeFile(c *RenderCommand, overwrite bool, ec *errors.UIErrorContext) (renderDisposition, error) {
+	renderToDir := path.Clean(c.renderToDir)
+	if err := validateOutDir(renderToDir); err != nil {
+		ec.Add(errors.FilesystemContextDestDir, renderToDir)
+		return "", err
+	}
+
+	outFile := r.outFile(c)
+
+	existed, err := filesystem.Exists(outFile)
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		return "", err
+	}
+
+	var opts []filesystem.WriteOption
+	var progress *terminal.ByteProgress
+	if c.writeProgress != nil && len(r.Content) >= writeProgressThreshold {
+		progress = terminal.NewByteProgress(c.writeProgress, r.Name, int64(len(r.Content)))
+		opts = append(opts, filesystem.WithWriteProgress(progress.Update))
+	}
+
+	err = filesystem.WriteFileAllContext(c.Ctx, outFile, r.Content, overwrite, r.fileMode(), c.dirMode(), opts...)
+	if progress != nil {
+		if err != nil {
+			progress.Abort()
+		} else {
+			progress.Done()
+		}
+	}
+	if err != nil {
+		ec.Add(errors.FilesystemContextDestFile, outFile)
+		if stdErrors.Is(err, os.ErrExist) {
+			return renderSkippedDeclined, err
+		}
+		return "", err
+	}
+
+	if existed {
+		return renderOverwritten, nil
+	}
+	return renderWritten, nil
+}
+
+// hasLargeRender reports whether the content of any of the renders is large
+// enough for the progress of writing it to be displayed.
+func hasLargeRender(renders []Render) bool {
+	for _, r := range renders {
+		if len(r.Content) >= writeProgressThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// renderWritesSucceeded reports whether all the writes were successful. Writes
+// skipped as the content is unchanged are considered successful.
+func renderWritesSucceeded(writes []renderWrite) bool {
+	for _, w := range writes {
+		if w.err != nil && !stdErrors.Is(w.err, errRenderUnchanged) {
+			return false
+		}
+	}
+	return true
+}
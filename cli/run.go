@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/nomad-pack/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
 	"github.com/hashicorp/nomad-pack/internal/runner"
 	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/posener/complete"
@@ -43,14 +44,20 @@ func (c *RunCommand) run() int {
 
 	c.packConfig.Name = c.args[0]
 
+	if err := validateChecksum(c.packConfig); err != nil {
+		c.ui.Error(err.Error())
+		return 1
+	}
+
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
 
 	// verify packs exist before running jobs
 	err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui)
 	if err != nil {
 		return 1
 	}
+	c.reportPackChecksum(c.packConfig)
 
 	// If no deploymentName set default to pack@ref
 	c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
@@ -133,9 +140,11 @@ func (c *RunCommand) run() int {
 		c.ui.Success(fmt.Sprintf("Pack successfully deployed. Use %s with --ref=%s to manage this this deployed instance with plan, stop, destroy, or info", c.packConfig.Name, c.packConfig.Ref))
 	}
 
-	output, err := packManager.ProcessOutputTemplate()
+	output, err := packManager.ProcessOutputTemplate("")
 	if err != nil {
-		c.ui.ErrorWithContext(err, "failed to render output template", "Pack Name: "+c.packConfig.Name)
+		outputErrorContext := manager.TemplateErrorContext(err)
+		outputErrorContext.Add(errors.UIContextPrefixPackName, c.packConfig.Name)
+		c.ui.ErrorWithContext(err, "failed to render output template", outputErrorContext.GetAll()...)
 		return 1
 	}
 
@@ -173,6 +182,9 @@ Supports tags, SHA, and latest. If no ref is specified, defaults to latest.
 Using ref with a file path is not supported.`,
 		})
 
+		c.checksumFlag(f, c.packConfig)
+		c.nomadVariablesFlag(f)
+
 		f.Uint64Var(&flag.Uint64Var{
 			Name:    "check-index",
 			Target:  &c.jobConfig.RunConfig.CheckIndex,
@@ -283,7 +295,7 @@ func (c *RunCommand) Help() string {
 	nomad-pack run example --var-file="./overrides.hcl"
 
 	# Run an example pack with cli variable overrides
-	nomad-pack run example --var="redis_image_version=latest" --var="redis_resources={"cpu": "1000", "memory": "512"}"
+	nomad-pack run example --var="redis_image_version=latest" --var='redis_resources={"cpu": "1000", "memory": "512"}'
 
 	# Run a pack under development from the filesystem - supports current working 
     # directory or relative path
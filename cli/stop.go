@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/nomad-pack/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
 	"github.com/posener/complete"
 )
 
@@ -52,7 +53,7 @@ func (c *StopCommand) Run(args []string) int {
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
 
 	client, err := v1.NewClient()
 	if err != nil {
@@ -128,18 +129,36 @@ func (c *StopCommand) Run(args []string) int {
 	}
 
 	var errs []error
+	results := make([]stopJobResult, 0, len(jobs))
 	for _, job := range jobs {
-		err = c.checkForConflicts(jobsApi, *job.ID)
+		result := stopJobResult{job: *job.ID}
 
+		stub, err := c.checkForConflicts(jobsApi, *job.ID)
 		if err != nil {
 			errs = append(errs, err)
 			c.ui.Warning(fmt.Sprintf("skipping job %q - conflict check failed with err: %s", *job.ID, err))
+			result.status, result.err = stopJobSkipped, err
+			results = append(results, result)
+			continue
+		}
+		result.namespace, result.region = stub.GetNamespace(), job.GetRegion()
+
+		// Jobs which have already been stopped, such as by a previous stop
+		// which failed part of the way through, are left as they are
+		// rather than being deregistered again. They are still purged when
+		// destroying.
+		if !c.purge && stub.GetStop() {
+			c.ui.Info(fmt.Sprintf("Job %q already stopped", *job.ID))
+			result.status = stopJobAlreadyStopped
+			results = append(results, result)
 			continue
 		}
 
 		// TODO: add interactive support
 		if !c.confirmStop() {
 			c.ui.Info(fmt.Sprintf("%s job %q aborted by user", strings.Title(stopOrDestroy), *job.ID))
+			result.status = stopJobSkipped
+			results = append(results, result)
 			continue
 		}
 
@@ -148,21 +167,28 @@ func (c *StopCommand) Run(args []string) int {
 		writeOpts.Region = *job.Region
 		writeOpts.Namespace = *job.Namespace
 
-		result, _, err := client.Jobs().Delete(writeOpts.Ctx(), *job.Name, c.purge, c.global)
+		deleteResult, _, err := client.Jobs().Delete(writeOpts.Ctx(), *job.Name, c.purge, c.global)
 		if err != nil {
 			errs = append(errs, err)
 			c.ui.ErrorWithContext(err, fmt.Sprintf("error deregistering job: %q", *job.ID))
+			result.status, result.err = stopJobFailed, err
+			results = append(results, result)
 			continue
 		}
 
 		// If we are stopping a periodic job there won't be an evalID.
-		if result.EvalID != nil && *result.EvalID != "" {
-			c.ui.Info(fmt.Sprintf("EvalID: %q", *result.EvalID))
+		if deleteResult.EvalID != nil && *deleteResult.EvalID != "" {
+			c.ui.Info(fmt.Sprintf("EvalID: %q", *deleteResult.EvalID))
+			result.evalID = *deleteResult.EvalID
 		}
 
 		c.ui.Success(fmt.Sprintf("Job %q %s", *job.Name, stoppedOrDestroyed))
+		result.status = stoppedOrDestroyed
+		results = append(results, result)
 	}
 
+	c.ui.Table(stopResultsTable(results))
+
 	if len(errs) > 0 {
 		c.ui.Warning(fmt.Sprintf("Pack %q %s complete with errors", c.packConfig.Name, stopOrDestroy))
 		for _, err := range errs {
@@ -176,24 +202,70 @@ func (c *StopCommand) Run(args []string) int {
 	return 0
 }
 
-func (c *StopCommand) checkForConflicts(jobsApi *v1.Jobs, jobName string) error {
+// checkForConflicts checks the job name matches a single job in the cluster,
+// returning the job.
+func (c *StopCommand) checkForConflicts(jobsApi *v1.Jobs, jobName string) (*v1client.JobListStub, error) {
 	queryOpts := newQueryOpts()
 	queryOpts.Prefix = jobName
 
 	jobs, _, err := jobsApi.GetJobs(queryOpts.Ctx())
 	if err != nil {
-		return fmt.Errorf("error checking for conflicts for job %q: %s", jobName, err)
+		return nil, fmt.Errorf("error checking for conflicts for job %q: %s", jobName, err)
 	}
 
 	if len(*jobs) == 0 {
-		return fmt.Errorf("no job(s) with prefix or id %q found", jobName)
+		return nil, fmt.Errorf("no job(s) with prefix or id %q found", jobName)
 	}
 
 	if len(*jobs) > 1 {
-		return fmt.Errorf("prefix matched multiple jobs\n\n%s", createStatusListOutput(*jobs, c.allNamespaces()))
+		return nil, fmt.Errorf("prefix matched multiple jobs\n\n%s", createStatusListOutput(*jobs, c.allNamespaces()))
 	}
 
-	return nil
+	return &(*jobs)[0], nil
+}
+
+// stopJob* are the results of stopping a job which are not a success. A job
+// which was successfully stopped or destroyed has the result "stopped" or
+// "destroyed".
+const (
+	stopJobAlreadyStopped = "already stopped"
+	stopJobSkipped        = "skipped"
+	stopJobFailed         = "failed"
+)
+
+// stopJobResult is the result of stopping a single job of the pack.
+type stopJobResult struct {
+	job       string
+	namespace string
+	region    string
+	status    string
+	evalID    string
+	err       error
+}
+
+// stopResultsTable returns a table of the result of stopping each job.
+func stopResultsTable(results []stopJobResult) *terminal.Table {
+	tbl := terminal.NewTable("Job Name", "Namespace", "Region", "Result", "Eval ID", "Error")
+	for _, result := range results {
+		var color, errMsg string
+		switch {
+		case result.err != nil:
+			color, errMsg = terminal.Red, result.err.Error()
+		case result.status == stopJobAlreadyStopped || result.status == stopJobSkipped:
+			color = terminal.Yellow
+		default:
+			color = terminal.Green
+		}
+		tbl.Rows = append(tbl.Rows, []terminal.TableEntry{
+			{Value: result.job},
+			{Value: result.namespace},
+			{Value: result.region},
+			{Value: result.status, Color: color},
+			{Value: result.evalID},
+			{Value: errMsg, Color: terminal.Red},
+		})
+	}
+	return tbl
 }
 
 // TODO: Add interactive support
@@ -311,7 +383,8 @@ func (c *StopCommand) Help() string {
 	By default, the stop command will stop ALL jobs in the pack deployment. If a pack
 	was run using var overrides to specify the job name(s), the var overrides MUST be
 	provided when stopping the pack to guarantee nomad-pack targets the correct job(s)
-	in the pack deployment.
+	in the pack deployment. Jobs which are already stopped are left as they are,
+	unless purging them, and the result of stopping each job is listed once done.
 	
 ` + c.GetExample() + c.Flags().Help())
 }
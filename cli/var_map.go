@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// varMapValue is the flag.Value of --var-map, which sets the variables of a
+// JSON object. Each variable is written to the --var overrides, so that
+// whichever of the two flags sets a variable last wins, and is parsed
+// according to its declared type in the same way.
+type varMapValue struct {
+	c *baseCommand
+}
+
+func (v *varMapValue) Set(val string) error {
+	vars, err := parseVarMap(val)
+	if err != nil {
+		return err
+	}
+
+	if v.c.vars == nil {
+		v.c.vars = make(map[string]string)
+	}
+	if v.c.varMapValues == nil {
+		v.c.varMapValues = make(map[string]string)
+	}
+	for name, rawVal := range vars {
+		v.c.vars[name] = rawVal
+		v.c.varMapValues[name] = rawVal
+	}
+	return nil
+}
+
+func (v *varMapValue) String() string  { return "" }
+func (v *varMapValue) Example() string { return `'{"key":"value"}'` }
+func (v *varMapValue) Type() string    { return "JSON" }
+
+// varMapNames returns the names of the --var overrides which were set using
+// --var-map, and not later replaced using --var.
+func (c *baseCommand) varMapNames() map[string]bool {
+	names := make(map[string]bool, len(c.varMapValues))
+	for name, rawVal := range c.varMapValues {
+		if val, ok := c.vars[name]; ok && val == rawVal {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseVarMap parses a --var-map JSON object into the raw --var values of
+// each of its keys. A malformed object results in an error naming the line
+// and column the parsing failed at.
+func parseVarMap(val string) (map[string]string, error) {
+	dec := json.NewDecoder(strings.NewReader(val))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case stdErrors.As(err, &syntaxErr):
+			// The offset is after the byte which could not be parsed.
+			line, col := jsonPosition(val, syntaxErr.Offset-1)
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %v", line, col, err)
+		case stdErrors.As(err, &typeErr):
+			return nil, fmt.Errorf("value must be a JSON object, not %s", typeErr.Value)
+		default:
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+	}
+	if dec.More() {
+		line, col := jsonPosition(val, dec.InputOffset())
+		return nil, fmt.Errorf("invalid JSON at line %d, column %d: unexpected content after the object", line, col)
+	}
+	if obj == nil {
+		return nil, stdErrors.New("value must be a JSON object, not null")
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make(map[string]string, len(obj))
+	for _, name := range names {
+		rawVal, err := varMapRawValue(obj[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %v", name, err)
+		}
+		vars[name] = rawVal
+	}
+	return vars, nil
+}
+
+// varMapRawValue returns the raw --var value of a decoded JSON value. Strings
+// are used as they are, with a leading @ escaped so they are not read from a
+// file. Objects and arrays are encoded as JSON, which is also valid HCL, with
+// template sequences escaped so they are not interpolated.
+func varMapRawValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		if strings.HasPrefix(v, "@") {
+			return "@" + v, nil
+		}
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case nil:
+		return "null", nil
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		raw := strings.TrimSpace(buf.String())
+		raw = strings.ReplaceAll(raw, "${", "$${")
+		raw = strings.ReplaceAll(raw, "%{", "%%{")
+		return raw, nil
+	}
+}
+
+// jsonPosition returns the 1-based line and column of the byte at offset
+// within the JSON value.
+func jsonPosition(val string, offset int64) (line, col int) {
+	if offset < 0 {
+		offset = 0
+	} else if offset > int64(len(val)) {
+		offset = int64(len(val))
+	}
+	before := val[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = len(before) - strings.LastIndex(before, "\n")
+	return line, col
+}
@@ -4,11 +4,13 @@ go 1.16
 
 require (
 	github.com/Masterminds/sprig v2.22.0+incompatible
+	github.com/aws/aws-sdk-go v1.38.20
 	github.com/bgentry/speakeasy v0.1.0
 	github.com/briandowns/spinner v1.11.1
 	github.com/containerd/console v1.0.1
 	github.com/davecgh/go-spew v1.1.1
 	github.com/fatih/color v1.9.0
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/hashicorp/go-getter v1.5.4
 	github.com/hashicorp/hcl/v2 v2.10.1
 	github.com/hashicorp/nomad-openapi v0.0.0-20211120040829-8bd0a1f543b4
@@ -20,6 +22,7 @@ require (
 	github.com/mitchellh/go-wordwrap v1.0.1
 	github.com/morikuni/aec v1.0.0
 	github.com/olekukonko/tablewriter v0.0.4
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/posener/complete v1.2.3
 	github.com/ryanuber/columnize v2.1.2+incompatible
 	github.com/spf13/afero v1.6.0
@@ -27,8 +30,9 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/zclconf/go-cty v1.9.0
 	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	golang.org/x/sys v0.0.0-20210818153620-00dd8d7831e7
 	golang.org/x/text v0.3.6 // indirect
 	google.golang.org/grpc v1.33.1
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
@@ -10,6 +10,7 @@ import (
 	gg "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 	pkgVersion "github.com/hashicorp/nomad-pack/internal/pkg/version"
 )
 
@@ -45,6 +46,13 @@ func (c *Cache) Add(opts *AddOpts) (cachedRegistry *Registry, err error) {
 		return
 	}
 
+	// Fail fast rather than attempting to clone the registry when network
+	// access is disabled.
+	if opts.Offline {
+		err = fmt.Errorf("%w: %s", errors.ErrPackNotCached, AppendRef(path.Join(opts.RegistryName, opts.PackName), opts.Ref))
+		return
+	}
+
 	cachedRegistry, err = c.addFromURI(opts)
 
 	return
@@ -64,7 +72,7 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 		opts.Ref = DefaultRef
 	}
 
-	err = c.cloneRemoteGitRegistry(opts)
+	err = c.fetchRemoteRegistry(opts)
 	if err != nil {
 		return
 	}
@@ -82,6 +90,9 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 
 	logger.Debug(fmt.Sprintf("Processing pack entries at %s", c.clonePath()))
 
+	unlock := writeLockRegistry(opts.RegistryPath())
+	defer unlock()
+
 	// Move the cloned registry packs to the global cache.
 	packEntries, err := os.ReadDir(c.clonedPacksPath())
 	for _, packEntry := range packEntries {
@@ -122,32 +133,55 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 	return
 }
 
-// cloneRemoteGitRegistry clones a remote git repository to the cache.
-func (c *Cache) cloneRemoteGitRegistry(opts *AddOpts) (err error) {
+// fetchRemoteRegistry clones a remote git repository, or pulls the packs
+// from an OCI registry, to the cache. The registry source is tried first,
+// followed by each of the mirrors in order, until one of them is fetched
+// successfully.
+func (c *Cache) fetchRemoteRegistry(opts *AddOpts) (err error) {
 	logger := c.cfg.Logger
 
-	url := opts.Source
-
-	// Append the pack name to the go-getter url if a pack name was specified
+	clonePath := c.clonePath()
+	// If pack name is set, add an intermediary "packs" and pack dir manually.
 	if opts.PackName != "" {
-		src := strings.TrimRight(opts.Source, ".git") // to make the next command work consistently
-		url = fmt.Sprintf("%s.git//packs/%s", src, opts.PackName)
+		clonePath = path.Join(clonePath, "packs", opts.PackName)
 	}
 
-	// If ref is set, add query string variable
-	if !opts.IsLatest() {
-		url = fmt.Sprintf("%s?ref=%s", url, opts.Ref)
-	}
+	sources := append([]string{opts.Source}, opts.Mirrors...)
+	for i, source := range sources {
+		url := opts.fetchURL(source)
+		if !IsOCISource(source) {
+			logger.Debug(fmt.Sprintf("go-getter URL is %s", url))
+		}
 
-	logger.Debug(fmt.Sprintf("go-getter URL is %s", url))
+		err = retryFetch(opts.FetchRetries, opts.FetchRetryWait, logger, func() error {
+			// Remove anything left by a failed attempt, so the clone starts
+			// afresh.
+			if err := os.RemoveAll(c.clonePath()); err != nil {
+				return err
+			}
+			if IsOCISource(source) {
+				return c.pullOCIRegistry(opts, source)
+			}
+			return gg.Get(clonePath, fmt.Sprintf("git::%s", url))
+		})
+		if err == nil {
+			if i == 0 {
+				logging.Debug(logger, "registry fetched from source", logging.F("registry", opts.RegistryName), logging.F("source", source))
+			} else {
+				logging.Log(logger, logging.LevelInfo, "registry fetched from mirror", logging.F("registry", opts.RegistryName), logging.F("source", source))
+			}
+			break
+		}
 
-	clonePath := c.clonePath()
-	// If pack name is set, add an intermediary "packs" and pack dir manually.
-	if opts.PackName != "" {
-		clonePath = path.Join(clonePath, "packs", opts.PackName)
+		if i < len(sources)-1 {
+			logging.Log(logger, logging.LevelWarning, "registry fetch failed, trying the next mirror",
+				logging.F("registry", opts.RegistryName), logging.F("source", source), logging.F("error", err))
+		}
 	}
-	err = gg.Get(clonePath, fmt.Sprintf("git::%s", url))
 	if err != nil {
+		if len(sources) > 1 {
+			err = fmt.Errorf("failed to fetch registry from any of %d sources: %w", len(sources), err)
+		}
 		logger.ErrorWithContext(err, "could not install registry", c.ErrorContext.GetAll()...)
 		return
 	}
@@ -157,6 +191,55 @@ func (c *Cache) cloneRemoteGitRegistry(opts *AddOpts) (err error) {
 	return
 }
 
+// maxFetchRetryWait caps the exponential backoff between registry fetch
+// attempts.
+const maxFetchRetryWait = time.Minute
+
+// permanentFetchErrors are lower case fragments of the errors returned when
+// a registry fetch fails for a reason which retrying will not resolve.
+var permanentFetchErrors = []string{
+	"not found",
+	"does not exist",
+	"couldn't find remote ref",
+	"authentication failed",
+	"could not read username",
+	"is not a pack",
+	"not a valid oci tag",
+}
+
+// isPermanentFetchError returns whether the registry fetch error is
+// definitive, such as the repository or ref not existing, rather than a
+// transient network error.
+func isPermanentFetchError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range permanentFetchErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryFetch calls fetch, retrying up to retries times when it fails with a
+// transient error. The wait between attempts starts at wait and doubles on
+// each retry, up to maxFetchRetryWait.
+func retryFetch(retries int, wait time.Duration, logger logging.Logger, fetch func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fetch()
+		if err == nil || attempt >= retries || isPermanentFetchError(err) {
+			return err
+		}
+
+		logger.Debug(fmt.Sprintf("registry fetch failed, retrying in %s (retry %d of %d): %s",
+			wait, attempt+1, retries, err))
+		time.Sleep(wait)
+
+		if wait *= 2; wait > maxFetchRetryWait {
+			wait = maxFetchRetryWait
+		}
+	}
+}
+
 func (c *Cache) processPackEntry(opts *AddOpts, packEntry os.DirEntry) (err error) {
 	logger := c.cfg.Logger
 	logger.Debug(fmt.Sprintf("Processing pack %s@%s", packEntry.Name(), opts.Ref))
@@ -192,12 +275,20 @@ func (c *Cache) processPackEntry(opts *AddOpts, packEntry os.DirEntry) (err erro
 
 	logger.Debug(fmt.Sprintf("Writing pack to %s", opts.PackPath()))
 
-	err = filesystem.CopyDir(opts.clonedPackPath(c), opts.PackPath(), c.cfg.Logger)
+	stats, err := filesystem.CopyDirWithStats(opts.clonedPackPath(c), opts.PackPath(), c.cfg.Logger)
 	if err != nil {
 		logger.ErrorWithContext(err, fmt.Sprintf("error copying cloned pack %s to %s", opts.clonedPackPath(c), opts.PackPath()))
 		return
 	}
 
+	logger.Debug(fmt.Sprintf("Copied %d files (%d bytes) and created %d directories",
+		stats.FilesCopied, stats.BytesWritten, stats.DirsCreated))
+
+	if stats.SymlinksSkipped > 0 {
+		logger.Warning(fmt.Sprintf("Skipped %d symlinks while writing pack %s; run with debug logging to see which",
+			stats.SymlinksSkipped, packEntry.Name()))
+	}
+
 	// Load the pack to the output registry
 	logger.Debug(fmt.Sprintf("Loading cloned pack from %s", opts.PackPath()))
 
@@ -276,17 +367,25 @@ func (c *Cache) logLatest(opts *AddOpts) (err error) {
 		err = logFile.Close()
 	}()
 
-	// Calculate the SHA of the target pack
-	logger.Debug("Calculating SHA for latest")
-	// TODO: Test this is the right path after refactor.
-	currentSHA, err := pkgVersion.GitSHA(c.clonePath())
-	if err != nil {
-		logger.Debug("error calculating SHA")
-		return
-	}
+	var logEntry string
+	if digest, ok := c.pulledDigests[opts.PackName]; ok {
+		// Packs pulled from an OCI registry are identified by the digest of
+		// their manifest rather than a git SHA.
+		logEntry = fmt.Sprintf("digest %s downloaded at UTC %s\n", digest, time.Now().UTC())
+	} else {
+		// Calculate the SHA of the target pack
+		logger.Debug("Calculating SHA for latest")
+		// TODO: Test this is the right path after refactor.
+		var currentSHA string
+		currentSHA, err = pkgVersion.GitSHA(c.clonePath())
+		if err != nil {
+			logger.Debug("error calculating SHA")
+			return
+		}
 
-	// Format a log entry with SHA and timestamp
-	logEntry := fmt.Sprintf("SHA %s downloaded at UTC %s\n", currentSHA, time.Now().UTC())
+		// Format a log entry with SHA and timestamp
+		logEntry = fmt.Sprintf("SHA %s downloaded at UTC %s\n", currentSHA, time.Now().UTC())
+	}
 
 	// Write log entry to file
 	if _, err = logFile.WriteString(logEntry); err != nil {
@@ -304,17 +403,57 @@ type AddOpts struct {
 	// Required name for the registry. Used when managing a registry by a user defined name.
 	RegistryName string
 	// The well known location of a registry. Used when adding a registry. URL
-	// or file directory currently supported.
+	// or file directory currently supported, as well as OCI registries using
+	// the oci:// scheme.
 	Source string
 	// Optional target pack. Used when managing a specific pack within a registry.
 	PackName string
 	// Optional ref of pack or registry at which to add. Ignored it not
-	// specifying a git source. Defaults to latest.
+	// specifying a git or OCI source, where it is the tag of each pack.
+	// Defaults to latest.
 	Ref string
-	// Optional username for basic auth to a registry that requires authentication.
+	// Optional username for basic auth to a registry that requires
+	// authentication. For OCI registries, the docker credentials of the
+	// registry are used if neither the username nor password are set.
 	Username string
 	// Optional password for basic auth to a registry that requires authentication.
 	Password string
+	// Optional number of times to retry fetching the registry after a
+	// transient error, waiting FetchRetryWait before the first retry and
+	// doubling the wait for each subsequent retry.
+	FetchRetries   int
+	FetchRetryWait time.Duration
+	// Optional flag to disable network access, in which case the registry
+	// is not fetched and an error is returned instead.
+	Offline bool
+	// Optional mirrors of the registry source, such as an internal mirror of
+	// a public registry. If fetching from Source fails, each mirror is tried
+	// in order until one succeeds.
+	Mirrors []string
+}
+
+// fetchURL returns the go-getter URL used to fetch the registry, or the
+// target pack, from the source.
+func (opts *AddOpts) fetchURL(source string) string {
+	url := source
+
+	// OCI sources are pulled by pullOCIRegistry rather than go-getter.
+	if IsOCISource(source) {
+		return url
+	}
+
+	// Append the pack name to the go-getter url if a pack name was specified
+	if opts.PackName != "" {
+		src := strings.TrimRight(source, ".git") // to make the next command work consistently
+		url = fmt.Sprintf("%s.git//packs/%s", src, opts.PackName)
+	}
+
+	// If ref is set, add query string variable
+	if !opts.IsLatest() {
+		url = fmt.Sprintf("%s?ref=%s", url, opts.Ref)
+	}
+
+	return url
 }
 
 // RegistryPath fulfills the cacheOperationProvider interface for AddOpts
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
 	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 )
 
@@ -16,6 +17,10 @@ const (
 	DefaultRef            = "latest"
 	DevRegistryName       = "dev"
 	DevRef                = "dev"
+
+	// CachePathEnvVar is the environment variable which overrides the
+	// default cache location.
+	CachePathEnvVar = "NOMAD_PACK_CACHE"
 )
 
 // NewCache instantiates a new cache instance with the specified config. If no
@@ -44,12 +49,33 @@ func NewCache(cfg *CacheConfig) (cache *Cache, err error) {
 	return
 }
 
+// ensureGlobalCache creates the cache directory if necessary and checks that
+// it is writable, so that an unusable cache path fails clearly up front
+// rather than part way through adding a registry.
 func (c *Cache) ensureGlobalCache() error {
-	return os.MkdirAll(c.cfg.Path, 0755)
+	if c.cfg.Path == "" {
+		return errors.ErrCachePathRequired
+	}
+
+	if err := filesystem.CreatePath(c.cfg.Path, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory %q: %v", c.cfg.Path, err)
+	}
+
+	f, err := os.CreateTemp(c.cfg.Path, ".write-check-")
+	if err != nil {
+		return fmt.Errorf("cache directory %q is not writable: %v", c.cfg.Path, err)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
 }
 
-// DefaultCachePath returns the default cache path.
+// DefaultCachePath returns the default cache path, which can be overridden
+// using the CachePathEnvVar environment variable.
 func DefaultCachePath() string {
+	if envPath := os.Getenv(CachePathEnvVar); envPath != "" {
+		return envPath
+	}
+
 	cacheDir, err := os.UserCacheDir()
 	if err != nil {
 		homeDir, err := os.UserHomeDir()
@@ -75,6 +101,9 @@ type Cache struct {
 	// ErrorContext stores any errors that were encountered along the way so that
 	// error handling can be dealt with in one place.
 	ErrorContext *errors.ErrorContext
+	// pulledDigests holds the manifest digest of each pack pulled from an
+	// OCI registry by the current add, keyed by the pack name.
+	pulledDigests map[string]string
 }
 
 // CacheConfig encapsulates the configuration options for a cache instance.
@@ -180,14 +209,43 @@ func (c *Cache) Load() (err error) {
 	return
 }
 
-// VerifyPackExists verifies that a pack exists at the specified path.
+// VerifyPackExists verifies that a pack exists at the specified path. Packs
+// within a registry are only read from the local cache, so a missing registry
+// pack is reported as not being in the cache, and the registry is locked for
+// reading while the pack is verified. If the config has a Checksum,
+// the checksum of the pack is computed and must match it.
 func VerifyPackExists(cfg *PackConfig, errCtx *errors.UIErrorContext, logger logging.Logger) (err error) {
+	// Registry packs are held in the cache, so must not change while they
+	// are being verified.
+	if cfg.Registry != DevRegistryName {
+		unlock := readLockRegistry(path.Dir(cfg.Path))
+		defer unlock()
+	}
+
 	if _, err = os.Stat(cfg.Path); os.IsNotExist(err) {
+		if cfg.Registry != DevRegistryName {
+			err = fmt.Errorf("%w: %s", errors.ErrPackNotCached, AppendRef(path.Join(cfg.Registry, cfg.Name), cfg.Ref))
+		}
 		logger.ErrorWithContext(err, "failed to find pack", errCtx.GetAll()...)
 		return
 	}
 
-	return
+	if cfg.Checksum == "" {
+		return nil
+	}
+
+	var actual string
+	if actual, err = verifyPackChecksum(cfg); err != nil {
+		checksumCtx := errCtx.Copy()
+		checksumCtx.Add(errors.UIContextPrefixExpectedChecksum, cfg.Checksum)
+		if actual != "" {
+			checksumCtx.Add(errors.UIContextPrefixActualChecksum, actual)
+		}
+		logger.ErrorWithContext(err, "failed to verify pack checksum", checksumCtx.GetAll()...)
+		return
+	}
+
+	return nil
 }
 
 // AppendRef is a utility function to format a pack name at a specific ref.
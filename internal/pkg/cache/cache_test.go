@@ -1,11 +1,23 @@
 package cache
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	stdErrors "errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
@@ -236,6 +248,170 @@ func TestAddRegistryNoSource(t *testing.T) {
 	require.Equal(t, errors.ErrRegistrySourceRequired, err)
 }
 
+func TestAddRegistryOffline(t *testing.T) {
+	cacheDir := t.TempDir()
+	opts := testAddOpts("offline")
+	opts.PackName = "simple_service"
+	opts.Offline = true
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   cacheDir,
+		Logger: logging.NewTestLogger(t.Log),
+	})
+	require.NoError(t, err)
+
+	registry, err := cache.Add(opts)
+	require.Nil(t, registry)
+	require.True(t, stdErrors.Is(err, errors.ErrPackNotCached))
+	require.Contains(t, err.Error(), "offline/simple_service")
+
+	// Nothing is fetched into the cache.
+	_, err = os.Stat(path.Join(cacheDir, "offline"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestAddRegistryMirror(t *testing.T) {
+	// Create a local git repository serving as the mirror of a registry
+	// source which cannot be fetched.
+	mirror := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(mirror, "packs", "hello"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(mirror, "packs", "hello", "metadata.hcl"),
+		[]byte("app {\n  url = \"\"\n}\n\npack {\n  name = \"hello\"\n}\n"), 0644))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = mirror
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	cachePath := t.TempDir()
+	cache, err := NewCache(&CacheConfig{
+		Path:   cachePath,
+		Logger: logging.NewTestLogger(t.Log),
+	})
+	require.NoError(t, err)
+
+	registry, err := cache.Add(&AddOpts{
+		RegistryName: "mirrored",
+		Source:       "file://" + path.Join(t.TempDir(), "missing"),
+		Mirrors:      []string{"file://" + mirror},
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.Packs, 1)
+	require.Equal(t, "hello@latest", registry.Packs[0].Name())
+
+	// Without a working mirror, the add fails.
+	_, err = cache.Add(&AddOpts{
+		RegistryName: "unavailable",
+		Source:       "file://" + path.Join(t.TempDir(), "missing"),
+		Mirrors:      []string{"file://" + path.Join(t.TempDir(), "missing")},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "any of 2 sources")
+}
+
+func TestVerifyPackExistsNotCached(t *testing.T) {
+	cfg := &PackConfig{
+		Registry: DefaultRegistryName,
+		Name:     "missing",
+		Ref:      "v0.0.1",
+		Path:     path.Join(t.TempDir(), "missing@v0.0.1"),
+	}
+
+	err := VerifyPackExists(cfg, errors.NewUIErrorContext(), logging.NewTestLogger(t.Log))
+	require.True(t, stdErrors.Is(err, errors.ErrPackNotCached))
+	require.Contains(t, err.Error(), "default/missing@v0.0.1")
+}
+
+func TestVerifyPackExistsChecksum(t *testing.T) {
+	packPath := path.Join(t.TempDir(), "example@latest")
+	require.NoError(t, os.MkdirAll(path.Join(packPath, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(packPath, "metadata.hcl"), []byte("app {}\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(packPath, "templates", "example.nomad.tpl"), []byte("job \"example\" {}\n"), 0644))
+
+	sum, err := PackChecksum(packPath, ChecksumAlgoSHA256)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sum, "sha256:"))
+
+	// The checksum does not depend on the location of the pack.
+	copyPath := path.Join(t.TempDir(), "example@latest")
+	require.NoError(t, os.MkdirAll(path.Join(copyPath, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(copyPath, "templates", "example.nomad.tpl"), []byte("job \"example\" {}\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(copyPath, "metadata.hcl"), []byte("app {}\n"), 0644))
+	copySum, err := PackChecksum(copyPath, ChecksumAlgoSHA256)
+	require.NoError(t, err)
+	require.Equal(t, sum, copySum)
+
+	cfg := &PackConfig{
+		Registry: DefaultRegistryName,
+		Name:     "example",
+		Ref:      DefaultRef,
+		Path:     packPath,
+		Checksum: strings.ToUpper(sum),
+	}
+	var logs []string
+	logger := logging.NewTestLogger(func(args ...interface{}) {
+		logs = append(logs, fmt.Sprint(args...))
+	})
+	require.NoError(t, VerifyPackExists(cfg, errors.NewUIErrorContext(), logger))
+
+	// Changing the content of the pack causes a mismatch, which reports the
+	// computed checksum.
+	require.NoError(t, os.WriteFile(path.Join(packPath, "metadata.hcl"), []byte("app { url = \"changed\" }\n"), 0644))
+	err = VerifyPackExists(cfg, errors.NewUIErrorContext(), logger)
+	require.True(t, stdErrors.Is(err, errors.ErrPackChecksumMismatch))
+	require.Contains(t, strings.Join(logs, "\n"), errors.UIContextPrefixActualChecksum+"sha256:")
+
+	// The checksum must be in the algo:hex form, with a supported algorithm.
+	for _, checksum := range []string{"abc", "md5:" + strings.Repeat("0", 32), "sha256:xyz", "sha512:" + strings.Repeat("0", 64)} {
+		cfg.Checksum = checksum
+		err = VerifyPackExists(cfg, errors.NewUIErrorContext(), logger)
+		require.True(t, stdErrors.Is(err, errors.ErrInvalidPackChecksum), checksum)
+	}
+}
+
+func TestPackChecksumSymlinks(t *testing.T) {
+	shared := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(shared, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(shared, "templates", "example.nomad.tpl"), []byte("job \"example\" {}\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(shared, "_helpers.tpl"), []byte("helpers"), 0644))
+
+	packPath := path.Join(t.TempDir(), "example")
+	require.NoError(t, os.MkdirAll(packPath, 0755))
+	require.NoError(t, os.WriteFile(path.Join(packPath, "metadata.hcl"), []byte("app {}\n"), 0644))
+	require.NoError(t, os.Symlink(path.Join(shared, "templates"), path.Join(packPath, "templates")))
+	require.NoError(t, os.Symlink(path.Join(shared, "_helpers.tpl"), path.Join(packPath, "_helpers.tpl")))
+
+	sum, err := PackChecksum(packPath, ChecksumAlgoSHA256)
+	require.NoError(t, err)
+
+	// Symlinked files and directories are hashed as the pack loader sees
+	// them, so the checksum matches a pack holding the same files directly.
+	copyPath := path.Join(t.TempDir(), "example")
+	require.NoError(t, os.MkdirAll(path.Join(copyPath, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(copyPath, "metadata.hcl"), []byte("app {}\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(copyPath, "templates", "example.nomad.tpl"), []byte("job \"example\" {}\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(copyPath, "_helpers.tpl"), []byte("helpers"), 0644))
+	copySum, err := PackChecksum(copyPath, ChecksumAlgoSHA256)
+	require.NoError(t, err)
+	require.Equal(t, copySum, sum)
+
+	// Changing the target of a symlinked template changes the checksum.
+	require.NoError(t, os.WriteFile(path.Join(shared, "templates", "example.nomad.tpl"), []byte("job \"changed\" {}\n"), 0644))
+	changedSum, err := PackChecksum(packPath, ChecksumAlgoSHA256)
+	require.NoError(t, err)
+	require.NotEqual(t, sum, changedSum)
+
+	// A symlink leading back up the tree is reported.
+	require.NoError(t, os.Symlink(packPath, path.Join(shared, "templates", "loop")))
+	_, err = PackChecksum(packPath, ChecksumAlgoSHA256)
+	require.EqualError(t, err, "pack contains a symlink cycle at templates/loop")
+}
+
 func TestDeleteRegistry(t *testing.T) {
 	cacheDir := t.TempDir()
 	opts := testAddOpts("delete-registry")
@@ -403,3 +579,457 @@ func TestParsePackURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryFetch(t *testing.T) {
+	var logs []string
+	logger := logging.NewTestLogger(func(args ...interface{}) {
+		logs = append(logs, fmt.Sprint(args...))
+	})
+
+	// Transient errors are retried until the fetch succeeds.
+	var attempts int
+	err := retryFetch(3, time.Millisecond, logger, func() error {
+		attempts++
+		if attempts < 3 {
+			return stdErrors.New("could not resolve host: github.com")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Len(t, logs, 2)
+	require.True(t, strings.HasPrefix(logs[0], "registry fetch failed, retrying in 1ms (retry 1 of 3)"), logs[0])
+	require.True(t, strings.HasPrefix(logs[1], "registry fetch failed, retrying in 2ms (retry 2 of 3)"), logs[1])
+
+	// The last error is returned once the retries are exhausted.
+	attempts = 0
+	err = retryFetch(2, time.Millisecond, logger, func() error {
+		attempts++
+		return stdErrors.New("connection reset by peer")
+	})
+	require.EqualError(t, err, "connection reset by peer")
+	require.Equal(t, 3, attempts)
+
+	// Definitive errors are not retried.
+	attempts = 0
+	err = retryFetch(3, time.Millisecond, logger, func() error {
+		attempts++
+		return stdErrors.New("remote: Repository not found.")
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestVerifyCache(t *testing.T) {
+	dir, err := os.MkdirTemp("", "verify-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first := path.Join(dir, "first")
+	second := path.Join(dir, "second")
+	require.NoError(t, os.Mkdir(first, 0755))
+	require.NoError(t, os.Mkdir(second, 0755))
+
+	logger := logging.NewTestLogger(func(args ...interface{}) {})
+	errCtx := errors.NewUIErrorContext()
+	firstCfg := &PackConfig{Registry: DefaultRegistryName, Name: "first", Ref: DefaultRef, Path: first}
+	secondCfg := &PackConfig{Registry: DefaultRegistryName, Name: "second", Ref: DefaultRef, Path: second}
+
+	verifyCache := NewVerifyCache(1)
+	require.NoError(t, verifyCache.Verify(firstCfg, errCtx, logger))
+	require.Equal(t, 1, verifyCache.Len())
+
+	// Once verified, the pack is not checked again.
+	require.NoError(t, os.Remove(first))
+	require.NoError(t, verifyCache.Verify(firstCfg, errCtx, logger))
+
+	// A different ref is verified separately.
+	refCfg := *firstCfg
+	refCfg.Ref = "v0.0.1"
+	require.Error(t, verifyCache.Verify(&refCfg, errCtx, logger))
+	require.Equal(t, 1, verifyCache.Len())
+
+	// Verifying another pack evicts the first, as the cache is full.
+	require.NoError(t, verifyCache.Verify(secondCfg, errCtx, logger))
+	require.Equal(t, 1, verifyCache.Len())
+	require.Error(t, verifyCache.Verify(firstCfg, errCtx, logger))
+
+	// A nil cache verifies every call.
+	var nilCache *VerifyCache
+	require.NoError(t, nilCache.Verify(secondCfg, errCtx, logger))
+	require.Error(t, nilCache.Verify(firstCfg, errCtx, logger))
+}
+
+func TestVerifyCache_VerifyPacks(t *testing.T) {
+	registryPath := path.Join(t.TempDir(), DefaultRegistryName)
+
+	var cfgs []*PackConfig
+	var errCtxs []*errors.UIErrorContext
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("pack%d", i)
+		packPath := path.Join(registryPath, AppendRef(name, DefaultRef))
+
+		// Every third pack is missing from the cache.
+		if i%3 != 0 {
+			require.NoError(t, os.MkdirAll(packPath, 0755))
+		}
+
+		errCtx := errors.NewUIErrorContext()
+		errCtx.Add(errors.UIContextPrefixPackName, name)
+		cfgs = append(cfgs, &PackConfig{Registry: DefaultRegistryName, Name: name, Ref: DefaultRef, Path: packPath})
+		errCtxs = append(errCtxs, errCtx)
+	}
+
+	verifyCache := NewVerifyCache(0)
+	failures := verifyCache.VerifyPacks(cfgs, errCtxs, 3)
+
+	// The failures are returned in the order of the packs, along with the
+	// error which would have been output.
+	require.Len(t, failures, 4)
+	for i, failure := range failures {
+		require.Equal(t, cfgs[i*3], failure.Config)
+		require.ErrorIs(t, failure.Err, errors.ErrPackNotCached)
+		require.Equal(t, "failed to find pack", failure.Subject)
+		require.Equal(t, errCtxs[i*3].GetAll(), failure.Context)
+	}
+	require.Equal(t, 6, verifyCache.Len())
+
+	// A nil cache verifies the packs in the same way.
+	var nilCache *VerifyCache
+	require.Len(t, nilCache.VerifyPacks(cfgs, errCtxs, 0), 4)
+}
+
+func TestListCached(t *testing.T) {
+	cachePath := t.TempDir()
+	writePack := func(registry, packDir string, size int) {
+		packPath := path.Join(cachePath, registry, packDir)
+		require.NoError(t, os.MkdirAll(path.Join(packPath, "templates"), 0755))
+		require.NoError(t, os.WriteFile(path.Join(packPath, "templates", "job.nomad.tpl"), make([]byte, size), 0644))
+	}
+	writePack("default", "hello@v0.0.2", 20)
+	writePack("default", "hello@latest", 10)
+	writePack("default", "traefik@latest", 5)
+	require.NoError(t, os.MkdirAll(path.Join(cachePath, "empty"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(cachePath, tmpDir, "packs"), 0755))
+
+	// The last fetched time of a pack at the latest ref is when latest.log
+	// was written.
+	fetched := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	logPath := path.Join(cachePath, "default", "hello@latest", "latest.log")
+	require.NoError(t, os.WriteFile(logPath, []byte("SHA abc downloaded\n"), 0644))
+	require.NoError(t, os.Chtimes(logPath, fetched, fetched))
+
+	registries, err := ListCached(cachePath, "")
+	require.NoError(t, err)
+	require.Len(t, registries, 2)
+
+	require.Equal(t, "default", registries[0].Name)
+	require.Equal(t, int64(54), registries[0].Size)
+	require.Len(t, registries[0].Packs, 3)
+
+	var packs []string
+	for _, p := range registries[0].Packs {
+		packs = append(packs, AppendRef(p.Name, p.Ref))
+	}
+	require.Equal(t, []string{"hello@latest", "hello@v0.0.2", "traefik@latest"}, packs)
+	require.Equal(t, int64(29), registries[0].Packs[0].Size)
+	require.True(t, fetched.Equal(registries[0].Packs[0].LastFetched))
+
+	require.Equal(t, "empty", registries[1].Name)
+	require.Empty(t, registries[1].Packs)
+
+	// Filtering by registry only lists that registry.
+	registries, err = ListCached(cachePath, "empty")
+	require.NoError(t, err)
+	require.Len(t, registries, 1)
+
+	_, err = ListCached(cachePath, "missing")
+	require.True(t, stdErrors.Is(err, errors.ErrRegistryNotFound))
+
+	// A cache which does not exist yet is empty.
+	registries, err = ListCached(path.Join(cachePath, "missing"), "")
+	require.NoError(t, err)
+	require.Empty(t, registries)
+}
+
+func TestPrunablePacks(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	registries := []*CachedRegistry{{
+		Name: "default",
+		Packs: []*CachedPack{
+			{Registry: "default", Name: "hello", Ref: "latest", LastFetched: now.Add(-time.Hour)},
+			{Registry: "default", Name: "hello", Ref: "v0.0.1", LastFetched: now.Add(-48 * time.Hour)},
+			{Registry: "default", Name: "hello", Ref: "v0.0.2", LastFetched: now.Add(-2 * time.Hour)},
+		},
+	}}
+
+	refs := func(packs []*CachedPack) []string {
+		var out []string
+		for _, p := range packs {
+			out = append(out, p.Ref)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"v0.0.1"},
+		refs(PrunablePacks(registries, &PruneOpts{OlderThan: 24 * time.Hour}, now)))
+	require.Equal(t, []string{"v0.0.1", "v0.0.2"},
+		refs(PrunablePacks(registries, &PruneOpts{KeepRefs: []string{"latest"}}, now)))
+	require.Equal(t, []string{"v0.0.1"},
+		refs(PrunablePacks(registries, &PruneOpts{OlderThan: 24 * time.Hour, KeepRefs: []string{"latest", "v0.0.2"}}, now)))
+	require.Empty(t, PrunablePacks(registries, &PruneOpts{}, now))
+}
+
+func TestRemoveCachedPack(t *testing.T) {
+	cachePath := t.TempDir()
+	packPath := path.Join(cachePath, "default", "hello@v0.0.1")
+	require.NoError(t, os.MkdirAll(packPath, 0755))
+
+	logger := logging.NewTestLogger(t.Log)
+	require.NoError(t, RemoveCachedPack(cachePath, &CachedPack{Path: packPath}, logger))
+	_, err := os.Stat(packPath)
+	require.True(t, os.IsNotExist(err))
+
+	// A pack path outside of the cache is never removed.
+	outside := t.TempDir()
+	require.Error(t, RemoveCachedPack(cachePath, &CachedPack{Path: outside}, logger))
+	_, err = os.Stat(outside)
+	require.NoError(t, err)
+}
+
+func TestCachePathOverride(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	orig, ok := os.LookupEnv(CachePathEnvVar)
+	require.NoError(t, os.Setenv(CachePathEnvVar, cacheDir))
+	defer func() {
+		if ok {
+			os.Setenv(CachePathEnvVar, orig)
+		} else {
+			os.Unsetenv(CachePathEnvVar)
+		}
+	}()
+	require.Equal(t, cacheDir, DefaultCachePath())
+
+	// The pack config cache path takes precedence over the default.
+	override := path.Join(t.TempDir(), "override")
+	cfg := &PackConfig{Name: "missing", CachePath: override}
+	cfg.Init()
+	require.Equal(t, path.Join(override, DefaultRegistryName, "missing@latest"), cfg.Path)
+
+	// The cache directory is created if missing, but must be a directory.
+	_, err := NewCache(&CacheConfig{Path: override, Logger: logging.NewTestLogger(t.Log)})
+	require.NoError(t, err)
+
+	file := path.Join(cacheDir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	_, err = NewCache(&CacheConfig{Path: path.Join(file, "cache"), Logger: logging.NewTestLogger(t.Log)})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to create cache directory")
+}
+
+// testOCIRegistry returns a server implementing enough of the OCI
+// distribution API to pull packs, requiring a bearer token obtained using
+// basic credentials of user and pass.
+func testOCIRegistry(t *testing.T, artifacts map[string]map[string]string) *httptest.Server {
+	blobs := make(map[string][]byte)
+	manifests := make(map[string][]byte)
+	repos := make([]string, 0, len(artifacts))
+
+	for repo, files := range artifacts {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, content := range files {
+			// Content of the form "-> target" is written as a symlink.
+			if strings.HasPrefix(content, "-> ") {
+				require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Linkname: strings.TrimPrefix(content, "-> "), Typeflag: tar.TypeSymlink}))
+				continue
+			}
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+
+		sum := sha256.Sum256(buf.Bytes())
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = buf.Bytes()
+
+		artifactType := PackArtifactType
+		if strings.HasSuffix(repo, "image") {
+			artifactType = "application/vnd.oci.image.config.v1+json"
+		}
+		manifest, err := json.Marshal(&ociManifest{
+			MediaType:    ociManifestMediaType,
+			ArtifactType: artifactType,
+			Layers:       []ociDescriptor{{MediaType: PackLayerMediaType, Digest: digest, Size: int64(buf.Len())}},
+		})
+		require.NoError(t, err)
+		manifests[repo] = manifest
+		repos = append(repos, repo)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		p := strings.TrimPrefix(r.URL.Path, "/v2/")
+		switch {
+		case p == "_catalog":
+			_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": repos})
+		case strings.Contains(p, "/manifests/"):
+			manifest, ok := manifests[p[:strings.Index(p, "/manifests/")]]
+			if !ok || !strings.HasSuffix(p, "/manifests/latest") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(manifest)
+		case strings.Contains(p, "/blobs/"):
+			blob, ok := blobs[p[strings.Index(p, "/blobs/")+len("/blobs/"):]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAddRegistryOCI(t *testing.T) {
+	pack := map[string]string{
+		"metadata.hcl": `app {
+  url    = "https://example.com"
+  author = "test"
+}
+
+pack {
+  name    = "hello"
+  url     = "https://example.com/packs/hello"
+  version = "0.0.1"
+}
+`,
+		"variables.hcl":             "",
+		"templates/hello.nomad.tpl": "job \"hello\" {}\n",
+	}
+	srv := testOCIRegistry(t, map[string]map[string]string{
+		"packs/hello": pack,
+		"packs/image": pack,
+	})
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// Credentials are read from the docker configuration.
+	dockerConfig := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	require.NoError(t, os.WriteFile(path.Join(dockerConfig, "config.json"),
+		[]byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)), 0600))
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	require.NoError(t, os.Setenv("DOCKER_CONFIG", dockerConfig))
+
+	cachePath := t.TempDir()
+	cache, err := NewCache(&CacheConfig{
+		Path:   cachePath,
+		Logger: logging.NewTestLogger(t.Log),
+	})
+	require.NoError(t, err)
+
+	registry, err := cache.Add(&AddOpts{
+		RegistryName: "oci",
+		Source:       OCISourcePrefix + host + "/packs",
+		PackName:     "hello",
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.Packs, 1)
+	require.Equal(t, "hello", registry.Packs[0].Name())
+	require.Equal(t, "latest", registry.Packs[0].Ref)
+	require.FileExists(t, path.Join(cachePath, "oci", "hello@latest", "templates", "hello.nomad.tpl"))
+
+	latestLog, err := os.ReadFile(path.Join(cachePath, "oci", "hello@latest", "latest.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(latestLog), "digest sha256:")
+
+	// Artifacts which are not packs are rejected before being extracted.
+	_, err = cache.Add(&AddOpts{
+		RegistryName: "oci",
+		Source:       OCISourcePrefix + host + "/packs",
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "packs/image:latest is not a pack")
+
+	// Refs must be valid tags.
+	_, err = cache.Add(&AddOpts{
+		RegistryName: "oci",
+		Source:       OCISourcePrefix + host + "/packs",
+		PackName:     "hello",
+		Ref:          "v0.1.0/../x",
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a valid OCI tag")
+}
+
+func TestAddRegistryOCISymlink(t *testing.T) {
+	srv := testOCIRegistry(t, map[string]map[string]string{
+		"packs/hello": {
+			"metadata.hcl": `app {
+  url    = "https://example.com"
+  author = "test"
+}
+
+pack {
+  name    = "hello"
+  url     = "https://example.com/packs/hello"
+  version = "0.0.1"
+}
+`,
+			"variables.hcl":              "",
+			"templates/hello.nomad.tpl":  "job \"hello\" {}\n",
+			"templates/passwd.nomad.tpl": "-> /etc/passwd",
+		},
+	})
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	dockerConfig := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	require.NoError(t, os.WriteFile(path.Join(dockerConfig, "config.json"),
+		[]byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)), 0600))
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	require.NoError(t, os.Setenv("DOCKER_CONFIG", dockerConfig))
+
+	cachePath := t.TempDir()
+	cache, err := NewCache(&CacheConfig{
+		Path:   cachePath,
+		Logger: logging.NewTestLogger(t.Log),
+	})
+	require.NoError(t, err)
+
+	// Symlinks within the layer are skipped, rather than failing the pull.
+	registry, err := cache.Add(&AddOpts{
+		RegistryName: "oci",
+		Source:       OCISourcePrefix + host + "/packs",
+		PackName:     "hello",
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.Packs, 1)
+
+	packDir := path.Join(cachePath, "oci", "hello@latest")
+	require.FileExists(t, path.Join(packDir, "templates", "hello.nomad.tpl"))
+	_, err = os.Lstat(path.Join(packDir, "templates", "passwd.nomad.tpl"))
+	require.True(t, os.IsNotExist(err))
+}
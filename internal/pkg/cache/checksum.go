@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+// ChecksumAlgo* are the hash algorithms supported for pack checksums.
+const (
+	ChecksumAlgoSHA256 = "sha256"
+	ChecksumAlgoSHA512 = "sha512"
+)
+
+// DefaultChecksumAlgo is the algorithm used to compute a pack checksum when
+// none has been provided to compare against.
+const DefaultChecksumAlgo = ChecksumAlgoSHA256
+
+// newPackHash returns a new hash for the checksum algorithm.
+func newPackHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case ChecksumAlgoSHA256:
+		return sha256.New(), nil
+	case ChecksumAlgoSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported algorithm %q, must be one of %s, %s",
+			errors.ErrInvalidPackChecksum, algo, ChecksumAlgoSHA256, ChecksumAlgoSHA512)
+	}
+}
+
+// ParseChecksum splits a pack checksum in the algo:hex form into its
+// algorithm and hex encoded digest, checking the algorithm is supported and
+// the digest is the expected length.
+func ParseChecksum(checksum string) (algo, digest string, err error) {
+	segments := strings.SplitN(checksum, ":", 2)
+	if len(segments) != 2 {
+		return "", "", fmt.Errorf("%w: %q must be in the form algo:hex", errors.ErrInvalidPackChecksum, checksum)
+	}
+	algo, digest = strings.ToLower(segments[0]), strings.ToLower(segments[1])
+
+	h, err := newPackHash(algo)
+	if err != nil {
+		return "", "", err
+	}
+	if raw, decodeErr := hex.DecodeString(digest); decodeErr != nil || len(raw) != h.Size() {
+		return "", "", fmt.Errorf("%w: %q is not a valid %s digest", errors.ErrInvalidPackChecksum, digest, algo)
+	}
+	return algo, digest, nil
+}
+
+// PackChecksum computes the checksum of the pack at dir using the algorithm,
+// returned in the algo:hex form accepted by ParseChecksum. Each regular file
+// within the pack is hashed, and the checksum is the hash of the resulting
+// list of digests and slash separated paths sorted by path, so it does not
+// depend on the location of the pack or the order the files are read in.
+//
+// Symlinks are followed in the same way as when the pack is loaded, so the
+// content of a symlinked file or directory is hashed at the path of the
+// symlink, and changing its target changes the checksum.
+func PackChecksum(dir, algo string) (string, error) {
+	if _, err := newPackHash(algo); err != nil {
+		return "", err
+	}
+
+	var files []string
+	if err := checksumFiles(dir, "", map[filesystem.FileID]struct{}{}, &files); err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	sum, _ := newPackHash(algo)
+	for _, file := range files {
+		digest, err := fileDigest(filepath.Join(dir, filepath.FromSlash(file)), algo)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(sum, "%s  %s\n", digest, file)
+	}
+	return algo + ":" + hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// checksumFiles adds the slash separated paths, relative to root, of the
+// regular files within the rel directory of root to files, recursing into
+// directories and following symlinks. The identities of the directories being
+// walked are held in ancestors, so a symlink leading back up the tree is
+// reported rather than followed forever.
+func checksumFiles(root, rel string, ancestors map[filesystem.FileID]struct{}, files *[]string) error {
+	dir := filepath.Join(root, filepath.FromSlash(rel))
+
+	id, err := filesystem.FileIdentity(dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := ancestors[id]; ok {
+		return fmt.Errorf("pack contains a symlink cycle at %s", rel)
+	}
+	ancestors[id] = struct{}{}
+	defer delete(ancestors, id)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryRel := path.Join(rel, entry.Name())
+
+		// Stat follows symlinks, so the target decides whether the entry is
+		// hashed as a file or walked as a directory.
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %v", entryRel, err)
+		}
+
+		switch {
+		case info.IsDir() && entry.Name() == ".git":
+		case info.IsDir():
+			if err := checksumFiles(root, entryRel, ancestors, files); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			*files = append(*files, entryRel)
+		}
+	}
+	return nil
+}
+
+// fileDigest returns the hex encoded digest of the file at path.
+func fileDigest(path, algo string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h, err := newPackHash(algo)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyPackChecksum compares the checksum of the pack at cfg.Path against
+// cfg.Checksum, returning ErrPackChecksumMismatch along with the computed
+// checksum if they differ.
+func verifyPackChecksum(cfg *PackConfig) (actual string, err error) {
+	algo, digest, err := ParseChecksum(cfg.Checksum)
+	if err != nil {
+		return "", err
+	}
+	if actual, err = PackChecksum(cfg.Path, algo); err != nil {
+		return "", err
+	}
+	if actual != algo+":"+digest {
+		return actual, fmt.Errorf("%w: %s", errors.ErrPackChecksumMismatch, AppendRef(cfg.Name, cfg.Ref))
+	}
+	return actual, nil
+}
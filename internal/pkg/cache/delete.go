@@ -34,6 +34,9 @@ func (c *Cache) Delete(opts *DeleteOpts) (err error) {
 	c.ErrorContext.Add(errors.RegistryContextPrefixPackName, opts.PackName)
 	c.ErrorContext.Add(errors.RegistryContextPrefixRef, opts.Ref)
 
+	unlock := writeLockRegistry(opts.RegistryPath())
+	defer unlock()
+
 	// If no pack name or revision is set, delete the whole registry and return.
 	if opts.PackName == "" && opts.Ref == "" {
 		err = os.RemoveAll(opts.RegistryPath())
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+// CachedRegistry describes a registry directory within the cache, as found
+// on disk without loading its packs.
+type CachedRegistry struct {
+	Name  string
+	Path  string
+	Size  int64
+	Packs []*CachedPack
+}
+
+// CachedPack describes a pack at a single ref within a cached registry.
+type CachedPack struct {
+	Registry string
+	Name     string
+	Ref      string
+	Path     string
+	Size     int64
+
+	// LastFetched is when the pack was last fetched into the cache. This is
+	// the time latest.log was last written for packs at the latest ref, and
+	// the modification time of the pack directory otherwise.
+	LastFetched time.Time
+}
+
+// ListCached reads the registries and packs within the cache directory,
+// returning them sorted by name and ref. If registryName is set, only that
+// registry is listed, and ErrRegistryNotFound is returned if it is not in
+// the cache. Unlike Load, the packs are not parsed, so packs with invalid
+// metadata are still listed.
+func ListCached(cachePath, registryName string) ([]*CachedRegistry, error) {
+	// A cache which has not been created yet is empty.
+	entries, err := os.ReadDir(cachePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var registries []*CachedRegistry
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == tmpDir || entry.Name() == ".git" {
+			continue
+		}
+		if registryName != "" && entry.Name() != registryName {
+			continue
+		}
+
+		registry, err := listCachedRegistry(path.Join(cachePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		registries = append(registries, registry)
+	}
+
+	if registryName != "" && len(registries) == 0 {
+		return nil, fmt.Errorf("%w: %s", errors.ErrRegistryNotFound, registryName)
+	}
+
+	sort.Slice(registries, func(i, j int) bool { return registries[i].Name < registries[j].Name })
+	return registries, nil
+}
+
+// listCachedRegistry reads the packs within the registry directory.
+func listCachedRegistry(registryPath string) (*CachedRegistry, error) {
+	registry := &CachedRegistry{
+		Name: path.Base(registryPath),
+		Path: registryPath,
+	}
+
+	entries, err := os.ReadDir(registryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+
+		packPath := path.Join(registryPath, entry.Name())
+		stats, err := filesystem.DirStats(packPath)
+		if err != nil {
+			return nil, err
+		}
+		lastFetched, err := packLastFetched(packPath)
+		if err != nil {
+			return nil, err
+		}
+
+		registry.Packs = append(registry.Packs, &CachedPack{
+			Registry:    registry.Name,
+			Name:        strings.SplitN(entry.Name(), "@", 2)[0],
+			Ref:         refFromPackEntry(entry),
+			Path:        packPath,
+			Size:        stats.Bytes,
+			LastFetched: lastFetched,
+		})
+		registry.Size += stats.Bytes
+	}
+
+	sort.Slice(registry.Packs, func(i, j int) bool {
+		if registry.Packs[i].Name != registry.Packs[j].Name {
+			return registry.Packs[i].Name < registry.Packs[j].Name
+		}
+		return registry.Packs[i].Ref < registry.Packs[j].Ref
+	})
+	return registry, nil
+}
+
+// packLastFetched returns when the pack at packPath was last fetched.
+func packLastFetched(packPath string) (time.Time, error) {
+	if info, err := os.Stat(path.Join(packPath, "latest.log")); err == nil {
+		return info.ModTime(), nil
+	} else if !os.IsNotExist(err) {
+		return time.Time{}, err
+	}
+
+	info, err := os.Stat(packPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
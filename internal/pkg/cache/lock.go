@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// registryLocks holds a lock for each registry path, so that packs can be
+// verified concurrently while changes to the same registry within the cache
+// are serialized. Reads of a registry share its lock, while writes hold it
+// exclusively.
+var registryLocks = struct {
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex
+}{locks: make(map[string]*sync.RWMutex)}
+
+// registryLock returns the lock of the registry at the path.
+func registryLock(registryPath string) *sync.RWMutex {
+	registryPath = filepath.Clean(registryPath)
+
+	registryLocks.mu.Lock()
+	defer registryLocks.mu.Unlock()
+
+	lock, ok := registryLocks.locks[registryPath]
+	if !ok {
+		lock = new(sync.RWMutex)
+		registryLocks.locks[registryPath] = lock
+	}
+	return lock
+}
+
+// readLockRegistry locks the registry at the path for reading, returning the
+// function which unlocks it.
+func readLockRegistry(registryPath string) func() {
+	lock := registryLock(registryPath)
+	lock.RLock()
+	return lock.RUnlock
+}
+
+// writeLockRegistry locks the registry at the path for writing, returning the
+// function which unlocks it.
+func writeLockRegistry(registryPath string) func() {
+	lock := registryLock(registryPath)
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,561 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+const (
+	// OCISourcePrefix is the scheme prefix of a registry source held in an
+	// OCI registry, such as oci://registry.example.com/packs. Each pack is a
+	// repository beneath the source, with the ref as its tag.
+	OCISourcePrefix = "oci://"
+
+	// PackArtifactType is the artifact type of a pack pushed to an OCI
+	// registry. It is accepted as either the artifactType or the config
+	// media type of the manifest.
+	PackArtifactType = "application/vnd.nomad-pack.config.v1+json"
+
+	// PackLayerMediaType is the media type of the layer holding the pack,
+	// which is a gzipped tar archive with the pack files at its root.
+	PackLayerMediaType = "application/vnd.nomad-pack.layer.v1.tar+gzip"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// maxOCIBlobSize caps the size of a pack layer, so a misconfigured or
+	// malicious registry cannot fill the disk.
+	maxOCIBlobSize = 100 << 20
+
+	ociRequestTimeout = time.Minute
+)
+
+// IsOCISource returns whether the registry source is held in an OCI
+// registry.
+func IsOCISource(source string) bool {
+	return strings.HasPrefix(source, OCISourcePrefix)
+}
+
+// OCIRegistryName returns the name of the registry within the cache holding
+// the packs pulled from the OCI registry source, when the source is used in
+// place of a registry name.
+func OCIRegistryName(source string) string {
+	name := strings.TrimPrefix(source, OCISourcePrefix)
+	name = strings.NewReplacer("/", "-", ":", "-").Replace(strings.Trim(name, "/"))
+	return "oci-" + name
+}
+
+// ociManifest is the subset of an OCI image manifest needed to pull a pack.
+type ociManifest struct {
+	MediaType    string          `json:"mediaType"`
+	ArtifactType string          `json:"artifactType"`
+	Config       ociDescriptor   `json:"config"`
+	Layers       []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// ociClient makes requests to the distribution API of a single OCI registry
+// host, authenticating with basic credentials or, when the registry asks
+// for one, a bearer token obtained using them.
+type ociClient struct {
+	scheme   string
+	host     string
+	username string
+	password string
+	token    string
+	http     *http.Client
+}
+
+// newOCIClient returns a client for the host of the OCI source. The
+// username and password are used if set, otherwise the credentials are
+// looked up in the docker configuration, including any credential helpers.
+func newOCIClient(source, username, password string) (*ociClient, string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid OCI source %q: %v", source, err)
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("invalid OCI source %q: missing registry host", source)
+	}
+
+	client := &ociClient{
+		scheme:   "https",
+		host:     u.Host,
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: ociRequestTimeout},
+	}
+
+	// Registries on the local machine, such as those used for development,
+	// rarely serve TLS.
+	if isLoopbackHost(u.Hostname()) {
+		client.scheme = "http"
+	}
+
+	if client.username == "" && client.password == "" {
+		client.username, client.password, err = dockerCredentials(u.Host)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return client, strings.Trim(u.Path, "/"), nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// pullPack pulls the pack from the repository at the tag, verifying the
+// media types of the artifact and the digest of its layer before extracting
+// it to dest. The digest of the manifest is returned.
+func (c *ociClient) pullPack(repository, tag, dest string) (string, error) {
+	body, resp, err := c.get(fmt.Sprintf("/v2/%s/manifests/%s", repository, tag), ociManifestMediaType)
+	if err != nil {
+		return "", err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return "", fmt.Errorf("failed to decode manifest of %s:%s: %v", repository, tag, err)
+	}
+
+	layer, err := manifest.packLayer()
+	if err != nil {
+		return "", fmt.Errorf("%s:%s %v", repository, tag, err)
+	}
+
+	blob, err := c.fetchBlob(repository, layer)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(blob)
+
+	f, err := os.Open(blob)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", err
+	}
+	// Like copying a pack from a git registry, symlinks and other special
+	// files within the layer are skipped, and later entries replace earlier
+	// ones with the same name.
+	opts := []filesystem.ExtractOption{filesystem.WithSkipSpecialEntries(), filesystem.WithReplaceExisting()}
+	if err := filesystem.ExtractTarGz(f, dest, opts...); err != nil {
+		return "", fmt.Errorf("failed to extract %s:%s: %v", repository, tag, err)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return digest, nil
+}
+
+// packLayer verifies the manifest is that of a pack, returning the layer
+// which holds it.
+func (m *ociManifest) packLayer() (*ociDescriptor, error) {
+	if m.ArtifactType != PackArtifactType && m.Config.MediaType != PackArtifactType {
+		mediaType := m.ArtifactType
+		if mediaType == "" {
+			mediaType = m.Config.MediaType
+		}
+		return nil, fmt.Errorf("is not a pack: artifact type is %q, expected %q", mediaType, PackArtifactType)
+	}
+
+	var layer *ociDescriptor
+	for i := range m.Layers {
+		if m.Layers[i].MediaType != PackLayerMediaType {
+			continue
+		}
+		if layer != nil {
+			return nil, fmt.Errorf("has more than one layer of media type %q", PackLayerMediaType)
+		}
+		layer = &m.Layers[i]
+	}
+	if layer == nil {
+		return nil, fmt.Errorf("has no layer of media type %q", PackLayerMediaType)
+	}
+	if layer.Size > maxOCIBlobSize {
+		return nil, fmt.Errorf("layer is %d bytes, larger than the limit of %d bytes", layer.Size, maxOCIBlobSize)
+	}
+	return layer, nil
+}
+
+// fetchBlob downloads the blob to a temporary file, returning its path once
+// the content has been verified against the digest of the descriptor.
+func (c *ociClient) fetchBlob(repository string, desc *ociDescriptor) (string, error) {
+	expected := strings.TrimPrefix(desc.Digest, "sha256:")
+	if expected == desc.Digest {
+		return "", fmt.Errorf("unsupported digest %q of %s layer", desc.Digest, repository)
+	}
+
+	resp, err := c.do(fmt.Sprintf("/v2/%s/blobs/%s", repository, desc.Digest), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	f, err := ioutil.TempFile("", "nomad-pack-oci-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(resp.Body, maxOCIBlobSize+1))
+	if err == nil && n > maxOCIBlobSize {
+		err = fmt.Errorf("layer is larger than the limit of %d bytes", maxOCIBlobSize)
+	}
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != expected {
+		err = fmt.Errorf("digest of %s layer does not match %s", repository, desc.Digest)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// catalog returns the names of the repositories directly beneath the
+// prefix, which are the packs of the registry. Not every registry supports
+// listing its repositories.
+func (c *ociClient) catalog(prefix string) ([]string, error) {
+	body, _, err := c.get("/v2/_catalog?n=10000", "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the packs of the registry, use --target to add a single pack: %v", err)
+	}
+
+	var out struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode registry catalog: %v", err)
+	}
+
+	var names []string
+	for _, repo := range out.Repositories {
+		name := repo
+		if prefix != "" {
+			if !strings.HasPrefix(repo, prefix+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(repo, prefix+"/")
+		}
+		if name != "" && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// get performs a GET request, returning the body of a successful response.
+func (c *ociClient) get(p, accept string) ([]byte, *http.Response, error) {
+	resp, err := c.do(p, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOCIBlobSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp, nil
+}
+
+// do performs a GET request, authenticating and retrying if the registry
+// responds that authentication is required. A response with any status other
+// than 200 is returned as an error.
+func (c *ociClient) do(p, accept string) (*http.Response, error) {
+	resp, err := c.request(p, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = c.request(p, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s%s not found", c.host, p)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, fmt.Errorf("authentication failed for %s, check the docker credentials for the registry", c.host)
+	default:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response code %d from %s%s: %s", resp.StatusCode, c.host, p, strings.TrimSpace(string(body)))
+	}
+}
+
+func (c *ociClient) request(p, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, c.scheme+"://"+c.host+p, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "" || c.password != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.http.Do(req)
+}
+
+// challengeParam matches the parameters of a WWW-Authenticate challenge.
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate responds to the WWW-Authenticate challenge of the registry.
+// Basic challenges are answered by the credentials sent with the retried
+// request, while bearer challenges require a token from the realm.
+func (c *ociClient) authenticate(challenge string) error {
+	parts := strings.SplitN(challenge, " ", 2)
+	switch strings.ToLower(parts[0]) {
+	case "basic":
+		if c.username == "" && c.password == "" {
+			return fmt.Errorf("authentication failed for %s, no docker credentials found for the registry", c.host)
+		}
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("authentication failed for %s, unsupported challenge %q", c.host, challenge)
+	}
+
+	values := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(parts[len(parts)-1], -1) {
+		values[match[1]] = match[2]
+	}
+	if values["realm"] == "" {
+		return fmt.Errorf("authentication failed for %s, challenge has no realm", c.host)
+	}
+
+	realm, err := url.Parse(values["realm"])
+	if err != nil {
+		return fmt.Errorf("authentication failed for %s, invalid realm: %v", c.host, err)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("authentication failed for %s, token request returned %d", c.host, resp.StatusCode)
+	}
+
+	var out struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("authentication failed for %s, invalid token response: %v", c.host, err)
+	}
+	c.token = out.Token
+	if c.token == "" {
+		c.token = out.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("authentication failed for %s, token response has no token", c.host)
+	}
+	return nil
+}
+
+// dockerConfig is the subset of the docker configuration file holding
+// registry credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// dockerCredentials returns the credentials for the registry host from the
+// docker configuration, in the same manner as the docker CLI: a credential
+// helper configured for the host, then the default credential store, then
+// the credentials held in the file. Empty credentials are returned if there
+// are none.
+func dockerCredentials(host string) (string, string, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to read docker config: %v", err)
+	}
+
+	var cfg dockerConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return "", "", fmt.Errorf("failed to decode docker config: %v", err)
+	}
+
+	if helper := cfg.CredHelpers[host]; helper != "" {
+		return credentialHelper(helper, host)
+	}
+	if cfg.CredsStore != "" {
+		username, password, err := credentialHelper(cfg.CredsStore, host)
+		if err != nil || username != "" || password != "" {
+			return username, password, err
+		}
+	}
+
+	for _, key := range []string{host, "https://" + host, "http://" + host} {
+		entry, ok := cfg.Auths[key]
+		if !ok || entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode docker credentials for %s: %v", host, err)
+		}
+		creds := strings.SplitN(string(decoded), ":", 2)
+		if len(creds) != 2 {
+			return "", "", fmt.Errorf("failed to decode docker credentials for %s: missing password", host)
+		}
+		return creds[0], creds[1], nil
+	}
+	return "", "", nil
+}
+
+// credentialHelper returns the credentials for the host from the named
+// docker credential helper. Empty credentials are returned if the helper
+// does not hold any for the host.
+func credentialHelper(helper, host string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(string(out), "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("docker credential helper %q failed: %v", helper, err)
+	}
+
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return "", "", fmt.Errorf("failed to decode output of docker credential helper %q: %v", helper, err)
+	}
+	return creds.Username, creds.Secret, nil
+}
+
+// ociTag returns the tag of the ref, which defaults to latest.
+func ociTag(ref string) (string, error) {
+	if ref == "" {
+		return DefaultRef, nil
+	}
+	if !ociTagPattern.MatchString(ref) {
+		return "", fmt.Errorf("ref %q is not a valid OCI tag", ref)
+	}
+	return ref, nil
+}
+
+var ociTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// pullOCIRegistry pulls the packs of the OCI registry source into the clone
+// path, in the same layout as a cloned git registry. If the opts do not
+// target a pack, every pack beneath the source is pulled, which requires the
+// registry to support listing its repositories.
+func (c *Cache) pullOCIRegistry(opts *AddOpts, source string) error {
+	client, prefix, err := newOCIClient(source, opts.Username, opts.Password)
+	if err != nil {
+		return err
+	}
+
+	tag, err := ociTag(opts.Ref)
+	if err != nil {
+		return err
+	}
+
+	packNames := []string{opts.PackName}
+	if opts.PackName == "" {
+		if packNames, err = client.catalog(prefix); err != nil {
+			return err
+		}
+		if len(packNames) == 0 {
+			return fmt.Errorf("no packs found in OCI registry %s", source)
+		}
+	}
+
+	c.pulledDigests = make(map[string]string, len(packNames))
+	for _, name := range packNames {
+		repository := path.Join(prefix, name)
+		c.cfg.Logger.Debug(fmt.Sprintf("pulling pack %s:%s from %s", repository, tag, client.host))
+
+		digest, err := client.pullPack(repository, tag, path.Join(c.clonedPacksPath(), name))
+		if err != nil {
+			return err
+		}
+		c.pulledDigests[name] = digest
+	}
+	return nil
+}
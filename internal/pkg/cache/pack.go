@@ -16,6 +16,15 @@ type PackConfig struct {
 	Ref        string
 	Path       string
 	SourcePath string
+
+	// CachePath is the directory of the cache containing registry packs. If
+	// empty, DefaultCachePath is used.
+	CachePath string
+
+	// Checksum is the expected checksum of the pack, in the algo:hex form
+	// returned by PackChecksum. If set, VerifyPackExists fails unless the
+	// pack matches it.
+	Checksum string
 }
 
 func (cfg *PackConfig) Init() {
@@ -54,7 +63,11 @@ func (cfg *PackConfig) initFromDirectory(packPath string) {
 // initFromArgs is a utility function to build a pack path for registry added
 // packs. Not for use with file system based packs.
 func (cfg *PackConfig) initFromArgs() {
-	cfg.Path = path.Join(DefaultCachePath(), cfg.Registry, cfg.Name)
+	cachePath := cfg.CachePath
+	if cachePath == "" {
+		cachePath = DefaultCachePath()
+	}
+	cfg.Path = path.Join(cachePath, cfg.Registry, cfg.Name)
 	if cfg.Ref != "" {
 		cfg.Path = AppendRef(cfg.Path, cfg.Ref)
 	}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+// PruneOpts selects the cached packs to prune. A pack is selected if it
+// matches any of the set criteria.
+type PruneOpts struct {
+	// OlderThan selects the packs last fetched longer ago than the duration.
+	OlderThan time.Duration
+
+	// KeepRefs selects the packs at any ref other than those listed, such as
+	// the refs currently referenced by a CI pipeline.
+	KeepRefs []string
+}
+
+// PrunablePacks returns the packs within the registries selected by the
+// options, relative to now.
+func PrunablePacks(registries []*CachedRegistry, opts *PruneOpts, now time.Time) []*CachedPack {
+	keep := make(map[string]struct{}, len(opts.KeepRefs))
+	for _, ref := range opts.KeepRefs {
+		keep[ref] = struct{}{}
+	}
+
+	var prunable []*CachedPack
+	for _, registry := range registries {
+		for _, p := range registry.Packs {
+			if opts.OlderThan > 0 && now.Sub(p.LastFetched) > opts.OlderThan {
+				prunable = append(prunable, p)
+				continue
+			}
+			if len(keep) > 0 {
+				if _, ok := keep[p.Ref]; !ok {
+					prunable = append(prunable, p)
+				}
+			}
+		}
+	}
+	return prunable
+}
+
+// RemoveCachedPack removes the pack from the cache at cachePath. The removal
+// is refused if the pack path resolves outside of the cache.
+func RemoveCachedPack(cachePath string, p *CachedPack, logger logging.Logger) error {
+	unlock := writeLockRegistry(filepath.Dir(p.Path))
+	defer unlock()
+
+	return filesystem.RemovePath(cachePath, p.Path, logger)
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+// DefaultVerifyCacheSize is the number of verified packs held by a
+// VerifyCache created with a non-positive size.
+const DefaultVerifyCacheSize = 64
+
+// VerifyCache memoizes the packs verified by VerifyPackExists, so that
+// repeated verifications of the same pack within a process are not
+// repeated. Entries are keyed by the registry, ref, name and expected
+// checksum of the pack, so a different ref or checksum is verified
+// separately. Only successful verifications are held, and once full the least
+// recently used entry is evicted. A nil VerifyCache is valid and verifies
+// every call.
+type VerifyCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[verifyKey]*list.Element
+}
+
+// verifyKey identifies a verified pack.
+type verifyKey struct {
+	registry string
+	ref      string
+	name     string
+	checksum string
+}
+
+// NewVerifyCache returns a VerifyCache holding at most size packs. If size is
+// not positive, DefaultVerifyCacheSize is used.
+func NewVerifyCache(size int) *VerifyCache {
+	if size <= 0 {
+		size = DefaultVerifyCacheSize
+	}
+	return &VerifyCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[verifyKey]*list.Element, size),
+	}
+}
+
+// Verify verifies the pack exists in the same manner as VerifyPackExists,
+// returning early if the pack has already been verified.
+func (v *VerifyCache) Verify(cfg *PackConfig, errCtx *errors.UIErrorContext, logger logging.Logger) error {
+	if v == nil {
+		return VerifyPackExists(cfg, errCtx, logger)
+	}
+
+	key := verifyKey{registry: cfg.Registry, ref: cfg.Ref, name: cfg.Name, checksum: cfg.Checksum}
+
+	v.mu.Lock()
+	if elem, ok := v.entries[key]; ok {
+		v.order.MoveToFront(elem)
+		v.mu.Unlock()
+		return nil
+	}
+	v.mu.Unlock()
+
+	if err := VerifyPackExists(cfg, errCtx, logger); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.entries[key]; ok {
+		return nil
+	}
+	v.entries[key] = v.order.PushFront(key)
+	if v.order.Len() > v.size {
+		oldest := v.order.Back()
+		v.order.Remove(oldest)
+		delete(v.entries, oldest.Value.(verifyKey))
+	}
+	return nil
+}
+
+// Len returns the number of verified packs held.
+func (v *VerifyCache) Len() int {
+	if v == nil {
+		return 0
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.order.Len()
+}
+
+// DefaultVerifyWorkers is the number of packs verified concurrently by
+// VerifyPacks when a non-positive number of workers is passed.
+const DefaultVerifyWorkers = 4
+
+// VerifyFailure is a pack which failed verification by VerifyPacks, holding
+// the error which VerifyPackExists would otherwise have output.
+type VerifyFailure struct {
+	Config  *PackConfig
+	Err     error
+	Subject string
+	Context []string
+}
+
+// VerifyPacks verifies the packs exist in the same manner as Verify, using a
+// pool of at most workers goroutines. Rather than being output as each pack
+// fails, the failures are returned in the order of the packs, so they can be
+// reported together. errCtxs holds the error context of each pack.
+func (v *VerifyCache) VerifyPacks(cfgs []*PackConfig, errCtxs []*errors.UIErrorContext, workers int) []*VerifyFailure {
+	if workers <= 0 {
+		workers = DefaultVerifyWorkers
+	}
+	if workers > len(cfgs) {
+		workers = len(cfgs)
+	}
+
+	failures := make([]*VerifyFailure, len(cfgs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				logger := &failureLogger{failure: &VerifyFailure{Config: cfgs[idx]}}
+				if err := v.Verify(cfgs[idx], errCtxs[idx], logger); err != nil {
+					if logger.failure.Err == nil {
+						logger.failure.Err = err
+						logger.failure.Subject = "failed to verify pack"
+					}
+					failures[idx] = logger.failure
+				}
+			}
+		}()
+	}
+
+	for i := range cfgs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	out := make([]*VerifyFailure, 0, len(failures))
+	for _, failure := range failures {
+		if failure != nil {
+			out = append(out, failure)
+		}
+	}
+	return out
+}
+
+// failureLogger records the error output when verifying a pack, so that it
+// can be reported once all the packs have been verified. Other output is
+// discarded.
+type failureLogger struct {
+	failure *VerifyFailure
+}
+
+func (l *failureLogger) Debug(string)   {}
+func (l *failureLogger) Error(string)   {}
+func (l *failureLogger) Info(string)    {}
+func (l *failureLogger) Trace(string)   {}
+func (l *failureLogger) Warning(string) {}
+
+func (l *failureLogger) ErrorWithContext(err error, sub string, ctx ...string) {
+	l.failure.Err = err
+	l.failure.Subject = sub
+	l.failure.Context = ctx
+}
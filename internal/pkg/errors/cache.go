@@ -7,10 +7,13 @@ import (
 var (
 	ErrCachePathRequired       = stdErrors.New("cache path is required")
 	ErrInvalidCachePath        = stdErrors.New("invalid cache path")
+	ErrInvalidPackChecksum     = stdErrors.New("invalid pack checksum")
 	ErrInvalidRegistryRevision = stdErrors.New("invalid revision")
 	ErrInvalidRegistrySource   = stdErrors.New("invalid registry source")
 	ErrNoRegistriesAdded       = stdErrors.New("no registries were added to the cache")
+	ErrPackChecksumMismatch    = stdErrors.New("pack checksum mismatch")
 	ErrPackNameRequired        = stdErrors.New("pack name is required")
+	ErrPackNotCached           = stdErrors.New("pack not in local cache")
 	ErrPackNotFound            = stdErrors.New("pack not found")
 	ErrRegistryNameRequired    = stdErrors.New("registry name is required")
 	ErrRegistryNotFound        = stdErrors.New("registry not found")
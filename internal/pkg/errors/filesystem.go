@@ -0,0 +1,30 @@
+package errors
+
+import (
+	stdErrors "errors"
+	"fmt"
+)
+
+var (
+	ErrOpeningSourceFile = stdErrors.New("error opening source file")
+	ErrClosingSourceFile = stdErrors.New("error closing source file")
+	ErrOpeningDestFile   = stdErrors.New("error opening destination file")
+	ErrClosingDestFile   = stdErrors.New("error closing destination file")
+)
+
+// FilesystemContext* are the prefixes used to create the strings used in UI
+// error outputs which refer to filesystem destinations.
+const (
+	FilesystemContextDestDir  = "Destination Dir: "
+	FilesystemContextDestFile = "Destination File: "
+)
+
+// ErrNotDirectory is returned when an output path exists but is not a
+// directory, so cannot be written to.
+type ErrNotDirectory struct {
+	Path string
+}
+
+func (e *ErrNotDirectory) Error() string {
+	return fmt.Sprintf("output path %s exists and is not a directory", e.Path)
+}
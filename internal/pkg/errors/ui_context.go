@@ -14,18 +14,23 @@ var ErrNoTemplatesRendered = stdErrors.New("no templates were rendered by the re
 // UI errors outputs. If a prefix is used more than once, it should have a
 // const created.
 const (
-	UIContextPrefixGitRegistryURL = "Git Registry URL: "
-	UIContextPrefixPackName       = "Pack Name: "
-	UIContextPrefixPackPath       = "Pack Path: "
-	UIContextPrefixPackRef        = "Pack Ref: "
-	UIContextPrefixTemplateName   = "Template Name: "
-	UIContextPrefixJobName        = "Job Name: "
-	UIContextPrefixDeploymentName = "Deployment Name: "
-	UIContextPrefixRegion         = "Region: "
-	UIContextPrefixHCLRange       = "HCL Range: "
-	UIContextPrefixRegistryName   = "Registry Name: "
-	UIContextPrefixRegistryPath   = "Registry Path: "
-	UIContextPrefixRegistryTarget = "Registry Target: "
+	UIContextPrefixGitRegistryURL   = "Git Registry URL: "
+	UIContextPrefixPackName         = "Pack Name: "
+	UIContextPrefixPackPath         = "Pack Path: "
+	UIContextPrefixPackRef          = "Pack Ref: "
+	UIContextPrefixTemplateName     = "Template Name: "
+	UIContextPrefixTemplatePosition = "Template Position: "
+	UIContextPrefixJobName          = "Job Name: "
+	UIContextPrefixDeploymentName   = "Deployment Name: "
+	UIContextPrefixRegion           = "Region: "
+	UIContextPrefixHCLRange         = "HCL Range: "
+	UIContextPrefixVariableName     = "Variable Name: "
+	UIContextPrefixRegistryName     = "Registry Name: "
+	UIContextPrefixRegistryPath     = "Registry Path: "
+	UIContextPrefixRegistryTarget   = "Registry Target: "
+	UIContextPrefixExpectedChecksum = "Expected Checksum: "
+	UIContextPrefixActualChecksum   = "Actual Checksum: "
+	UIContextPrefixMetadataProblem  = "Metadata Problem: "
 )
 
 // UIErrorContext is used to store and manipulate error context strings used
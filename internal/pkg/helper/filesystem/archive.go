@@ -0,0 +1,213 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveFile is a single file to be written into an archive.
+type ArchiveFile struct {
+	// Name is the slash separated path of the file within the archive.
+	Name string
+
+	// Content is the file contents as a byte array.
+	Content []byte
+
+	// Mode is the permission of the file within the archive. If zero, 0644
+	// is used.
+	Mode os.FileMode
+}
+
+// WriteTarGz writes the passed files into a gzip compressed tarball on the
+// writer. Parent directories are added as entries so the relative structure
+// is preserved on extraction. Files are written in name order so the archive
+// layout is stable across runs.
+func WriteTarGz(w io.Writer, files []*ArchiveFile) (err error) {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	// Close the writers in order, making sure we don't overwrite an error that
+	// occurred while writing the entries.
+	defer func() {
+		if closeErr := tw.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing tar writer: %v", closeErr)
+		}
+		if closeErr := gzw.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing gzip writer: %v", closeErr)
+		}
+	}()
+
+	sorted := make([]*ArchiveFile, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	modTime := time.Now()
+	seenDirs := make(map[string]struct{})
+
+	for _, file := range sorted {
+		name := path.Clean(file.Name)
+
+		// Add any parent directories which have not yet been written.
+		for _, dir := range parentDirs(name) {
+			if _, ok := seenDirs[dir]; ok {
+				continue
+			}
+			seenDirs[dir] = struct{}{}
+
+			if err = tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     dir + "/",
+				Mode:     0755,
+				ModTime:  modTime,
+			}); err != nil {
+				return fmt.Errorf("error writing archive directory %s: %v", dir, err)
+			}
+		}
+
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+
+		if err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     int64(mode.Perm()),
+			Size:     int64(len(file.Content)),
+			ModTime:  modTime,
+		}); err != nil {
+			return fmt.Errorf("error writing archive header for %s: %v", name, err)
+		}
+
+		if _, err = tw.Write(file.Content); err != nil {
+			return fmt.Errorf("error writing archive content for %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// parentDirs returns the parent directories of the slash separated name,
+// ordered from the root down.
+func parentDirs(name string) []string {
+	var dirs []string
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// ExtractOption is a function that configures how ExtractTarGz handles the
+// entries of a tarball.
+type ExtractOption func(*extractConfig)
+
+type extractConfig struct {
+	skipSpecial bool
+	replace     bool
+}
+
+// WithSkipSpecialEntries skips symlinks and other entries which are not
+// regular files or directories, in place of rejecting the tarball.
+func WithSkipSpecialEntries() ExtractOption {
+	return func(c *extractConfig) {
+		c.skipSpecial = true
+	}
+}
+
+// WithReplaceExisting replaces files which already exist, such as those of
+// an earlier entry with the same name, in place of failing the extraction.
+func WithReplaceExisting() ExtractOption {
+	return func(c *extractConfig) {
+		c.replace = true
+	}
+}
+
+// ExtractTarGz extracts the gzip compressed tarball read from r into the
+// existing directory dir. Only regular files and directories are supported,
+// and entries with absolute paths or which would be extracted outside of dir
+// are rejected. By default, other entries are rejected and no file may be
+// extracted over an existing one; the options relax this.
+func ExtractTarGz(r io.Reader, dir string, opts ...ExtractOption) error {
+	var cfg extractConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("error reading gzip stream: %v", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive: %v", err)
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %s is outside of the extraction directory", hdr.Name)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return fmt.Errorf("error creating directory %s: %v", name, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("error creating directory %s: %v", path.Dir(name), err)
+			}
+			if err := extractFile(tr, dest, os.FileMode(hdr.Mode).Perm(), cfg.replace); err != nil {
+				return fmt.Errorf("error extracting %s: %v", name, err)
+			}
+		case tar.TypeXGlobalHeader:
+			// Global headers, such as those written by git archive, only
+			// hold metadata.
+		default:
+			if cfg.skipSpecial {
+				continue
+			}
+			return fmt.Errorf("archive entry %s is not a regular file or directory", hdr.Name)
+		}
+	}
+}
+
+// extractFile writes the content read from r to a new file at dest. When
+// replace is set, any existing file at dest is removed first.
+func extractFile(r io.Reader, dest string, mode os.FileMode, replace bool) (err error) {
+	if replace {
+		// Remove rather than truncate, so an existing symlink at dest is
+		// never followed.
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,134 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTarGz(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	err := WriteTarGz(&buf, []*ArchiveFile{
+		{Name: "outputs.tpl", Content: []byte("outputs")},
+		{Name: "example/web.nomad", Content: []byte("web")},
+		{Name: "example/api.nomad", Content: []byte("api"), Mode: 0755},
+	})
+	require.NoError(t, err)
+
+	gzr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+
+	var names []string
+	contents := make(map[string]string)
+	modes := make(map[string]int64)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		names = append(names, hdr.Name)
+		modes[hdr.Name] = hdr.Mode
+
+		if hdr.Typeflag == tar.TypeReg {
+			content, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			contents[hdr.Name] = string(content)
+		}
+	}
+
+	require.Equal(t, []string{"example/", "example/api.nomad", "example/web.nomad", "outputs.tpl"}, names)
+	require.Equal(t, "api", contents["example/api.nomad"])
+	require.Equal(t, "web", contents["example/web.nomad"])
+	require.Equal(t, "outputs", contents["outputs.tpl"])
+	require.Equal(t, int64(0755), modes["example/api.nomad"])
+	require.Equal(t, int64(0644), modes["example/web.nomad"])
+}
+
+func TestExtractTarGz(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTarGz(&buf, []*ArchiveFile{
+		{Name: "example/metadata.hcl", Content: []byte("metadata")},
+		{Name: "example/templates/run.sh.tpl", Content: []byte("run"), Mode: 0755},
+	}))
+
+	dir := t.TempDir()
+	require.NoError(t, ExtractTarGz(&buf, dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "example", "metadata.hcl"))
+	require.NoError(t, err)
+	require.Equal(t, "metadata", string(content))
+
+	info, err := os.Stat(filepath.Join(dir, "example", "templates", "run.sh.tpl"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// Entries outside of the extraction directory are rejected.
+	for _, name := range []string{"../escape", "/abs", "a/../../escape"} {
+		buf.Reset()
+		gzw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gzw)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644}))
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+
+		err := ExtractTarGz(&buf, t.TempDir())
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), "outside of the extraction directory")
+	}
+
+	// Symlinks and other special entries are rejected.
+	buf.Reset()
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	dir = t.TempDir()
+	err = ExtractTarGz(&buf, dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not a regular file or directory")
+	_, err = os.Lstat(filepath.Join(dir, "link"))
+	require.True(t, os.IsNotExist(err))
+
+	// The options skip special entries and replace duplicate files.
+	buf.Reset()
+	gzw = gzip.NewWriter(&buf)
+	tw = tar.NewWriter(gzw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc/passwd"}))
+	for _, content := range []string{"first", "second"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "file", Mode: 0644, Size: int64(len(content))}))
+		_, err = tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	archive := append([]byte(nil), buf.Bytes()...)
+
+	err = ExtractTarGz(bytes.NewReader(archive), t.TempDir(), WithSkipSpecialEntries())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "file exists")
+
+	dir = t.TempDir()
+	require.NoError(t, ExtractTarGz(bytes.NewReader(archive), dir, WithSkipSpecialEntries(), WithReplaceExisting()))
+	_, err = os.Lstat(filepath.Join(dir, "link"))
+	require.True(t, os.IsNotExist(err))
+	content, err = os.ReadFile(filepath.Join(dir, "file"))
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+}
@@ -0,0 +1,66 @@
+//go:build linux
+// +build linux
+
+package filesystem
+
+import (
+	"context"
+	stdErrors "errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyRangeSize is the number of bytes copied by each copy_file_range call.
+// Cancellation is checked between each call, so this bounds the amount of
+// work done after a context is canceled.
+const copyRangeSize = 8 * 1024 * 1024
+
+// copyFileRangeSyscall performs the copy_file_range system call. It is a
+// variable so tests can force the fallback to a buffered copy.
+var copyFileRangeSyscall = unix.CopyFileRange
+
+// copyFileRange copies from src to dst using copy_file_range, which copies
+// within the kernel and allows filesystems supporting reflinks to share the
+// data rather than duplicate it. It returns false if the copy should fall
+// back to a buffered copy, such as when the filesystems do not support the
+// system call, in which case the file offsets are left after the bytes
+// written so the buffered copy can continue from them.
+func copyFileRange(ctx context.Context, dst, src *os.File) (written int64, handled bool, err error) {
+	srcFd, dstFd := int(src.Fd()), int(dst.Fd())
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return written, true, err
+		}
+
+		n, rangeErr := copyFileRangeSyscall(srcFd, nil, dstFd, nil, copyRangeSize, 0)
+		if rangeErr != nil {
+			if copyFileRangeUnsupported(rangeErr) {
+				return written, false, nil
+			}
+			return written, true, rangeErr
+		}
+
+		// Some filesystems, such as procfs, report files as empty, so the
+		// first call copying nothing is not trusted to mean the end of the
+		// file.
+		if n == 0 {
+			return written, written > 0, nil
+		}
+		written += int64(n)
+	}
+}
+
+// copyFileRangeUnsupported returns whether the copy_file_range error
+// indicates the copy is not supported between the files, rather than the copy
+// itself failing. Errors such as EIO and EPERM are real failures of the copy,
+// which a buffered copy would only hide, so they are returned to the caller.
+func copyFileRangeUnsupported(err error) bool {
+	for _, errno := range []unix.Errno{unix.ENOSYS, unix.EXDEV, unix.EINVAL, unix.EOPNOTSUPP} {
+		if stdErrors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package filesystem
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyFile_CopyFileRangeFallback(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewTestLogger(t.Log)
+
+	// The content spans several buffers, so the fallback is exercised past
+	// the first chunk.
+	content := bytes.Repeat([]byte("nomad-pack "), 3*copyBufferSize/11+7)
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.WriteFile(src, content, 0640))
+
+	testCases := []struct {
+		name    string
+		syscall func(int, *int64, int, *int64, int, int) (int, error)
+	}{
+		{
+			name:    "fast path",
+			syscall: unix.CopyFileRange,
+		},
+		{
+			name: "unsupported",
+			syscall: func(int, *int64, int, *int64, int, int) (int, error) {
+				return 0, unix.ENOSYS
+			},
+		},
+		{
+			name: "unsupported after partial copy",
+			syscall: func(srcFd int, srcOff *int64, dstFd int, dstOff *int64, _ int, flags int) (int, error) {
+				pos, err := unix.Seek(srcFd, 0, 1)
+				require.NoError(t, err)
+				if pos > 0 {
+					return 0, unix.EXDEV
+				}
+				return unix.CopyFileRange(srcFd, srcOff, dstFd, dstOff, 100, flags)
+			},
+		},
+		{
+			name: "reported empty",
+			syscall: func(int, *int64, int, *int64, int, int) (int, error) {
+				return 0, nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(orig func(int, *int64, int, *int64, int, int) (int, error)) {
+				copyFileRangeSyscall = orig
+			}(copyFileRangeSyscall)
+			copyFileRangeSyscall = tc.syscall
+
+			dst := filepath.Join(dir, "dst")
+			defer os.Remove(dst)
+
+			require.NoError(t, CopyFile(src, dst, logger))
+
+			copied, err := os.ReadFile(dst)
+			require.NoError(t, err)
+			require.Equal(t, content, copied)
+
+			info, err := os.Stat(dst)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+		})
+	}
+}
+
+func TestCopyFile_CopyFileRangeError(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewTestLogger(t.Log)
+
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.WriteFile(src, []byte("content"), 0644))
+
+	defer func(orig func(int, *int64, int, *int64, int, int) (int, error)) {
+		copyFileRangeSyscall = orig
+	}(copyFileRangeSyscall)
+
+	// Errors other than the copy being unsupported fail the copy, rather
+	// than falling back to a buffered copy, and the partially written
+	// destination is removed.
+	for _, errno := range []unix.Errno{unix.ENOSPC, unix.EIO, unix.EPERM} {
+		copyFileRangeSyscall = func(int, *int64, int, *int64, int, int) (int, error) {
+			return 0, errno
+		}
+
+		dst := filepath.Join(dir, "dst")
+		err := CopyFile(src, dst, logger)
+		require.ErrorIs(t, err, errno)
+		require.NoFileExists(t, dst)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package filesystem
+
+import (
+	"context"
+	"os"
+)
+
+// copyFileRange always falls back to a buffered copy, as there is no fast
+// path on this platform.
+func copyFileRange(_ context.Context, _, _ *os.File) (int64, bool, error) {
+	return 0, false, nil
+}
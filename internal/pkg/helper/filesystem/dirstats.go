@@ -0,0 +1,151 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TreeStats details the contents of a directory tree, as returned by
+// DirStats.
+type TreeStats struct {
+	// Bytes is the total size of the regular files within the tree.
+	Bytes int64
+
+	// Files and Dirs are the number of regular files and directories within
+	// the tree, not including the directory itself.
+	Files int
+	Dirs  int
+
+	// SymlinksSkipped is the number of symlinks which were not followed.
+	SymlinksSkipped int
+}
+
+// DirStatsError is returned by DirStats when parts of the tree could not be
+// read, such as directories without read permission. The stats returned
+// along with it cover the rest of the tree.
+type DirStatsError struct {
+	// Paths are the paths which could not be read, in the order they were
+	// walked.
+	Paths []string
+
+	// Errs holds the error for each of the Paths.
+	Errs []error
+}
+
+func (e *DirStatsError) Error() string {
+	if len(e.Paths) == 1 {
+		return fmt.Sprintf("failed to read %s: %v", e.Paths[0], e.Errs[0])
+	}
+	return fmt.Sprintf("failed to read %d paths, including %s: %v", len(e.Paths), e.Paths[0], e.Errs[0])
+}
+
+// Unwrap returns the error of the first path which could not be read, so the
+// cause can be checked using errors.Is, such as for fs.ErrPermission.
+func (e *DirStatsError) Unwrap() error { return e.Errs[0] }
+
+// DirStatsOption configures DirStats.
+type DirStatsOption func(*dirStatsConfig)
+
+type dirStatsConfig struct {
+	followSymlinks bool
+
+	// ancestors holds the identity of each directory being walked, so that
+	// followed symlinks pointing back up the tree are not walked forever.
+	ancestors map[FileID]struct{}
+}
+
+// WithStatsFollowSymlinks controls whether DirStats follows symlinks,
+// counting their targets. Symlinks leading back to a directory already being
+// walked are not followed. By default, symlinks are skipped.
+func WithStatsFollowSymlinks(follow bool) DirStatsOption {
+	return func(cfg *dirStatsConfig) { cfg.followSymlinks = follow }
+}
+
+// DirStats walks the directory tree at dir once, returning the total size,
+// and number, of the regular files within it, along with the number of
+// directories. If parts of the tree cannot be read, the walk continues with
+// the rest of it, and the stats are returned along with a *DirStatsError
+// listing the paths which could not be read. An error is returned without
+// stats if dir itself is not a readable directory.
+func DirStats(dir string, opts ...DirStatsOption) (*TreeStats, error) {
+	cfg := &dirStatsConfig{ancestors: make(map[FileID]struct{})}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	if _, err := os.ReadDir(dir); err != nil {
+		return nil, err
+	}
+
+	stats := &TreeStats{}
+	statsErr := &DirStatsError{}
+	walkDirStats(dir, cfg, stats, statsErr)
+
+	if len(statsErr.Paths) > 0 {
+		return stats, statsErr
+	}
+	return stats, nil
+}
+
+// walkDirStats adds the contents of dir to stats, recording any paths which
+// cannot be read in statsErr.
+func walkDirStats(dir string, cfg *dirStatsConfig, stats *TreeStats, statsErr *DirStatsError) {
+	fail := func(p string, err error) {
+		statsErr.Paths = append(statsErr.Paths, p)
+		statsErr.Errs = append(statsErr.Errs, err)
+	}
+
+	id, err := FileIdentity(dir)
+	if err != nil {
+		fail(dir, err)
+		return
+	}
+	if _, ok := cfg.ancestors[id]; ok {
+		stats.SymlinksSkipped++
+		return
+	}
+	cfg.ancestors[id] = struct{}{}
+	defer delete(cfg.ancestors, id)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fail(dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+
+		var info os.FileInfo
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !cfg.followSymlinks {
+				stats.SymlinksSkipped++
+				continue
+			}
+			info, err = os.Stat(entryPath)
+		} else {
+			info, err = entry.Info()
+		}
+		if err != nil {
+			fail(entryPath, err)
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			stats.Dirs++
+			walkDirStats(entryPath, cfg, stats, statsErr)
+		case info.Mode().IsRegular():
+			stats.Files++
+			stats.Bytes += info.Size()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeStatsTree writes a nested directory tree within dir, holding 4 files
+// totalling 15 bytes across 3 directories.
+func writeStatsTree(t *testing.T, dir string) {
+	t.Helper()
+
+	files := map[string]string{
+		"a.txt":           "hello",
+		"one/b.txt":       "abc",
+		"one/two/c.txt":   "abcd",
+		"one/three/d.txt": "xyz",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestDirStats(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeStatsTree(t, dir)
+
+	stats, err := DirStats(dir)
+	require.NoError(t, err)
+	require.Equal(t, &TreeStats{Bytes: 15, Files: 4, Dirs: 3}, stats)
+
+	_, err = DirStats(filepath.Join(dir, "a.txt"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not a directory")
+
+	_, err = DirStats(filepath.Join(dir, "missing"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDirStats_Symlinks(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+
+	dir := t.TempDir()
+	writeStatsTree(t, dir)
+	require.NoError(t, os.Symlink("one", filepath.Join(dir, "link")))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(dir, "file-link")))
+
+	// A symlink back up the tree is not walked more than once.
+	require.NoError(t, os.Symlink("..", filepath.Join(dir, "one", "loop")))
+
+	stats, err := DirStats(dir)
+	require.NoError(t, err)
+	require.Equal(t, &TreeStats{Bytes: 15, Files: 4, Dirs: 3, SymlinksSkipped: 3}, stats)
+
+	// Following symlinks counts the linked directory, its subdirectories and
+	// files, along with the linked file. Each loop symlink found is counted
+	// as a directory but is not walked again.
+	stats, err = DirStats(dir, WithStatsFollowSymlinks(true))
+	require.NoError(t, err)
+	require.Equal(t, &TreeStats{Bytes: 30, Files: 8, Dirs: 8, SymlinksSkipped: 2}, stats)
+}
+
+func TestDirStats_PermissionDenied(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for root")
+	}
+
+	dir := t.TempDir()
+	writeStatsTree(t, dir)
+
+	denied := filepath.Join(dir, "one", "two")
+	require.NoError(t, os.Chmod(denied, 0))
+	t.Cleanup(func() { _ = os.Chmod(denied, 0755) })
+
+	// The rest of the tree is still counted, along with the unreadable
+	// directory itself.
+	stats, err := DirStats(dir)
+	require.Equal(t, &TreeStats{Bytes: 11, Files: 3, Dirs: 3}, stats)
+
+	var statsErr *DirStatsError
+	require.True(t, errors.As(err, &statsErr))
+	require.Equal(t, []string{denied}, statsErr.Paths)
+	require.True(t, errors.Is(err, fs.ErrPermission))
+
+	// The directory itself being unreadable returns no stats.
+	require.NoError(t, os.Chmod(dir, 0))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0755) })
+
+	stats, err = DirStats(dir)
+	require.Nil(t, stats)
+	require.True(t, errors.Is(err, fs.ErrPermission))
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the identity of the file at path, which is its device
+// and inode, so the same file is identified whichever path it is reached by.
+func fileIdentity(path string) (FileID, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return FileID{}, err
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return FileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, nil
+	}
+	return realPathIdentity(path)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package filesystem
+
+// fileIdentity returns the identity of the file at path. There is no inode
+// to identify the file by on this platform, so it is identified by its real
+// path instead.
+func fileIdentity(path string) (FileID, error) {
+	return realPathIdentity(path)
+}
@@ -1,68 +1,112 @@
 package filesystem
 
 import (
+	"context"
+	stdErrors "errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"os/user"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 )
 
-// CopyFile copies a file from one path to another
-func CopyFile(sourcePath, destinationPath string, logger logging.Logger) (err error) {
+// CopyFile copies a file from one path to another. If the source is a symlink
+// the contents of its target are copied, as the caller named the file
+// explicitly. Skipping symlinks only applies to the entries walked by CopyDir.
+func CopyFile(sourcePath, destinationPath string, logger logging.Logger, opts ...CopyOption) error {
+	return CopyFileContext(context.Background(), sourcePath, destinationPath, logger, opts...)
+}
+
+// CopyFileContext copies a file from one path to another in the same way as
+// CopyFile. If the context is canceled during the copy, it is aborted and the
+// partially written destination file is removed.
+func CopyFileContext(ctx context.Context, sourcePath, destinationPath string, logger logging.Logger, opts ...CopyOption) error {
+	cfg := &copyConfig{ctx: ctx}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return copyFile(sourcePath, destinationPath, logger, cfg)
+}
+
+func copyFile(sourcePath, destinationPath string, logger logging.Logger, cfg *copyConfig) (err error) {
 	// Open the source file
 	sourceFile, err := os.Open(sourcePath)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error opening source file: %s", err))
+		logging.Debug(logger, errors.ErrOpeningSourceFile.Error(), logging.F("source", sourcePath), logging.F("error", err))
 		return
 	}
 
-	// Set up a deferred close handler
+	// Set up a deferred close handler, making sure not to mask an earlier
+	// error.
 	defer func() {
-		if err = sourceFile.Close(); err != nil {
-			logger.Debug(fmt.Sprintf("error closing source file: %s", err))
+		if closeErr := sourceFile.Close(); closeErr != nil {
+			logging.Debug(logger, errors.ErrClosingSourceFile.Error(), logging.F("source", sourcePath), logging.F("error", closeErr))
+			if err == nil {
+				err = closeErr
+			}
 		}
 	}()
 
 	// Open the destination file
 	destinationFile, err := os.Create(destinationPath)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error opening destination file: %s", err))
+		logging.Debug(logger, errors.ErrOpeningDestFile.Error(), logging.F("dest", destinationPath), logging.F("error", err))
 		return
 	}
-	// Set up a deferred close handler
+
+	// Remove the partially written destination file if the copy fails, such
+	// as when the context is canceled. This is deferred before the close
+	// handler so that it runs once the file is closed.
 	defer func() {
-		if err = destinationFile.Close(); err != nil {
-			logger.Debug(fmt.Sprintf("error closing destination file: %s", err))
+		if err != nil {
+			_ = os.Remove(destinationPath)
+		}
+	}()
+
+	// Set up a deferred close handler, making sure not to mask an earlier
+	// error.
+	defer func() {
+		if closeErr := destinationFile.Close(); closeErr != nil {
+			logging.Debug(logger, errors.ErrClosingDestFile.Error(), logging.F("dest", destinationPath), logging.F("error", closeErr))
+			if err == nil {
+				err = closeErr
+			}
 		}
 	}()
 
 	// Copy the file
-	_, err = io.Copy(destinationFile, sourceFile)
+	written, err := copyFileContents(cfg.ctx, destinationFile, sourceFile)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error copying file: %s", err))
+		logging.Debug(logger, "error copying file", logging.F("source", sourcePath), logging.F("dest", destinationPath), logging.F("error", err))
 		return
 	}
+	cfg.stats.FilesCopied++
+	cfg.stats.BytesWritten += written
 
 	// Sync the file contents
 	err = destinationFile.Sync()
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error syncing destination file: %s", err))
+		logging.Debug(logger, "error syncing destination file", logging.F("dest", destinationPath), logging.F("error", err))
 		return
 	}
 
 	// Get the source file info so we can copy the permissions
 	sourceFileInfo, err := os.Stat(sourcePath)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error getting source file info: %s", err))
+		logging.Debug(logger, "error getting source file info", logging.F("source", sourcePath), logging.F("error", err))
 		return
 	}
 
 	// Set the destination file permissions from the source file mode
 	err = os.Chmod(destinationPath, sourceFileInfo.Mode())
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error getting setting destination file permissions: %s", err))
+		logging.Debug(logger, "error setting destination file permissions", logging.F("dest", destinationPath), logging.F("error", err))
 		return
 	}
 
@@ -70,8 +114,295 @@ func CopyFile(sourcePath, destinationPath string, logger logging.Logger) (err er
 	return
 }
 
-// CopyDir recursively copies a directory.
-func CopyDir(sourceDir string, destinationDir string, logger logging.Logger) (err error) {
+// copyFileContents copies the contents of src to dst, using the platform's
+// fast path where the filesystems support it. Otherwise, or if the fast path
+// stops part way, the remainder is copied using copyContents.
+func copyFileContents(ctx context.Context, dst, src *os.File) (int64, error) {
+	written, handled, err := copyFileRange(ctx, dst, src)
+	if handled || err != nil {
+		return written, err
+	}
+
+	n, err := copyContents(ctx, dst, src)
+	return written + n, err
+}
+
+// copyBufferSize is the size of the buffer used when copying file contents.
+// Cancellation is checked between each buffer, so this bounds the amount of
+// work done after a context is canceled.
+const copyBufferSize = 32 * 1024
+
+// copyContents copies from src to dst until EOF, checking the context for
+// cancellation between each chunk.
+func copyContents(ctx context.Context, dst io.Writer, src io.Reader) (written int64, err error) {
+	buf := make([]byte, copyBufferSize)
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			wn, writeErr := dst.Write(buf[:n])
+			written += int64(wn)
+			if writeErr != nil {
+				return written, writeErr
+			}
+			if wn != n {
+				return written, io.ErrShortWrite
+			}
+		}
+
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// CopyOption configures optional behaviour of CopyDir and CopyFile.
+type CopyOption func(*copyConfig)
+
+// copyConfig holds the configuration for a single CopyDir call and the state
+// needed while recursing.
+type copyConfig struct {
+	// ctx is checked for cancellation between files and while copying file
+	// contents.
+	ctx context.Context
+
+	followSymlinks bool
+
+	// merge allows copying into an existing destination directory, with
+	// mergePolicy deciding how existing destination files are handled.
+	merge       bool
+	mergePolicy MergePolicy
+
+	// conflicts collects the destination paths which conflicted during a
+	// merge using the MergeError policy.
+	conflicts []string
+
+	// stats tracks the work performed by the copy.
+	stats CopyStats
+
+	// ancestors maps the identity of each directory currently being copied
+	// to its path, so that symlinks pointing back up the tree can be
+	// detected.
+	ancestors map[FileID]string
+
+	// exclude holds the glob patterns of entries which are not copied,
+	// matched against their path relative to sourceRoot.
+	exclude    []string
+	sourceRoot string
+
+	// ignores holds the parsed ignore files of the directories currently
+	// being copied, from the source directory down.
+	ignores []*ignoreList
+}
+
+// WithFollowSymlinks controls whether CopyDir resolves the symlinks within
+// the source directory and copies the contents of their targets. When
+// disabled, which is the default, symlinks are skipped. CopyFile always
+// follows a symlink passed as its source.
+func WithFollowSymlinks(follow bool) CopyOption {
+	return func(cfg *copyConfig) { cfg.followSymlinks = follow }
+}
+
+// WithExclude skips copying the entries of CopyDir which match any of the
+// glob patterns, using the syntax of filepath.Match. Patterns are matched
+// against the slash separated path of the entry relative to the source
+// directory, such as templates/example.nomad.tpl. Patterns without a slash
+// are also matched against the entry name, so that *.swp excludes swap files
+// at any depth. Excluding a directory skips all of its contents.
+func WithExclude(patterns ...string) CopyOption {
+	return func(cfg *copyConfig) { cfg.exclude = append(cfg.exclude, patterns...) }
+}
+
+// excluded reports whether the source path matches any of the exclude
+// patterns. The patterns are validated before copying, so match errors can
+// be ignored.
+func (cfg *copyConfig) excluded(sourcePath string) bool {
+	rel, err := filepath.Rel(cfg.sourceRoot, sourcePath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range cfg.exclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignored reports whether the source path is ignored by the ignore files of
+// the directories being copied. As with gitignore, the file of the deepest
+// directory with a matching pattern decides.
+func (cfg *copyConfig) ignored(sourcePath string, isDir bool) bool {
+	for i := len(cfg.ignores) - 1; i >= 0; i-- {
+		if matched, ignored := cfg.ignores[i].match(sourcePath, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
+
+// MergePolicy controls how CopyDir handles files which already exist in the
+// destination when merging into an existing directory.
+type MergePolicy int
+
+const (
+	// MergeError records existing destination files as conflicts, which are
+	// returned as a MergeConflictError once the copy completes.
+	MergeError MergePolicy = iota
+
+	// MergeSkip leaves existing destination files untouched.
+	MergeSkip
+
+	// MergeOverwrite replaces existing destination files.
+	MergeOverwrite
+)
+
+// WithMerge allows CopyDir to copy into an existing destination directory,
+// descending into existing subdirectories and handling existing files using
+// the passed policy. Without this option, CopyDir errors if the destination
+// exists.
+func WithMerge(policy MergePolicy) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.merge = true
+		cfg.mergePolicy = policy
+	}
+}
+
+// ErrCopyCycle is wrapped by the CopyCycleError returned when following
+// symlinks leads a copy back into a directory it is already copying.
+var ErrCopyCycle = stdErrors.New("symlink cycle detected")
+
+// CopyCycleError is returned by CopyDir when following symlinks, and a
+// symlink resolves to a directory which is already being copied, which
+// would otherwise recurse forever. Path is the path at which the cycle was
+// found, and Ancestor the path of the directory it leads back to.
+type CopyCycleError struct {
+	Path     string
+	Ancestor string
+}
+
+func (e *CopyCycleError) Error() string {
+	return fmt.Sprintf("%s at %s, which leads back to %s", ErrCopyCycle, e.Path, e.Ancestor)
+}
+
+// Unwrap returns ErrCopyCycle, so the error can be identified using
+// errors.Is.
+func (e *CopyCycleError) Unwrap() error { return ErrCopyCycle }
+
+// FileID identifies a file or directory independently of the path used to
+// reach it, so that paths through symlinks to the same directory are seen
+// to be the same. On Unix it is the device and inode of the file.
+type FileID struct {
+	dev  uint64
+	ino  uint64
+	path string
+}
+
+// FileIdentity returns the identity of the file or directory at path,
+// following any symlinks.
+func FileIdentity(path string) (FileID, error) {
+	return fileIdentity(path)
+}
+
+// realPathIdentity identifies the file at path by its real path, for when
+// the platform does not provide a better identity.
+func realPathIdentity(path string) (FileID, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return FileID{}, err
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return FileID{}, err
+	}
+	return FileID{path: abs}, nil
+}
+
+// MergeConflictError is returned by CopyDir when merging with the MergeError
+// policy and one or more destination paths already exist. Paths which do not
+// conflict are still copied. A destination path is also considered a
+// conflict, regardless of policy, when it is a directory where the source is
+// a file, or vice versa.
+type MergeConflictError struct {
+	Paths []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("destination paths already exist: %s", strings.Join(e.Paths, ", "))
+}
+
+// CopyStats details the work performed by CopyDirWithStats.
+type CopyStats struct {
+	FilesCopied     int
+	DirsCreated     int
+	BytesWritten    int64
+	SymlinksSkipped int
+	EntriesExcluded int
+}
+
+// CopyDir recursively copies a directory. Entries matched by the patterns of
+// the IgnoreFileName files within the source directory are skipped.
+func CopyDir(sourceDir string, destinationDir string, logger logging.Logger, opts ...CopyOption) error {
+	_, err := copyDirWithStats(context.Background(), sourceDir, destinationDir, logger, opts...)
+	return err
+}
+
+// CopyDirContext recursively copies a directory in the same way as CopyDir.
+// The context is checked between files, and if canceled the copy is aborted
+// and any partially written file removed.
+func CopyDirContext(ctx context.Context, sourceDir string, destinationDir string, logger logging.Logger, opts ...CopyOption) error {
+	_, err := copyDirWithStats(ctx, sourceDir, destinationDir, logger, opts...)
+	return err
+}
+
+// CopyDirWithStats recursively copies a directory, returning statistics about
+// the copy. The stats are returned even on error, reflecting the work
+// performed before the failure.
+func CopyDirWithStats(sourceDir string, destinationDir string, logger logging.Logger, opts ...CopyOption) (*CopyStats, error) {
+	return copyDirWithStats(context.Background(), sourceDir, destinationDir, logger, opts...)
+}
+
+func copyDirWithStats(ctx context.Context, sourceDir string, destinationDir string, logger logging.Logger, opts ...CopyOption) (*CopyStats, error) {
+	cfg := &copyConfig{ctx: ctx, ancestors: make(map[FileID]string), sourceRoot: filepath.Clean(sourceDir)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	for _, pattern := range cfg.exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+			logging.Debug(logger, "invalid exclude pattern", logging.F("pattern", pattern), logging.F("error", err))
+			return &cfg.stats, err
+		}
+	}
+
+	if err := copyDir(sourceDir, destinationDir, logger, cfg); err != nil {
+		return &cfg.stats, err
+	}
+
+	if len(cfg.conflicts) > 0 {
+		err := &MergeConflictError{Paths: cfg.conflicts}
+		logging.Debug(logger, "destination paths already exist", logging.F("paths", cfg.conflicts))
+		return &cfg.stats, err
+	}
+	return &cfg.stats, nil
+}
+
+func copyDir(sourceDir string, destinationDir string, logger logging.Logger, cfg *copyConfig) (err error) {
 	// Clean the directory paths
 	sourceDir = filepath.Clean(sourceDir)
 	destinationDir = filepath.Clean(destinationDir)
@@ -79,65 +410,148 @@ func CopyDir(sourceDir string, destinationDir string, logger logging.Logger) (er
 	// Get the source directory info to validate that it is a directory
 	sourceDirInfo, err := os.Stat(sourceDir)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error getting source directory info: %s", err))
+		logging.Debug(logger, "error getting source directory info", logging.F("source", sourceDir), logging.F("error", err))
 		return
 	}
 
 	// Throw error if not a directory
-	// TODO: Might need to handle symlinks.
 	if !sourceDirInfo.IsDir() {
 		err = fmt.Errorf("source is not a directory")
-		logger.Debug(err.Error())
+		logging.Debug(logger, err.Error(), logging.F("source", sourceDir))
 		return
 	}
 
-	// Make sure the destination directory doesn't already exist
-	_, err = os.Stat(destinationDir)
-	if err != nil && !os.IsNotExist(err) {
-		logger.Debug(fmt.Sprintf("error getting destination file info: %s", err))
+	// Identify the source so that we can detect a followed symlink pointing
+	// back at a directory we are already copying.
+	sourceID, err := FileIdentity(sourceDir)
+	if err != nil {
+		logging.Debug(logger, "error resolving source directory path", logging.F("source", sourceDir), logging.F("error", err))
 		return
 	}
-	// throw error if it does exist
-	if err == nil {
-		err = fmt.Errorf("destination already exists")
-		logger.Debug(err.Error())
+	if ancestor, ok := cfg.ancestors[sourceID]; ok {
+		err = &CopyCycleError{Path: sourceDir, Ancestor: ancestor}
+		logging.Debug(logger, "symlink cycle detected", logging.F("source", sourceDir), logging.F("ancestor", ancestor))
 		return
 	}
+	cfg.ancestors[sourceID] = sourceDir
+	defer delete(cfg.ancestors, sourceID)
 
-	// Make the destination direction and copy the file permissions
-	err = os.MkdirAll(destinationDir, sourceDirInfo.Mode())
+	// The patterns of the directory's ignore file apply to its entries
+	// along with those of its ancestors.
+	ignore, err := readIgnoreFile(sourceDir)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error creating destination directory: %s", err))
+		logging.Debug(logger, "error reading ignore file", logging.F("source", sourceDir), logging.F("error", err))
+		return
+	}
+	if ignore != nil {
+		cfg.ignores = append(cfg.ignores, ignore)
+		defer func() { cfg.ignores = cfg.ignores[:len(cfg.ignores)-1] }()
+	}
+
+	// Make sure the destination directory doesn't already exist, unless we
+	// are merging into it.
+	destinationDirInfo, err := os.Stat(destinationDir)
+	if err != nil && !os.IsNotExist(err) {
+		logging.Debug(logger, "error getting destination file info", logging.F("dest", destinationDir), logging.F("error", err))
+		return
+	}
+
+	switch {
+	case err != nil:
+		// Make the destination direction and copy the file permissions
+		err = os.MkdirAll(destinationDir, sourceDirInfo.Mode())
+		if err != nil {
+			logging.Debug(logger, "error creating destination directory", logging.F("dest", destinationDir), logging.F("error", err))
+			return
+		}
+		cfg.stats.DirsCreated++
+	case !cfg.merge:
+		// throw error if it does exist
+		err = fmt.Errorf("destination already exists")
+		logging.Debug(logger, err.Error(), logging.F("dest", destinationDir))
 		return
+	case !destinationDirInfo.IsDir():
+		// A file can't be merged into, so record it as a conflict.
+		logging.Debug(logger, "destination is not a directory", logging.F("dest", destinationDir))
+		cfg.conflicts = append(cfg.conflicts, destinationDir)
+		return nil
 	}
 
 	// Read the contents of the source directory
 	sourceEntries, err := os.ReadDir(sourceDir)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("error reading source directory entries: %s", err))
+		logging.Debug(logger, "error reading source directory entries", logging.F("source", sourceDir), logging.F("error", err))
 		return
 	}
 
 	// Iterate over all the directory entries and copy them
 	for _, sourceEntry := range sourceEntries {
+		// Stop copying if we have been canceled.
+		if err = cfg.ctx.Err(); err != nil {
+			logging.Debug(logger, "copy canceled", logging.F("source", sourceDir), logging.F("error", err))
+			return
+		}
+
 		// Build the source and destination paths
 		sourcePath := filepath.Join(sourceDir, sourceEntry.Name())
 		destinationPath := filepath.Join(destinationDir, sourceEntry.Name())
 
+		if cfg.excluded(sourcePath) {
+			logging.Debug(logger, "skipping excluded entry", logging.F("source", sourcePath))
+			cfg.stats.EntriesExcluded++
+			continue
+		}
+
+		isDir := sourceEntry.IsDir()
+
+		// Symlinks are skipped unless we have been asked to follow them, in
+		// which case the target decides whether we copy a file or directory.
+		if sourceEntry.Type()&os.ModeSymlink != 0 {
+			if !cfg.followSymlinks {
+				logging.Debug(logger, "skipping symlink", logging.F("source", sourcePath))
+				cfg.stats.SymlinksSkipped++
+				continue
+			}
+
+			var targetInfo os.FileInfo
+			targetInfo, err = os.Stat(sourcePath)
+			if err != nil {
+				logging.Debug(logger, "error resolving symlink", logging.F("source", sourcePath), logging.F("error", err))
+				return
+			}
+			isDir = targetInfo.IsDir()
+		}
+
+		if cfg.ignored(sourcePath, isDir) {
+			logging.Debug(logger, "skipping ignored entry", logging.F("source", sourcePath))
+			cfg.stats.EntriesExcluded++
+			continue
+		}
+
 		// If a directory, then recurse, else copy all files
-		if sourceEntry.IsDir() {
-			err = CopyDir(sourcePath, destinationPath, logger)
+		if isDir {
+			err = copyDir(sourcePath, destinationPath, logger, cfg)
 			if err != nil {
 				return
 			}
 		} else {
-			// Skip symlinks.
-			if sourceEntry.Type()&os.ModeSymlink != 0 {
-				continue
+			// When merging, check whether the file already exists and apply
+			// the merge policy.
+			if cfg.merge {
+				if destinationInfo, statErr := os.Stat(destinationPath); statErr == nil {
+					switch {
+					case destinationInfo.IsDir(), cfg.mergePolicy == MergeError:
+						cfg.conflicts = append(cfg.conflicts, destinationPath)
+						continue
+					case cfg.mergePolicy == MergeSkip:
+						logging.Debug(logger, "skipping existing destination file", logging.F("source", sourcePath), logging.F("dest", destinationPath))
+						continue
+					}
+				}
 			}
 
 			// Copy file from source directory to destination directory
-			err = CopyFile(sourcePath, destinationPath, logger)
+			err = copyFile(sourcePath, destinationPath, logger, cfg)
 			if err != nil {
 				return
 			}
@@ -146,3 +560,506 @@ func CopyDir(sourceDir string, destinationDir string, logger logging.Logger) (er
 
 	return nil
 }
+
+// WalkFiles calls fn with the path of each file within dir, recursing into
+// sub-directories in the same order as CopyDir. As with CopyDir by default,
+// symlinks are skipped. Any error returned by fn stops the walk and is
+// returned.
+func WalkFiles(dir string, fn func(path string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+
+		switch {
+		case entry.Type()&os.ModeSymlink != 0:
+			continue
+		case entry.IsDir():
+			err = WalkFiles(entryPath, fn)
+		default:
+			err = fn(entryPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteFile writes the content to the file at path using the default 0644
+// permissions. If the file already exists, it is only replaced when overwrite
+// is true.
+func WriteFile(path string, content string, overwrite bool) error {
+	return WriteFileMode(path, content, overwrite, 0644)
+}
+
+// WriteFileMode writes the content to the file at path using the passed
+// permissions. If the file already exists, it is only replaced when overwrite
+// is true, and its permissions are updated to match mode. The error returned
+// when refusing to overwrite an existing file wraps os.ErrExist.
+//
+// The content is written to a temporary file which is synced and then renamed
+// over the destination, so the destination is never left partially written.
+func WriteFileMode(path string, content string, overwrite bool, mode os.FileMode) error {
+	return WriteFileModeContext(context.Background(), path, content, overwrite, mode)
+}
+
+// WriteFileModeContext writes the content to the file at path in the same way
+// as WriteFileMode. If the context is canceled during the write, it is
+// aborted and the destination is left untouched.
+//
+// While being written, the content is held in a temporary file named for the
+// destination with a .partial extension, such as .example.nomad.123.partial,
+// which is removed if the write fails. Partial files left by an earlier write
+// which was killed before it could clean up are removed first.
+func WriteFileModeContext(ctx context.Context, path string, content string, overwrite bool, mode os.FileMode, opts ...WriteOption) error {
+	return WriteFileFuncContext(ctx, path, overwrite, mode, func(w io.Writer) error {
+		_, err := copyContents(ctx, w, strings.NewReader(content))
+		return err
+	}, opts...)
+}
+
+// WriteFileFuncContext writes the content written by write to the file at
+// path, in the same way as WriteFileModeContext. The content is streamed into
+// the temporary file as it is written, so it need not be held in memory, and
+// the destination is only replaced once write returns without error.
+func WriteFileFuncContext(ctx context.Context, path string, overwrite bool, mode os.FileMode, write func(w io.Writer) error, opts ...WriteOption) error {
+	cfg := &writeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// Check to see if the file already exists and validate against the value
+	// of overwrite.
+	exists, err := Exists(path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		isDir, err := IsDir(path)
+		if err != nil {
+			return err
+		}
+		if isDir {
+			return fmt.Errorf("destination path is a directory")
+		}
+		if !overwrite {
+			return fmt.Errorf("destination file exists and overwrite is unset: %w", os.ErrExist)
+		}
+	}
+
+	removePartialFiles(path)
+
+	// Write the content to a temporary file in the same directory and rename
+	// it into place, so that a failure part way through the write does not
+	// leave a truncated destination file.
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), partialFilePattern(path))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %s", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	// Make sure the temporary file is removed if anything below fails. Once
+	// renamed, the removal is a no-op.
+	defer func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	var dst io.Writer = &contextWriter{ctx: ctx, w: tmpFile}
+	if cfg.progress != nil {
+		dst = &progressWriter{w: dst, fn: cfg.progress}
+	}
+	if err = write(dst); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to write rendered template to file: %s", err)
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync rendered template file: %s", err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close rendered template file: %s", err)
+	}
+
+	// The temporary file is created with 0600 permissions, so set the
+	// requested mode before moving it into place.
+	if err = os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("failed to set rendered template file permissions: %s", err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write rendered template to file: %s", err)
+	}
+
+	return nil
+}
+
+// WriteOption configures optional behaviour of WriteFileModeContext,
+// WriteFileFuncContext and WriteFileAllContext.
+type WriteOption func(*writeConfig)
+
+// writeConfig holds the configuration for a single file write.
+type writeConfig struct {
+	// progress is called with the total number of bytes written after each
+	// chunk of the content is written.
+	progress func(written int64)
+}
+
+// WithWriteProgress calls fn with the total number of bytes written after
+// each chunk of the content is written, so the progress of large writes can
+// be displayed.
+func WithWriteProgress(fn func(written int64)) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.progress = fn
+	}
+}
+
+// contextWriter fails writes once its context is canceled, so a write of
+// streamed content is aborted part way through.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *contextWriter) Write(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(b)
+}
+
+// progressWriter reports the total number of bytes written through it.
+type progressWriter struct {
+	w       io.Writer
+	written int64
+	fn      func(written int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.fn(p.written)
+	return n, err
+}
+
+// partialFileExt is the extension of the temporary files content is written
+// to before being renamed over the destination file.
+const partialFileExt = ".partial"
+
+// partialFilePattern returns the os.CreateTemp pattern of the partial files
+// written for the destination path.
+func partialFilePattern(path string) string {
+	return "." + filepath.Base(path) + ".*" + partialFileExt
+}
+
+// IsPartialFile reports whether the path is of a partial file, holding the
+// content of a write which has not completed.
+func IsPartialFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, ".") && strings.HasSuffix(base, partialFileExt)
+}
+
+// isPartialFileOf reports whether the file name is that of a partial file
+// written for the destination file name base. The random segment added by
+// os.CreateTemp only contains digits, so the partial files of a sibling such
+// as base.hcl, whose middle segment contains a dot, are not matched.
+func isPartialFileOf(name, base string) bool {
+	prefix := "." + base + "."
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, partialFileExt) {
+		return false
+	}
+	random := name[len(prefix) : len(name)-len(partialFileExt)]
+	if random == "" {
+		return false
+	}
+	for _, r := range random {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// removePartialFiles removes any partial files left for the destination path
+// by writes which were killed before they could remove them. The names are
+// compared exactly rather than globbed, so neither the partial files of
+// sibling destinations being written concurrently nor names containing glob
+// metacharacters are mismatched. Failures are ignored, as the partial files
+// do not affect the write.
+func removePartialFiles(path string) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return
+	}
+	base := filepath.Base(path)
+	for _, entry := range entries {
+		if isPartialFileOf(entry.Name(), base) {
+			_ = os.Remove(filepath.Join(filepath.Dir(path), entry.Name()))
+		}
+	}
+}
+
+// WriteFileAll writes the content to the file at path in the same way as
+// WriteFileMode, first creating any missing parent directories with the
+// dirMode permissions in the same way as CreatePath. Unlike WriteFileMode,
+// the parent directory does not need to exist.
+func WriteFileAll(path string, content string, overwrite bool, mode, dirMode os.FileMode) error {
+	return WriteFileAllContext(context.Background(), path, content, overwrite, mode, dirMode)
+}
+
+// WriteFileAllContext writes the content to the file at path in the same way
+// as WriteFileAll. If the context is canceled during the write, it is aborted
+// and the destination is left untouched, although any parent directories
+// created remain.
+func WriteFileAllContext(ctx context.Context, path string, content string, overwrite bool, mode, dirMode os.FileMode, opts ...WriteOption) error {
+	if err := CreatePath(filepath.Dir(path), dirMode); err != nil {
+		return fmt.Errorf("failed to create parent directories: %w", err)
+	}
+	return WriteFileModeContext(ctx, path, content, overwrite, mode, opts...)
+}
+
+// LinkFile creates a hardlink at path to the existing file at target. If a
+// file already exists at path, it is only replaced when overwrite is true,
+// otherwise the returned error wraps os.ErrExist. The link is created under a
+// temporary name and renamed into place, so an existing file is replaced
+// atomically. When the filesystem does not support hardlinks, the
+// *os.LinkError is returned.
+func LinkFile(target string, path string, overwrite bool) error {
+	exists, err := Exists(path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		isDir, err := IsDir(path)
+		if err != nil {
+			return err
+		}
+		if isDir {
+			return fmt.Errorf("destination path is a directory")
+		}
+		if !overwrite {
+			return fmt.Errorf("destination file exists and overwrite is unset: %w", os.ErrExist)
+		}
+	}
+
+	// Reserve a unique temporary name in the same directory, which the link
+	// replaces.
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %s", err)
+	}
+	tmpPath := tmpFile.Name()
+	_ = tmpFile.Close()
+	if err = os.Remove(tmpPath); err != nil {
+		return fmt.Errorf("failed to remove temporary file: %s", err)
+	}
+
+	if err = os.Link(target, tmpPath); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to link file: %s", err)
+	}
+	return nil
+}
+
+// Exists reports whether the path exists. An error is returned when this
+// cannot be determined, such as when permission to stat the path is denied,
+// so callers do not mistake an inaccessible path for an existing or missing
+// one.
+func Exists(path string) (bool, error) {
+	_, err := os.Lstat(path)
+	if err == nil {
+		return true, nil
+	}
+	if stdErrors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to determine if %s exists: %w", path, err)
+}
+
+// IsDir reports whether the path exists and is a directory, following any
+// symlink. As with Exists, an error is returned when this cannot be
+// determined.
+func IsDir(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err == nil {
+		return info.IsDir(), nil
+	}
+	if stdErrors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to determine if %s is a directory: %w", path, err)
+}
+
+// CreatePath creates the directory at path, along with any missing parents,
+// using the passed permissions. Unlike os.MkdirAll, the permissions of the
+// created directories are not masked by the umask, so that group or world
+// writable directories can be created. Existing directories are left as they
+// are.
+func CreatePath(path string, mode os.FileMode) error {
+	// Find the directories which need creating, from the deepest up, so
+	// only those have their permissions set.
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		exists, err := Exists(dir)
+		if err != nil {
+			return err
+		}
+		if exists {
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(path, mode); err != nil {
+		return err
+	}
+	for _, dir := range missing {
+		if err := os.Chmod(dir, mode); err != nil {
+			return fmt.Errorf("failed to set directory permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExpandPath expands a leading ~ or ~user to the relevant home directory, and
+// any $VAR or ${VAR} environment variable references within the path. An
+// error is returned if the home directory cannot be determined, such as when
+// the named user does not exist.
+func ExpandPath(path string) (string, error) {
+	return expandPath(path, os.UserHomeDir, user.Lookup)
+}
+
+// expandPath implements ExpandPath, allowing the home directory lookups to be
+// substituted.
+func expandPath(path string, homeDir func() (string, error), lookupUser func(string) (*user.User, error)) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return os.ExpandEnv(path), nil
+	}
+
+	// Split the ~user prefix from the remainder of the path, which is the only
+	// part subject to environment variable expansion.
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, "/"+string(os.PathSeparator)); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	var home string
+
+	if name == "" {
+		dir, err := homeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand ~ in path %q: %w", path, err)
+		}
+		home = dir
+	} else {
+		u, err := lookupUser(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to expand ~%s in path %q: %w", name, path, err)
+		}
+		home = u.HomeDir
+	}
+
+	return home + os.ExpandEnv(rest), nil
+}
+
+// UnsafeRemoveError is returned by RemovePath when it refuses to remove a
+// path, as doing so could delete data outside that intended.
+type UnsafeRemoveError struct {
+	Path   string
+	Reason string
+}
+
+func (e *UnsafeRemoveError) Error() string {
+	return fmt.Sprintf("refusing to remove %s: %s", e.Path, e.Reason)
+}
+
+// RemovePath removes the path, and any children, which must be located within
+// the base directory. An UnsafeRemoveError is returned, without removing
+// anything, if the path resolves outside of the base directory once any
+// symlinks within its parent directories are followed, or if either the base
+// or path is the filesystem root or the user's home directory.
+func RemovePath(base, path string, logger logging.Logger) error {
+	return removePath(base, path, logger, os.UserHomeDir)
+}
+
+// removePath implements RemovePath, allowing the home directory lookup to be
+// substituted.
+func removePath(base, path string, logger logging.Logger, homeDir func() (string, error)) error {
+	absBase, err := filepath.Abs(base)
+	if err == nil {
+		absBase, err = filepath.EvalSymlinks(absBase)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve base directory %s: %w", base, err)
+	}
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+
+	home, err := homeDir()
+	if err == nil {
+		if resolved, err := filepath.EvalSymlinks(home); err == nil {
+			home = resolved
+		}
+	} else {
+		home = ""
+	}
+
+	for _, p := range []string{absBase, absPath} {
+		if filepath.Dir(p) == p {
+			return &UnsafeRemoveError{Path: path, Reason: fmt.Sprintf("%s is the filesystem root", p)}
+		}
+		if home != "" && p == home {
+			return &UnsafeRemoveError{Path: path, Reason: fmt.Sprintf("%s is the home directory", p)}
+		}
+	}
+
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return &UnsafeRemoveError{Path: path, Reason: fmt.Sprintf("path is not within %s", base)}
+	}
+
+	logging.Debug(logger, "removing path", logging.F("path", absPath))
+
+	if err := os.RemoveAll(absPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolvePath returns the absolute form of the path with any symlinks in its
+// parent directories evaluated. The final element is not evaluated, so that a
+// symlink itself can be removed rather than its target.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir, name := filepath.Split(abs)
+	if name == "" {
+		return abs, nil
+	}
+
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedDir, name), nil
+}
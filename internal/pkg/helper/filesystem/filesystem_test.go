@@ -1,10 +1,19 @@
 package filesystem
 
 import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"io"
 	"os"
+	"os/user"
 	"path"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 	"github.com/stretchr/testify/require"
 )
@@ -39,3 +48,753 @@ func TestRenameAll(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteFileMode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dst := path.Join(dir, "run.sh")
+
+	// Writing a new file uses the passed mode.
+	err := WriteFileMode(dst, "#!/bin/sh", false, 0755)
+	require.NoError(t, err)
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// Without overwrite, the existing file is left untouched.
+	err = WriteFile(dst, "echo", false)
+	require.ErrorIs(t, err, os.ErrExist)
+
+	// Overwriting applies the new mode to the existing file.
+	err = WriteFile(dst, "echo", true)
+	require.NoError(t, err)
+
+	info, err = os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	// Directories are never written over.
+	err = WriteFile(dir, "echo", true)
+	require.Error(t, err)
+
+	// The temporary files used for the atomic write are not left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "run.sh", entries[0].Name())
+}
+
+func TestWriteFileAll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0755))
+	dst := path.Join(dir, "a", "b", "c", "d", "job.nomad")
+
+	// WriteFile requires the parent directory to exist.
+	require.Error(t, WriteFile(dst, "job", false))
+	_, err := os.Stat(path.Join(dir, "a"))
+	require.True(t, os.IsNotExist(err))
+
+	// The missing parents are created with the directory mode.
+	require.NoError(t, WriteFileAll(dst, "job", false, 0640, 0750))
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "job", string(content))
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	for _, p := range []string{"a", "a/b", "a/b/c", "a/b/c/d"} {
+		info, err := os.Stat(path.Join(dir, p))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0750), info.Mode().Perm(), p)
+	}
+
+	// Existing files are only replaced when overwriting.
+	require.ErrorIs(t, WriteFileAll(dst, "new", false, 0640, 0750), os.ErrExist)
+	require.NoError(t, WriteFileAll(dst, "new", true, 0640, 0750))
+
+	// A file in place of a parent directory is an error.
+	require.Error(t, WriteFileAll(path.Join(dst, "nested"), "job", false, 0640, 0750))
+}
+
+func TestWriteFileModeContext_SiblingPartialFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// The stale partial file of app is removed when writing app, while the
+	// in-flight partial files of siblings whose names start with app are
+	// not. Glob metacharacters in names are matched literally.
+	stale := ".app.123.partial"
+	siblings := []string{".app.hcl.456.partial", ".app.gz.789.partial", ".app.1.456.partial", ".[app].1.partial"}
+	for _, name := range append([]string{stale}, siblings...) {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte("a"), 0600))
+	}
+	require.NoError(t, WriteFileMode(path.Join(dir, "app"), "app", false, 0644))
+	require.NoFileExists(t, path.Join(dir, stale))
+	for _, name := range siblings {
+		require.FileExists(t, path.Join(dir, name))
+	}
+	require.NoError(t, WriteFileMode(path.Join(dir, "[app]"), "app", false, 0644))
+	require.NoFileExists(t, path.Join(dir, ".[app].1.partial"))
+
+	// Concurrently writing destinations whose names share a prefix does not
+	// remove the partial files of the other writes.
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*50)
+	for _, name := range []string{"app", "app.hcl", "app.gz"} {
+		dst := path.Join(dir, "concurrent", name)
+		require.NoError(t, os.MkdirAll(path.Dir(dst), 0755))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				errs <- WriteFileMode(dst, strings.Repeat("a", copyBufferSize), true, 0644)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestWriteFileModeContext_Progress(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dst := path.Join(dir, "large.nomad")
+	content := strings.Repeat("a", 3*copyBufferSize+1)
+
+	// Partial files left by a killed write are removed, while those of
+	// other files are not.
+	stale := path.Join(dir, ".large.nomad.123.partial")
+	other := path.Join(dir, ".other.nomad.456.partial")
+	require.True(t, IsPartialFile(stale))
+	require.False(t, IsPartialFile(dst))
+	require.NoError(t, os.WriteFile(stale, []byte("a"), 0600))
+	require.NoError(t, os.WriteFile(other, []byte("a"), 0600))
+
+	// The progress is reported after each chunk is written.
+	var progress []int64
+	err := WriteFileModeContext(context.Background(), dst, content, false, 0644, WithWriteProgress(func(written int64) {
+		progress = append(progress, written)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []int64{copyBufferSize, 2 * copyBufferSize, 3 * copyBufferSize, int64(len(content))}, progress)
+
+	written, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, content, string(written))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{".other.nomad.456.partial", "large.nomad"}, names)
+}
+
+func TestWriteFileFuncContext(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dst := path.Join(dir, "example.tar.gz")
+	require.NoError(t, os.WriteFile(dst, []byte("existing"), 0644))
+
+	// A write which fails part way through leaves the existing file and no
+	// partial files behind.
+	err := WriteFileFuncContext(context.Background(), dst, true, 0644, func(w io.Writer) error {
+		if _, err := w.Write([]byte("trunc")); err != nil {
+			return err
+		}
+		return stdErrors.New("interrupted")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "interrupted")
+
+	// Canceling the context aborts the write in the same way.
+	ctx, cancel := context.WithCancel(context.Background())
+	err = WriteFileFuncContext(ctx, dst, true, 0644, func(w io.Writer) error {
+		cancel()
+		_, err := w.Write([]byte("trunc"))
+		return err
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "existing", string(content))
+
+	// A successful write replaces the file with the streamed content.
+	require.NoError(t, WriteFileFuncContext(context.Background(), dst, true, 0600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "streamed")
+		return err
+	}))
+	content, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "streamed", string(content))
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLinkFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := path.Join(dir, "target")
+	require.NoError(t, os.WriteFile(target, []byte("content"), 0644))
+
+	link := path.Join(dir, "link")
+	require.NoError(t, LinkFile(target, link, false))
+
+	targetInfo, err := os.Stat(target)
+	require.NoError(t, err)
+	linkInfo, err := os.Stat(link)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(targetInfo, linkInfo))
+
+	// An existing file is only replaced when overwriting.
+	existing := path.Join(dir, "existing")
+	require.NoError(t, os.WriteFile(existing, []byte("existing"), 0644))
+	require.ErrorIs(t, LinkFile(target, existing, false), os.ErrExist)
+	require.NoError(t, LinkFile(target, existing, true))
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+
+	// A missing target fails with the link error.
+	var linkErr *os.LinkError
+	require.ErrorAs(t, LinkFile(path.Join(dir, "missing"), path.Join(dir, "other"), false), &linkErr)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
+
+func TestExistsAndIsDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := path.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+
+	exists, err := Exists(file)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	isDir, err := IsDir(file)
+	require.NoError(t, err)
+	require.False(t, isDir)
+
+	isDir, err = IsDir(dir)
+	require.NoError(t, err)
+	require.True(t, isDir)
+
+	exists, err = Exists(path.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Paths which cannot be checked are neither reported as existing nor
+	// missing, such as those traversing through a file.
+	_, err = Exists(path.Join(file, "child"))
+	require.Error(t, err)
+
+	_, err = IsDir(path.Join(file, "child"))
+	require.Error(t, err)
+
+	err = WriteFile(path.Join(file, "child"), "content", true)
+	require.Error(t, err)
+}
+
+func TestCopyDir_Symlinks(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	sharedDir := t.TempDir()
+
+	err := os.WriteFile(path.Join(sharedDir, "_helpers.tpl"), []byte("helpers"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(path.Join(srcDir, "job.nomad.tpl"), []byte("job"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(path.Join(sharedDir, "_helpers.tpl"), path.Join(srcDir, "_helpers.tpl")))
+	require.NoError(t, os.Symlink(sharedDir, path.Join(srcDir, "shared")))
+
+	logger := logging.NewTestLogger(t.Log)
+
+	// By default symlinks are skipped.
+	dst := path.Join(t.TempDir(), "skip")
+	require.NoError(t, CopyDir(srcDir, dst, logger))
+
+	_, err = os.Stat(path.Join(dst, "job.nomad.tpl"))
+	require.NoError(t, err)
+	_, err = os.Lstat(path.Join(dst, "_helpers.tpl"))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Lstat(path.Join(dst, "shared"))
+	require.True(t, os.IsNotExist(err))
+
+	// When following, the target contents are copied.
+	dst = path.Join(t.TempDir(), "follow")
+	require.NoError(t, CopyDir(srcDir, dst, logger, WithFollowSymlinks(true)))
+
+	content, err := os.ReadFile(path.Join(dst, "_helpers.tpl"))
+	require.NoError(t, err)
+	require.Equal(t, "helpers", string(content))
+
+	content, err = os.ReadFile(path.Join(dst, "shared", "_helpers.tpl"))
+	require.NoError(t, err)
+	require.Equal(t, "helpers", string(content))
+
+	info, err := os.Lstat(path.Join(dst, "shared"))
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+
+	// A symlink pointing back up the tree is reported as a cycle.
+	require.NoError(t, os.Symlink(srcDir, path.Join(sharedDir, "loop")))
+	err = CopyDir(srcDir, path.Join(t.TempDir(), "cycle"), logger, WithFollowSymlinks(true))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "symlink cycle detected")
+	require.True(t, stdErrors.Is(err, ErrCopyCycle))
+
+	var cycleErr *CopyCycleError
+	require.True(t, stdErrors.As(err, &cycleErr))
+	require.Equal(t, path.Join(srcDir, "shared", "loop"), cycleErr.Path)
+	require.Equal(t, srcDir, cycleErr.Ancestor)
+}
+
+func TestCopyDir_SymlinkLoop(t *testing.T) {
+	// A directory containing a symlink to itself would be copied forever
+	// without cycle detection, so the copy is run with a deadline.
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "file.txt"), []byte("content"), 0644))
+	require.NoError(t, os.Symlink(".", path.Join(srcDir, "self")))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- CopyDir(srcDir, path.Join(t.TempDir(), "dest"), logging.NewTestLogger(t.Log), WithFollowSymlinks(true))
+	}()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+		require.True(t, stdErrors.Is(err, ErrCopyCycle))
+		require.Contains(t, err.Error(), path.Join(srcDir, "self"))
+	case <-time.After(10 * time.Second):
+		t.Fatal("copying a symlink loop did not return")
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	require.NoError(t, os.Setenv("NOMAD_PACK_TEST_EXPAND", "renders"))
+	defer os.Unsetenv("NOMAD_PACK_TEST_EXPAND")
+
+	homeDir := func() (string, error) { return "/home/fake", nil }
+	lookupUser := func(name string) (*user.User, error) {
+		if name == "nomad" {
+			return &user.User{Username: name, HomeDir: "/home/nomad"}, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+
+	testCases := []struct {
+		name        string
+		path        string
+		expected    string
+		expectedErr string
+	}{
+		{name: "no expansion", path: "./out", expected: "./out"},
+		{name: "home", path: "~", expected: "/home/fake"},
+		{name: "home subdirectory", path: "~/out", expected: "/home/fake/out"},
+		{name: "named user", path: "~nomad/out", expected: "/home/nomad/out"},
+		{name: "env var", path: "./$NOMAD_PACK_TEST_EXPAND", expected: "./renders"},
+		{name: "braced env var", path: "./${NOMAD_PACK_TEST_EXPAND}/out", expected: "./renders/out"},
+		{name: "home and env var", path: "~/$NOMAD_PACK_TEST_EXPAND", expected: "/home/fake/renders"},
+		{name: "tilde not leading", path: "./~/out", expected: "./~/out"},
+		{name: "unknown user", path: "~missing/out", expectedErr: `failed to expand ~missing in path "~missing/out"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded, err := expandPath(tc.path, homeDir, lookupUser)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, expanded)
+		})
+	}
+}
+
+func TestCopyDir_Merge(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "metadata.hcl"), []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "templates", "job.nomad.tpl"), []byte("new"), 0644))
+
+	// newDst creates a destination which already contains the metadata file
+	// and the templates directory, but not the job template.
+	newDst := func(t *testing.T) string {
+		dst := t.TempDir()
+		require.NoError(t, os.MkdirAll(path.Join(dst, "templates"), 0755))
+		require.NoError(t, os.WriteFile(path.Join(dst, "metadata.hcl"), []byte("old"), 0644))
+		return dst
+	}
+
+	readFile := func(t *testing.T, name string) string {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	logger := logging.NewTestLogger(t.Log)
+
+	t.Run("strict by default", func(t *testing.T) {
+		err := CopyDir(srcDir, newDst(t), logger)
+		require.EqualError(t, err, "destination already exists")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		dst := newDst(t)
+		require.NoError(t, CopyDir(srcDir, dst, logger, WithMerge(MergeSkip)))
+		require.Equal(t, "old", readFile(t, path.Join(dst, "metadata.hcl")))
+		require.Equal(t, "new", readFile(t, path.Join(dst, "templates", "job.nomad.tpl")))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		dst := newDst(t)
+		require.NoError(t, CopyDir(srcDir, dst, logger, WithMerge(MergeOverwrite)))
+		require.Equal(t, "new", readFile(t, path.Join(dst, "metadata.hcl")))
+		require.Equal(t, "new", readFile(t, path.Join(dst, "templates", "job.nomad.tpl")))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dst := newDst(t)
+		err := CopyDir(srcDir, dst, logger, WithMerge(MergeError))
+
+		var conflictErr *MergeConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		require.Equal(t, []string{path.Join(dst, "metadata.hcl")}, conflictErr.Paths)
+
+		// Files which don't conflict are still copied.
+		require.Equal(t, "old", readFile(t, path.Join(dst, "metadata.hcl")))
+		require.Equal(t, "new", readFile(t, path.Join(dst, "templates", "job.nomad.tpl")))
+	})
+}
+
+func TestCopyDirWithStats(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "templates"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "metadata.hcl"), []byte("metadata"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "templates", "job.nomad.tpl"), []byte("job"), 0644))
+	require.NoError(t, os.Symlink(path.Join(srcDir, "metadata.hcl"), path.Join(srcDir, "link.hcl")))
+
+	stats, err := CopyDirWithStats(srcDir, path.Join(t.TempDir(), "pack"), logging.NewTestLogger(t.Log))
+	require.NoError(t, err)
+	require.Equal(t, &CopyStats{
+		FilesCopied:     2,
+		DirsCreated:     2,
+		BytesWritten:    int64(len("metadata") + len("job")),
+		SymlinksSkipped: 1,
+	}, stats)
+}
+
+func TestCopyDir_Exclude(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	for _, dir := range []string{".git", "templates", "tests/fixtures"} {
+		require.NoError(t, os.MkdirAll(path.Join(srcDir, dir), 0755))
+	}
+	for _, file := range []string{
+		".git/HEAD",
+		"metadata.hcl",
+		"templates/job.nomad.tpl",
+		"templates/.job.nomad.tpl.swp",
+		"tests/fixtures/vars.hcl",
+		"tests/run.sh",
+	} {
+		require.NoError(t, os.WriteFile(path.Join(srcDir, file), []byte(file), 0644))
+	}
+
+	dstDir := path.Join(t.TempDir(), "pack")
+	stats, err := CopyDirWithStats(srcDir, dstDir, logging.NewTestLogger(t.Log),
+		WithExclude(".git", "*.swp", "tests/fixtures"))
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.EntriesExcluded)
+	require.Equal(t, 3, stats.FilesCopied)
+
+	require.FileExists(t, path.Join(dstDir, "metadata.hcl"))
+	require.FileExists(t, path.Join(dstDir, "templates", "job.nomad.tpl"))
+	require.FileExists(t, path.Join(dstDir, "tests", "run.sh"))
+	require.NoDirExists(t, path.Join(dstDir, ".git"))
+	require.NoFileExists(t, path.Join(dstDir, "templates", ".job.nomad.tpl.swp"))
+	require.NoDirExists(t, path.Join(dstDir, "tests", "fixtures"))
+
+	// Invalid patterns are rejected before anything is copied.
+	err = CopyDir(srcDir, path.Join(t.TempDir(), "pack"), logging.NewTestLogger(t.Log), WithExclude("["))
+	require.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestCopyDir_IgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	for _, dir := range []string{"build", "docs/drafts", "templates/build"} {
+		require.NoError(t, os.MkdirAll(path.Join(srcDir, dir), 0755))
+	}
+	files := map[string]string{
+		".packignore":               "# Local files\n*.log\nbuild/\n/notes.md\n!keep.log\n",
+		"metadata.hcl":              "",
+		"notes.md":                  "",
+		"debug.log":                 "",
+		"keep.log":                  "",
+		"build/out.txt":             "",
+		"templates/job.nomad.tpl":   "",
+		"templates/notes.md":        "",
+		"templates/trace.log":       "",
+		"templates/build/x.tpl":     "",
+		"docs/.packignore":          "drafts/**\n!important.log\n",
+		"docs/README.md":            "",
+		"docs/important.log":        "",
+		"docs/drafts/wip.md":        "",
+		"docs/drafts/nested/old.md": "",
+	}
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "docs/drafts/nested"), 0755))
+	for file, content := range files {
+		require.NoError(t, os.WriteFile(path.Join(srcDir, file), []byte(content), 0644))
+	}
+
+	dstDir := path.Join(t.TempDir(), "pack")
+	require.NoError(t, CopyDir(srcDir, dstDir, logging.NewTestLogger(t.Log)))
+
+	for _, file := range []string{
+		".packignore",
+		"metadata.hcl",
+		"keep.log",
+		"templates/job.nomad.tpl",
+		"templates/notes.md",
+		"docs/.packignore",
+		"docs/README.md",
+		"docs/important.log",
+	} {
+		require.FileExists(t, path.Join(dstDir, file))
+	}
+	for _, file := range []string{
+		"notes.md",
+		"debug.log",
+		"templates/trace.log",
+		"docs/drafts/wip.md",
+		"docs/drafts/nested/old.md",
+	} {
+		require.NoFileExists(t, path.Join(dstDir, file))
+	}
+	require.NoDirExists(t, path.Join(dstDir, "build"))
+	require.NoDirExists(t, path.Join(dstDir, "templates", "build"))
+
+	// Invalid patterns fail the copy.
+	require.NoError(t, os.WriteFile(path.Join(srcDir, ".packignore"), []byte("[abc\n"), 0644))
+	err := CopyDir(srcDir, path.Join(t.TempDir(), "pack"), logging.NewTestLogger(t.Log))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 1")
+}
+
+func TestCopyContext_Canceled(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(srcDir, "job.nomad.tpl"), []byte("job"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	logger := logging.NewTestLogger(t.Log)
+
+	// Canceling the directory copy stops it before any files are copied.
+	dstDir := path.Join(t.TempDir(), "pack")
+	err := CopyDirContext(ctx, srcDir, dstDir, logger)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = os.Stat(path.Join(dstDir, "job.nomad.tpl"))
+	require.True(t, os.IsNotExist(err))
+
+	// Canceling a file copy removes the partially written destination.
+	dstFile := path.Join(t.TempDir(), "job.nomad.tpl")
+	err = CopyFileContext(ctx, path.Join(srcDir, "job.nomad.tpl"), dstFile, logger)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = os.Stat(dstFile)
+	require.True(t, os.IsNotExist(err))
+
+	// Canceling a write leaves the destination untouched.
+	dir := t.TempDir()
+	err = WriteFileModeContext(ctx, path.Join(dir, "job.nomad"), "job", false, 0644)
+	require.ErrorIs(t, err, context.Canceled)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestCopyFile_Symlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := path.Join(dir, "latest.log")
+	require.NoError(t, os.WriteFile(target, []byte("log"), 0644))
+	link := path.Join(dir, "link.log")
+	require.NoError(t, os.Symlink(target, link))
+
+	// A symlink passed as the source is followed without WithFollowSymlinks.
+	dst := path.Join(dir, "copy.log")
+	require.NoError(t, CopyFile(link, dst, logging.NewTestLogger(t.Log)))
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "log", string(content))
+
+	info, err := os.Lstat(dst)
+	require.NoError(t, err)
+	require.True(t, info.Mode().IsRegular())
+}
+
+func TestCopyFile_ErrorLogs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := path.Join(dir, "job.nomad.tpl")
+	require.NoError(t, os.WriteFile(src, []byte("job"), 0644))
+
+	// A symlink to a missing target fails when opened.
+	brokenLink := path.Join(dir, "broken.nomad.tpl")
+	require.NoError(t, os.Symlink(path.Join(dir, "missing.nomad.tpl"), brokenLink))
+
+	testCases := []struct {
+		name     string
+		src      string
+		dst      string
+		expected error
+	}{
+		{
+			name:     "opening source",
+			src:      brokenLink,
+			dst:      path.Join(dir, "out.nomad.tpl"),
+			expected: errors.ErrOpeningSourceFile,
+		},
+		{
+			name:     "opening destination",
+			src:      src,
+			dst:      path.Join(dir, "missing", "out.nomad.tpl"),
+			expected: errors.ErrOpeningDestFile,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var logs []string
+			logger := logging.NewTestLogger(func(args ...interface{}) {
+				logs = append(logs, fmt.Sprint(args...))
+			})
+
+			err := CopyFile(tc.src, tc.dst, logger)
+			require.Error(t, err)
+			require.Len(t, logs, 1)
+			require.True(t, strings.HasPrefix(logs[0], tc.expected.Error()+": "), logs[0])
+		})
+	}
+}
+
+func TestRemovePath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	base := path.Join(root, "out")
+	home := path.Join(root, "home")
+	require.NoError(t, os.MkdirAll(path.Join(base, "example"), 0755))
+	require.NoError(t, os.MkdirAll(home, 0755))
+	require.NoError(t, os.WriteFile(path.Join(root, "outside.nomad"), []byte("job"), 0644))
+
+	// A symlinked directory within the base which points outside of it must
+	// not allow removing its contents.
+	require.NoError(t, os.Symlink(root, path.Join(base, "escape")))
+
+	homeDir := func() (string, error) { return home, nil }
+	logger := logging.NewTestLogger(t.Log)
+
+	testCases := []struct {
+		name   string
+		base   string
+		path   string
+		reason string
+	}{
+		{name: "traversal", base: base, path: path.Join(base, "..", "outside.nomad"), reason: "path is not within"},
+		{name: "symlinked parent", base: base, path: path.Join(base, "escape", "outside.nomad"), reason: "path is not within"},
+		{name: "base itself", base: base, path: base, reason: "path is not within"},
+		{name: "root base", base: "/", path: path.Join(base, "example"), reason: "is the filesystem root"},
+		{name: "home base", base: home, path: path.Join(home, "file"), reason: "is the home directory"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := removePath(tc.base, tc.path, logger, homeDir)
+
+			var unsafeErr *UnsafeRemoveError
+			require.ErrorAs(t, err, &unsafeErr)
+			require.Contains(t, unsafeErr.Reason, tc.reason)
+		})
+	}
+	require.FileExists(t, path.Join(root, "outside.nomad"))
+
+	file := path.Join(base, "example", "stale.nomad")
+	require.NoError(t, os.WriteFile(file, []byte("job"), 0644))
+	require.NoError(t, removePath(base, file, logger, homeDir))
+	require.NoFileExists(t, file)
+
+	// Removing the symlink itself is permitted, and leaves its target intact.
+	require.NoError(t, removePath(base, path.Join(base, "escape"), logger, homeDir))
+	require.DirExists(t, root)
+}
+
+func TestCreatePath(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0755))
+
+	// Created directories use the mode regardless of the umask.
+	nested := path.Join(dir, "a", "b")
+	require.NoError(t, CreatePath(nested, 0775))
+	for _, p := range []string{path.Join(dir, "a"), nested} {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0775), info.Mode().Perm(), p)
+	}
+
+	// Existing directories are left unchanged.
+	require.NoError(t, CreatePath(path.Join(nested, "c"), 0700))
+	info, err := os.Stat(nested)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0775), info.Mode().Perm())
+	info, err = os.Stat(path.Join(nested, "c"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	info, err = os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
@@ -0,0 +1,170 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file within a directory copied by CopyDir
+// which lists the patterns of the entries to skip, using the gitignore
+// syntax. Patterns are matched against paths relative to the directory of
+// the file, and the patterns of a file in a subdirectory take precedence
+// over those of its ancestors.
+const IgnoreFileName = ".packignore"
+
+// ignoreRule is a single pattern of an ignore file.
+type ignoreRule struct {
+	re *regexp.Regexp
+
+	// negate re-includes entries matched by an earlier pattern.
+	negate bool
+
+	// dirOnly restricts the pattern to matching directories.
+	dirOnly bool
+}
+
+// ignoreList is the parsed patterns of the ignore file within dir.
+type ignoreList struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// readIgnoreFile parses the ignore file within dir, returning nil if there is
+// none.
+func readIgnoreFile(dir string) (*ignoreList, error) {
+	file := filepath.Join(dir, IgnoreFileName)
+
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	defer f.Close()
+
+	list := &ignoreList{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		rule, ok, err := parseIgnoreRule(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in %s line %d: %w", file, line, err)
+		}
+		if ok {
+			list.rules = append(list.rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	return list, nil
+}
+
+// parseIgnoreRule parses a line of an ignore file, returning false for blank
+// lines and comments.
+func parseIgnoreRule(line string) (ignoreRule, bool, error) {
+	var rule ignoreRule
+
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false, nil
+	}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false, nil
+	}
+
+	// Patterns containing a slash are relative to the directory of the
+	// ignore file, while others match at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	re, err := ignorePatternRegexp(line, anchored)
+	if err != nil {
+		return rule, false, err
+	}
+	rule.re = re
+	return rule, true, nil
+}
+
+// ignorePatternRegexp converts the gitignore pattern to a regular expression
+// matching slash separated relative paths. A "*" matches anything except a
+// slash, while "**" matches across directories.
+func ignorePatternRegexp(pattern string, anchored bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// match returns whether any of the patterns match the path, and if so,
+// whether the path is ignored according to the last matching pattern.
+func (l *ignoreList) match(path string, isDir bool) (matched, ignored bool) {
+	rel, err := filepath.Rel(l.dir, path)
+	if err != nil {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, rule := range l.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			matched, ignored = true, !rule.negate
+		}
+	}
+	return matched, ignored
+}
@@ -0,0 +1,53 @@
+// Package suggest finds close matches for mistyped names, so that errors can
+// suggest what was likely meant.
+package suggest
+
+// maxDistance is the greatest edit distance at which a candidate is
+// considered a likely typo of a name.
+const maxDistance = 2
+
+// Closest returns the candidate closest to name, if it is close enough to
+// likely be a typo of it. An empty string is returned otherwise. When several
+// candidates are equally close, the first is returned.
+func Closest(name string, candidates []string) string {
+	best, bestDist := "", maxDistance+1
+	for _, candidate := range candidates {
+		if dist := Distance(name, candidate); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// Distance returns the Levenshtein distance between a and b, which is the
+// number of single character insertions, deletions and substitutions needed
+// to change one into the other.
+func Distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
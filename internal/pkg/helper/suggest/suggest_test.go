@@ -0,0 +1,23 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	require.Equal(t, 0, Distance("replicas", "replicas"))
+	require.Equal(t, 1, Distance("replcas", "replicas"))
+	require.Equal(t, 3, Distance("kitten", "sitting"))
+	require.Equal(t, 4, Distance("", "port"))
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"count", "datacenters", "replicas"}
+
+	require.Equal(t, "replicas", Closest("replcas", candidates))
+	require.Equal(t, "count", Closest("cuont", candidates))
+	require.Equal(t, "", Closest("region", candidates))
+	require.Equal(t, "", Closest("replcas", nil))
+}
@@ -0,0 +1,73 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// httpUploader uploads files to an HTTP endpoint using PUT requests.
+type httpUploader struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func newHTTPUploader(base *url.URL) *httpUploader {
+	return &httpUploader{base: base, client: http.DefaultClient}
+}
+
+// fileURL returns the URL of the named file, joined to the base URL path.
+func (h *httpUploader) fileURL(name string) string {
+	u := *h.base
+	u.Path = path.Join("/", u.Path, name)
+	u.RawPath = ""
+	return u.String()
+}
+
+// Exists implements Uploader using a HEAD request. Endpoints which do not
+// allow HEAD requests cannot report whether a file exists.
+func (h *httpUploader) Exists(ctx context.Context, name string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.fileURL(name), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotImplemented:
+		return false, ErrExistsUnsupported
+	default:
+		return false, fmt.Errorf("unexpected response checking %s exists: %s", name, resp.Status)
+	}
+}
+
+// Upload implements Uploader using a PUT request.
+func (h *httpUploader) Upload(ctx context.Context, name string, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, h.fileURL(name), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response uploading %s: %s", name, resp.Status)
+	}
+	return nil
+}
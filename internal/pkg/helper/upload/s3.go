@@ -0,0 +1,75 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// s3Uploader uploads files as objects within an S3 bucket.
+type s3Uploader struct {
+	bucket string
+	prefix string
+	client *s3.S3
+}
+
+func newS3Uploader(u *url.URL) (*s3Uploader, error) {
+	if u.Host == "" {
+		return nil, errors.New("S3 destination URL must include a bucket, such as s3://bucket/prefix")
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	return &s3Uploader{
+		bucket: u.Host,
+		prefix: strings.TrimPrefix(u.Path, "/"),
+		client: s3.New(sess),
+	}, nil
+}
+
+// key returns the object key of the named file.
+func (s *s3Uploader) key(name string) string { return path.Join(s.prefix, name) }
+
+// Exists implements Uploader using a HeadObject request.
+func (s *s3Uploader) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	var awsErr awserr.RequestFailure
+	if errors.As(err, &awsErr) && awsErr.StatusCode() == 404 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check %s exists: %w", name, err)
+}
+
+// Upload implements Uploader using a PutObject request.
+func (s *s3Uploader) Upload(ctx context.Context, name string, content []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+		Body:   bytes.NewReader(content),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Package upload provides writing of files to remote destinations, such as
+// HTTP endpoints and S3 buckets, identified by URL.
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrExistsUnsupported is returned by Uploader.Exists when the destination
+// does not support checking whether a file exists.
+var ErrExistsUnsupported = errors.New("destination does not support checking whether files exist")
+
+// Uploader writes named files to a remote destination. The names are slash
+// separated paths, relative to the destination URL.
+type Uploader interface {
+
+	// Exists reports whether the named file exists at the destination. If the
+	// destination cannot report this, ErrExistsUnsupported is returned.
+	Exists(ctx context.Context, name string) (bool, error)
+
+	// Upload writes the content to the named file at the destination,
+	// replacing any existing file.
+	Upload(ctx context.Context, name string, content []byte) error
+}
+
+// schemes lists the URL schemes supported by New.
+var schemes = []string{"http", "https", "s3"}
+
+// IsURL reports whether the destination is a URL with a scheme supported by
+// New, rather than a local path.
+func IsURL(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// New returns an Uploader for the destination URL. HTTP and HTTPS URLs upload
+// each file using a PUT request to the file name joined to the URL path,
+// while S3 URLs, in the form s3://bucket/prefix, upload each file as an object
+// using the default AWS credential chain.
+func New(dest string) (Uploader, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return newHTTPUploader(u), nil
+	case "s3":
+		return newS3Uploader(u)
+	default:
+		return nil, fmt.Errorf("unsupported destination URL scheme %q, supported schemes are: %s",
+			u.Scheme, strings.Join(schemes, ", "))
+	}
+}
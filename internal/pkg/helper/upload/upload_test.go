@@ -0,0 +1,76 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsURL(t *testing.T) {
+	require.True(t, IsURL("https://example.com/renders"))
+	require.True(t, IsURL("s3://bucket/prefix"))
+	require.False(t, IsURL("./out"))
+	require.False(t, IsURL("/tmp/out"))
+	require.False(t, IsURL("ftp://example.com"))
+}
+
+func TestHTTPUploader(t *testing.T) {
+	var mu sync.Mutex
+	files := map[string]string{"/renders/existing.nomad": "job"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodHead:
+			if _, ok := files[r.URL.Path]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			content, _ := io.ReadAll(r.Body)
+			files[r.URL.Path] = string(content)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	uploader, err := New(srv.URL + "/renders")
+	require.NoError(t, err)
+
+	exists, err := uploader.Exists(ctx, "existing.nomad")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = uploader.Exists(ctx, "example/web.nomad")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, uploader.Upload(ctx, "example/web.nomad", []byte("web")))
+	require.Equal(t, "web", files["/renders/example/web.nomad"])
+}
+
+func TestHTTPUploader_ExistsUnsupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer srv.Close()
+
+	uploader, err := New(srv.URL)
+	require.NoError(t, err)
+
+	_, err = uploader.Exists(context.Background(), "example/web.nomad")
+	require.ErrorIs(t, err, ErrExistsUnsupported)
+
+	err = uploader.Upload(context.Background(), "example/web.nomad", nil)
+	require.EqualError(t, err, "unexpected response uploading example/web.nomad: 405 Method Not Allowed")
+}
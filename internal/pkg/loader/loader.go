@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -62,14 +63,19 @@ func loadDir(dir string) (*pack.Pack, error) {
 
 		content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
 
-		files = append(files, &pack.File{Name: n, Path: name, Content: content})
+		files = append(files, &pack.File{Name: n, Path: name, Content: content, Mode: fi.Mode().Perm()})
 		return nil
 	}
 
 	if err = walk(abs, walkFn); err != nil {
 		return nil, err
 	}
-	return loadFiles(files)
+
+	p, err := loadFiles(files)
+	if p != nil {
+		p.Path = strings.TrimSuffix(abs, string(filepath.Separator))
+	}
+	return p, err
 }
 
 func loadFiles(files []*pack.File) (*pack.Pack, error) {
@@ -85,6 +91,12 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 			if p.Metadata == nil {
 				p.Metadata = new(pack.Metadata)
 			}
+
+			// Validate the file against the metadata schema first, so every
+			// problem is reported rather than only the first found decoding.
+			if err := pack.ValidateMetadataFile(f.Name, f.Content); err != nil {
+				return p, err
+			}
 			if err := hclsimple.Decode(f.Name, f.Content, nil, p.Metadata); err != nil {
 				return p, fmt.Errorf("failed to decode %s: %v", f.Name, err)
 			}
@@ -92,10 +104,27 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 		case f.Name == "variables.hcl":
 			p.RootVariableFile = f
 
-		case f.Name == "outputs.tpl":
-			// This sets the default output template file. It can be overridden
-			// from the CLI.
-			p.OutputTemplateFile = f
+		case !strings.Contains(f.Name, "/") && strings.HasSuffix(f.Name, ".tpl"):
+			// Any template in the root of the pack is an output template,
+			// which can be selected from the CLI.
+			if p.OutputTemplateFiles == nil {
+				p.OutputTemplateFiles = make(map[string]*pack.File)
+			}
+			p.OutputTemplateFiles[f.Name] = f
+
+			// This sets the default output template file.
+			if f.Name == "outputs.tpl" {
+				p.OutputTemplateFile = f
+			}
+
+		case isProfileFile(f.Name):
+			// Each variable file in the profiles directory is a profile,
+			// named for the file without its extension.
+			if p.ProfileFiles == nil {
+				p.ProfileFiles = make(map[string]*pack.File)
+			}
+			name := strings.TrimPrefix(f.Name, "profiles/")
+			p.ProfileFiles[strings.TrimSuffix(name, path.Ext(name))] = f
 
 		case strings.HasPrefix(f.Name, "templates/") &&
 			strings.HasSuffix(f.Name, ".nomad.tpl") ||
@@ -114,5 +143,39 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 	if p.Metadata == nil {
 		return p, errors.New("metadata.hcl file not found")
 	}
-	return p, p.Metadata.Validate()
+	if err := p.Metadata.Validate(); err != nil {
+		return p, err
+	}
+
+	// Annotations of templates which do not exist are likely to be typos, so
+	// are rejected rather than silently ignored.
+	for _, tpl := range p.Metadata.Templates {
+		if !hasTemplateFile(p, tpl.Name) {
+			return p, fmt.Errorf("metadata.hcl annotates template %q which is not found", tpl.Name)
+		}
+	}
+	return p, nil
+}
+
+// isProfileFile returns whether the named pack file is a profile, which is an
+// HCL or JSON variable file directly within the profiles directory.
+func isProfileFile(name string) bool {
+	if !strings.HasPrefix(name, "profiles/") || strings.Count(name, "/") != 1 {
+		return false
+	}
+	switch path.Ext(name) {
+	case ".hcl", ".json":
+		return true
+	}
+	return false
+}
+
+// hasTemplateFile returns whether the pack contains the named template file.
+func hasTemplateFile(p *pack.Pack, name string) bool {
+	for _, f := range p.TemplateFiles {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is the level a structured log message is logged at.
+type Level string
+
+const (
+	LevelTrace   Level = "trace"
+	LevelDebug   Level = "debug"
+	LevelInfo    Level = "info"
+	LevelWarning Level = "warn"
+	LevelError   Level = "error"
+)
+
+// Field is a key/value pair attached to a log message, such as the path of
+// the file being copied.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns a Field with the key and value.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// FieldLogger is implemented by loggers which record the fields of a message
+// separately from it, rather than as part of the message text.
+type FieldLogger interface {
+	Log(level Level, message string, fields ...Field)
+}
+
+// Log logs the message and its fields at the level. When the logger is a
+// FieldLogger the fields are passed to it as they are, otherwise they are
+// appended to the message in the form "message: key=value key=value".
+func Log(l Logger, level Level, message string, fields ...Field) {
+	if fl, ok := l.(FieldLogger); ok {
+		fl.Log(level, message, fields...)
+		return
+	}
+
+	message = FormatMessage(message, fields...)
+	switch level {
+	case LevelTrace:
+		l.Trace(message)
+	case LevelInfo:
+		l.Info(message)
+	case LevelWarning:
+		l.Warning(message)
+	case LevelError:
+		l.Error(message)
+	default:
+		l.Debug(message)
+	}
+}
+
+// Debug logs the message and its fields at the DEBUG log level.
+func Debug(l Logger, message string, fields ...Field) {
+	Log(l, LevelDebug, message, fields...)
+}
+
+// Trace logs the message and its fields at the TRACE log level.
+func Trace(l Logger, message string, fields ...Field) {
+	Log(l, LevelTrace, message, fields...)
+}
+
+// FormatMessage returns the message with the fields appended in the form
+// "message: key=value key=value". Values containing spaces, quotes or equals
+// signs are quoted.
+func FormatMessage(message string, fields ...Field) string {
+	if len(fields) == 0 {
+		return message
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString(":")
+	for _, field := range fields {
+		b.WriteString(" ")
+		b.WriteString(field.Key)
+		b.WriteString("=")
+		b.WriteString(formatValue(field.Value))
+	}
+	return b.String()
+}
+
+// formatValue formats the field value for text output.
+func formatValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case error:
+		s = v.Error()
+	case []string:
+		s = strings.Join(v, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
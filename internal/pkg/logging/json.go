@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// JSONLogger is a Logger which writes each message as a JSON object on its
+// own line, so the output can be consumed by log aggregation tools. Fields
+// passed using Log are kept as separate keys of the object.
+type JSONLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// jsonEntry is a message written by the JSONLogger.
+type jsonEntry struct {
+	Time    string                 `json:"time"`
+	Level   Level                  `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// NewJSONLogger returns a JSONLogger writing to w.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{w: w, now: time.Now}
+}
+
+// Log satisfies the FieldLogger interface.
+func (l *JSONLogger) Log(level Level, message string, fields ...Field) {
+	entry := jsonEntry{
+		Time:    l.now().UTC().Format(time.RFC3339Nano),
+		Level:   level,
+		Message: message,
+	}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			entry.Fields[field.Key] = jsonValue(field.Value)
+		}
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		// The values are only those that can be encoded, so this is not
+		// expected. Fall back to logging the fields as text.
+		entry.Message = FormatMessage(message, fields...)
+		entry.Fields = nil
+		out, _ = json.Marshal(entry)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(append(out, '\n'))
+}
+
+// jsonValue returns the field value in a form which encodes meaningfully as
+// JSON. Errors would otherwise encode as an empty object.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case nil, string, bool, []string,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	default:
+		if _, err := json.Marshal(v); err != nil {
+			return fmt.Sprint(v)
+		}
+		return v
+	}
+}
+
+// Debug logs at the DEBUG log level
+func (l *JSONLogger) Debug(message string) {
+	l.Log(LevelDebug, message)
+}
+
+// Error logs at the ERROR log level
+func (l *JSONLogger) Error(message string) {
+	l.Log(LevelError, message)
+}
+
+// ErrorWithContext logs at the ERROR log level including additional context so
+// users can easily identify issues. The error and context are logged as the
+// "error" and "context" fields.
+func (l *JSONLogger) ErrorWithContext(err error, sub string, ctx ...string) {
+	fields := []Field{F("error", err)}
+	if len(ctx) > 0 {
+		fields = append(fields, F("context", ctx))
+	}
+	l.Log(LevelError, sub, fields...)
+}
+
+// Info logs at the INFO log level
+func (l *JSONLogger) Info(message string) {
+	l.Log(LevelInfo, message)
+}
+
+// Trace logs at the TRACE log level
+func (l *JSONLogger) Trace(message string) {
+	l.Log(LevelTrace, message)
+}
+
+// Warning logs at the WARN log level
+func (l *JSONLogger) Warning(message string) {
+	l.Log(LevelWarning, message)
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMessage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fields   []Field
+		expected string
+	}{
+		{
+			name:     "no fields",
+			expected: "copying file",
+		},
+		{
+			name:     "plain values",
+			fields:   []Field{F("source", "/tmp/a"), F("count", 2)},
+			expected: "copying file: source=/tmp/a count=2",
+		},
+		{
+			name:     "quoted values",
+			fields:   []Field{F("error", errors.New("no such file")), F("empty", "")},
+			expected: `copying file: error="no such file" empty=""`,
+		},
+		{
+			name:     "string slice",
+			fields:   []Field{F("paths", []string{"a", "b"})},
+			expected: "copying file: paths=a,b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, FormatMessage("copying file", tc.fields...))
+		})
+	}
+}
+
+func TestLog_TextFallback(t *testing.T) {
+	var logs []string
+	logger := NewTestLogger(func(args ...interface{}) {
+		logs = append(logs, fmt.Sprint(args...))
+	})
+
+	Debug(logger, "skipping symlink", F("source", "/tmp/link"))
+	require.Equal(t, []string{"skipping symlink: source=/tmp/link"}, logs)
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	logger.now = func() time.Time { return time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	Debug(logger, "copying file",
+		F("source", "/tmp/a"),
+		F("dest", "/tmp/b"),
+		F("error", errors.New("disk full")),
+		F("size", 42))
+	logger.Warning("symlinks skipped")
+	logger.ErrorWithContext(errors.New("not found"), "failed to find pack", "Pack Name: example")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var entries []map[string]interface{}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	require.Equal(t, map[string]interface{}{
+		"time":    "2021-10-01T12:00:00Z",
+		"level":   "debug",
+		"message": "copying file",
+		"fields": map[string]interface{}{
+			"source": "/tmp/a",
+			"dest":   "/tmp/b",
+			"error":  "disk full",
+			"size":   float64(42),
+		},
+	}, entries[0])
+
+	require.Equal(t, "warn", entries[1]["level"])
+	require.Equal(t, "symlinks skipped", entries[1]["message"])
+	require.NotContains(t, entries[1], "fields")
+
+	require.Equal(t, "error", entries[2]["level"])
+	require.Equal(t, "failed to find pack", entries[2]["message"])
+	require.Equal(t, map[string]interface{}{
+		"error":   "not found",
+		"context": []interface{}{"Pack Name: example"},
+	}, entries[2]["fields"])
+}
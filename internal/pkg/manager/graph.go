@@ -0,0 +1,263 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+)
+
+// DependencyGraph describes the relationships between a pack and the packs it
+// depends on, directly or transitively, as resolved when rendering the pack.
+// Its fields are sorted, so the graphs of two versions of a pack can be
+// compared.
+type DependencyGraph struct {
+
+	// Root is the name of the parent pack.
+	Root string `json:"root"`
+
+	// Registry and Ref identify the parent pack when it is from a registry.
+	Registry string `json:"registry,omitempty"`
+	Ref      string `json:"ref,omitempty"`
+
+	// Packs are the parent pack and each of the packs it depends on, sorted
+	// by name.
+	Packs []*GraphPack `json:"packs"`
+
+	// Dependencies are the enabled dependencies between the packs, sorted by
+	// the names of the depending and dependent packs.
+	Dependencies []*GraphDependency `json:"dependencies"`
+
+	// Cycles are the dependency cycles found, each listing the names of the
+	// packs in the cycle, starting and ending with the same pack.
+	Cycles [][]string `json:"cycles"`
+}
+
+// GraphPack is a pack within a DependencyGraph.
+type GraphPack struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Path is the directory the pack is loaded from, relative to the parent
+	// pack.
+	Path string `json:"path"`
+}
+
+// GraphDependency is a dependency of one pack on another within a
+// DependencyGraph.
+type GraphDependency struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Source is the source of the dependency as declared in the metadata of
+	// the depending pack, which is empty for dependencies vendored within
+	// its deps directory.
+	Source string `json:"source"`
+
+	// Cycle is true when the dependency completes a dependency cycle, in
+	// which case the dependent pack is not followed again.
+	Cycle bool `json:"cycle"`
+}
+
+// graphStep is a pack within the chain of dependencies being walked.
+type graphStep struct {
+	name    string
+	absPath string
+}
+
+// graphWalker builds a DependencyGraph by walking the dependencies of the
+// parent pack.
+type graphWalker struct {
+	graph    *DependencyGraph
+	rootPath string
+
+	// loaded maps the name of each pack added to the graph to its absolute
+	// path, so that distinct packs with the same name are detected.
+	loaded map[string]string
+}
+
+// DependencyGraph loads the pack and the packs it depends on, returning the
+// graph of their dependencies. Dependency cycles are recorded within the
+// graph, rather than returned as an error as when rendering. Dependencies
+// which are not enabled are not included.
+func (pm *PackManager) DependencyGraph() (*DependencyGraph, error) {
+
+	parentPack, err := pm.loadParentPack()
+	if err != nil {
+		return nil, err
+	}
+
+	rootPath, err := filepath.Abs(pm.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve pack path: %v", err)
+	}
+
+	w := &graphWalker{
+		graph: &DependencyGraph{
+			Root:         parentPack.Name(),
+			Packs:        []*GraphPack{},
+			Dependencies: []*GraphDependency{},
+			Cycles:       [][]string{},
+		},
+		rootPath: rootPath,
+		loaded:   map[string]string{},
+	}
+
+	depsPath := path.Join(pm.cfg.Path, "deps")
+	if err := w.walk(parentPack, pm.cfg.Path, depsPath, nil); err != nil {
+		return nil, fmt.Errorf("failed to load pack dependency: %v", err)
+	}
+
+	sort.Slice(w.graph.Packs, func(i, j int) bool {
+		return w.graph.Packs[i].Name < w.graph.Packs[j].Name
+	})
+	sort.SliceStable(w.graph.Dependencies, func(i, j int) bool {
+		a, b := w.graph.Dependencies[i], w.graph.Dependencies[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	sort.Slice(w.graph.Cycles, func(i, j int) bool {
+		return strings.Join(w.graph.Cycles[i], "\x00") < strings.Join(w.graph.Cycles[j], "\x00")
+	})
+	return w.graph, nil
+}
+
+// walk adds the pack at curPath to the graph, followed by its dependencies,
+// resolving them in the same manner as loadAndValidatePack. The chain holds
+// the packs being walked, from the parent down, which is used to detect
+// dependency cycles.
+func (w *graphWalker) walk(cur *pack.Pack, curPath, depsPath string, chain []graphStep) error {
+
+	absPath, err := filepath.Abs(curPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pack path: %v", err)
+	}
+
+	if existing, ok := w.loaded[cur.Name()]; ok {
+		if existing != absPath {
+			return fmt.Errorf("packs at %s and %s have the same name %q", existing, absPath, cur.Name())
+		}
+
+		// The pack is depended on by more than one pack, and its own
+		// dependencies have already been walked.
+		return nil
+	}
+	w.loaded[cur.Name()] = absPath
+
+	relPath, err := filepath.Rel(w.rootPath, absPath)
+	if err != nil {
+		relPath = absPath
+	}
+	w.graph.Packs = append(w.graph.Packs, &GraphPack{
+		Name:    cur.Name(),
+		Version: cur.Metadata.Pack.Version,
+		Path:    filepath.ToSlash(relPath),
+	})
+
+	chain = append(chain, graphStep{name: cur.Name(), absPath: absPath})
+
+	for _, dependency := range cur.Metadata.Dependencies {
+
+		// Skip any dependencies that are not enabled.
+		if !*dependency.Enabled {
+			continue
+		}
+
+		dependencyPath, dependencyDepsPath := dependencyPaths(dependency, curPath, depsPath)
+
+		dependentPack, err := loadDependency(dependencyPath)
+		if err != nil {
+			return err
+		}
+
+		dependencyAbsPath, err := filepath.Abs(dependencyPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve pack path: %v", err)
+		}
+
+		edge := &GraphDependency{
+			From:   cur.Name(),
+			To:     dependentPack.Name(),
+			Source: dependency.Source,
+		}
+		w.graph.Dependencies = append(w.graph.Dependencies, edge)
+
+		if cycle := chainCycle(chain, dependencyAbsPath); cycle != nil {
+			edge.Cycle = true
+			w.graph.Cycles = append(w.graph.Cycles, cycle)
+			continue
+		}
+
+		// The chain is copied so that sibling dependencies do not share the
+		// backing array.
+		if err := w.walk(dependentPack, dependencyPath, dependencyDepsPath, append([]graphStep{}, chain...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chainCycle returns the names of the packs in the cycle formed by depending
+// on the pack at absPath, or nil if the pack is not within the chain.
+func chainCycle(chain []graphStep, absPath string) []string {
+	for i, step := range chain {
+		if step.absPath != absPath && !sameDir(step.absPath, absPath) {
+			continue
+		}
+		cycle := make([]string, 0, len(chain)-i+1)
+		for _, s := range chain[i:] {
+			cycle = append(cycle, s.name)
+		}
+		return append(cycle, step.name)
+	}
+	return nil
+}
+
+// DOT returns the graph in the Graphviz DOT language, which can be rendered
+// using "dot -Tpng". Dependencies completing a cycle are drawn in red.
+func (g *DependencyGraph) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(g.Root))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, p := range g.Packs {
+		label := p.Name
+		if p.Version != "" {
+			label += "\n" + p.Version
+		}
+		attrs := []string{"label=" + strconv.Quote(label)}
+		if p.Name == g.Root {
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(p.Name), strings.Join(attrs, ", "))
+	}
+
+	for _, d := range g.Dependencies {
+		var attrs []string
+		label := d.Source
+		if d.Cycle {
+			label = strings.TrimSpace(label + " (cycle)")
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		if label != "" {
+			attrs = append([]string{"label=" + strconv.Quote(label)}, attrs...)
+		}
+		fmt.Fprintf(&b, "  %s -> %s", strconv.Quote(d.From), strconv.Quote(d.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
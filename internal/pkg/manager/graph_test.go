@@ -0,0 +1,118 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeGraphPack writes a pack named name within dir, depending on the packs
+// at the passed sources.
+func writeGraphPack(t *testing.T, dir, name string, sources ...string) {
+	t.Helper()
+
+	metadata := fmt.Sprintf(`app {
+  url    = ""
+  author = ""
+}
+
+pack {
+  name    = %q
+  url     = ""
+  version = "0.1.0"
+}
+`, name)
+	for _, source := range sources {
+		metadata += fmt.Sprintf("\ndependency %q {\n  source = %q\n}\n", filepath.Base(source), source)
+	}
+
+	packPath := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Join(packPath, "templates"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packPath, "metadata.hcl"), []byte(metadata), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packPath, "variables.hcl"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packPath, "templates", name+".nomad.tpl"), []byte("job"), 0644))
+}
+
+func TestPackManager_DependencyGraph(t *testing.T) {
+	dir := t.TempDir()
+	writeGraphPack(t, dir, "app", "../db", "../cache")
+	writeGraphPack(t, dir, "db", "../cache")
+	writeGraphPack(t, dir, "cache")
+
+	pm := NewPackManager(&Config{Path: filepath.Join(dir, "app")}, nil)
+	graph, err := pm.DependencyGraph()
+	require.NoError(t, err)
+
+	require.Equal(t, &DependencyGraph{
+		Root: "app",
+		Packs: []*GraphPack{
+			{Name: "app", Version: "0.1.0", Path: "."},
+			{Name: "cache", Version: "0.1.0", Path: "../cache"},
+			{Name: "db", Version: "0.1.0", Path: "../db"},
+		},
+		Dependencies: []*GraphDependency{
+			{From: "app", To: "cache", Source: "../cache"},
+			{From: "app", To: "db", Source: "../db"},
+			{From: "db", To: "cache", Source: "../cache"},
+		},
+		Cycles: [][]string{},
+	}, graph)
+
+	require.Equal(t, `digraph "app" {
+  rankdir=LR;
+  node [shape=box];
+  "app" [label="app\n0.1.0", style=bold];
+  "cache" [label="cache\n0.1.0"];
+  "db" [label="db\n0.1.0"];
+  "app" -> "cache" [label="../cache"];
+  "app" -> "db" [label="../db"];
+  "db" -> "cache" [label="../cache"];
+}
+`, graph.DOT())
+}
+
+func TestPackManager_DependencyGraphCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeGraphPack(t, dir, "app", "../db")
+	writeGraphPack(t, dir, "db", "../cache")
+	writeGraphPack(t, dir, "cache", "../db")
+
+	pm := NewPackManager(&Config{Path: filepath.Join(dir, "app")}, nil)
+	graph, err := pm.DependencyGraph()
+	require.NoError(t, err)
+
+	require.Equal(t, []*GraphDependency{
+		{From: "app", To: "db", Source: "../db"},
+		{From: "cache", To: "db", Source: "../db", Cycle: true},
+		{From: "db", To: "cache", Source: "../cache"},
+	}, graph.Dependencies)
+	require.Equal(t, [][]string{{"db", "cache", "db"}}, graph.Cycles)
+	require.Contains(t, graph.DOT(), `"cache" -> "db" [label="../db (cycle)", color=red, fontcolor=red];`)
+
+	// Rendering the pack still fails on the cycle.
+	_, err = pm.loadAndValidatePacks()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "dependency cycle detected")
+}
+
+func TestPackManager_DependencySymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeGraphPack(t, dir, "app", "./deps/app")
+
+	// The dependency is a symlink back to the pack itself, so each level
+	// has a new path but is the same directory.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "deps"), 0755))
+	require.NoError(t, os.Symlink("..", filepath.Join(dir, "app", "deps", "app")))
+
+	pm := NewPackManager(&Config{Path: filepath.Join(dir, "app")}, nil)
+	graph, err := pm.DependencyGraph()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"app", "app"}}, graph.Cycles)
+
+	_, err = pm.loadAndValidatePacks()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "dependency cycle detected")
+}
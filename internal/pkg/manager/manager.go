@@ -1,13 +1,21 @@
 package manager
 
 import (
+	stdErrors "errors"
 	"fmt"
+	"math/rand"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
 	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
 	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
@@ -19,14 +27,82 @@ type Config struct {
 	Path            string
 	VariableFiles   []string
 	VariableCLIArgs map[string]string
+
+	// AutoVariableFiles are the variable files which were discovered
+	// automatically. They take a lower precedence than VariableFiles.
+	AutoVariableFiles []string
+
+	// Profile is the name of the profile of the parent pack whose variable
+	// values are used. The profile values take a lower precedence than all
+	// the other overrides. If empty, no profile is used.
+	Profile string
+
+	// EnvPrefix is the prefix of the environment variables which set pack
+	// variables. If empty, the environment is not used.
+	EnvPrefix string
+
+	// VariableMapNames are the names of the VariableCLIArgs which were set
+	// using --var-map. Those which are not declared by the pack result in a
+	// warning, rather than an error, unless StrictVars is set.
+	VariableMapNames map[string]bool
+
+	// StrictVars makes environment variables and VariableMapNames which set
+	// variables not declared by the pack an error, rather than a warning.
+	StrictVars bool
+
+	// VariableFileMerge is the strategy used to merge the values set by the
+	// VariableFiles and AutoVariableFiles, which is one of
+	// variable.VarFileMerges. If empty, the values are replaced.
+	VariableFileMerge string
+
+	// LeftDelim and RightDelim are the template action delimiters used when
+	// rendering the pack. If empty, the renderer defaults are used.
+	LeftDelim  string
+	RightDelim string
+
+	// VerifyCache memoizes the packs verified by VerifyPack, and can be
+	// shared by the pack managers within a process. If nil, every
+	// verification is performed.
+	VerifyCache *cache.VerifyCache
+
+	// Offline disables network access while rendering, so the Nomad API
+	// template functions are not available.
+	Offline bool
+
+	// NomadVariables enables the template functions which read Nomad
+	// variables from the cluster. It is ignored when Offline is set.
+	NomadVariables bool
+
+	// Trace, if set, is used to log the parsing and execution of the pack
+	// templates at debug level, for debugging packs.
+	Trace logging.Logger
+
+	// KeepGoing renders the remaining templates when a template fails,
+	// returning the failures within the rendered output rather than as an
+	// error.
+	KeepGoing bool
+
+	// Seed, if set, seeds the source of randomness used by the random
+	// template functions, so renders are reproducible.
+	Seed *int64
+
+	// Parallelism is the number of templates rendered concurrently. If not
+	// positive, it is based on GOMAXPROCS.
+	Parallelism int
+
+	// MetadataOverrides overrides fields of the parent pack's metadata, keyed
+	// by the field in the form block.field, such as pack.version.
+	MetadataOverrides map[string]string
 }
 
 // PackManager is responsible for loading, parsing, and rendering a Pack and
 // all dependencies.
 type PackManager struct {
-	cfg      *Config
-	client   *v1.Client
-	renderer *renderer.Renderer
+	cfg        *Config
+	client     *v1.Client
+	renderer   *renderer.Renderer
+	parsedVars *variable.ParsedVariables
+	warnings   []string
 }
 
 func NewPackManager(cfg *Config, client *v1.Client) *PackManager {
@@ -36,6 +112,12 @@ func NewPackManager(cfg *Config, client *v1.Client) *PackManager {
 	}
 }
 
+// VerifyPack verifies that the pack described by cfg exists, using the
+// configured VerifyCache to skip packs which have already been verified.
+func (pm *PackManager) VerifyPack(cfg *cache.PackConfig, errCtx *errors.UIErrorContext, logger logging.Logger) error {
+	return pm.cfg.VerifyCache.Verify(cfg, errCtx, logger)
+}
+
 // ProcessTemplates is responsible for running all backend process for the PackManager
 // returning an error along with the ProcessedPack. This contains all the
 // rendered templates.
@@ -54,20 +136,32 @@ func (pm *PackManager) ProcessTemplates() (*renderer.Rendered, []*errors.Wrapped
 		}}
 	}
 
-	// Root vars are nested under the parent pack name, which is currently
-	// just the pack name without the version. We want to slice the string
-	// so it's just the pack name without the version
-	parentName := path.Base(pm.cfg.Path)
-	idx := strings.LastIndex(path.Base(pm.cfg.Path), "@")
-	if idx != -1 {
-		parentName = path.Base(pm.cfg.Path)[0:idx]
+	if errs := overrideMetadata(loadedPack, pm.cfg.MetadataOverrides); errs != nil {
+		return nil, errs
+	}
+
+	var profileFile *pack.File
+	if pm.cfg.Profile != "" {
+		if profileFile, err = loadedPack.Profile(pm.cfg.Profile); err != nil {
+			return nil, []*errors.WrappedUIContext{{
+				Err:     err,
+				Subject: "failed to select profile",
+				Context: errors.NewUIErrorContext(),
+			}}
+		}
 	}
 
 	variableParser, err := variable.NewParser(&variable.ParserConfig{
-		ParentName:        parentName,
+		ParentName:        pm.ParentName(),
 		RootVariableFiles: loadedPack.RootVariableFiles(),
+		ProfileFile:       profileFile,
 		FileOverrides:     pm.cfg.VariableFiles,
+		AutoFileOverrides: pm.cfg.AutoVariableFiles,
 		CLIOverrides:      pm.cfg.VariableCLIArgs,
+		CLIMapNames:       pm.cfg.VariableMapNames,
+		EnvPrefix:         pm.cfg.EnvPrefix,
+		StrictVars:        pm.cfg.StrictVars,
+		FileMerge:         pm.cfg.VariableFileMerge,
 	})
 	if err != nil {
 		return nil, []*errors.WrappedUIContext{{
@@ -79,7 +173,16 @@ func (pm *PackManager) ProcessTemplates() (*renderer.Rendered, []*errors.Wrapped
 
 	parsedVars, diags := variableParser.Parse()
 	if diags != nil && diags.HasErrors() {
-		return nil, errors.HCLDiagsToWrappedUIContext(diags)
+		return nil, variableDiagsToWrappedUIContext(variableParser, diags)
+	}
+
+	pm.parsedVars = parsedVars
+
+	pm.warnings = nil
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagWarning {
+			pm.warnings = append(pm.warnings, diag.Detail)
+		}
 	}
 
 	mapVars, diags := parsedVars.ConvertVariablesToMapInterface()
@@ -88,10 +191,57 @@ func (pm *PackManager) ProcessTemplates() (*renderer.Rendered, []*errors.Wrapped
 	}
 
 	r := new(renderer.Renderer)
-	r.Client = pm.client
+	if !pm.cfg.Offline {
+		r.Client = pm.client
+	}
+	if pm.cfg.NomadVariables && !pm.cfg.Offline {
+		if r.NomadVariables, err = renderer.NewNomadVariablesFromEnv(); err != nil {
+			return nil, []*errors.WrappedUIContext{{
+				Err:     err,
+				Subject: "failed to configure Nomad variables",
+				Context: errors.NewUIErrorContext(),
+			}}
+		}
+	}
+	r.LeftDelim = pm.cfg.LeftDelim
+	r.RightDelim = pm.cfg.RightDelim
+	r.Trace = pm.cfg.Trace
+	r.KeepGoing = pm.cfg.KeepGoing
+	r.Parallelism = pm.cfg.Parallelism
+	if pm.cfg.Seed != nil {
+		r.Rand = rand.New(rand.NewSource(*pm.cfg.Seed))
+	}
 	pm.renderer = r
 
 	rendered, err := r.Render(loadedPack, mapVars)
+	if err != nil {
+		return nil, []*errors.WrappedUIContext{{
+			Err:     err,
+			Subject: "failed to render templates",
+			Context: TemplateErrorContext(err),
+		}}
+	}
+	return rendered, nil
+}
+
+// ProcessRootVariables loads the pack and its dependencies, returning the
+// variables declared by each without applying any overrides. This is useful
+// for describing the variables a pack accepts.
+func (pm *PackManager) ProcessRootVariables() (*variable.ParsedVariables, []*errors.WrappedUIContext) {
+
+	loadedPack, err := pm.loadAndValidatePacks()
+	if err != nil {
+		return nil, []*errors.WrappedUIContext{{
+			Err:     err,
+			Subject: "failed to validate packs",
+			Context: errors.NewUIErrorContext(),
+		}}
+	}
+
+	variableParser, err := variable.NewParser(&variable.ParserConfig{
+		ParentName:        pm.ParentName(),
+		RootVariableFiles: loadedPack.RootVariableFiles(),
+	})
 	if err != nil {
 		return nil, []*errors.WrappedUIContext{{
 			Err:     err,
@@ -99,39 +249,149 @@ func (pm *PackManager) ProcessTemplates() (*renderer.Rendered, []*errors.Wrapped
 			Context: errors.NewUIErrorContext(),
 		}}
 	}
-	return rendered, nil
+
+	parsedVars, diags := variableParser.Parse()
+	if diags != nil && diags.HasErrors() {
+		return nil, errors.HCLDiagsToWrappedUIContext(diags)
+	}
+	return parsedVars, nil
 }
 
-// ProcessOutputTemplate performs the output template rendering.
-func (pm *PackManager) ProcessOutputTemplate() (string, error) { return pm.renderer.RenderOutput() }
+// ParentName returns the name of the parent pack, which the root variables
+// are nested under. This is currently just the pack name without the
+// version, so we slice the version from the pack path.
+func (pm *PackManager) ParentName() string {
+	parentName := path.Base(pm.cfg.Path)
+	idx := strings.LastIndex(parentName, "@")
+	if idx != -1 {
+		parentName = parentName[0:idx]
+	}
+	return parentName
+}
+
+// variableDiagsToWrappedUIContext converts the diagnostics returned when
+// parsing the variables, including the name of the variable each concerns as
+// context where known.
+func variableDiagsToWrappedUIContext(parser *variable.Parser, diags hcl.Diagnostics) []*errors.WrappedUIContext {
+	wrapped := errors.HCLDiagsToWrappedUIContext(diags)
+	for i, diag := range diags {
+		if name := parser.DiagnosticVariable(diag); name != "" {
+			wrapped[i].Context.Add(errors.UIContextPrefixVariableName, name)
+		}
+	}
+	return wrapped
+}
+
+// TemplateErrorContext returns the UI error context for an error returned
+// when rendering, which identifies the template and the position of the error
+// within it when the error is a renderer.TemplateError.
+func TemplateErrorContext(err error) *errors.UIErrorContext {
+	var tplErr *renderer.TemplateError
+	if stdErrors.As(err, &tplErr) {
+		return tplErr.Context()
+	}
+	return errors.NewUIErrorContext()
+}
+
+// ProcessOutputTemplate performs the output template rendering of the named
+// output template file. If name is empty, the default output template is
+// rendered.
+func (pm *PackManager) ProcessOutputTemplate(name string) (string, error) {
+	return pm.renderer.RenderOutput(name)
+}
+
+// ProcessDependencyOutputTemplates performs the rendering of the default
+// output template of each dependency pack. Failures are returned within each
+// output, so one failing output template does not prevent the others from
+// rendering.
+func (pm *PackManager) ProcessDependencyOutputTemplates() []*renderer.DependencyOutput {
+	return pm.renderer.RenderDependencyOutputs()
+}
 
 // loadAndValidatePacks triggers the initial parent load and then starts the
 // dependent pack loader. The returned pack will therefore be fully populated.
 func (pm *PackManager) loadAndValidatePacks() (*pack.Pack, error) {
 
-	parentPack, err := loader.Load(pm.cfg.Path)
+	parentPack, err := pm.loadParentPack()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load pack: %v", err)
-	}
-
-	if err := parentPack.Validate(); err != nil {
-		return nil, fmt.Errorf("failed to validate pack: %v", err)
+		return nil, err
 	}
 
 	// Using the input path to the parent pack, define the path where
 	// dependencies are stored.
 	depsPath := path.Join(pm.cfg.Path, "deps")
 
-	if err := pm.loadAndValidatePack(parentPack, depsPath); err != nil {
+	loaded := map[string]string{}
+	if err := pm.loadAndValidatePack(parentPack, pm.cfg.Path, depsPath, nil, loaded); err != nil {
 		return nil, fmt.Errorf("failed to load pack dependency: %v", err)
 	}
 
 	return parentPack, nil
 }
 
+// overrideMetadata applies the overrides to the metadata of the pack,
+// returning an error for each key which does not exist.
+func overrideMetadata(p *pack.Pack, overrides map[string]string) []*errors.WrappedUIContext {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []*errors.WrappedUIContext
+	for _, key := range keys {
+		if err := p.Metadata.Override(key, overrides[key]); err != nil {
+			errs = append(errs, &errors.WrappedUIContext{
+				Err:     err,
+				Subject: "failed to override pack metadata",
+				Context: errors.NewUIErrorContext(),
+			})
+		}
+	}
+	return errs
+}
+
+// loadParentPack loads and validates the parent pack, without loading its
+// dependencies.
+func (pm *PackManager) loadParentPack() (*pack.Pack, error) {
+	parentPack, err := loader.Load(pm.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pack: %w", err)
+	}
+
+	if err := parentPack.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate pack: %v", err)
+	}
+	return parentPack, nil
+}
+
 // loadAndValidatePack recursively loads a pack and it's dependencies. Errors
-// result in an immediate return.
-func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string) error {
+// result in an immediate return. Dependencies with a local source are loaded
+// from the path relative to the pack at curPath, and their own dependencies
+// from the deps directory within it. Otherwise, dependencies are loaded from
+// depsPath. The chain holds the absolute paths of the packs being loaded,
+// from the parent down, which is used to detect dependency cycles, while
+// loaded maps the name of each loaded pack to its absolute path, so that
+// distinct packs with the same name are detected.
+func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, curPath, depsPath string, chain []string, loaded map[string]string) error {
+
+	absPath, err := filepath.Abs(curPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pack path: %v", err)
+	}
+	for i, p := range chain {
+		if p == absPath || sameDir(p, absPath) {
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(chain[i:], absPath), " -> "))
+		}
+	}
+	chain = append(chain, absPath)
+
+	// Renders are keyed by pack name, so two packs with the same name would
+	// overwrite each other's templates.
+	if existing, ok := loaded[cur.Name()]; ok && existing != absPath {
+		return fmt.Errorf("packs at %s and %s have the same name %q", existing, absPath, cur.Name())
+	}
+	loaded[cur.Name()] = absPath
 
 	for _, dependency := range cur.Metadata.Dependencies {
 
@@ -140,24 +400,93 @@ func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string) erro
 			continue
 		}
 
+		dependencyPath, dependencyDepsPath := dependencyPaths(dependency, curPath, depsPath)
+
 		// Load and validate the dependent pack.
-		dependentPack, err := loader.Load(path.Join(depsPath, dependency.Name))
+		dependentPack, err := loadDependency(dependencyPath)
 		if err != nil {
-			return fmt.Errorf("failed to load dependent pack: %v", err)
-		}
-
-		if err := dependentPack.Validate(); err != nil {
-			return fmt.Errorf("failed to validate dependent pack: %v", err)
+			return err
 		}
 
 		// Add the dependency to the current pack.
 		cur.AddDependencies(dependentPack)
 
-		// Recursive call.
-		if err := pm.loadAndValidatePack(dependentPack, depsPath); err != nil {
+		// Recursive call. The chain is copied so that sibling dependencies
+		// do not share the backing array.
+		if err := pm.loadAndValidatePack(dependentPack, dependencyPath, dependencyDepsPath, append([]string{}, chain...), loaded); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// sameDir reports whether the paths are the same directory, such as when a
+// local dependency is reached through a symlink to one of its ancestors.
+func sameDir(a, b string) bool {
+	aID, err := filesystem.FileIdentity(a)
+	if err != nil {
+		return false
+	}
+	bID, err := filesystem.FileIdentity(b)
+	return err == nil && aID == bID
+}
+
+// dependencyPaths returns the path the dependency of the pack at curPath is
+// loaded from, and the path its own dependencies are loaded from. Dependencies
+// with a local source are loaded from the path relative to the pack, with
+// their dependencies in the deps directory within it. Otherwise, dependencies
+// are loaded from depsPath.
+func dependencyPaths(dependency *pack.Dependency, curPath, depsPath string) (string, string) {
+	if !dependency.IsLocalSource() {
+		return path.Join(depsPath, dependency.Name), depsPath
+	}
+	dependencyPath := dependency.Source
+	if !filepath.IsAbs(dependencyPath) {
+		dependencyPath = filepath.Join(curPath, dependencyPath)
+	}
+	return dependencyPath, path.Join(dependencyPath, "deps")
+}
+
+// loadDependency loads and validates the dependent pack at dependencyPath.
+func loadDependency(dependencyPath string) (*pack.Pack, error) {
+	dependentPack, err := loader.Load(dependencyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dependent pack: %v", err)
+	}
+
+	if err := dependentPack.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate dependent pack: %v", err)
+	}
+	return dependentPack, nil
+}
+
+// Warnings returns the warnings found while processing the variables in the
+// last call to ProcessTemplates.
+func (pm *PackManager) Warnings() []string {
+	return pm.warnings
+}
+
+// ProcessedVariables returns the variables resolved by the last call to
+// ProcessTemplates, including the source of each value.
+func (pm *PackManager) ProcessedVariables() *variable.ParsedVariables {
+	return pm.parsedVars
+}
+
+// VariableReferences returns the pack variable references made by the
+// templates processed by the last call to ProcessTemplates.
+func (pm *PackManager) VariableReferences() []renderer.VariableReference {
+	if pm.renderer == nil {
+		return nil
+	}
+	return pm.renderer.VariableReferences()
+}
+
+// TemplateBlocks returns the job and group blocks found within the templates
+// processed by the last call to ProcessTemplates.
+func (pm *PackManager) TemplateBlocks() []renderer.TemplateBlock {
+	if pm.renderer == nil {
+		return nil
+	}
+	return pm.renderer.TemplateBlocks()
+}
@@ -0,0 +1,138 @@
+package renderer
+
+import (
+	"regexp"
+	"sort"
+	"text/template/parse"
+)
+
+// TemplateBlock is a job or group block with a literal name found within the
+// text of a template, along with the variables referenced by the conditions
+// of the enclosing if and with actions, which control whether the block is
+// rendered.
+type TemplateBlock struct {
+
+	// Template is the name of the template containing the block, which for
+	// blocks within a define action is the name of the definition.
+	Template string
+
+	// Kind is the block type, either job or group.
+	Kind string
+
+	// Name is the label of the block.
+	Name string
+
+	// Job is the name of the job block preceding a group block within the
+	// template, if it has a literal name.
+	Job string
+
+	// Conditions are the variables referenced by the conditions enclosing
+	// the block, sorted by pack and variable. It is empty when the block is
+	// always rendered.
+	Conditions []VariableReference
+}
+
+// blockHeaderRe matches the header of a job or group block with a literal
+// name at the start of a line.
+var blockHeaderRe = regexp.MustCompile(`(?m)^[ \t]*(job|group)[ \t]+"([^"]+)"[ \t]*\{`)
+
+// TemplateBlocks returns the job and group blocks with literal names found
+// within the templates parsed during the last call to Render, in the order
+// they appear within each template, with the templates sorted by name.
+// Blocks whose headers are split by actions, such as those with a templated
+// name, are not found.
+func (r *Renderer) TemplateBlocks() []TemplateBlock {
+	if r.tpl == nil {
+		return nil
+	}
+
+	templates := r.tpl.Templates()
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+
+	var blocks []TemplateBlock
+	for _, t := range templates {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		w := blockWalker{template: t.Name()}
+		w.walk(t.Tree.Root, []string{}, nil)
+		blocks = append(blocks, w.blocks...)
+	}
+	return blocks
+}
+
+// blockWalker walks a template parse tree collecting the blocks found in its
+// text, tracking the conditions which enclose them. The dot is tracked in
+// the same manner as referenceWalker.
+type blockWalker struct {
+	template string
+	job      string
+	blocks   []TemplateBlock
+}
+
+func (w *blockWalker) walk(node parse.Node, dot []string, conds []VariableReference) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walk(child, dot, conds)
+		}
+	case *parse.TextNode:
+		for _, m := range blockHeaderRe.FindAllSubmatch(n.Text, -1) {
+			block := TemplateBlock{
+				Template:   w.template,
+				Kind:       string(m[1]),
+				Name:       string(m[2]),
+				Conditions: conds,
+			}
+			if block.Kind == "job" {
+				w.job = block.Name
+			} else {
+				block.Job = w.job
+			}
+			w.blocks = append(w.blocks, block)
+		}
+	case *parse.IfNode:
+		inner := w.conditions(n.Pipe, dot, conds)
+		w.walk(n.List, dot, inner)
+		w.walk(n.ElseList, dot, inner)
+	case *parse.WithNode:
+		inner := w.conditions(n.Pipe, dot, conds)
+		w.walk(n.List, (&referenceWalker{}).pipeDot(n.Pipe, dot), inner)
+		w.walk(n.ElseList, dot, inner)
+	case *parse.RangeNode:
+		w.walk(n.List, nil, conds)
+		w.walk(n.ElseList, dot, conds)
+	}
+}
+
+// conditions returns the enclosing conditions along with the variables
+// referenced by the pipeline, without duplicates.
+func (w *blockWalker) conditions(pipe *parse.PipeNode, dot []string, conds []VariableReference) []VariableReference {
+	refs := make(map[VariableReference]struct{})
+	(&referenceWalker{refs: refs}).walk(pipe, dot)
+
+	out := append([]VariableReference{}, conds...)
+	for ref := range refs {
+		ref.Template = w.template
+		var seen bool
+		for _, existing := range out {
+			if existing == ref {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, ref)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Pack != out[j].Pack {
+			return out[i].Pack < out[j].Pack
+		}
+		return out[i].Variable < out[j].Variable
+	})
+	return out
+}
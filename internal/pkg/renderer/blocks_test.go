@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_TemplateBlocks(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{
+			App:  &pack.MetadataApp{},
+			Pack: &pack.MetadataPack{Name: "example"},
+		},
+		TemplateFiles: []*pack.File{
+			{
+				Name: "templates/example.nomad.tpl",
+				Content: []byte(`job "example" {
+  group "app" {}
+  [[ if .example.enable_cache ]]
+  group "cache" {}
+  [[ else ]]
+  [[ with .example.fallback ]]
+  group "fallback" {}
+  [[ end ]]
+  [[ end ]]
+  [[ range .example.workers ]]
+  group "worker" {}
+  [[ end ]]
+}`),
+			},
+			{
+				Name:    "templates/other.nomad.tpl",
+				Content: []byte(`job [[ .example.name | quote ]] { group "other" {} }`),
+			},
+		},
+	}
+
+	r := new(Renderer)
+	_, err := r.Render(p, map[string]interface{}{"example": map[string]interface{}{}})
+	require.NoError(t, err)
+
+	ref := func(variable string) VariableReference {
+		return VariableReference{Template: "example/templates/example.nomad.tpl", Pack: "example", Variable: variable}
+	}
+	tpl := "example/templates/example.nomad.tpl"
+
+	// Blocks with templated names, and those not at the start of a line,
+	// are not found.
+	require.Equal(t, []TemplateBlock{
+		{Template: tpl, Kind: "job", Name: "example"},
+		{Template: tpl, Kind: "group", Name: "app", Job: "example"},
+		{Template: tpl, Kind: "group", Name: "cache", Job: "example", Conditions: []VariableReference{ref("enable_cache")}},
+		{Template: tpl, Kind: "group", Name: "fallback", Job: "example", Conditions: []VariableReference{ref("enable_cache"), ref("fallback")}},
+		{Template: tpl, Kind: "group", Name: "worker", Job: "example"},
+	}, r.TemplateBlocks())
+}
@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// templateErrorPosition matches the position text/template includes at the
+// start of its errors, which is of the form "template: name:line: " for parse
+// errors and "template: name:line:column: " for execution errors. The name
+// is that of the template file the error occurred in, which may be a helper
+// template included by the template being rendered.
+var templateErrorPosition = regexp.MustCompile(`^template: (.+?):(\d+):(?:(\d+):)? `)
+
+// TemplateError is an error parsing or executing a pack template, including
+// the position within the template file which caused it when known.
+type TemplateError struct {
+
+	// Name is the name of the template being parsed or rendered, such as
+	// example/templates/example.nomad.tpl.
+	Name string
+
+	// File is the template file the error occurred in, which is the path on
+	// disk when known, otherwise the template name. It differs from Name
+	// when the error occurred within an included helper template.
+	File string
+
+	// Line and Column are the position of the error within File. They are
+	// zero when not known; parse errors only include the line.
+	Line   int
+	Column int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *TemplateError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// Position returns the position of the error in the form file:line:column,
+// which editors can open directly. The column is omitted when not known and
+// an empty string is returned when the line is not known.
+func (e *TemplateError) Position() string {
+	switch {
+	case e.Line == 0:
+		return ""
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+}
+
+// Context returns the UI error context identifying the template and the
+// position of the error within it.
+func (e *TemplateError) Context() *errors.UIErrorContext {
+	ctx := errors.NewUIErrorContext()
+	ctx.Add(errors.UIContextPrefixTemplateName, e.Name)
+	if pos := e.Position(); pos != "" {
+		ctx.Add(errors.UIContextPrefixTemplatePosition, pos)
+	}
+	return ctx
+}
+
+// newTemplateError returns a TemplateError for the named template wrapping
+// err, with the position extracted from cause, which is the error returned
+// by text/template.
+func (r *Renderer) newTemplateError(name string, cause, err error) *TemplateError {
+	tplErr := &TemplateError{Name: name, File: name, Err: err}
+
+	match := templateErrorPosition.FindStringSubmatch(cause.Error())
+	if match == nil {
+		return tplErr
+	}
+	tplErr.File = match[1]
+	if file, ok := r.files[match[1]]; ok {
+		tplErr.File = file
+	}
+	tplErr.Line, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		tplErr.Column, _ = strconv.Atoi(match[3])
+	}
+	return tplErr
+}
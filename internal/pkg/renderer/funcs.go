@@ -1,20 +1,34 @@
 package renderer
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
 	"github.com/davecgh/go-spew/spew"
 	v1client "github.com/hashicorp/nomad-openapi/clients/go/v1"
 	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"gopkg.in/yaml.v3"
 )
 
+// maxPackFileSize is the largest file which can be read by the fileContents
+// and fileBase64 template functions, so that enormous files are not embedded
+// within the renders by accident.
+const maxPackFileSize = 1 << 20
+
 // funcMap instantiates our default template function map with populated
 // functions for use within text.Template.
-func funcMap(nomadClient *v1.Client) template.FuncMap {
+func funcMap(r *Renderer) template.FuncMap {
+	nomadClient := r.Client
 
 	// Sprig defines our base map.
 	f := sprig.TxtFuncMap()
@@ -30,22 +44,159 @@ func funcMap(nomadClient *v1.Client) template.FuncMap {
 		f["nomadRegions"] = nomadRegions(nomadClient)
 	}
 
+	// The Nomad variable functions are always defined, so that a pack using
+	// them fails with an explanation when they are not enabled.
+	f["nomadVariable"], f["nomadVariableItems"] = nomadVariableFuncs(r.NomadVariables)
+
 	// Add additional custom functions.
-	f["fileContents"] = fileContents
+	f["fileContents"] = r.packFileContents
+	f["fileBase64"] = r.packFileBase64
 	f["toStringList"] = toStringList
+	f["toYAML"] = toYAML
+	f["fromYAML"] = fromYAML
+
+	if r.Rand != nil {
+		seededFuncs(f, r.Rand)
+	}
 
 	return f
 }
 
-// fileContents reads the passed path and returns the content as a string.
-func fileContents(file string) (string, error) {
-	content, err := ioutil.ReadFile(file)
+// sprigFuncDescription is the description of the template functions
+// provided by Sprig, which are documented by Sprig itself.
+const sprigFuncDescription = "Sprig function, see https://masterminds.github.io/sprig/."
+
+// funcDescriptions are the descriptions of the template functions added to
+// the Sprig functions by funcMap. Each added function must be described.
+var funcDescriptions = map[string]string{
+	"spewDump":           "Dumps the passed values, including their types and structure, for debugging.",
+	"spewPrintf":         "Formats the values according to the format, like printf, including their types and structure.",
+	"nomadNamespaces":    "Lists the namespaces of the Nomad cluster. Requires access to the cluster.",
+	"nomadNamespace":     "Reads the named namespace of the Nomad cluster. Requires access to the cluster.",
+	"nomadRegions":       "Lists the regions of the Nomad cluster. Requires access to the cluster.",
+	"nomadVariable":      "Reads the Nomad variable at the path. Requires --nomad-variables.",
+	"nomadVariableItems": "Reads the items of the Nomad variable at the path as a map. Requires --nomad-variables.",
+	"fileContents":       "Reads the file at the path, relative to the pack directory, which it cannot be outside of.",
+	"fileBase64":         "Reads the file at the path, relative to the pack directory, and base64 encodes it.",
+	"toStringList":       "Formats the list as an HCL list of strings, such as [\"a\", \"b\"].",
+	"toYAML":             "Encodes the value as YAML.",
+	"fromYAML":           "Decodes the YAML string into a value.",
+}
+
+// FuncInfo describes a template function available to pack templates.
+type FuncInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Signature   string `json:"signature" yaml:"signature"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// FuncList returns the template functions available to pack templates,
+// sorted by name. These are the functions of the same map used when
+// rendering, including those which require access to a Nomad cluster.
+func FuncList() []*FuncInfo {
+	f := funcMap(&Renderer{Client: &v1.Client{}})
+
+	funcs := make([]*FuncInfo, 0, len(f))
+	for name, fn := range f {
+		description, ok := funcDescriptions[name]
+		if !ok {
+			description = sprigFuncDescription
+		}
+		funcs = append(funcs, &FuncInfo{
+			Name:        name,
+			Signature:   funcSignature(name, reflect.TypeOf(fn)),
+			Description: description,
+		})
+	}
+
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+// funcSignature returns the signature of the named function, such as
+// "fileContents(string) (string, error)".
+func funcSignature(name string, typ reflect.Type) string {
+	return name + strings.TrimPrefix(typ.String(), "func")
+}
+
+// packFileContents reads the file at the passed path, relative to the
+// directory of the pack being rendered, and returns the content as a string.
+func (r *Renderer) packFileContents(name string) (string, error) {
+	content, err := r.readPackFile(name)
 	if err != nil {
-		return "", fmt.Errorf("failed to read %s: %v", file, err)
+		return "", err
 	}
 	return string(content), nil
 }
 
+// packFileBase64 reads the file at the passed path, relative to the
+// directory of the pack being rendered, and returns the content base64
+// encoded. This allows binary files to be embedded.
+func (r *Renderer) packFileBase64(name string) (string, error) {
+	content, err := r.readPackFile(name)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(content), nil
+}
+
+// readPackFile reads the file at the passed path, relative to the directory
+// of the pack being rendered. Paths which resolve outside of the pack
+// directory, including through symlinks, and files larger than
+// maxPackFileSize are rejected.
+func (r *Renderer) readPackFile(name string) ([]byte, error) {
+	if r.fileRoot == "" {
+		return nil, fmt.Errorf("failed to read %s: pack directory is unknown", name)
+	}
+	if filepath.IsAbs(name) {
+		return nil, fmt.Errorf("path %q must be relative to the pack directory", name)
+	}
+
+	root, err := filepath.EvalSymlinks(r.fileRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve pack directory: %v", err)
+	}
+
+	target := filepath.Join(root, filepath.FromSlash(name))
+	if !withinDir(root, target) {
+		return nil, fmt.Errorf("path %q is outside of the pack directory", name)
+	}
+
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if !withinDir(root, resolved) {
+		return nil, fmt.Errorf("path %q is outside of the pack directory", name)
+	}
+
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("failed to read %s: not a regular file", name)
+	}
+	if fi.Size() > maxPackFileSize {
+		return nil, fmt.Errorf("file %q is %d bytes, which exceeds the limit of %d bytes", name, fi.Size(), maxPackFileSize)
+	}
+
+	content, err := ioutil.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	return content, nil
+}
+
+// withinDir returns whether path is dir or within it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // nomadNamespaces performs a Nomad API query against the namespace endpoint to
 // list the namespaces.
 func nomadNamespaces(client *v1.Client) func() (*[]v1client.Namespace, error) {
@@ -83,6 +234,61 @@ func toStringList(l []interface{}) (string, error) {
 	return "[" + out + "]", nil
 }
 
+// defaultYAMLIndent is the number of spaces used to indent nested YAML by
+// toYAML, when not specified.
+const defaultYAMLIndent = 2
+
+// toYAML marshals the passed value to YAML, without a trailing newline. It
+// takes either the value alone, or the number of spaces to indent nested
+// values by followed by the value, so it can be called as [[ toYAML 4 .x ]]
+// or [[ .x | toYAML 4 ]]. The output can then be indented as a block using
+// the indent and nindent functions.
+func toYAML(args ...interface{}) (string, error) {
+	indent := defaultYAMLIndent
+
+	var v interface{}
+	switch len(args) {
+	case 1:
+		v = args[0]
+	case 2:
+		switch i := args[0].(type) {
+		case int:
+			indent = i
+		case int64:
+			indent = int(i)
+		default:
+			return "", fmt.Errorf("toYAML indent must be an integer, got %T", args[0])
+		}
+		if indent < 1 {
+			return "", fmt.Errorf("toYAML indent must be at least 1, got %d", indent)
+		}
+		v = args[1]
+	default:
+		return "", fmt.Errorf("toYAML takes 1 or 2 arguments, got %d", len(args))
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("failed to marshal value to YAML: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal value to YAML: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// fromYAML parses the passed YAML document into a map, so that it can be
+// iterated over within a template.
+func fromYAML(s string) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+	}
+	return out, nil
+}
+
 // spewDump dumps the entire contents of the interface as a string. The output
 // includes the content types and values and is extremely useful for debugging.
 func spewDump(a interface{}) string { return spew.Sdump(a) }
@@ -1,9 +1,16 @@
 package renderer
 
 import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/Masterminds/sprig"
+	v1 "github.com/hashicorp/nomad-openapi/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_toStringList(t *testing.T) {
@@ -30,3 +37,100 @@ func Test_toStringList(t *testing.T) {
 		assert.Equal(t, tc.expectedOutput, actualOutput)
 	}
 }
+
+func TestRenderer_readPackFile(t *testing.T) {
+	dir := t.TempDir()
+	packDir := filepath.Join(dir, "pack")
+	require.NoError(t, os.MkdirAll(filepath.Join(packDir, "files"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "files", "run.sh"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "large"), make([]byte, maxPackFileSize+1), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(packDir, "link")))
+
+	r := &Renderer{fileRoot: packDir}
+
+	content, err := r.packFileContents("files/run.sh")
+	require.NoError(t, err)
+	require.Equal(t, "#!/bin/sh\n", content)
+
+	encoded, err := r.packFileBase64("files/run.sh")
+	require.NoError(t, err)
+	require.Equal(t, "IyEvYmluL3NoCg==", encoded)
+
+	testCases := []struct {
+		name   string
+		expErr string
+	}{
+		{name: "../secret", expErr: `path "../secret" is outside of the pack directory`},
+		{name: "files/../../secret", expErr: `path "files/../../secret" is outside of the pack directory`},
+		{name: "link", expErr: `path "link" is outside of the pack directory`},
+		{name: filepath.Join(dir, "secret"), expErr: "must be relative to the pack directory"},
+		{name: "large", expErr: `file "large" is 1048577 bytes, which exceeds the limit`},
+		{name: "files", expErr: "not a regular file"},
+		{name: "missing", expErr: "failed to read missing"},
+	}
+	for _, tc := range testCases {
+		_, err := r.packFileContents(tc.name)
+		require.Error(t, err, tc.name)
+		require.True(t, strings.Contains(err.Error(), tc.expErr), err.Error())
+	}
+}
+
+func Test_toYAML(t *testing.T) {
+	value := map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"c"}}}
+
+	out, err := toYAML(value)
+	require.NoError(t, err)
+	require.Equal(t, "a:\n  b:\n    - c", out)
+
+	out, err = toYAML(4, value)
+	require.NoError(t, err)
+	require.Equal(t, "a:\n    b:\n        - c", out)
+
+	_, err = toYAML("4", value)
+	require.EqualError(t, err, "toYAML indent must be an integer, got string")
+
+	_, err = toYAML(0, value)
+	require.EqualError(t, err, "toYAML indent must be at least 1, got 0")
+
+	_, err = toYAML()
+	require.EqualError(t, err, "toYAML takes 1 or 2 arguments, got 0")
+}
+
+func Test_fromYAML(t *testing.T) {
+	out, err := fromYAML("a: 1\nb:\n  - c\n")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": 1, "b": []interface{}{"c"}}, out)
+
+	_, err = fromYAML("a: [")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to parse YAML")
+}
+
+func TestFuncList(t *testing.T) {
+	funcs := FuncList()
+	require.True(t, sort.SliceIsSorted(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name }))
+
+	byName := make(map[string]*FuncInfo, len(funcs))
+	for _, fn := range funcs {
+		byName[fn.Name] = fn
+	}
+
+	// Every function added to the Sprig functions is described.
+	for name := range funcMap(&Renderer{Client: &v1.Client{}}) {
+		if _, ok := sprig.TxtFuncMap()[name]; ok {
+			continue
+		}
+		require.Contains(t, funcDescriptions, name)
+		require.NotEqual(t, sprigFuncDescription, byName[name].Description)
+	}
+
+	require.Equal(t, &FuncInfo{
+		Name:        "fileContents",
+		Signature:   "fileContents(string) (string, error)",
+		Description: funcDescriptions["fileContents"],
+	}, byName["fileContents"])
+	require.NotContains(t, byName, "file")
+	require.Equal(t, "toYAML(...interface {}) (string, error)", byName["toYAML"].Signature)
+	require.Equal(t, sprigFuncDescription, byName["upper"].Description)
+}
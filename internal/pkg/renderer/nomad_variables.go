@@ -0,0 +1,246 @@
+package renderer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNomadVariablesDisabled is returned by the Nomad variable template
+// functions when they have not been enabled.
+var ErrNomadVariablesDisabled = errors.New("Nomad variable functions are disabled")
+
+// nomadVariablesTimeout bounds each request to read a Nomad variable, so an
+// unreachable cluster does not stall the render.
+const nomadVariablesTimeout = 10 * time.Second
+
+// NomadVariables reads Nomad variables for the nomadVariable and
+// nomadVariableItems template functions. Each path is only read once, with
+// the items, or the error, held for any later lookups of the same path. As
+// the items can be secret, they are only held in memory.
+type NomadVariables struct {
+	// Address is the address of the Nomad API, such as
+	// http://127.0.0.1:4646.
+	Address string
+
+	// Token is the ACL token used to read the variables. If empty, the
+	// request is anonymous.
+	Token string
+
+	// Namespace and Region are the namespace and region the variables are
+	// read from. If empty, the defaults of the cluster are used.
+	Namespace string
+	Region    string
+
+	// HTTPClient is the client used to make requests. If nil, a client
+	// with a timeout of nomadVariablesTimeout is used.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	reads map[string]*nomadVariableRead
+}
+
+// nomadVariableRead is the result of reading a Nomad variable path.
+type nomadVariableRead struct {
+	items map[string]string
+	err   error
+}
+
+// NewNomadVariablesFromEnv returns a NomadVariables configured from the same
+// environment variables as the Nomad CLI: NOMAD_ADDR, NOMAD_TOKEN,
+// NOMAD_NAMESPACE, NOMAD_REGION, and the NOMAD_CACERT, NOMAD_CLIENT_CERT,
+// NOMAD_CLIENT_KEY and NOMAD_SKIP_VERIFY TLS settings.
+func NewNomadVariablesFromEnv() (*NomadVariables, error) {
+	addr := os.Getenv("NOMAD_ADDR")
+	if addr == "" {
+		addr = "http://127.0.0.1:4646"
+	}
+
+	tlsConfig, err := nomadTLSConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &NomadVariables{
+		Address:   addr,
+		Token:     os.Getenv("NOMAD_TOKEN"),
+		Namespace: os.Getenv("NOMAD_NAMESPACE"),
+		Region:    os.Getenv("NOMAD_REGION"),
+	}
+	if tlsConfig != nil {
+		v.HTTPClient = &http.Client{
+			Timeout:   nomadVariablesTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+	}
+	return v, nil
+}
+
+// nomadTLSConfigFromEnv returns the TLS configuration set by the environment,
+// or nil if none is set.
+func nomadTLSConfigFromEnv() (*tls.Config, error) {
+	caCert := os.Getenv("NOMAD_CACERT")
+	clientCert := os.Getenv("NOMAD_CLIENT_CERT")
+	clientKey := os.Getenv("NOMAD_CLIENT_KEY")
+	skipVerify, _ := strconv.ParseBool(os.Getenv("NOMAD_SKIP_VERIFY"))
+
+	if caCert == "" && clientCert == "" && !skipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: skipVerify}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading NOMAD_CACERT: %v", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		cfg.RootCAs.AppendCertsFromPEM(pem)
+	}
+	if clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading NOMAD_CLIENT_CERT: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// Items returns the items of the Nomad variable at the path. Only the first
+// lookup of a path reads it from the cluster.
+func (v *NomadVariables) Items(path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, errors.New("Nomad variable path must not be empty")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if read, ok := v.reads[path]; ok {
+		return read.items, read.err
+	}
+
+	items, err := v.read(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read Nomad variable %q: %v", path, err)
+	}
+	if v.reads == nil {
+		v.reads = make(map[string]*nomadVariableRead)
+	}
+	v.reads[path] = &nomadVariableRead{items: items, err: err}
+	return items, err
+}
+
+// Item returns the value of the key within the Nomad variable at the path.
+func (v *NomadVariables) Item(path, key string) (string, error) {
+	items, err := v.Items(path)
+	if err != nil {
+		return "", err
+	}
+	val, ok := items[key]
+	if !ok {
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return "", fmt.Errorf("Nomad variable %q has no item %q, its items are: %s",
+			strings.Trim(path, "/"), key, strings.Join(keys, ", "))
+	}
+	return val, nil
+}
+
+// read reads the items of the Nomad variable at the path from the cluster.
+func (v *NomadVariables) read(path string) (map[string]string, error) {
+	u, err := url.Parse(strings.TrimSuffix(v.Address, "/") + "/v1/var/" + path)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	if v.Namespace != "" {
+		query.Set("namespace", v.Namespace)
+	}
+	if v.Region != "" {
+		query.Set("region", v.Region)
+	}
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), nomadVariablesTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Nomad-Token", v.Token)
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: nomadVariablesTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errors.New("variable not found")
+	case http.StatusForbidden:
+		return nil, errors.New("permission denied, check NOMAD_TOKEN can read the variable")
+	default:
+		return nil, fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out struct {
+		Items map[string]string
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if out.Items == nil {
+		out.Items = make(map[string]string)
+	}
+	return out.Items, nil
+}
+
+// nomadVariableFuncs returns the nomadVariable and nomadVariableItems
+// template functions. If v is nil, the functions are disabled, and return
+// an error explaining how to enable them.
+func nomadVariableFuncs(v *NomadVariables) (item func(string, string) (string, error), items func(string) (map[string]string, error)) {
+	if v == nil {
+		disabled := fmt.Errorf("%w, use --nomad-variables to enable them", ErrNomadVariablesDisabled)
+		item = func(path, _ string) (string, error) {
+			return "", fmt.Errorf("failed to read Nomad variable %q: %w", path, disabled)
+		}
+		items = func(path string) (map[string]string, error) {
+			return nil, fmt.Errorf("failed to read Nomad variable %q: %w", path, disabled)
+		}
+		return item, items
+	}
+	return v.Item, v.Items
+}
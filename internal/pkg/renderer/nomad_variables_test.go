@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNomadVariables(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		require.Equal(t, "secret-token", r.Header.Get("X-Nomad-Token"))
+		require.Equal(t, "apps", r.URL.Query().Get("namespace"))
+
+		switch r.URL.Path {
+		case "/v1/var/nomad/jobs/web":
+			fmt.Fprint(w, `{"Namespace": "apps", "Path": "nomad/jobs/web", "Items": {"password": "hunter2", "user": "web"}}`)
+		case "/v1/var/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	v := &NomadVariables{Address: srv.URL, Token: "secret-token", Namespace: "apps"}
+
+	items, err := v.Items("nomad/jobs/web")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"password": "hunter2", "user": "web"}, items)
+
+	// Repeated lookups of the same path, including of individual items, are
+	// served without fetching the variable again.
+	val, err := v.Item("/nomad/jobs/web", "password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", val)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	_, err = v.Item("nomad/jobs/web", "pasword")
+	require.EqualError(t, err, `Nomad variable "nomad/jobs/web" has no item "pasword", its items are: password, user`)
+
+	_, err = v.Items("nomad/jobs/missing")
+	require.EqualError(t, err, `failed to read Nomad variable "nomad/jobs/missing": variable not found`)
+
+	_, err = v.Items("forbidden")
+	require.Contains(t, err.Error(), "permission denied")
+
+	// Failures are also held, so an unreachable cluster is not retried.
+	_, err = v.Items("nomad/jobs/missing")
+	require.Error(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestNomadVariables_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	v := &NomadVariables{Address: addr}
+	_, err := v.Item("nomad/jobs/web", "password")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `failed to read Nomad variable "nomad/jobs/web"`)
+}
+
+func TestNomadVariableFuncs_Disabled(t *testing.T) {
+	item, items := nomadVariableFuncs(nil)
+
+	_, err := item("nomad/jobs/web", "password")
+	require.True(t, errors.Is(err, ErrNomadVariablesDisabled))
+	require.Contains(t, err.Error(), "--nomad-variables")
+
+	_, err = items("nomad/jobs/web")
+	require.True(t, errors.Is(err, ErrNomadVariablesDisabled))
+}
@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"fmt"
+	"math/rand"
+	"text/template"
+)
+
+const (
+	randAlphaChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	randNumericChars = "0123456789"
+)
+
+// seededFuncs replaces the random template functions of the map with
+// versions using the passed source of randomness, so renders are reproducible
+// when it is seeded. The key generation, certificate and password hashing
+// functions continue to use cryptographic randomness.
+func seededFuncs(f template.FuncMap, rng *rand.Rand) {
+	f["randAlphaNum"] = func(count int) string { return randString(rng, count, randAlphaChars+randNumericChars) }
+	f["randAlpha"] = func(count int) string { return randString(rng, count, randAlphaChars) }
+	f["randNumeric"] = func(count int) string { return randString(rng, count, randNumericChars) }
+	f["randAscii"] = func(count int) string { return randASCII(rng, count) }
+	f["uuidv4"] = func() string { return randUUID(rng) }
+	f["shuffle"] = func(s string) string { return randShuffle(rng, s) }
+}
+
+// randString returns a string of count characters chosen at random from
+// chars.
+func randString(rng *rand.Rand, count int, chars string) string {
+	if count <= 0 {
+		return ""
+	}
+	b := make([]byte, count)
+	for i := range b {
+		b[i] = chars[rng.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// randASCII returns a string of count printable ASCII characters chosen at
+// random, including the space.
+func randASCII(rng *rand.Rand, count int) string {
+	if count <= 0 {
+		return ""
+	}
+	b := make([]byte, count)
+	for i := range b {
+		b[i] = byte(' ' + rng.Intn('~'-' '+1))
+	}
+	return string(b)
+}
+
+// randUUID returns a random version 4 UUID.
+func randUUID(rng *rand.Rand) string {
+	var b [16]byte
+	_, _ = rng.Read(b[:])
+
+	// Set the version and variant bits as required by RFC 4122.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// randShuffle returns the characters of s in a random order.
+func randShuffle(rng *rand.Rand, s string) string {
+	runes := []rune(s)
+	rng.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+	return string(runes)
+}
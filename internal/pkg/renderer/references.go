@@ -0,0 +1,150 @@
+package renderer
+
+import (
+	"sort"
+	"text/template/parse"
+)
+
+// VariableReference is a reference to a pack variable made by a template, in
+// the form [[ .pack_name.variable_name ]].
+type VariableReference struct {
+
+	// Template is the name of the template making the reference, which for
+	// references within a define action is the name of the definition.
+	Template string
+
+	// Pack is the name of the pack the variable belongs to.
+	Pack string
+
+	// Variable is the name of the variable.
+	Variable string
+}
+
+// VariableReferences returns the pack variable references made by the
+// templates parsed during the last call to Render, sorted by template, pack,
+// and variable. References are identified by their field chain from the
+// template data root. Fields accessed relative to a changed dot, such as
+// within range, cannot be attributed to a variable so are not included,
+// with the exception of the dot being set to a pack using with.
+func (r *Renderer) VariableReferences() []VariableReference {
+	if r.tpl == nil {
+		return nil
+	}
+
+	seen := make(map[VariableReference]struct{})
+
+	for _, t := range r.tpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		w := referenceWalker{template: t.Name(), refs: seen}
+		w.walk(t.Tree.Root, []string{})
+	}
+
+	refs := make([]VariableReference, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Template != refs[j].Template {
+			return refs[i].Template < refs[j].Template
+		}
+		if refs[i].Pack != refs[j].Pack {
+			return refs[i].Pack < refs[j].Pack
+		}
+		return refs[i].Variable < refs[j].Variable
+	})
+	return refs
+}
+
+// referenceWalker walks a template parse tree collecting the variable
+// references. The dot passed when walking is the field chain of the current
+// dot from the data root, or nil if it is unknown.
+type referenceWalker struct {
+	template string
+	refs     map[VariableReference]struct{}
+}
+
+func (w *referenceWalker) walk(node parse.Node, dot []string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe, dot)
+	case *parse.TemplateNode:
+		w.walk(n.Pipe, dot)
+	case *parse.IfNode:
+		w.walk(n.Pipe, dot)
+		w.walk(n.List, dot)
+		w.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		w.walk(n.Pipe, dot)
+		w.walk(n.List, w.pipeDot(n.Pipe, dot))
+		w.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		w.walk(n.Pipe, dot)
+		w.walk(n.List, nil)
+		w.walk(n.ElseList, dot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			w.walk(cmd, dot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			w.walk(arg, dot)
+		}
+	case *parse.FieldNode:
+		w.add(dot, n.Ident)
+	case *parse.VariableNode:
+		// Only $ is known to refer to the data root; other variables could
+		// hold anything.
+		if n.Ident[0] == "$" {
+			w.add([]string{}, n.Ident[1:])
+		}
+	case *parse.ChainNode:
+		switch inner := n.Node.(type) {
+		case *parse.FieldNode:
+			w.add(dot, append(append([]string{}, inner.Ident...), n.Field...))
+		case *parse.VariableNode:
+			if inner.Ident[0] == "$" {
+				w.add([]string{}, append(append([]string{}, inner.Ident[1:]...), n.Field...))
+			}
+		default:
+			w.walk(n.Node, dot)
+		}
+	}
+}
+
+// pipeDot returns the field chain the pipeline sets the dot to, which is only
+// known when it consists of a single field.
+func (w *referenceWalker) pipeDot(pipe *parse.PipeNode, dot []string) []string {
+	if dot == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return nil
+	}
+	return append(append([]string{}, dot...), field.Ident...)
+}
+
+// add records the reference made by the field chain relative to the dot, if
+// it identifies a pack variable.
+func (w *referenceWalker) add(dot, fields []string) {
+	if dot == nil {
+		return
+	}
+	chain := append(append([]string{}, dot...), fields...)
+	if len(chain) < 2 {
+		return
+	}
+	w.refs[VariableReference{Template: w.template, Pack: chain[0], Variable: chain[1]}] = struct{}{}
+}
@@ -0,0 +1,51 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_VariableReferences(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{
+			App:  &pack.MetadataApp{},
+			Pack: &pack.MetadataPack{Name: "example"},
+		},
+		TemplateFiles: []*pack.File{
+			{
+				Name:    "templates/_helpers.tpl",
+				Content: []byte(`[[ define "region" ]][[ if .example.region ]]region = [[ .example.region | quote ]][[ end ]][[ end ]]`),
+			},
+			{
+				Name: "templates/example.nomad.tpl",
+				Content: []byte(`job [[ .example.job_name | quote ]] {
+[[ template "region" . ]]
+[[ with .example ]]count = [[ .count ]][[ end ]]
+[[ range .example.datacenters ]][[ .ignored ]] [[ $.example.constraint ]][[ end ]]
+[[ .nomad_pack.pack.name ]]
+}`),
+			},
+		},
+	}
+
+	r := new(Renderer)
+	_, err := r.Render(p, map[string]interface{}{"example": map[string]interface{}{}})
+	require.NoError(t, err)
+
+	ref := func(tpl, variable string) VariableReference {
+		return VariableReference{Template: tpl, Pack: "example", Variable: variable}
+	}
+	job := "example/templates/example.nomad.tpl"
+
+	// References within defined templates are attributed to the definition.
+	require.Equal(t, []VariableReference{
+		ref(job, "constraint"),
+		ref(job, "count"),
+		ref(job, "datacenters"),
+		ref(job, "job_name"),
+		{Template: job, Pack: "nomad_pack", Variable: "pack"},
+		ref("region", "region"),
+	}, r.VariableReferences())
+}
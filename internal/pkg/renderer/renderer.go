@@ -2,11 +2,18 @@ package renderer
 
 import (
 	"fmt"
+	"math/rand"
+	"os"
 	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 
 	v1 "github.com/hashicorp/nomad-openapi/v1"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 )
 
@@ -23,18 +30,79 @@ type Renderer struct {
 	// when accessing it.
 	Client *v1.Client
 
+	// NomadVariables, if set, enables the template functions which read
+	// Nomad variables from the cluster. As the variables are often secret,
+	// reading them must be opted in to.
+	NomadVariables *NomadVariables
+
+	// LeftDelim and RightDelim are the template action delimiters used when
+	// parsing the pack templates, including the output templates. If empty,
+	// the defaults of [[ and ]] are used.
+	LeftDelim  string
+	RightDelim string
+
+	// Trace, if set, is used to log at debug level each template as it is
+	// parsed and executed, the variables in its scope, the templates it
+	// includes, and the template functions it calls.
+	Trace logging.Logger
+
+	// KeepGoing controls whether the remaining templates are rendered when a
+	// template fails to parse or execute. If set, the failures are returned
+	// by Rendered.Failures rather than as an error.
+	KeepGoing bool
+
+	// Rand, if set, is the source of randomness used by the random template
+	// functions, such as randAlphaNum and uuidv4, so renders are
+	// reproducible when it is seeded. Otherwise, they use the package-global
+	// random sources.
+	Rand *rand.Rand
+
+	// Parallelism is the number of templates executed concurrently. If not
+	// positive, runtime.GOMAXPROCS is used. The templates are executed
+	// serially when Trace or Rand is set, so the trace output is readable
+	// and seeded renders are reproducible.
+	Parallelism int
+
 	// stores the pack information, variables and tpl, so we can perform the
 	// output template rendering after pack deployment.
 	pack      *pack.Pack
 	variables map[string]interface{}
 	tpl       *template.Template
+
+	// fileRoot is the directory of the pack whose template is being
+	// executed, which the file template functions read relative to.
+	fileRoot string
+
+	// files maps the name of each template to the path of its file, so the
+	// positions of errors can be reported against the file.
+	files map[string]string
+
+	// definedIn maps each template name to the file which defined it, and
+	// traceTemplate is the template being executed. These are only tracked
+	// when tracing.
+	definedIn     map[string]string
+	traceTemplate string
 }
 
 // toRender details an individual template to render along with it's scoped
 // variables.
 type toRender struct {
 	content   string
+	mode      os.FileMode
 	variables map[string]interface{}
+	root      string
+
+	// file is the path of the template file, or empty if the pack was not
+	// loaded from disk.
+	file string
+
+	// outputPath is the path the template is rendered to as annotated by
+	// the pack metadata, including the pack name, or empty if there is none.
+	outputPath string
+
+	// executable is whether the pack metadata annotates the template as
+	// executable.
+	executable bool
 }
 
 const (
@@ -42,6 +110,19 @@ const (
 	rightTemplateDelim = "]]"
 )
 
+// delims returns the template action delimiters, falling back to the
+// defaults when not set.
+func (r *Renderer) delims() (string, string) {
+	left, right := r.LeftDelim, r.RightDelim
+	if left == "" {
+		left = leftTemplateDelim
+	}
+	if right == "" {
+		right = rightTemplateDelim
+	}
+	return left, right
+}
+
 // Render is responsible for iterating the pack and rendering each defined
 // template using the parsed variable map.
 func (r *Renderer) Render(p *pack.Pack, variables map[string]interface{}) (*Rendered, error) {
@@ -52,7 +133,11 @@ func (r *Renderer) Render(p *pack.Pack, variables map[string]interface{}) (*Rend
 
 	// Set up our new template, add the function mapping, and set the
 	// delimiters.
-	tpl := template.New("tpl").Funcs(funcMap(r.Client)).Delims(leftTemplateDelim, rightTemplateDelim)
+	funcs := funcMap(r)
+	if r.Trace != nil {
+		funcs = r.traceFuncMap(funcs)
+	}
+	tpl := template.New("tpl").Funcs(funcs).Delims(r.delims())
 
 	// Control the behaviour of rendering when it encounters an element
 	// referenced which doesn't exist within the variable mapping.
@@ -62,40 +147,75 @@ func (r *Renderer) Render(p *pack.Pack, variables map[string]interface{}) (*Rend
 		tpl.Option("missingkey=zero")
 	}
 
-	for name, src := range templatesToRender {
-		if tpl.Lookup(name) == nil {
-			if _, err := tpl.New(name).Parse(src.content); err != nil {
-				return nil, err
-			}
-		}
-	}
-
 	// Generate our output structure.
 	rendered := &Rendered{
 		parentRenders:    make(map[string]string),
 		dependentRenders: make(map[string]string),
+		fileModes:        make(map[string]os.FileMode),
+		outputPaths:      make(map[string]string),
+		executables:      make(map[string]bool),
 	}
 
+	r.files = make(map[string]string, len(templatesToRender))
 	for name, src := range templatesToRender {
+		if src.file != "" {
+			r.files[name] = src.file
+		}
+	}
 
+	for name, src := range templatesToRender {
+		if src.outputPath != "" {
+			rendered.outputPaths[name] = src.outputPath
+		}
+		if src.executable {
+			rendered.executables[name] = true
+		}
+		if tpl.Lookup(name) == nil {
+			if err := r.traceParse(tpl, name, src.content); err != nil {
+				tplErr := r.newTemplateError(name, err, err)
+				if !r.KeepGoing {
+					return nil, tplErr
+				}
+				rendered.failures = append(rendered.failures, &TemplateFailure{Name: name, Err: tplErr})
+			}
+		}
+	}
+
+	// The templates are executed in order of their names, so the random
+	// template functions produce the same values when seeded.
+	names := make([]string, 0, len(templatesToRender))
+	for name := range templatesToRender {
 		// Skip the helper templates as we don't need to render these. They are
-		// called and used from within full templates.
-		if strings.Contains(name, "templates/_") {
+		// called and used from within full templates. Templates which failed
+		// to parse have already been recorded as failed.
+		if strings.Contains(name, "templates/_") || tpl.Lookup(name) == nil {
 			continue
 		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		// Execute the template render and add this to the output unless there
-		// is an error.
-		var buf strings.Builder
+	results, err := r.executeTemplates(tpl, names, templatesToRender)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := tpl.ExecuteTemplate(&buf, name, src.variables); err != nil {
-			return nil, fmt.Errorf("failed to render %s: %v", name, err)
+	// The results are added to the output in order of the template names,
+	// whatever order they were executed in.
+	for i, name := range names {
+		src := templatesToRender[name]
+		if results[i].err != nil {
+			if !r.KeepGoing {
+				return nil, r.newTemplateError(name, results[i].err, fmt.Errorf("failed to render %s: %v", name, results[i].err))
+			}
+			rendered.failures = append(rendered.failures, &TemplateFailure{Name: name, Err: r.newTemplateError(name, results[i].err, results[i].err)})
+			continue
 		}
 
 		// Even when using "missingkey=zero", missing values will be rendered
 		// when "<no value>" rather than an empty string. This modifies that
 		// behaviour.
-		replacedTpl := strings.ReplaceAll(buf.String(), "<no value>", "")
+		replacedTpl := strings.ReplaceAll(results[i].out, "<no value>", "")
 
 		// Split the name so the element at index zero becomes the pack name.
 		nameSplit := strings.Split(name, "/")
@@ -107,8 +227,13 @@ func (r *Renderer) Render(p *pack.Pack, variables map[string]interface{}) (*Rend
 		} else {
 			rendered.dependentRenders[name] = replacedTpl
 		}
+		rendered.fileModes[name] = src.mode
 	}
 
+	sort.Slice(rendered.failures, func(i, j int) bool {
+		return rendered.failures[i].Name < rendered.failures[j].Name
+	})
+
 	r.variables = variables
 	r.pack = p
 	r.tpl = tpl
@@ -116,21 +241,171 @@ func (r *Renderer) Render(p *pack.Pack, variables map[string]interface{}) (*Rend
 	return rendered, nil
 }
 
-// RenderOutput performs the output template rendering.
-func (r *Renderer) RenderOutput() (string, error) {
+// executeResult is the output, or error, of executing a single template.
+type executeResult struct {
+	out string
+	err error
+}
+
+// executeTemplates executes the named templates, returning the result of
+// each in the same order as the names. The templates are executed by a pool
+// of Parallelism workers, each with its own clone of tpl, as the file
+// template functions read relative to the pack of the template being
+// executed. An error is only returned if the template cannot be cloned.
+func (r *Renderer) executeTemplates(tpl *template.Template, names []string, templates map[string]toRender) ([]executeResult, error) {
+	results := make([]executeResult, len(names))
+
+	workers := r.Parallelism
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if r.Trace != nil || r.Rand != nil {
+		workers = 1
+	}
+	if workers > len(names) {
+		workers = len(names)
+	}
+
+	if workers <= 1 {
+		for i, name := range names {
+			src := templates[name]
+
+			// Files read by the template are relative to the directory of
+			// the pack which contains it.
+			r.fileRoot = src.root
+			r.traceExecute(tpl, name, src.variables)
+			results[i] = executeTemplate(tpl, name, src.variables)
+		}
+		return results, nil
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		worker := &Renderer{files: r.files}
+		workerTpl, err := tpl.Clone()
+		if err != nil {
+			close(indexes)
+			wg.Wait()
+			return nil, fmt.Errorf("failed to prepare templates: %v", err)
+		}
+		workerTpl.Funcs(template.FuncMap{
+			"fileContents": worker.packFileContents,
+			"fileBase64":   worker.packFileBase64,
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				src := templates[names[idx]]
+				worker.fileRoot = src.root
+				results[idx] = executeTemplate(workerTpl, names[idx], src.variables)
+			}
+		}()
+	}
+
+	for i := range names {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+// executeTemplate executes the named template with the variables.
+func executeTemplate(tpl *template.Template, name string, variables map[string]interface{}) executeResult {
+	var buf strings.Builder
+	if err := tpl.ExecuteTemplate(&buf, name, variables); err != nil {
+		return executeResult{err: err}
+	}
+	return executeResult{out: buf.String()}
+}
+
+// RenderOutput performs the output template rendering. The name identifies
+// the output template file within the pack to render; if empty, the default
+// output template is used.
+func (r *Renderer) RenderOutput(name string) (string, error) {
+
+	outputFile, err := r.pack.OutputTemplate(name)
+	if err != nil {
+		return "", err
+	}
 
 	// If we don't have a template file, then return early.
-	if r.pack.OutputTemplateFile == nil {
+	if outputFile == nil {
 		return "", nil
 	}
 
-	if _, err := r.tpl.New(r.pack.OutputTemplateFile.Name).Parse(string(r.pack.OutputTemplateFile.Content)); err != nil {
-		return "", err
+	return r.renderOutputFile(r.pack, outputFile.Name, outputFile, r.variables)
+}
+
+// DependencyOutput is the rendered output template of a dependency pack.
+type DependencyOutput struct {
+
+	// Pack is the name of the dependency pack.
+	Pack string
+
+	// Name is the path and file name of the output template, such as
+	// redis/outputs.tpl.
+	Name string
+
+	// Content is the rendered output template, which is empty if rendering
+	// failed.
+	Content string
+
+	// Err is the error rendering the output template, if any.
+	Err error
+}
+
+// RenderDependencyOutputs renders the default output template of each of the
+// dependencies of the pack, at any depth, which has one. Each is rendered
+// with the dependency's scoped variables. A failure to render one output
+// template is returned within its DependencyOutput, so the remaining output
+// templates are still rendered. The outputs are sorted by name.
+func (r *Renderer) RenderDependencyOutputs() []*DependencyOutput {
+	var outputs []*DependencyOutput
+	r.renderDependencyOutputs(r.pack, map[string]bool{}, &outputs)
+
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	return outputs
+}
+
+// renderDependencyOutputs appends the rendered output templates of the
+// dependencies of the pack, recursing into their own dependencies. A pack
+// depended on by more than one pack is only rendered once, tracked by seen.
+func (r *Renderer) renderDependencyOutputs(p *pack.Pack, seen map[string]bool, outputs *[]*DependencyOutput) {
+	for _, dep := range p.Dependencies() {
+		if seen[dep.Name()] {
+			continue
+		}
+		seen[dep.Name()] = true
+
+		if dep.OutputTemplateFile != nil {
+			name := path.Join(dep.Name(), dep.OutputTemplateFile.Name)
+			content, err := r.renderOutputFile(dep, name, dep.OutputTemplateFile, packVariables(dep, r.variables))
+			*outputs = append(*outputs, &DependencyOutput{Pack: dep.Name(), Name: name, Content: content, Err: err})
+		}
+		r.renderDependencyOutputs(dep, seen, outputs)
+	}
+}
+
+// renderOutputFile parses the output template file of the pack under the
+// passed name and executes it with the variables.
+func (r *Renderer) renderOutputFile(p *pack.Pack, name string, outputFile *pack.File, variables map[string]interface{}) (string, error) {
+	if p.Path != "" {
+		r.files[name] = filepath.Join(p.Path, outputFile.Name)
+	}
+	if err := r.traceParse(r.tpl, name, string(outputFile.Content)); err != nil {
+		return "", r.newTemplateError(name, err, err)
 	}
 
 	var buf strings.Builder
-	if err := r.tpl.ExecuteTemplate(&buf, r.pack.OutputTemplateFile.Name, r.variables); err != nil {
-		return "", fmt.Errorf("failed to render %s: %v", r.pack.OutputTemplateFile.Name, err)
+	r.fileRoot = p.Path
+	r.traceExecute(r.tpl, name, variables)
+	if err := r.tpl.ExecuteTemplate(&buf, name, variables); err != nil {
+		return "", r.newTemplateError(name, err, fmt.Errorf("failed to render %s: %v", name, err))
 	}
 
 	return buf.String(), nil
@@ -138,9 +413,35 @@ func (r *Renderer) RenderOutput() (string, error) {
 
 // prepareTemplates recurses the pack and it's dependencies to populate to the
 // passed map with the templates to render along with the variables which
-// correspond.
+// correspond. The passed variables are those of all packs, so that
+// dependencies at any depth can be scoped to their own variables.
 func prepareTemplates(p *pack.Pack, templates map[string]toRender, variables map[string]interface{}) {
 
+	newVars := packVariables(p, variables)
+
+	// Iterate the dependencies and prepareTemplates for each.
+	for _, child := range p.Dependencies() {
+		prepareTemplates(child, templates, variables)
+	}
+
+	// Add each template within the pack with scoped variables.
+	for _, t := range p.TemplateFiles {
+		src := toRender{content: string(t.Content), mode: t.Mode, variables: newVars, root: p.Path}
+		if p.Path != "" {
+			src.file = filepath.Join(p.Path, filepath.FromSlash(t.Name))
+		}
+		if outputPath := p.Metadata.OutputPath(t.Name); outputPath != "" {
+			src.outputPath = path.Join(p.Name(), outputPath)
+		}
+		src.executable = p.Metadata.Executable(t.Name)
+		templates[path.Join(p.Name(), t.Name)] = src
+	}
+}
+
+// packVariables returns the variables in scope of the pack's templates, from
+// the variables of all packs, along with the pack's metadata.
+func packVariables(p *pack.Pack, variables map[string]interface{}) map[string]interface{} {
+
 	newVars := make(map[string]interface{})
 
 	// If the pack is a dependency, it only has access to its namespaced
@@ -155,17 +456,7 @@ func prepareTemplates(p *pack.Pack, templates map[string]toRender, variables map
 	}
 
 	// Add the pack's metadata to the variable mapping.
-	newVars = p.Metadata.AddToInterfaceMap(newVars)
-
-	// Iterate the dependencies and prepareTemplates for each.
-	for _, child := range p.Dependencies() {
-		prepareTemplates(child, templates, newVars)
-	}
-
-	// Add each template within the pack with scoped variables.
-	for _, t := range p.TemplateFiles {
-		templates[path.Join(p.Name(), t.Name)] = toRender{content: string(t.Content), variables: newVars}
-	}
+	return p.Metadata.AddToInterfaceMap(newVars)
 }
 
 // Rendered encapsulates all the rendered template files associated with the
@@ -174,6 +465,21 @@ func prepareTemplates(p *pack.Pack, templates map[string]toRender, variables map
 type Rendered struct {
 	parentRenders    map[string]string
 	dependentRenders map[string]string
+	fileModes        map[string]os.FileMode
+	outputPaths      map[string]string
+	executables      map[string]bool
+	failures         []*TemplateFailure
+}
+
+// TemplateFailure is a template which failed to parse or execute when
+// rendering with KeepGoing set.
+type TemplateFailure struct {
+
+	// Name is the path and file name of the template.
+	Name string
+
+	// Err is the error parsing or executing the template.
+	Err error
 }
 
 // ParentRenders returns a map of rendered templates belonging to the parent
@@ -192,3 +498,20 @@ func (r *Rendered) DependentRenders() map[string]string { return r.dependentRend
 // LenDependentRenders returns the number of dependent rendered templates that
 // are stored.
 func (r *Rendered) LenDependentRenders() int { return len(r.dependentRenders) }
+
+// FileMode returns the permission bits of the source template for the passed
+// render name. If the render is not found, zero is returned.
+func (r *Rendered) FileMode(name string) os.FileMode { return r.fileModes[name] }
+
+// OutputPath returns the path, including the pack name, which the pack
+// metadata annotates the passed template to be rendered to. If there is no
+// annotation, an empty string is returned.
+func (r *Rendered) OutputPath(name string) string { return r.outputPaths[name] }
+
+// Executable returns whether the pack metadata annotates the passed template
+// as executable.
+func (r *Rendered) Executable(name string) bool { return r.executables[name] }
+
+// Failures returns the templates which failed to render, sorted by name. It
+// is only populated when rendering with KeepGoing set.
+func (r *Rendered) Failures() []*TemplateFailure { return r.failures }
@@ -0,0 +1,349 @@
+package renderer
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Delims(t *testing.T) {
+	metadata := func(name string) *pack.Metadata {
+		return &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: name}}
+	}
+
+	dep := &pack.Pack{
+		Metadata: metadata("dep"),
+		TemplateFiles: []*pack.File{
+			{Name: "templates/dep.nomad.tpl", Content: []byte(`dep = << .dep.value >> {{ keep }}`)},
+		},
+	}
+	p := &pack.Pack{
+		Metadata: metadata("example"),
+		TemplateFiles: []*pack.File{
+			{Name: "templates/example.nomad.tpl", Content: []byte(`example = << .example.value >> [[ keep ]]`)},
+		},
+		OutputTemplateFile: &pack.File{Name: "outputs.tpl", Content: []byte(`output = << .example.value >>`)},
+	}
+	p.AddDependencies(dep)
+
+	r := &Renderer{LeftDelim: "<<", RightDelim: ">>"}
+	rendered, err := r.Render(p, map[string]interface{}{
+		"example": map[string]interface{}{"value": "a"},
+		"dep":     map[string]interface{}{"value": "b"},
+	})
+	require.NoError(t, err)
+
+	// The delimiters apply to the parent, dependent, and output templates,
+	// while the default delimiters are output as is.
+	require.Equal(t, "example = a [[ keep ]]", rendered.ParentRenders()["example/templates/example.nomad.tpl"])
+	require.Equal(t, "dep = b {{ keep }}", rendered.DependentRenders()["dep/templates/dep.nomad.tpl"])
+
+	output, err := r.RenderOutput("")
+	require.NoError(t, err)
+	require.Equal(t, "output = a", output)
+}
+
+func TestRenderer_Trace(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: "example"}},
+		TemplateFiles: []*pack.File{
+			{Name: "templates/_helpers.tpl", Content: []byte(`[[ define "name" ]][[ template "prefix" . ]][[ .example.value | upper ]][[ end ]][[ define "prefix" ]]job-[[ end ]]`)},
+			{Name: "templates/example.nomad.tpl", Content: []byte(`job = [[ template "name" . ]] [[ printf "%s-%d" "a" 1 ]]`)},
+		},
+	}
+
+	var logs []string
+	r := &Renderer{Trace: logging.NewTestLogger(func(args ...interface{}) {
+		logs = append(logs, fmt.Sprint(args...))
+	})}
+	rendered, err := r.Render(p, map[string]interface{}{
+		"example": map[string]interface{}{"value": "a"},
+	})
+	require.NoError(t, err)
+
+	// Tracing does not change the render.
+	require.Equal(t, "job = job-A a-1", rendered.ParentRenders()["example/templates/example.nomad.tpl"])
+
+	require.Contains(t, logs, "template defines templates: template=example/templates/_helpers.tpl defines=name,prefix")
+	require.Contains(t, logs, "executing template: template=example/templates/example.nomad.tpl")
+	require.Contains(t, logs, "variables in scope: template=example/templates/example.nomad.tpl variables=example.value,nomad_pack.app,nomad_pack.pack")
+	require.Contains(t, logs, `template inclusion: template=example/templates/example.nomad.tpl chain="example/templates/example.nomad.tpl -> name (example/templates/_helpers.tpl)"`)
+	require.Contains(t, logs, `template inclusion: template=example/templates/example.nomad.tpl chain="example/templates/example.nomad.tpl -> name (example/templates/_helpers.tpl) -> prefix (example/templates/_helpers.tpl)"`)
+	require.Contains(t, logs, `template called function: template=example/templates/example.nomad.tpl function=upper args="\"a\""`)
+}
+
+func TestRenderer_KeepGoing(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: "example"}},
+		TemplateFiles: []*pack.File{
+			{Name: "templates/good.nomad.tpl", Content: []byte(`good = [[ .example.value ]]`)},
+			{Name: "templates/exec.nomad.tpl", Content: []byte(`exec = [[ fail "boom" ]]`)},
+			{Name: "templates/parse.nomad.tpl", Content: []byte(`parse = [[ if ]]`)},
+		},
+	}
+	variables := map[string]interface{}{
+		"example": map[string]interface{}{"value": "a"},
+	}
+
+	_, err := (&Renderer{}).Render(p, variables)
+	require.Error(t, err)
+
+	rendered, err := (&Renderer{KeepGoing: true}).Render(p, variables)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"example/templates/good.nomad.tpl": "good = a"}, rendered.ParentRenders())
+
+	failures := rendered.Failures()
+	require.Len(t, failures, 2)
+	require.Equal(t, "example/templates/exec.nomad.tpl", failures[0].Name)
+	require.Contains(t, failures[0].Err.Error(), "boom")
+	require.Equal(t, "example/templates/parse.nomad.tpl", failures[1].Name)
+	require.Contains(t, failures[1].Err.Error(), "missing value for if")
+}
+
+func TestRenderer_OutputPath(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{
+			App:  &pack.MetadataApp{},
+			Pack: &pack.MetadataPack{Name: "example"},
+			Templates: []*pack.MetadataTemplate{
+				{Name: "templates/web.nomad.tpl", OutputPath: "jobs/web.nomad"},
+				{Name: "templates/run.nomad.tpl", Executable: true},
+			},
+		},
+		TemplateFiles: []*pack.File{
+			{Name: "templates/web.nomad.tpl", Content: []byte(`web`)},
+			{Name: "templates/api.nomad.tpl", Content: []byte(`api`)},
+			{Name: "templates/run.nomad.tpl", Content: []byte(`run`)},
+		},
+	}
+
+	rendered, err := (&Renderer{}).Render(p, map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, "example/jobs/web.nomad", rendered.OutputPath("example/templates/web.nomad.tpl"))
+	require.Empty(t, rendered.OutputPath("example/templates/api.nomad.tpl"))
+	require.True(t, rendered.Executable("example/templates/run.nomad.tpl"))
+	require.False(t, rendered.Executable("example/templates/web.nomad.tpl"))
+}
+
+func TestRenderer_TemplateErrorPosition(t *testing.T) {
+	newPack := func(content string) *pack.Pack {
+		return &pack.Pack{
+			Path:     "/packs/example",
+			Metadata: &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: "example"}},
+			TemplateFiles: []*pack.File{
+				{Name: "templates/_helpers.tpl", Content: []byte("[[ define \"name\" ]]\n  [[ fail \"boom\" ]][[ end ]]")},
+				{Name: "templates/example.nomad.tpl", Content: []byte(content)},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		content  string
+		position string
+	}{
+		{
+			name:     "parse error",
+			content:  "job {\n[[ if ]]",
+			position: "/packs/example/templates/example.nomad.tpl:2",
+		},
+		{
+			name:     "execution error",
+			content:  "job {\n  name = [[ .example.missing.field ]]",
+			position: "/packs/example/templates/example.nomad.tpl:2:20",
+		},
+		{
+			name:     "error within helper",
+			content:  `[[ template "name" . ]]`,
+			position: "/packs/example/templates/_helpers.tpl:2:5",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&Renderer{Strict: true}).Render(newPack(tc.content), map[string]interface{}{
+				"example": map[string]interface{}{},
+			})
+			require.Error(t, err)
+
+			var tplErr *TemplateError
+			require.ErrorAs(t, err, &tplErr)
+			require.Equal(t, "example/templates/example.nomad.tpl", tplErr.Name)
+			require.Equal(t, tc.position, tplErr.Position())
+			require.Equal(t, []string{
+				"Template Name: example/templates/example.nomad.tpl",
+				"Template Position: " + tc.position,
+			}, tplErr.Context().GetAll())
+		})
+	}
+
+	// Errors without a position are reported against the template alone.
+	tplErr := (&Renderer{}).newTemplateError("example/templates/example.nomad.tpl", fmt.Errorf("boom"), fmt.Errorf("boom"))
+	require.Empty(t, tplErr.Position())
+	require.Equal(t, []string{"Template Name: example/templates/example.nomad.tpl"}, tplErr.Context().GetAll())
+}
+
+func TestRenderer_DependencyOutputs(t *testing.T) {
+	metadata := func(name string) *pack.Metadata {
+		return &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: name}}
+	}
+
+	cache := &pack.Pack{
+		Metadata:           metadata("cache"),
+		OutputTemplateFile: &pack.File{Name: "outputs.tpl", Content: []byte(`[[ fail "boom" ]]`)},
+	}
+	db := &pack.Pack{
+		Metadata:           metadata("db"),
+		OutputTemplateFile: &pack.File{Name: "outputs.tpl", Content: []byte(`db port [[ .db.port ]] of [[ .nomad_pack.pack.name ]]`)},
+	}
+	db.AddDependencies(cache)
+	web := &pack.Pack{Metadata: metadata("web")}
+	p := &pack.Pack{
+		Metadata:           metadata("example"),
+		OutputTemplateFile: &pack.File{Name: "outputs.tpl", Content: []byte(`example`)},
+	}
+	p.AddDependencies(db, web, cache)
+
+	r := &Renderer{}
+	_, err := r.Render(p, map[string]interface{}{
+		"example": map[string]interface{}{},
+		"db":      map[string]interface{}{"port": 5432},
+	})
+	require.NoError(t, err)
+
+	// Each dependency with an output template is rendered once with its own
+	// scoped variables, and a failure does not stop the others rendering.
+	outputs := r.RenderDependencyOutputs()
+	require.Len(t, outputs, 2)
+
+	require.Equal(t, "cache", outputs[0].Pack)
+	require.Equal(t, "cache/outputs.tpl", outputs[0].Name)
+	require.Error(t, outputs[0].Err)
+	require.Contains(t, outputs[0].Err.Error(), "boom")
+
+	require.Equal(t, &DependencyOutput{Pack: "db", Name: "db/outputs.tpl", Content: "db port 5432 of db"}, outputs[1])
+
+	// The parent output template is unaffected by the dependency outputs.
+	output, err := r.RenderOutput("")
+	require.NoError(t, err)
+	require.Equal(t, "example", output)
+}
+
+func TestRenderer_Rand(t *testing.T) {
+	p := &pack.Pack{
+		Metadata: &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: "example"}},
+		TemplateFiles: []*pack.File{
+			{Name: "templates/a.nomad.tpl", Content: []byte(`[[ randAlphaNum 16 ]] [[ randAlpha 4 ]] [[ randNumeric 4 ]] [[ randAscii 4 ]]`)},
+			{Name: "templates/b.nomad.tpl", Content: []byte(`[[ uuidv4 ]] [[ shuffle "abcdefgh" ]]`)},
+		},
+	}
+
+	render := func(seed int64) map[string]string {
+		r := &Renderer{Rand: rand.New(rand.NewSource(seed))}
+		rendered, err := r.Render(p, map[string]interface{}{})
+		require.NoError(t, err)
+		return rendered.ParentRenders()
+	}
+
+	// Renders with the same seed are identical, regardless of the order the
+	// templates are held in.
+	first := render(42)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, first, render(42))
+	}
+	require.NotEqual(t, first, render(43))
+
+	a := strings.Fields(first["example/templates/a.nomad.tpl"])
+	require.Regexp(t, `^[A-Za-z0-9]{16}$`, a[0])
+	require.Regexp(t, `^[A-Za-z]{4}$`, a[1])
+	require.Regexp(t, `^[0-9]{4}$`, a[2])
+
+	b := strings.Fields(first["example/templates/b.nomad.tpl"])
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, b[0])
+	require.ElementsMatch(t, []rune("abcdefgh"), []rune(b[1]))
+}
+
+// parallelPack returns a pack with n templates, each including a helper
+// template and reading a file from the pack directory.
+func parallelPack(t testing.TB, n int) *pack.Pack {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "value.txt"), []byte("from-file"), 0644))
+
+	files := []*pack.File{
+		{Name: "templates/_helpers.tpl", Content: []byte(`[[ define "greeting" ]]hello [[ . ]][[ end ]]`)},
+	}
+	for i := 0; i < n; i++ {
+		files = append(files, &pack.File{
+			Name: fmt.Sprintf("templates/job_%03d.nomad.tpl", i),
+			Content: []byte(fmt.Sprintf(`[[ template "greeting" .example.value ]] %d [[ fileContents "value.txt" ]]
+[[ range $i := until 200 ]][[ $i | add 1 | printf "%%05d" ]][[ end ]]`, i)),
+		})
+	}
+	return &pack.Pack{
+		Metadata:      &pack.Metadata{App: &pack.MetadataApp{}, Pack: &pack.MetadataPack{Name: "example"}},
+		TemplateFiles: files,
+		Path:          dir,
+	}
+}
+
+func TestRenderer_Parallelism(t *testing.T) {
+	p := parallelPack(t, 50)
+	variables := map[string]interface{}{
+		"example": map[string]interface{}{"value": "world"},
+	}
+
+	serial, err := (&Renderer{Parallelism: 1}).Render(p, variables)
+	require.NoError(t, err)
+	require.Len(t, serial.ParentRenders(), 50)
+	require.True(t, strings.HasPrefix(serial.ParentRenders()["example/templates/job_007.nomad.tpl"], "hello world 7 from-file\n00001"))
+
+	for _, parallelism := range []int{0, 2, 8, 100} {
+		rendered, err := (&Renderer{Parallelism: parallelism}).Render(p, variables)
+		require.NoError(t, err)
+		require.Equal(t, serial.ParentRenders(), rendered.ParentRenders())
+	}
+
+	// Failures are reported in order of the template names, whatever order
+	// the templates are executed in.
+	p.TemplateFiles = append(p.TemplateFiles,
+		&pack.File{Name: "templates/fail_b.nomad.tpl", Content: []byte(`[[ fail "b" ]]`)},
+		&pack.File{Name: "templates/fail_a.nomad.tpl", Content: []byte(`[[ fail "a" ]]`)},
+	)
+	_, err = (&Renderer{Parallelism: 8}).Render(p, variables)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "fail_a.nomad.tpl")
+
+	rendered, err := (&Renderer{Parallelism: 8, KeepGoing: true}).Render(p, variables)
+	require.NoError(t, err)
+	require.Len(t, rendered.ParentRenders(), 50)
+	require.Len(t, rendered.Failures(), 2)
+	require.Equal(t, "example/templates/fail_a.nomad.tpl", rendered.Failures()[0].Name)
+}
+
+func BenchmarkRenderer_Render(b *testing.B) {
+	p := parallelPack(b, 200)
+	variables := map[string]interface{}{
+		"example": map[string]interface{}{"value": "world"},
+	}
+
+	for _, parallelism := range []int{1, 0} {
+		name := "serial"
+		if parallelism == 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := (&Renderer{Parallelism: parallelism}).Render(p, variables); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
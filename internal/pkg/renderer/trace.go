@@ -0,0 +1,209 @@
+package renderer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+// maxTraceValueLen is the length beyond which the values of template function
+// arguments are truncated when traced.
+const maxTraceValueLen = 64
+
+// trace logs the message and its fields at debug level when tracing is
+// enabled.
+func (r *Renderer) trace(message string, fields ...logging.Field) {
+	if r.Trace != nil {
+		logging.Debug(r.Trace, message, fields...)
+	}
+}
+
+// traceParse parses the named template, logging the templates it defines
+// when tracing is enabled. The defined templates are recorded against the
+// file, so inclusions can be traced back to the file they are defined in.
+func (r *Renderer) traceParse(tpl *template.Template, name, content string) error {
+	r.trace("parsing template", logging.F("template", name))
+
+	if _, err := tpl.New(name).Parse(content); err != nil {
+		return err
+	}
+	if r.Trace == nil {
+		return nil
+	}
+
+	if r.definedIn == nil {
+		r.definedIn = make(map[string]string)
+	}
+	var defined []string
+	for _, t := range tpl.Templates() {
+		if _, ok := r.definedIn[t.Name()]; !ok {
+			r.definedIn[t.Name()] = name
+			if t.Name() != name {
+				defined = append(defined, t.Name())
+			}
+		}
+	}
+	if len(defined) > 0 {
+		sort.Strings(defined)
+		r.trace("template defines templates", logging.F("template", name), logging.F("defines", defined))
+	}
+	return nil
+}
+
+// traceExecute logs the template about to be executed, the names of the
+// variables in its scope, and the chains of templates it includes.
+func (r *Renderer) traceExecute(tpl *template.Template, name string, variables map[string]interface{}) {
+	if r.Trace == nil {
+		return
+	}
+	r.traceTemplate = name
+
+	r.trace("executing template", logging.F("template", name))
+	r.trace("variables in scope", logging.F("template", name), logging.F("variables", scopeNames(variables)))
+
+	var chains [][]string
+	includeChains(tpl, name, []string{name}, &chains)
+	for _, chain := range chains {
+		names := make([]string, len(chain))
+		for i, included := range chain {
+			names[i] = included
+			if file := r.definedIn[included]; i > 0 && file != "" && file != included {
+				names[i] = fmt.Sprintf("%s (%s)", included, file)
+			}
+		}
+		r.trace("template inclusion", logging.F("template", name), logging.F("chain", strings.Join(names, " -> ")))
+	}
+}
+
+// scopeNames returns the sorted names of the variables, which are nested
+// within a map for each pack and the pack metadata.
+func scopeNames(variables map[string]interface{}) []string {
+	var names []string
+	for key, val := range variables {
+		nested, ok := val.(map[string]interface{})
+		if !ok || len(nested) == 0 {
+			names = append(names, key)
+			continue
+		}
+		for nestedKey := range nested {
+			names = append(names, key+"."+nestedKey)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// includeChains appends to chains each chain of templates included by the
+// named template, starting with chain. A template including itself ends the
+// chain, so recursive templates are only followed once.
+func includeChains(tpl *template.Template, name string, chain []string, chains *[][]string) {
+	t := tpl.Lookup(name)
+	if t == nil || t.Tree == nil {
+		return
+	}
+
+	var included []string
+	includedTemplates(t.Tree.Root, &included)
+
+	for _, next := range included {
+		nextChain := append(append([]string{}, chain...), next)
+		*chains = append(*chains, nextChain)
+
+		recursive := false
+		for _, prev := range chain {
+			if prev == next {
+				recursive = true
+				break
+			}
+		}
+		if !recursive {
+			includeChains(tpl, next, nextChain, chains)
+		}
+	}
+}
+
+// includedTemplates appends the names of the templates included by the
+// template actions within the parse tree node.
+func includedTemplates(node parse.Node, names *[]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			includedTemplates(child, names)
+		}
+	case *parse.TemplateNode:
+		*names = append(*names, n.Name)
+	case *parse.IfNode:
+		includedTemplates(n.List, names)
+		includedTemplates(n.ElseList, names)
+	case *parse.WithNode:
+		includedTemplates(n.List, names)
+		includedTemplates(n.ElseList, names)
+	case *parse.RangeNode:
+		includedTemplates(n.List, names)
+		includedTemplates(n.ElseList, names)
+	}
+}
+
+// traceFuncMap wraps each of the template functions so their invocations are
+// logged along with the template being executed.
+func (r *Renderer) traceFuncMap(funcs template.FuncMap) template.FuncMap {
+	traced := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		traced[name] = r.traceFunc(name, fn)
+	}
+	return traced
+}
+
+// traceFunc wraps the template function so each call is logged.
+func (r *Renderer) traceFunc(name string, fn interface{}) interface{} {
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		return fn
+	}
+	fnType := fnVal.Type()
+
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		values := make([]string, 0, len(args))
+		for i, arg := range args {
+			if fnType.IsVariadic() && i == len(args)-1 {
+				for j := 0; j < arg.Len(); j++ {
+					values = append(values, traceValue(arg.Index(j)))
+				}
+				continue
+			}
+			values = append(values, traceValue(arg))
+		}
+		r.trace("template called function",
+			logging.F("template", r.traceTemplate),
+			logging.F("function", name),
+			logging.F("args", values))
+
+		if fnType.IsVariadic() {
+			return fnVal.CallSlice(args)
+		}
+		return fnVal.Call(args)
+	}).Interface()
+}
+
+// traceValue formats the template function argument for tracing, truncating
+// long values.
+func traceValue(v reflect.Value) string {
+	var s string
+	if v.IsValid() && v.CanInterface() {
+		s = fmt.Sprintf("%#v", v.Interface())
+	} else {
+		s = "<nil>"
+	}
+	if len(s) > maxTraceValueLen {
+		s = s[:maxTraceValueLen] + "..."
+	}
+	return s
+}
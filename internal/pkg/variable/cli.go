@@ -2,9 +2,11 @@ package variable
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -37,34 +39,48 @@ func (p *Parser) parseCLIVariable(name string, rawVal string) hcl.Diagnostics {
 
 	// Generate a filename based on the CLI var, so we have some context for any
 	// HCL diagnostics.
+	fromMap := p.cfg.CLIMapNames[name]
 	fakeRange := hcl.Range{Filename: fmt.Sprintf("<value for var.%s from arguments>", name)}
+	if fromMap {
+		fakeRange.Filename = fmt.Sprintf("<value for var.%s from --var-map>", name)
+	}
 
 	// If the variable has not been configured in the root then exit. This is a
 	// standard requirement, especially because we would be unable to ensure a
 	// consistent type.
 	existing, exists := p.rootVars[packVarName[0]][packVarName[1]]
 	if !exists {
-		return hcl.Diagnostics{diagnosticMissingRootVar(name, &fakeRange)}
+		similar := p.similarVariable(packVarName[0], packVarName[1], name)
+		if fromMap {
+			return hcl.Diagnostics{p.diagnosticUndeclaredMapVar(name, similar, &fakeRange)}
+		}
+		return hcl.Diagnostics{diagnosticMissingRootVar(name, similar, &fakeRange)}
 	}
 
-	expr, diags := expressionFromVariableDefinition(fakeRange.Filename, rawVal, existing.Type)
-	if diags.HasErrors() {
-		return diags
+	// A value prefixed with @ is read from the named file, unless escaped as
+	// @@, so that values such as certificates need not be inlined.
+	displayVal, origin := rawVal, "--var "+name
+	if fromMap {
+		origin = "--var-map"
 	}
-
-	val, diags := expr.Value(nil)
-	if diags.HasErrors() {
-		return diags
+	if strings.HasPrefix(rawVal, "@@") {
+		rawVal, displayVal = rawVal[1:], rawVal[1:]
+	} else if strings.HasPrefix(rawVal, "@") {
+		file := rawVal[1:]
+		content, diag := p.readCLIValueFile(name, file, existing.Type, &fakeRange)
+		if diag != nil {
+			p.setDiagnosticVariable(diag, name)
+			return hcl.Diagnostics{diag}
+		}
+		rawVal, origin = content, fmt.Sprintf("--var %s=@%s", name, file)
+		fakeRange = hcl.Range{Filename: file}
 	}
 
-	// If our stored type isn't cty.NilType then attempt to covert the override
-	// variable, so we know they are compatible.
-	if existing.Type != cty.NilType {
-		var err *hcl.Diagnostic
-		val, err = convertValUsingType(val, existing.Type, expr.Range().Ptr())
-		if err != nil {
-			return hcl.Diagnostics{err}
-		}
+	val, diags := parseRawVariableValue(fakeRange.Filename, rawVal, existing.Type)
+	if diags.HasErrors() {
+		diag := diagnosticInvalidCLIValue(name, displayVal, existing.Type, diags, &fakeRange)
+		p.setDiagnosticVariable(diag, name)
+		return hcl.Diagnostics{diag}
 	}
 
 	// We have a verified override variable.
@@ -73,12 +89,90 @@ func (p *Parser) parseCLIVariable(name string, rawVal string) hcl.Diagnostics {
 		Type:      val.Type(),
 		Value:     val,
 		DeclRange: fakeRange,
+		Source:    Source{Kind: SourceCLI, Origin: origin},
 	}
 	p.cliOverrideVars[packVarName[0]] = append(p.cliOverrideVars[packVarName[0]], &v)
 
 	return nil
 }
 
+// diagnosticUndeclaredMapVar returns the diagnostic for a --var-map key which
+// names a variable not declared by the pack. It is a warning unless
+// StrictVars is set.
+func (p *Parser) diagnosticUndeclaredMapVar(name, similar string, sub *hcl.Range) *hcl.Diagnostic {
+	severity := hcl.DiagWarning
+	if p.cfg.StrictVars {
+		severity = hcl.DiagError
+	}
+	detail := fmt.Sprintf("The --var-map object sets the variable %q, which is not declared by the pack.", name)
+	if similar != "" {
+		detail += fmt.Sprintf(" Did you mean %q?", similar)
+	}
+	return &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  "Undeclared variable in --var-map",
+		Detail:   detail,
+		Subject:  sub,
+	}
+}
+
+// readCLIValueFile reads the value of a --var given as @file. String values
+// are used exactly as they are in the file, including any trailing newline,
+// while the surrounding whitespace of other values is removed so they can be
+// parsed according to their type.
+func (p *Parser) readCLIValueFile(name, file string, typ cty.Type, sub *hcl.Range) (string, *hcl.Diagnostic) {
+	if file == "" {
+		return "", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid -var option",
+			Detail: fmt.Sprintf("The value of variable %q must name a file after the @, such as --var %s=@/path/to/file. "+
+				"To set a value starting with @, escape it as @@.", name, name),
+			Subject: sub,
+		}
+	}
+
+	content, err := p.fs.ReadFile(file)
+	if err != nil {
+		return "", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read variable value file",
+			Detail: fmt.Sprintf("The file %q given for variable %q using --var %s=@%s could not be read: %s. "+
+				"To set a value starting with @, escape it as @@.", file, name, name, file, err),
+			Subject: sub,
+		}
+	}
+
+	if typ == cty.String || typ == cty.NilType {
+		return string(content), nil
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// parseRawVariableValue parses the raw string value of a variable set outside
+// of a variable file, such as on the CLI, according to the variable type.
+func parseRawVariableValue(file, rawVal string, varType cty.Type) (cty.Value, hcl.Diagnostics) {
+	expr, diags := expressionFromVariableDefinition(file, rawVal, varType)
+	if diags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+
+	// If our stored type isn't cty.NilType then attempt to covert the override
+	// variable, so we know they are compatible.
+	if varType != cty.NilType {
+		var err *hcl.Diagnostic
+		val, err = convertValUsingType(val, varType, expr.Range().Ptr())
+		if err != nil {
+			return cty.DynamicVal, hcl.Diagnostics{err}
+		}
+	}
+	return val, nil
+}
+
 // expressionFromVariableDefinition attempts to convert the string HCL
 // expression to a hydrated hclsyntax.Expression.
 func expressionFromVariableDefinition(file, val string, varType cty.Type) (hclsyntax.Expression, hcl.Diagnostics) {
@@ -89,3 +183,97 @@ func expressionFromVariableDefinition(file, val string, varType cty.Type) (hclsy
 		return hclsyntax.ParseExpression([]byte(val), file, hcl.Pos{Line: 1, Column: 1})
 	}
 }
+
+// diagnosticInvalidCLIValue returns a diagnostic describing a --var value
+// which is not valid for the type of the variable, replacing the diagnostics
+// returned when parsing it. It includes an example of the correct form, and
+// for collection and structural types whose value could not be parsed, a
+// hint about quoting, as the shell removing the quotes around strings is
+// the most common cause.
+func diagnosticInvalidCLIValue(name, rawVal string, typ cty.Type, diags hcl.Diagnostics, sub *hcl.Range) *hcl.Diagnostic {
+
+	typeName := "any"
+	if typ != cty.NilType {
+		typeName = typeexpr.TypeString(typ)
+	}
+
+	var reason string
+	var parseFailed bool
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		reason = strings.TrimSuffix(diag.Detail, ".")
+		parseFailed = diag.Summary != diagnosticInvalidValueForType(nil, nil).Summary
+		break
+	}
+
+	// A primitive value which failed to parse, such as an unquoted word
+	// given for a bool, is reported as a traversal which is not meaningful
+	// to the user, so the type required is given instead.
+	if parseFailed && typ.IsPrimitiveType() {
+		reason = fmt.Sprintf("a %s is required", typeName)
+	}
+
+	detail := fmt.Sprintf("The value %q given for variable %q is not a valid %s: %s. Set it in the form --var %s.",
+		rawVal, name, typeName, reason, exampleCLIVar(name, typ))
+
+	if parseFailed && !typ.IsPrimitiveType() && typ != cty.NilType {
+		detail += " Strings within the value must be double quoted and, as the shell removes quotes, the whole" +
+			" value wrapped in single quotes."
+	}
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value for variable",
+		Detail:   detail,
+		Subject:  sub,
+	}
+}
+
+// exampleCLIVar returns an example --var argument setting the named variable
+// to a value of the type. Values other than primitives are single quoted, so
+// the shell does not remove the quotes within them.
+func exampleCLIVar(name string, typ cty.Type) string {
+	switch typ {
+	case cty.String, cty.NilType, cty.DynamicPseudoType:
+		return name + "=value"
+	case cty.Number, cty.Bool:
+		return name + "=" + exampleValue(typ)
+	default:
+		return fmt.Sprintf("'%s=%s'", name, exampleValue(typ))
+	}
+}
+
+// exampleValue returns an example HCL expression of a value of the type.
+func exampleValue(typ cty.Type) string {
+	switch {
+	case typ == cty.Number:
+		return "1"
+	case typ == cty.Bool:
+		return "true"
+	case typ.IsListType(), typ.IsSetType():
+		return "[" + exampleValue(typ.ElementType()) + "]"
+	case typ.IsTupleType():
+		elems := make([]string, len(typ.TupleElementTypes()))
+		for i, elemType := range typ.TupleElementTypes() {
+			elems[i] = exampleValue(elemType)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case typ.IsMapType():
+		return "{key = " + exampleValue(typ.ElementType()) + "}"
+	case typ.IsObjectType():
+		names := make([]string, 0, len(typ.AttributeTypes()))
+		for attrName := range typ.AttributeTypes() {
+			names = append(names, attrName)
+		}
+		sort.Strings(names)
+		attrs := make([]string, len(names))
+		for i, attrName := range names {
+			attrs[i] = attrName + " = " + exampleValue(typ.AttributeType(attrName))
+		}
+		return "{" + strings.Join(attrs, ", ") + "}"
+	default:
+		return `"value"`
+	}
+}
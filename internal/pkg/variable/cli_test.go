@@ -1,11 +1,14 @@
 package variable
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -44,6 +47,7 @@ func TestParser_parseCLIVariable(t *testing.T) {
 						Type:      cty.String,
 						Value:     cty.StringVal("vlc"),
 						DeclRange: hcl.Range{Filename: "<value for var.region from arguments>"},
+						Source:    Source{Kind: SourceCLI, Origin: "--var region"},
 					},
 				},
 			},
@@ -75,6 +79,7 @@ func TestParser_parseCLIVariable(t *testing.T) {
 						Type:      cty.String,
 						Value:     cty.StringVal("vlc"),
 						DeclRange: hcl.Range{Filename: "<value for var.example.region from arguments>"},
+						Source:    Source{Kind: SourceCLI, Origin: "--var example.region"},
 					},
 				},
 			},
@@ -129,3 +134,205 @@ func TestParser_parseCLIVariable(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_parseCLIVariableInvalidValue(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputType      cty.Type
+		inputRawVal    string
+		expectedDetail string
+	}{
+		{
+			name:           "number",
+			inputType:      cty.Number,
+			inputRawVal:    "three",
+			expectedDetail: `The value "three" given for variable "example.replicas" is not a valid number: This variable value is not compatible with the variable's type constraint: a number is required. Set it in the form --var example.replicas=1.`,
+		},
+		{
+			name:           "bool",
+			inputType:      cty.Bool,
+			inputRawVal:    "yes",
+			expectedDetail: `The value "yes" given for variable "example.replicas" is not a valid bool: a bool is required. Set it in the form --var example.replicas=true.`,
+		},
+		{
+			name:           "list",
+			inputType:      cty.List(cty.String),
+			inputRawVal:    "3",
+			expectedDetail: `The value "3" given for variable "example.replicas" is not a valid list(string): This variable value is not compatible with the variable's type constraint: list of string required. Set it in the form --var 'example.replicas=["value"]'.`,
+		},
+		{
+			name:           "list unquoted strings",
+			inputType:      cty.List(cty.String),
+			inputRawVal:    "[dc1]",
+			expectedDetail: `The value "[dc1]" given for variable "example.replicas" is not a valid list(string): Variables may not be used here. Set it in the form --var 'example.replicas=["value"]'. Strings within the value must be double quoted and, as the shell removes quotes, the whole value wrapped in single quotes.`,
+		},
+		{
+			name:           "map",
+			inputType:      cty.Map(cty.Number),
+			inputRawVal:    "{cpu: 1000",
+			expectedDetail: `The value "{cpu: 1000" given for variable "example.replicas" is not a valid map(number): Expected a newline or comma to mark the beginning of the next attribute. Set it in the form --var 'example.replicas={key = 1}'. Strings within the value must be double quoted and, as the shell removes quotes, the whole value wrapped in single quotes.`,
+		},
+		{
+			name:           "object",
+			inputType:      cty.Object(map[string]cty.Type{"memory": cty.Number, "cpu": cty.Number}),
+			inputRawVal:    "{cpu: 1000, memory: 512",
+			expectedDetail: `The value "{cpu: 1000, memory: 512" given for variable "example.replicas" is not a valid object({cpu=number,memory=number}): Expected a newline or comma to mark the beginning of the next attribute. Set it in the form --var 'example.replicas={cpu = 1, memory = 1}'. Strings within the value must be double quoted and, as the shell removes quotes, the whole value wrapped in single quotes.`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Parser{
+				fs:  afero.Afero{Fs: afero.OsFs{}},
+				cfg: &ParserConfig{ParentName: "example"},
+				rootVars: map[string]map[string]*Variable{
+					"example": {
+						"replicas": &Variable{
+							Name:      "replicas",
+							Type:      tc.inputType,
+							DeclRange: hcl.Range{Filename: "variables.hcl"},
+						},
+					},
+				},
+				cliOverrideVars: make(map[string][]*Variable),
+			}
+
+			diags := p.parseCLIVariable("example.replicas", tc.inputRawVal)
+			require.Len(t, diags, 1)
+			require.Equal(t, "Invalid value for variable", diags[0].Summary)
+			require.Equal(t, tc.expectedDetail, diags[0].Detail)
+			require.Equal(t, "example.replicas", p.DiagnosticVariable(diags[0]))
+		})
+	}
+}
+
+func TestParser_parseCLIVariableUndeclared(t *testing.T) {
+	p := &Parser{
+		fs:  afero.Afero{Fs: afero.OsFs{}},
+		cfg: &ParserConfig{ParentName: "example"},
+		rootVars: map[string]map[string]*Variable{
+			"example": {
+				"replicas": &Variable{Name: "replicas", Type: cty.Number},
+				"region":   &Variable{Name: "region", Type: cty.String},
+			},
+		},
+		cliOverrideVars: make(map[string][]*Variable),
+	}
+
+	// A close match of a declared variable is suggested, in the same form as
+	// the variable was passed.
+	diags := p.parseCLIVariable("replcas", "3")
+	require.Len(t, diags, 1)
+	require.Equal(t, "Missing base variable declaration to override", diags[0].Summary)
+	require.Contains(t, diags[0].Detail, `Did you mean "replicas"?`)
+
+	diags = p.parseCLIVariable("example.regoin", "ams")
+	require.Len(t, diags, 1)
+	require.Contains(t, diags[0].Detail, `Did you mean "example.region"?`)
+
+	diags = p.parseCLIVariable("datacenters", `["dc1"]`)
+	require.Len(t, diags, 1)
+	require.NotContains(t, diags[0].Detail, "Did you mean")
+}
+
+func TestParser_parseCLIVariableMap(t *testing.T) {
+	newParser := func(strict bool) *Parser {
+		return &Parser{
+			fs: afero.Afero{Fs: afero.OsFs{}},
+			cfg: &ParserConfig{
+				ParentName:  "example",
+				CLIMapNames: map[string]bool{"replicas": true, "regoin": true},
+				StrictVars:  strict,
+			},
+			rootVars: map[string]map[string]*Variable{
+				"example": {
+					"replicas": &Variable{Name: "replicas", Type: cty.Number},
+					"region":   &Variable{Name: "region", Type: cty.String},
+				},
+			},
+			cliOverrideVars: make(map[string][]*Variable),
+		}
+	}
+
+	p := newParser(false)
+	require.Nil(t, p.parseCLIVariable("replicas", "3"))
+	v := p.cliOverrideVars["example"][0]
+	require.True(t, cty.NumberIntVal(3).RawEquals(v.Value))
+	require.Equal(t, Source{Kind: SourceCLI, Origin: "--var-map"}, v.Source)
+
+	// Undeclared keys are a warning, unless strict.
+	diags := p.parseCLIVariable("regoin", "ams")
+	require.Len(t, diags, 1)
+	require.Equal(t, hcl.DiagWarning, diags[0].Severity)
+	require.Equal(t, "Undeclared variable in --var-map", diags[0].Summary)
+	require.Contains(t, diags[0].Detail, `Did you mean "region"?`)
+
+	diags = newParser(true).parseCLIVariable("regoin", "ams")
+	require.Len(t, diags, 1)
+	require.Equal(t, hcl.DiagError, diags[0].Severity)
+
+	// Variables set using --var are still always an error.
+	diags = p.parseCLIVariable("datacenters", `["dc1"]`)
+	require.Len(t, diags, 1)
+	require.Equal(t, hcl.DiagError, diags[0].Severity)
+}
+
+func TestParser_parseCLIVariableFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0644))
+	countFile := filepath.Join(dir, "count")
+	require.NoError(t, os.WriteFile(countFile, []byte("3\n"), 0644))
+
+	newParser := func() *Parser {
+		return &Parser{
+			fs:  afero.Afero{Fs: afero.OsFs{}},
+			cfg: &ParserConfig{ParentName: "example"},
+			rootVars: map[string]map[string]*Variable{
+				"example": {
+					"cert":  &Variable{Name: "cert", Type: cty.String},
+					"count": &Variable{Name: "count", Type: cty.Number},
+				},
+			},
+			cliOverrideVars: make(map[string][]*Variable),
+		}
+	}
+
+	t.Run("string value read verbatim", func(t *testing.T) {
+		p := newParser()
+		require.Nil(t, p.parseCLIVariable("cert", "@"+certFile))
+		v := p.cliOverrideVars["example"][0]
+		require.Equal(t, cty.StringVal("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), v.Value)
+		require.Equal(t, "--var cert=@"+certFile, v.Source.Origin)
+		require.Equal(t, certFile, v.DeclRange.Filename)
+	})
+
+	t.Run("value converted to the variable type", func(t *testing.T) {
+		p := newParser()
+		require.Nil(t, p.parseCLIVariable("count", "@"+countFile))
+		require.True(t, cty.NumberIntVal(3).RawEquals(p.cliOverrideVars["example"][0].Value))
+	})
+
+	t.Run("escaped literal", func(t *testing.T) {
+		p := newParser()
+		require.Nil(t, p.parseCLIVariable("cert", "@@"+certFile))
+		require.Equal(t, cty.StringVal("@"+certFile), p.cliOverrideVars["example"][0].Value)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		p := newParser()
+		missing := filepath.Join(dir, "missing")
+		diags := p.parseCLIVariable("cert", "@"+missing)
+		require.Len(t, diags, 1)
+		require.Equal(t, "Failed to read variable value file", diags[0].Summary)
+		require.Contains(t, diags[0].Detail, missing)
+		require.Equal(t, "cert", p.DiagnosticVariable(diags[0]))
+	})
+
+	t.Run("missing file name", func(t *testing.T) {
+		p := newParser()
+		diags := p.parseCLIVariable("cert", "@")
+		require.Len(t, diags, 1)
+		require.Equal(t, "Invalid -var option", diags[0].Summary)
+	})
+}
@@ -50,6 +50,25 @@ func decodeVariableBlock(block *hcl.Block) (*Variable, hcl.Diagnostics) {
 		}
 	}
 
+	// A variable doesn't need to declare whether it is sensitive, in which
+	// case it is not.
+	if attr, exists := content.Attributes[variableAttributeSensitive]; exists {
+		val, sensitiveDiags := attr.Expr.Value(nil)
+		diags = safeDiagnosticsExtend(diags, sensitiveDiags)
+
+		if val.Type() == cty.Bool && val.IsKnown() && !val.IsNull() {
+			v.Sensitive = val.True()
+		} else {
+			diags = safeDiagnosticsAppend(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid type for sensitive",
+				Detail: fmt.Sprintf("The sensitive attribute is expected to be of type bool, got %s",
+					val.Type().FriendlyName()),
+				Subject: attr.Range.Ptr(),
+			})
+		}
+	}
+
 	// A variable doesn't need to declare a type. If it does, process this and
 	// store it, along with any processing errors.
 	if attr, exists := content.Attributes[variableAttributeType]; exists {
@@ -73,6 +92,17 @@ func decodeVariableBlock(block *hcl.Block) (*Variable, hcl.Diagnostics) {
 		}
 
 		v.Value = val
+		v.Source = Source{Kind: SourceDefault, Origin: rangeOrigin(attr.Range)}
+	}
+
+	// A variable doesn't need to declare any validation blocks. Each which
+	// is declared is decoded, so all invalid rules are reported at once.
+	for _, validationBlock := range content.Blocks {
+		validation, validationDiags := decodeValidationBlock(validationBlock)
+		diags = safeDiagnosticsExtend(diags, validationDiags)
+		if validation != nil && !validationDiags.HasErrors() {
+			v.Validations = append(v.Validations, validation)
+		}
 	}
 
 	return v, diags
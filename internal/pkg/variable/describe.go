@@ -0,0 +1,73 @@
+package variable
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Description describes a single declared variable in a form which can be
+// serialized and consumed programmatically.
+type Description struct {
+
+	// Name is the variable name.
+	Name string `json:"name"`
+
+	// Type is the variable type constraint, written using the HCL type
+	// expression syntax, such as "string", "list(number)" or
+	// "object({name=string})". Variables without a type constraint have the
+	// type "any".
+	Type string `json:"type"`
+
+	// Description is the variable description, which is empty if unset.
+	Description string `json:"description"`
+
+	// Default is the default value of the variable converted to its JSON
+	// equivalent. It is nil when the variable has no default, in which case
+	// Required is true.
+	Default  interface{} `json:"default"`
+	Required bool        `json:"required"`
+}
+
+// Describe returns the descriptions of the variables declared by the named
+// pack, sorted by variable name.
+func (p *ParsedVariables) Describe(packName string) ([]*Description, error) {
+
+	vars := p.Vars[packName]
+	out := make([]*Description, 0, len(vars))
+
+	for name, v := range vars {
+		desc := &Description{
+			Name:        name,
+			Type:        typeString(v),
+			Description: v.Description,
+			Required:    v.Value == cty.NilVal,
+		}
+		if !desc.Required {
+			def, err := convertCtyToInterface(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert default value of variable %q: %v", name, err)
+			}
+			desc.Default = def
+		}
+		out = append(out, desc)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// typeString returns the type expression of the variable type constraint,
+// falling back to the type of the default value when no type is declared.
+func typeString(v *Variable) string {
+	typ := v.Type
+	if typ == cty.NilType && v.Value != cty.NilVal {
+		typ = v.Value.Type()
+	}
+	if typ == cty.NilType {
+		return "any"
+	}
+	return typeexpr.TypeString(typ)
+}
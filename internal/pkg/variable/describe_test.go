@@ -0,0 +1,56 @@
+package variable
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedVariables_Describe(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name: "variables.hcl",
+				Path: "example/variables.hcl",
+				Content: []byte(`
+variable "job_name" {
+  description = "The name of the job"
+  type        = string
+}
+
+variable "count" {
+  default = 2
+}
+
+variable "untyped" {}
+
+variable "resources" {
+  type = object({
+    cpu    = number
+    memory = number
+  })
+  default = {
+    cpu    = 100
+    memory = 256
+  }
+}
+`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	desc, err := parsed.Describe("example")
+	require.NoError(t, err)
+	require.Equal(t, []*Description{
+		{Name: "count", Type: "number", Default: 2},
+		{Name: "job_name", Type: "string", Description: "The name of the job", Required: true},
+		{Name: "resources", Type: "object({cpu=number,memory=number})", Default: map[string]interface{}{"cpu": 100, "memory": 256}},
+		{Name: "untyped", Type: "any", Required: true},
+	}, desc)
+}
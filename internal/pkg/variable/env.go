@@ -0,0 +1,91 @@
+package variable
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// DefaultEnvPrefix is the default prefix of the environment variables which
+// set pack variables.
+const DefaultEnvPrefix = "NOMAD_PACK_VAR_"
+
+// parseEnvVariables scans the environment for variables with the EnvPrefix,
+// parsing each value according to the type of the variable it names. The
+// variable name can be namespaced by the pack name, as with CLI variables.
+// Environment variables naming a variable which is not declared result in a
+// warning, since the environment may be shared with other packs.
+func (p *Parser) parseEnvVariables() hcl.Diagnostics {
+
+	environ := p.cfg.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+
+	// Sort the environment, so the diagnostics are output in a consistent
+	// order.
+	environ = append([]string{}, environ...)
+	sort.Strings(environ)
+
+	var diags hcl.Diagnostics
+
+	for _, kv := range environ {
+		split := strings.SplitN(kv, "=", 2)
+		if len(split) != 2 || !strings.HasPrefix(split[0], p.cfg.EnvPrefix) {
+			continue
+		}
+		envName, rawVal := split[0], split[1]
+
+		name := strings.TrimPrefix(envName, p.cfg.EnvPrefix)
+		if name == "" {
+			continue
+		}
+
+		packName, varName := p.cfg.ParentName, name
+		if split := strings.SplitN(name, ".", 2); len(split) == 2 {
+			packName, varName = split[0], split[1]
+		}
+
+		fakeRange := hcl.Range{Filename: fmt.Sprintf("<value for var.%s from environment variable %s>", name, envName)}
+
+		existing, exists := p.rootVars[packName][varName]
+		if !exists {
+			severity := hcl.DiagWarning
+			if p.cfg.StrictVars {
+				severity = hcl.DiagError
+			}
+			detail := fmt.Sprintf("The environment variable %s sets the variable %q, which is not declared by the pack.",
+				envName, name)
+			if similar := p.similarVariable(packName, varName, name); similar != "" {
+				detail += fmt.Sprintf(" Did you mean %q?", similar)
+			}
+			diags = safeDiagnosticsAppend(diags, &hcl.Diagnostic{
+				Severity: severity,
+				Summary:  "Undeclared variable in environment",
+				Detail:   detail,
+				Subject:  &fakeRange,
+			})
+			continue
+		}
+
+		val, valDiags := parseRawVariableValue(fakeRange.Filename, rawVal, existing.Type)
+		if valDiags.HasErrors() {
+			diags = safeDiagnosticsExtend(diags, valDiags)
+			continue
+		}
+
+		v := Variable{
+			Name:      varName,
+			Type:      val.Type(),
+			Value:     val,
+			DeclRange: fakeRange,
+			Source:    Source{Kind: SourceEnv, Origin: envName},
+		}
+		p.envOverrideVars[packName] = append(p.envOverrideVars[packName], &v)
+	}
+
+	return diags
+}
@@ -0,0 +1,107 @@
+package variable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParser_EnvVariables(t *testing.T) {
+	overrideFile := filepath.Join(t.TempDir(), "overrides.hcl")
+	require.NoError(t, os.WriteFile(overrideFile, []byte(`image = "redis:file"`), 0644))
+
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name: "variables.hcl",
+				Path: "example/variables.hcl",
+				Content: []byte(`variable "count" {
+  type    = number
+  default = 1
+}
+
+variable "image" {
+  type    = string
+  default = "redis:default"
+}
+
+variable "datacenters" {
+  type    = list(string)
+  default = ["dc1"]
+}
+`),
+			},
+		},
+		FileOverrides: []string{overrideFile},
+		EnvPrefix:     DefaultEnvPrefix,
+		Environ: []string{
+			"NOMAD_PACK_VAR_count=3",
+			"NOMAD_PACK_VAR_image=redis:env",
+			`NOMAD_PACK_VAR_example.datacenters=["dc1", "dc2"]`,
+			"NOMAD_PACK_VAR_unknown=true",
+			"NOMAD_PACK_VAR_=ignored",
+			"OTHER_count=5",
+		},
+	})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	// Undeclared variables are a warning rather than an error.
+	require.Len(t, diags, 1)
+	require.Equal(t, hcl.DiagWarning, diags[0].Severity)
+	require.Contains(t, diags[0].Detail, "NOMAD_PACK_VAR_unknown")
+
+	vars := parsed.Vars["example"]
+	require.True(t, vars["count"].Value.Equals(cty.NumberIntVal(3)).True())
+	require.Equal(t, Source{Kind: SourceEnv, Origin: "NOMAD_PACK_VAR_count"}, vars["count"].Source)
+	require.Equal(t, cty.ListVal([]cty.Value{cty.StringVal("dc1"), cty.StringVal("dc2")}), vars["datacenters"].Value)
+
+	// Variable files take precedence over the environment.
+	require.Equal(t, cty.StringVal("redis:file"), vars["image"].Value)
+	require.Equal(t, SourceFile, vars["image"].Source.Kind)
+}
+
+func TestParser_EnvVariablesInvalidValue(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name:    "variables.hcl",
+				Path:    "example/variables.hcl",
+				Content: []byte(`variable "count" { type = number }`),
+			},
+		},
+		EnvPrefix: DefaultEnvPrefix,
+		Environ:   []string{"NOMAD_PACK_VAR_count=many"},
+	})
+	require.NoError(t, err)
+
+	_, diags := parser.Parse()
+	require.True(t, diags.HasErrors())
+}
+
+func TestParser_EnvVariablesStrict(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName:        "example",
+		RootVariableFiles: testRootVariableFiles(),
+		EnvPrefix:         DefaultEnvPrefix,
+		Environ:           []string{"NOMAD_PACK_VAR_regoin=ams"},
+		StrictVars:        true,
+	})
+	require.NoError(t, err)
+
+	// Undeclared variables are an error, suggesting the declared variable
+	// which was likely meant.
+	_, diags := parser.Parse()
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 1)
+	require.Contains(t, diags[0].Detail, `Did you mean "region"?`)
+}
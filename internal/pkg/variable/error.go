@@ -21,11 +21,15 @@ func safeDiagnosticsExtend(base, new hcl.Diagnostics) hcl.Diagnostics {
 	return base
 }
 
-func diagnosticMissingRootVar(name string, sub *hcl.Range) *hcl.Diagnostic {
+func diagnosticMissingRootVar(name, similar string, sub *hcl.Range) *hcl.Diagnostic {
+	detail := fmt.Sprintf(`There is no variable named %q. An override file can only override a variable that was already declared in a primary configuration file.`, name)
+	if similar != "" {
+		detail += fmt.Sprintf(" Did you mean %q?", similar)
+	}
 	return &hcl.Diagnostic{
 		Severity: hcl.DiagError,
 		Summary:  "Missing base variable declaration to override",
-		Detail:   fmt.Sprintf(`There is no variable named %q. An override file can only override a variable that was already declared in a primary configuration file.`, name),
+		Detail:   detail,
 		Subject:  sub,
 	}
 }
@@ -0,0 +1,126 @@
+package variable
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// RedactedValue replaces the value of sensitive variables when explaining
+// them.
+const RedactedValue = "(sensitive)"
+
+// Explanation describes the final value of a single variable and the source
+// it was set from.
+type Explanation struct {
+
+	// Pack is the name of the pack which declares the variable.
+	Pack string
+
+	// Name is the variable name.
+	Name string
+
+	// Value is the JSON encoding of the final variable value. It is empty if
+	// the variable has no value, and RedactedValue if it is sensitive.
+	Value string
+
+	// Source identifies where the final value was set.
+	Source Source
+}
+
+// SensitiveValues returns the non-empty strings within the values of the
+// variables declared as sensitive, including those nested within
+// collections, so they can be masked when displayed. The strings are sorted
+// longest first, so that a value containing another is masked whole.
+func (p *ParsedVariables) SensitiveValues() []string {
+
+	seen := make(map[string]bool)
+	var out []string
+
+	for _, vars := range p.Vars {
+		for _, v := range vars {
+			if !v.Sensitive || v.Value == cty.NilVal {
+				continue
+			}
+			for _, s := range ctyStrings(v.Value) {
+				if !seen[s] {
+					seen[s] = true
+					out = append(out, s)
+				}
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// ctyStrings returns the non-empty string values within val.
+func ctyStrings(val cty.Value) []string {
+	if !val.IsKnown() || val.IsNull() {
+		return nil
+	}
+
+	switch {
+	case val.Type() == cty.String:
+		if s := val.AsString(); s != "" {
+			return []string{s}
+		}
+		return nil
+	case val.CanIterateElements():
+		var out []string
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			out = append(out, ctyStrings(elem)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// Explain returns the explanation of each variable, sorted by pack and
+// variable name.
+func (p *ParsedVariables) Explain() ([]*Explanation, error) {
+
+	var out []*Explanation
+
+	for packName, vars := range p.Vars {
+		for name, v := range vars {
+			e := &Explanation{Pack: packName, Name: name, Source: v.Source}
+
+			switch {
+			case v.Value == cty.NilVal:
+			case v.Sensitive:
+				e.Value = RedactedValue
+			default:
+				val, err := convertCtyToInterface(v.Value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert value of variable %q: %v", name, err)
+				}
+				b, err := json.Marshal(val)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode value of variable %q: %v", name, err)
+				}
+				e.Value = string(b)
+			}
+
+			out = append(out, e)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Pack != out[j].Pack {
+			return out[i].Pack < out[j].Pack
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
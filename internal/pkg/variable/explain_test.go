@@ -0,0 +1,117 @@
+package variable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedVariables_Explain(t *testing.T) {
+	overrideFile := filepath.Join(t.TempDir(), "overrides.hcl")
+	require.NoError(t, os.WriteFile(overrideFile, []byte("count = 3\npassword = \"hunter2\"\n"), 0644))
+
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name: "variables.hcl",
+				Path: "example/variables.hcl",
+				Content: []byte(`variable "job_name" {
+  default = "example"
+}
+
+variable "count" {
+  type    = number
+  default = 1
+}
+
+variable "region" {
+  type    = string
+  default = "global"
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+variable "unset" {}
+`),
+			},
+		},
+		FileOverrides: []string{overrideFile},
+		CLIOverrides:  map[string]string{"region": "europe"},
+	})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	explanations, err := parsed.Explain()
+	require.NoError(t, err)
+	require.Equal(t, []*Explanation{
+		{Pack: "example", Name: "count", Value: "3", Source: Source{Kind: SourceFile, Origin: overrideFile + ":1"}},
+		{Pack: "example", Name: "job_name", Value: `"example"`, Source: Source{Kind: SourceDefault, Origin: "example/variables.hcl:2"}},
+		{Pack: "example", Name: "password", Value: RedactedValue, Source: Source{Kind: SourceFile, Origin: overrideFile + ":2"}},
+		{Pack: "example", Name: "region", Value: `"europe"`, Source: Source{Kind: SourceCLI, Origin: "--var region"}},
+		{Pack: "example", Name: "unset"},
+	}, explanations)
+}
+
+func TestParser_SensitiveInvalidType(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name:    "variables.hcl",
+				Path:    "example/variables.hcl",
+				Content: []byte(`variable "password" { sensitive = "yes" }`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, diags := parser.Parse()
+	require.True(t, diags.HasErrors())
+	require.Contains(t, diags.Error(), "Invalid type for sensitive")
+}
+
+func TestParsedVariables_SensitiveValues(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name: "variables.hcl",
+				Path: "example/variables.hcl",
+				Content: []byte(`variable "password" {
+  sensitive = true
+  default   = "hunter2"
+}
+
+variable "tokens" {
+  type      = map(string)
+  sensitive = true
+  default   = { a = "hunter", b = "", c = "token-abc" }
+}
+
+variable "port" {
+  sensitive = true
+  default   = 8080
+}
+
+variable "region" {
+  default = "global"
+}
+`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Equal(t, []string{"token-abc", "hunter2", "hunter"}, parsed.SensitiveValues())
+}
@@ -0,0 +1,155 @@
+package variable
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect used for generated schemas.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema builds a JSON Schema document describing the variables which
+// can be set within a variable override file. The parent pack variables are
+// described at the top level, while dependent pack variables are nested
+// within an object named after the dependent pack, matching how overrides
+// are parsed. Variables which do not have a default value are required.
+func (p *ParsedVariables) JSONSchema(parentName string) ([]byte, error) {
+
+	schema, err := variablesJSONSchema(p.Vars[parentName])
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = jsonSchemaDraft
+
+	// Sort the dependent pack names, so the output is stable.
+	var packNames []string
+	for packName := range p.Vars {
+		if packName != parentName {
+			packNames = append(packNames, packName)
+		}
+	}
+	sort.Strings(packNames)
+
+	properties := schema["properties"].(map[string]interface{})
+
+	for _, packName := range packNames {
+		packSchema, err := variablesJSONSchema(p.Vars[packName])
+		if err != nil {
+			return nil, err
+		}
+		packSchema["description"] = fmt.Sprintf("Variables for the %s dependent pack.", packName)
+		properties[packName] = packSchema
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// variablesJSONSchema builds the object schema describing a single pack's
+// variables.
+func variablesJSONSchema(vars map[string]*Variable) (map[string]interface{}, error) {
+
+	properties := make(map[string]interface{}, len(vars))
+	required := []string{}
+
+	for name, v := range vars {
+		typ := v.Type
+		if typ == cty.NilType && v.Value != cty.NilVal {
+			typ = v.Value.Type()
+		}
+
+		varSchema := ctyTypeJSONSchema(typ)
+
+		if v.Description != "" {
+			varSchema["description"] = v.Description
+		}
+
+		if v.Value == cty.NilVal {
+			required = append(required, name)
+		} else {
+			def, err := convertCtyToInterface(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert default value of variable %q: %v", name, err)
+			}
+			varSchema["default"] = def
+		}
+
+		properties[name] = varSchema
+	}
+
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+// ctyTypeJSONSchema returns the JSON Schema describing the passed cty type.
+// Dynamic or unknown types are described by an empty schema, which allows any
+// value.
+func ctyTypeJSONSchema(typ cty.Type) map[string]interface{} {
+	switch {
+	case typ == cty.String:
+		return map[string]interface{}{"type": "string"}
+	case typ == cty.Number:
+		return map[string]interface{}{"type": "number"}
+	case typ == cty.Bool:
+		return map[string]interface{}{"type": "boolean"}
+
+	case typ.IsListType():
+		return map[string]interface{}{
+			"type":  "array",
+			"items": ctyTypeJSONSchema(typ.ElementType()),
+		}
+
+	case typ.IsSetType():
+		return map[string]interface{}{
+			"type":        "array",
+			"items":       ctyTypeJSONSchema(typ.ElementType()),
+			"uniqueItems": true,
+		}
+
+	case typ.IsTupleType():
+		elemTypes := typ.TupleElementTypes()
+		items := make([]interface{}, len(elemTypes))
+		for i, elemType := range elemTypes {
+			items[i] = ctyTypeJSONSchema(elemType)
+		}
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    items,
+			"minItems": len(items),
+			"maxItems": len(items),
+		}
+
+	case typ.IsMapType():
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": ctyTypeJSONSchema(typ.ElementType()),
+		}
+
+	case typ.IsObjectType():
+		attrTypes := typ.AttributeTypes()
+		properties := make(map[string]interface{}, len(attrTypes))
+		required := make([]string, 0, len(attrTypes))
+		for name, attrType := range attrTypes {
+			properties[name] = ctyTypeJSONSchema(attrType)
+			required = append(required, name)
+		}
+		sort.Strings(required)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+
+	default:
+		return map[string]interface{}{}
+	}
+}
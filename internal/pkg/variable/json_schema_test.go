@@ -0,0 +1,85 @@
+package variable
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedVariables_JSONSchema(t *testing.T) {
+	parser, err := NewParser(&ParserConfig{
+		ParentName: "example",
+		RootVariableFiles: map[string]*pack.File{
+			"example": {
+				Name: "variables.hcl",
+				Path: "example/variables.hcl",
+				Content: []byte(`
+variable "job_name" {
+  description = "The name of the job"
+  type        = string
+}
+
+variable "resources" {
+  type = object({
+    cpu    = number
+    memory = number
+  })
+  default = {
+    cpu    = 100
+    memory = 256
+  }
+}
+`),
+			},
+			"redis": {
+				Name:    "variables.hcl",
+				Path:    "redis/variables.hcl",
+				Content: []byte(`variable "ports" { type = set(number) }`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	schema, err := parsed.JSONSchema("example")
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["job_name"],
+  "properties": {
+    "job_name": {
+      "type": "string",
+      "description": "The name of the job"
+    },
+    "resources": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["cpu", "memory"],
+      "properties": {
+        "cpu": {"type": "number"},
+        "memory": {"type": "number"}
+      },
+      "default": {"cpu": 100, "memory": 256}
+    },
+    "redis": {
+      "type": "object",
+      "description": "Variables for the redis dependent pack.",
+      "additionalProperties": false,
+      "required": ["ports"],
+      "properties": {
+        "ports": {
+          "type": "array",
+          "items": {"type": "number"},
+          "uniqueItems": true
+        }
+      }
+    }
+  }
+}`, string(schema))
+}
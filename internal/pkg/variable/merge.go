@@ -0,0 +1,71 @@
+package variable
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// VarFileMerge* are the strategies used to merge the values of variables set
+// by variable files into their existing values.
+const (
+	// VarFileMergeReplace replaces the existing value of a variable with the
+	// value set by the file.
+	VarFileMergeReplace = "replace"
+
+	// VarFileMergeDeep deep merges object and map values set by the file into
+	// the existing value of a variable. See deepMergeValues for the rules.
+	VarFileMergeDeep = "deep"
+)
+
+// VarFileMerges lists all the supported variable file merge strategies.
+var VarFileMerges = []string{VarFileMergeReplace, VarFileMergeDeep}
+
+// mergeDeep merges the new variable into the variable in the same way as
+// merge, other than its value being deep merged into the existing value
+// using deepMergeValues. The merged value is converted to the type of the
+// variable, so it must still satisfy the type constraint.
+func (v *Variable) mergeDeep(new *Variable) hcl.Diagnostics {
+	if v.Value == cty.NilVal || new.Value == cty.NilVal {
+		return v.merge(new)
+	}
+
+	merged := *new
+	merged.Value = deepMergeValues(v.Value, new.Value)
+	merged.Type = cty.NilType
+	return v.merge(&merged)
+}
+
+// deepMergeValues returns the override value deep merged into the base
+// value. When both values are objects or maps, the result is an object with
+// the attributes of both, where those present in both are merged
+// recursively. Otherwise, including for lists, sets and tuples, the override
+// value replaces the base value.
+func deepMergeValues(base, override cty.Value) cty.Value {
+	if !deepMergeable(base) || !deepMergeable(override) {
+		return override
+	}
+
+	attrs := make(map[string]cty.Value)
+	for it := base.ElementIterator(); it.Next(); {
+		k, val := it.Element()
+		attrs[k.AsString()] = val
+	}
+	for it := override.ElementIterator(); it.Next(); {
+		k, val := it.Element()
+		if existing, ok := attrs[k.AsString()]; ok {
+			val = deepMergeValues(existing, val)
+		}
+		attrs[k.AsString()] = val
+	}
+	return cty.ObjectVal(attrs)
+}
+
+// deepMergeable returns whether the value is an object or map whose
+// attributes can be merged.
+func deepMergeable(val cty.Value) bool {
+	if val == cty.NilVal || val.IsNull() || !val.IsKnown() {
+		return false
+	}
+	typ := val.Type()
+	return typ.IsObjectType() || typ.IsMapType()
+}
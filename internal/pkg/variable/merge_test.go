@@ -0,0 +1,219 @@
+package variable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// testMergeRootVariableFiles returns the root variable file for an example
+// pack declaring a nested object variable.
+func testMergeRootVariableFiles() map[string]*pack.File {
+	return map[string]*pack.File{
+		"example": {
+			Name: "variables.hcl",
+			Path: "example/variables.hcl",
+			Content: []byte(`
+variable "app" {
+  type = object({
+    image = string
+    resources = object({
+      cpu    = number
+      memory = number
+    })
+    ports = list(number)
+    labels = map(string)
+  })
+  default = {
+    image = "web:1.0"
+    resources = {
+      cpu    = 500
+      memory = 256
+    }
+    ports  = [80]
+    labels = { team = "web" }
+  }
+}
+`),
+		},
+	}
+}
+
+func TestParser_FileMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	// The files are named so that sorting them would reverse their order.
+	first := writeFile("z.hcl", `
+app = {
+  image = "web:2.0"
+  resources = {
+    cpu    = 500
+    memory = 512
+  }
+  ports  = [80, 443]
+  labels = { team = "web", tier = "frontend" }
+}
+`)
+	second := writeFile("a.hcl", `
+app = {
+  image = "web:2.0"
+  resources = {
+    cpu    = 1000
+    memory = 512
+  }
+  ports  = [8080]
+  labels = { team = "platform" }
+}
+`)
+	partial := writeFile("partial.hcl", `
+app = {
+  resources = {
+    cpu = 1000
+  }
+  labels = { owner = "ops" }
+}
+`)
+
+	testCases := []struct {
+		name          string
+		fileOverrides []string
+		fileMerge     string
+		expectedValue cty.Value
+	}{
+		{
+			name:          "later file replaces",
+			fileOverrides: []string{first, second},
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"image": cty.StringVal("web:2.0"),
+				"resources": cty.ObjectVal(map[string]cty.Value{
+					"cpu":    cty.NumberIntVal(1000),
+					"memory": cty.NumberIntVal(512),
+				}),
+				"ports":  cty.ListVal([]cty.Value{cty.NumberIntVal(8080)}),
+				"labels": cty.MapVal(map[string]cty.Value{"team": cty.StringVal("platform")}),
+			}),
+		},
+		{
+			name:          "order is left to right",
+			fileOverrides: []string{second, first},
+			fileMerge:     VarFileMergeReplace,
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"image": cty.StringVal("web:2.0"),
+				"resources": cty.ObjectVal(map[string]cty.Value{
+					"cpu":    cty.NumberIntVal(500),
+					"memory": cty.NumberIntVal(512),
+				}),
+				"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+				"labels": cty.MapVal(map[string]cty.Value{
+					"team": cty.StringVal("web"),
+					"tier": cty.StringVal("frontend"),
+				}),
+			}),
+		},
+		{
+			name:          "deep merges nested objects and maps",
+			fileOverrides: []string{first, partial},
+			fileMerge:     VarFileMergeDeep,
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"image": cty.StringVal("web:2.0"),
+				"resources": cty.ObjectVal(map[string]cty.Value{
+					"cpu":    cty.NumberIntVal(1000),
+					"memory": cty.NumberIntVal(512),
+				}),
+				"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+				"labels": cty.MapVal(map[string]cty.Value{
+					"owner": cty.StringVal("ops"),
+					"team":  cty.StringVal("web"),
+					"tier":  cty.StringVal("frontend"),
+				}),
+			}),
+		},
+		{
+			name:          "deep merge replaces lists",
+			fileOverrides: []string{first, second},
+			fileMerge:     VarFileMergeDeep,
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"image": cty.StringVal("web:2.0"),
+				"resources": cty.ObjectVal(map[string]cty.Value{
+					"cpu":    cty.NumberIntVal(1000),
+					"memory": cty.NumberIntVal(512),
+				}),
+				"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(8080)}),
+				"labels": cty.MapVal(map[string]cty.Value{
+					"team": cty.StringVal("platform"),
+					"tier": cty.StringVal("frontend"),
+				}),
+			}),
+		},
+		{
+			name:          "deep merges into the default",
+			fileOverrides: []string{partial},
+			fileMerge:     VarFileMergeDeep,
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"image": cty.StringVal("web:1.0"),
+				"resources": cty.ObjectVal(map[string]cty.Value{
+					"cpu":    cty.NumberIntVal(1000),
+					"memory": cty.NumberIntVal(256),
+				}),
+				"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(80)}),
+				"labels": cty.MapVal(map[string]cty.Value{
+					"owner": cty.StringVal("ops"),
+					"team":  cty.StringVal("web"),
+				}),
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName:        "example",
+				RootVariableFiles: testMergeRootVariableFiles(),
+				FileOverrides:     tc.fileOverrides,
+				FileMerge:         tc.fileMerge,
+			})
+			require.NoError(t, err)
+
+			parsed, diags := parser.Parse()
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			// Replaced values keep the types of the file they were set by,
+			// so are compared once converted to the declared type.
+			actual, err := convert.Convert(parsed.Vars["example"]["app"].Value, tc.expectedValue.Type())
+			require.NoError(t, err)
+			require.True(t, tc.expectedValue.RawEquals(actual), "expected %#v, got %#v", tc.expectedValue, actual)
+		})
+	}
+}
+
+func TestParser_FileMergeTypeConstraint(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.hcl")
+	require.NoError(t, os.WriteFile(invalid, []byte(`app = { resources = { cpu = "lots" } }`), 0644))
+
+	parser, err := NewParser(&ParserConfig{
+		ParentName:        "example",
+		RootVariableFiles: testMergeRootVariableFiles(),
+		FileOverrides:     []string{invalid},
+		FileMerge:         VarFileMergeDeep,
+	})
+	require.NoError(t, err)
+
+	_, diags := parser.Parse()
+	require.True(t, diags.HasErrors())
+	require.Equal(t, "Invalid default value for variable", diags[0].Summary)
+}
+
+func TestNewParser_InvalidFileMerge(t *testing.T) {
+	_, err := NewParser(&ParserConfig{ParentName: "example", FileMerge: "shallow"})
+	require.EqualError(t, err, `unsupported variable file merge strategy "shallow"`)
+}
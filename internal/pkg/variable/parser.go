@@ -1,19 +1,49 @@
 package variable
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/suggest"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/spf13/afero"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// StdinFileOverride is the file override name which indicates the variable
+// overrides should be read from standard input.
+const StdinFileOverride = "-"
+
+// AutoFileOverridePatterns are the glob patterns matching the variable files
+// which are loaded automatically from the working directory, without being
+// passed as a variable file.
+var AutoFileOverridePatterns = []string{"*.auto.nomadpack.hcl", "*.auto.pkrvars.hcl"}
+
+// FindAutoFileOverrides returns the files within dir matching any of the
+// AutoFileOverridePatterns, sorted by name so that their precedence is
+// predictable.
+func FindAutoFileOverrides(dir string) ([]string, error) {
+	var files []string
+	for _, pattern := range AutoFileOverridePatterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 // Parser can parse, merge, and validate HCL variables from multiple different
 // sources.
 type Parser struct {
@@ -25,10 +55,17 @@ type Parser struct {
 	// the second is by the variable name.
 	rootVars map[string]map[string]*Variable
 
-	// fileOverrideVars and cliOverrideVars are the override variables. The
-	// maps are keyed by the pack name they are associated to.
-	fileOverrideVars map[string][]*Variable
-	cliOverrideVars  map[string][]*Variable
+	// profileOverrideVars, envOverrideVars, fileOverrideVars and
+	// cliOverrideVars are the override variables. The maps are keyed by the
+	// pack name they are associated to.
+	profileOverrideVars map[string][]*Variable
+	envOverrideVars     map[string][]*Variable
+	fileOverrideVars    map[string][]*Variable
+	cliOverrideVars     map[string][]*Variable
+
+	// diagVariables maps the diagnostics concerning an individual variable
+	// to the name of the variable, as passed by the user.
+	diagVariables map[*hcl.Diagnostic]string
 }
 
 // ParserConfig contains details of the numerous sources of variables which
@@ -42,15 +79,57 @@ type ParserConfig struct {
 	// pack name.
 	RootVariableFiles map[string]*pack.File
 
+	// ProfileFile is the variable file of the profile selected from the
+	// parent pack, if any. It overrides the default root declarations, but
+	// takes the lowest precedence of all the overrides.
+	ProfileFile *pack.File
+
 	// FileOverrides is a list of files which contain variable overrides in the
-	// form key=value. The files will be stored before processing to ensure a
-	// consistent processing experience. Overrides here will replace any
-	// default root declarations.
+	// form key=value. The files are processed in order, so where files set
+	// the same variable, the later file takes precedence. Overrides here will
+	// replace any default root declarations. A file named StdinFileOverride
+	// is read from Stdin and can be either HCL or JSON.
 	FileOverrides []string
 
+	// FileMerge is the strategy used to merge the values set by the
+	// FileOverrides and AutoFileOverrides into the existing variable values,
+	// which is one of VarFileMerges. If empty, VarFileMergeReplace is used.
+	FileMerge string
+
+	// AutoFileOverrides is a list of variable files which were discovered
+	// automatically, rather than being passed explicitly. They are processed
+	// in order before FileOverrides, so take a lower precedence.
+	AutoFileOverrides []string
+
+	// Stdin is the reader used for the StdinFileOverride file. If nil,
+	// os.Stdin is used.
+	Stdin io.Reader
+
+	// EnvPrefix is the prefix of the environment variables which set pack
+	// variables, with the remainder of the name being the variable name.
+	// These take a lower precedence than FileOverrides. If empty, the
+	// environment is not scanned.
+	EnvPrefix string
+
+	// Environ is the environment scanned for variables, in the form
+	// key=value. If nil, os.Environ is used.
+	Environ []string
+
 	// CLIOverrides are key=value variables and take the highest precedence of
 	// all sources. If the same key is supplied twice, the last wins.
 	CLIOverrides map[string]string
+
+	// CLIMapNames are the names of the CLIOverrides which were set from a
+	// JSON object of variables, such as using --var-map. As the object may be
+	// shared with other packs, those naming a variable which is not declared
+	// result in a warning, rather than an error.
+	CLIMapNames map[string]bool
+
+	// StrictVars makes environment variables and CLIMapNames which set
+	// variables not declared by the pack an error, rather than a warning.
+	// Variables set by files and the other CLIOverrides must always be
+	// declared.
+	StrictVars bool
 }
 
 func NewParser(cfg *ParserConfig) (*Parser, error) {
@@ -60,24 +139,49 @@ func NewParser(cfg *ParserConfig) (*Parser, error) {
 		return nil, errors.New("variable parser config requires ParentName to be set")
 	}
 
-	// Sort the file overrides to ensure variable merging is consistent on
-	// multiple passes.
-	sort.Strings(cfg.FileOverrides)
+	switch cfg.FileMerge {
+	case "", VarFileMergeReplace, VarFileMergeDeep:
+	default:
+		return nil, fmt.Errorf("unsupported variable file merge strategy %q", cfg.FileMerge)
+	}
+
+	for _, file := range cfg.AutoFileOverrides {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("variable file %q not found", file)
+		}
+	}
+
+	var stdinOverrides int
 	for _, file := range cfg.FileOverrides {
+		if file == StdinFileOverride {
+			stdinOverrides++
+			continue
+		}
 		_, err := os.Stat(file)
 		if err != nil {
 			return nil, fmt.Errorf("variable file %q not found", file)
 		}
 	}
 
+	// Standard input can only be consumed once.
+	if stdinOverrides > 1 {
+		return nil, errors.New("standard input can only be used as a variable file once")
+	}
+
+	if cfg.Stdin == nil {
+		cfg.Stdin = os.Stdin
+	}
+
 	return &Parser{
 		fs: afero.Afero{
 			Fs: afero.OsFs{},
 		},
-		cfg:              cfg,
-		rootVars:         make(map[string]map[string]*Variable),
-		fileOverrideVars: make(map[string][]*Variable),
-		cliOverrideVars:  make(map[string][]*Variable),
+		cfg:                 cfg,
+		rootVars:            make(map[string]map[string]*Variable),
+		profileOverrideVars: make(map[string][]*Variable),
+		envOverrideVars:     make(map[string][]*Variable),
+		fileOverrideVars:    make(map[string][]*Variable),
+		cliOverrideVars:     make(map[string][]*Variable),
 	}, nil
 }
 
@@ -90,15 +194,30 @@ func (p *Parser) Parse() (*ParsedVariables, hcl.Diagnostics) {
 		return nil, diags
 	}
 
-	// Parse file and CLI overrides.
+	// Parse profile, environment, file and CLI overrides. Automatically
+	// loaded files are parsed first, so that explicitly passed files take
+	// precedence when merging.
+	if p.cfg.ProfileFile != nil {
+		diags = safeDiagnosticsExtend(diags, p.parseProfileFile(p.cfg.ProfileFile))
+	}
+	if p.cfg.EnvPrefix != "" {
+		// Warnings are kept, as undeclared environment variables are not an
+		// error.
+		diags = diags.Extend(p.parseEnvVariables())
+	}
+	for _, fileOverride := range p.cfg.AutoFileOverrides {
+		fileOverrideDiags := p.parseOverridesFile(fileOverride)
+		diags = safeDiagnosticsExtend(diags, fileOverrideDiags)
+	}
 	for _, fileOverride := range p.cfg.FileOverrides {
 		fileOverrideDiags := p.parseOverridesFile(fileOverride)
 		diags = safeDiagnosticsExtend(diags, fileOverrideDiags)
 	}
 
 	for k, v := range p.cfg.CLIOverrides {
-		cliOverrideDiags := p.parseCLIVariable(k, v)
-		diags = safeDiagnosticsExtend(diags, cliOverrideDiags)
+		// Warnings are kept, as undeclared --var-map variables are not an
+		// error.
+		diags = diags.Extend(p.parseCLIVariable(k, v))
 	}
 
 	if diags.HasErrors() {
@@ -106,27 +225,52 @@ func (p *Parser) Parse() (*ParsedVariables, hcl.Diagnostics) {
 	}
 
 	// Iterate all our override variables and merge these into our root
-	// variables with the CLI taking highest priority.
-	for _, override := range []map[string][]*Variable{p.fileOverrideVars, p.cliOverrideVars} {
-		for packName, variables := range override {
+	// variables with the CLI taking highest priority. The values set by
+	// variable files are deep merged when configured to be.
+	overrides := []struct {
+		vars map[string][]*Variable
+		kind SourceKind
+	}{
+		{p.profileOverrideVars, SourceProfile},
+		{p.envOverrideVars, SourceEnv},
+		{p.fileOverrideVars, SourceFile},
+		{p.cliOverrideVars, SourceCLI},
+	}
+	for _, override := range overrides {
+		for packName, variables := range override.vars {
 			for _, v := range variables {
 				existing, exists := p.rootVars[packName][v.Name]
 				if !exists {
-					diags = diags.Append(diagnosticMissingRootVar(v.Name, v.DeclRange.Ptr()))
+					similar := p.similarVariable(packName, v.Name, v.Name)
+					diags = diags.Append(diagnosticMissingRootVar(v.Name, similar, v.DeclRange.Ptr()))
 					continue
 				}
-				if mergeDiags := existing.merge(v); mergeDiags.HasErrors() {
+				merge := existing.merge
+				if override.kind == SourceFile && p.cfg.FileMerge == VarFileMergeDeep {
+					merge = existing.mergeDeep
+				}
+				if mergeDiags := merge(v); mergeDiags.HasErrors() {
 					diags = diags.Extend(mergeDiags)
 				}
 			}
 		}
 	}
 
+	// The values are only validated once all overrides have been merged, as
+	// the rules apply to the final values.
+	if !diags.HasErrors() {
+		diags = diags.Extend(p.validateVariables())
+	}
+
 	return &ParsedVariables{Vars: p.rootVars}, diags
 }
 
 func (p *Parser) loadOverrideFile(file string) (hcl.Body, hcl.Diagnostics) {
 
+	if file == StdinFileOverride {
+		return p.loadStdinOverrideFile()
+	}
+
 	src, err := p.fs.ReadFile(file)
 	if err != nil {
 		return nil, hcl.Diagnostics{
@@ -138,11 +282,60 @@ func (p *Parser) loadOverrideFile(file string) (hcl.Body, hcl.Diagnostics) {
 		}
 	}
 
-	return p.loadPackFile(&pack.File{Path: file, Content: src})
+	return p.loadPackFile(&pack.File{Name: file, Path: file, Content: src})
+}
+
+// loadStdinOverrideFile reads the override variables from standard input.
+// As there is no file extension to identify the format, it is detected from
+// the content.
+func (p *Parser) loadStdinOverrideFile() (hcl.Body, hcl.Diagnostics) {
+
+	src, err := ioutil.ReadAll(p.cfg.Stdin)
+	if err != nil {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read standard input",
+				Detail:   fmt.Sprintf("The variable overrides could not be read from standard input: %s.", err),
+			},
+		}
+	}
+
+	return p.loadPackFile(&pack.File{Name: "stdin", Path: "<stdin>", Content: src})
+}
+
+// varFileFormat* are the formats variable files can be written in.
+const (
+	varFileFormatHCL  = "HCL"
+	varFileFormatJSON = "JSON"
+)
+
+// detectVarFileFormat returns the format of the named variable file, along
+// with how it was detected. Files with a .json or .hcl extension are parsed
+// in that format. Otherwise, the format is sniffed from the content, with
+// content starting with an opening brace being JSON and anything else HCL.
+func detectVarFileFormat(name string, content []byte) (format, detectedBy string) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return varFileFormatJSON, "file extension"
+	case ".hcl":
+		return varFileFormatHCL, "file extension"
+	}
+	return sniffVarFileFormat(content), "content"
+}
+
+// sniffVarFileFormat returns the format of the variable file content.
+func sniffVarFileFormat(content []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return varFileFormatJSON
+	}
+	return varFileFormatHCL
 }
 
 // loadPackFile takes a pack.File and parses this using a hclparse.Parser. The
-// file can be either HCL and JSON format.
+// file can be either HCL and JSON format, which is detected using
+// detectVarFileFormat. When the file cannot be parsed, the diagnostics are
+// preceded by one naming the file and the format it was parsed as.
 func (p *Parser) loadPackFile(file *pack.File) (hcl.Body, hcl.Diagnostics) {
 
 	var (
@@ -154,14 +347,29 @@ func (p *Parser) loadPackFile(file *pack.File) (hcl.Body, hcl.Diagnostics) {
 	// names collide from different packs will cause problems.
 	hclParser := hclparse.NewParser()
 
-	// Depending on the fix extension, use the correct HCL parser.
-	switch {
-	case strings.HasSuffix(file.Name, ".json"):
+	// Depending on the file format, use the correct HCL parser.
+	format, detectedBy := detectVarFileFormat(file.Name, file.Content)
+	switch format {
+	case varFileFormatJSON:
 		hclFile, diags = hclParser.ParseJSON(file.Content, file.Path)
 	default:
 		hclFile, diags = hclParser.ParseHCL(file.Content, file.Path)
 	}
 
+	if diags.HasErrors() {
+		detail := fmt.Sprintf("The variable file %q could not be parsed as %s, the format detected from its %s.",
+			file.Path, format, detectedBy)
+		if sniffed := sniffVarFileFormat(file.Content); sniffed != format {
+			detail += fmt.Sprintf(" Its content appears to be %s; check the file extension matches its format.", sniffed)
+		}
+		diags = append(hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to parse variable file",
+			Detail:   detail,
+			Subject:  &hcl.Range{Filename: file.Path, Start: hcl.InitialPos, End: hcl.InitialPos},
+		}}, diags...)
+	}
+
 	// If the returned file or body is nil, then we'll return a non-nil empty
 	// body, so we'll meet our contract that nil means an error reading the
 	// file.
@@ -173,8 +381,22 @@ func (p *Parser) loadPackFile(file *pack.File) (hcl.Body, hcl.Diagnostics) {
 }
 
 func (p *Parser) parseOverridesFile(file string) hcl.Diagnostics {
-
 	body, diags := p.loadOverrideFile(file)
+	return p.parseOverridesBody(body, diags, p.fileOverrideVars, SourceFile)
+}
+
+// parseProfileFile parses the variable file of the selected profile, which
+// is stored within the pack rather than read from disk.
+func (p *Parser) parseProfileFile(file *pack.File) hcl.Diagnostics {
+	body, diags := p.loadPackFile(file)
+	return p.parseOverridesBody(body, diags, p.profileOverrideVars, SourceProfile)
+}
+
+// parseOverridesBody parses the attributes of a loaded variable file body
+// into the overrides, recording them as set from the kind of source.
+func (p *Parser) parseOverridesBody(body hcl.Body, diags hcl.Diagnostics,
+	overrides map[string][]*Variable, kind SourceKind) hcl.Diagnostics {
+
 	if body == nil {
 		return diags
 	}
@@ -200,27 +422,64 @@ func (p *Parser) parseOverridesFile(file string) hcl.Diagnostics {
 		// a dependent pack and then handle it accordingly.
 		isPackVar, packVarDiags := p.isPackVariableObject(attr.Name, expr.Type())
 		diags = safeDiagnosticsExtend(diags, packVarDiags)
-		p.handleOverrideVar(isPackVar, attr, expr)
+		p.handleOverrideVar(isPackVar, attr, expr, overrides, kind)
 	}
 
 	return diags
 }
 
-func (p *Parser) handleOverrideVar(isPackVar bool, attr *hcl.Attribute, expr cty.Value) {
+// similarVariable returns the variable declared by the named pack which is
+// closest to varName, as it was likely meant when varName is not declared.
+// The variable is returned in the same form as name, which is how varName
+// was passed by the user, such as prefixed by the pack name. An empty string
+// is returned if no declared variable is close.
+func (p *Parser) similarVariable(packName, varName, name string) string {
+	declared := make([]string, 0, len(p.rootVars[packName]))
+	for declaredName := range p.rootVars[packName] {
+		declared = append(declared, declaredName)
+	}
+	sort.Strings(declared)
+
+	closest := suggest.Closest(varName, declared)
+	if closest == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, varName) + closest
+}
+
+// DiagnosticVariable returns the name of the variable, as passed by the user,
+// which the diagnostic returned by Parse concerns. If the diagnostic does not
+// concern an individual variable, an empty string is returned.
+func (p *Parser) DiagnosticVariable(diag *hcl.Diagnostic) string {
+	return p.diagVariables[diag]
+}
+
+// setDiagnosticVariable records the variable which the diagnostic concerns.
+func (p *Parser) setDiagnosticVariable(diag *hcl.Diagnostic, name string) {
+	if p.diagVariables == nil {
+		p.diagVariables = make(map[*hcl.Diagnostic]string)
+	}
+	p.diagVariables[diag] = name
+}
+
+func (p *Parser) handleOverrideVar(isPackVar bool, attr *hcl.Attribute, expr cty.Value,
+	overrides map[string][]*Variable, kind SourceKind) {
 	if isPackVar {
-		p.handlePackVariableObject(attr.Name, expr, attr.Range)
+		p.handlePackVariableObject(attr.Name, expr, attr.Range, overrides, kind)
 	} else {
 		v := Variable{
 			Name:      attr.Name,
 			Type:      expr.Type(),
 			Value:     expr,
 			DeclRange: attr.Range,
+			Source:    Source{Kind: kind, Origin: rangeOrigin(attr.Range)},
 		}
-		p.fileOverrideVars[p.cfg.ParentName] = append(p.fileOverrideVars[p.cfg.ParentName], &v)
+		overrides[p.cfg.ParentName] = append(overrides[p.cfg.ParentName], &v)
 	}
 }
 
-func (p *Parser) handlePackVariableObject(name string, expr cty.Value, declRange hcl.Range) {
+func (p *Parser) handlePackVariableObject(name string, expr cty.Value, declRange hcl.Range,
+	overrides map[string][]*Variable, kind SourceKind) {
 	for k := range expr.Type().AttributeTypes() {
 		av := expr.GetAttr(k)
 		v := Variable{
@@ -228,8 +487,9 @@ func (p *Parser) handlePackVariableObject(name string, expr cty.Value, declRange
 			Type:      av.Type(),
 			Value:     av,
 			DeclRange: declRange,
+			Source:    Source{Kind: kind, Origin: rangeOrigin(declRange)},
 		}
-		p.fileOverrideVars[name] = append(p.fileOverrideVars[name], &v)
+		overrides[name] = append(overrides[name], &v)
 	}
 }
 
@@ -0,0 +1,300 @@
+package variable
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// testRootVariableFiles returns the root variable file for an example pack
+// declaring a single region variable.
+func testRootVariableFiles() map[string]*pack.File {
+	return map[string]*pack.File{
+		"example": {
+			Name:    "variables.hcl",
+			Path:    "example/variables.hcl",
+			Content: []byte("variable \"region\" {\n  type    = string\n  default = \"vlc\"\n}\n"),
+		},
+	}
+}
+
+func TestParser_StdinFileOverride(t *testing.T) {
+	testCases := []struct {
+		name          string
+		stdin         string
+		cliOverrides  map[string]string
+		expectedValue cty.Value
+	}{
+		{
+			name:          "hcl",
+			stdin:         `region = "ams"`,
+			expectedValue: cty.StringVal("ams"),
+		},
+		{
+			name:          "json",
+			stdin:         `{"region": "lhr"}`,
+			expectedValue: cty.StringVal("lhr"),
+		},
+		{
+			name:          "cli takes precedence",
+			stdin:         `region = "ams"`,
+			cliOverrides:  map[string]string{"region": "sfo"},
+			expectedValue: cty.StringVal("sfo"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName:        "example",
+				RootVariableFiles: testRootVariableFiles(),
+				FileOverrides:     []string{StdinFileOverride},
+				CLIOverrides:      tc.cliOverrides,
+				Stdin:             strings.NewReader(tc.stdin),
+			})
+			require.NoError(t, err)
+
+			parsed, diags := parser.Parse()
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.Equal(t, tc.expectedValue, parsed.Vars["example"]["region"].Value)
+		})
+	}
+}
+
+func TestNewParser_StdinFileOverrideOnce(t *testing.T) {
+	_, err := NewParser(&ParserConfig{
+		ParentName:    "example",
+		FileOverrides: []string{StdinFileOverride, StdinFileOverride},
+	})
+	require.EqualError(t, err, "standard input can only be used as a variable file once")
+}
+
+func TestParser_AutoFileOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	b := writeFile("b.auto.nomadpack.hcl", `region = "b"`)
+	a := writeFile("a.auto.pkrvars.hcl", `region = "a"`)
+	explicit := writeFile("explicit.hcl", `region = "explicit"`)
+
+	// Only files matching the patterns are discovered, sorted by name.
+	autoFiles, err := FindAutoFileOverrides(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{a, b}, autoFiles)
+
+	testCases := []struct {
+		name          string
+		fileOverrides []string
+		cliOverrides  map[string]string
+		expectedValue cty.Value
+	}{
+		{
+			name:          "last auto file takes precedence",
+			expectedValue: cty.StringVal("b"),
+		},
+		{
+			name:          "explicit file takes precedence",
+			fileOverrides: []string{explicit},
+			expectedValue: cty.StringVal("explicit"),
+		},
+		{
+			name:          "cli takes precedence",
+			fileOverrides: []string{explicit},
+			cliOverrides:  map[string]string{"region": "sfo"},
+			expectedValue: cty.StringVal("sfo"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName:        "example",
+				RootVariableFiles: testRootVariableFiles(),
+				AutoFileOverrides: autoFiles,
+				FileOverrides:     tc.fileOverrides,
+				CLIOverrides:      tc.cliOverrides,
+			})
+			require.NoError(t, err)
+
+			parsed, diags := parser.Parse()
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.Equal(t, tc.expectedValue, parsed.Vars["example"]["region"].Value)
+		})
+	}
+}
+
+func TestParser_ProfileFile(t *testing.T) {
+	explicit := filepath.Join(t.TempDir(), "explicit.hcl")
+	require.NoError(t, os.WriteFile(explicit, []byte(`region = "explicit"`), 0644))
+
+	profile := &pack.File{
+		Name:    "profiles/prod.hcl",
+		Path:    "example/profiles/prod.hcl",
+		Content: []byte(`region = "prod"`),
+	}
+
+	testCases := []struct {
+		name           string
+		fileOverrides  []string
+		cliOverrides   map[string]string
+		expectedValue  cty.Value
+		expectedSource SourceKind
+	}{
+		{
+			name:           "profile overrides default",
+			expectedValue:  cty.StringVal("prod"),
+			expectedSource: SourceProfile,
+		},
+		{
+			name:           "file takes precedence",
+			fileOverrides:  []string{explicit},
+			expectedValue:  cty.StringVal("explicit"),
+			expectedSource: SourceFile,
+		},
+		{
+			name:           "cli takes precedence",
+			cliOverrides:   map[string]string{"region": "sfo"},
+			expectedValue:  cty.StringVal("sfo"),
+			expectedSource: SourceCLI,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName:        "example",
+				RootVariableFiles: testRootVariableFiles(),
+				ProfileFile:       profile,
+				FileOverrides:     tc.fileOverrides,
+				CLIOverrides:      tc.cliOverrides,
+			})
+			require.NoError(t, err)
+
+			parsed, diags := parser.Parse()
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.Equal(t, tc.expectedValue, parsed.Vars["example"]["region"].Value)
+			require.Equal(t, tc.expectedSource, parsed.Vars["example"]["region"].Source.Kind)
+		})
+	}
+}
+
+func TestDetectVarFileFormat(t *testing.T) {
+	testCases := []struct {
+		name       string
+		content    string
+		format     string
+		detectedBy string
+	}{
+		{name: "vars.json", content: `region = "ams"`, format: varFileFormatJSON, detectedBy: "file extension"},
+		{name: "vars.JSON", content: `{}`, format: varFileFormatJSON, detectedBy: "file extension"},
+		{name: "vars.hcl", content: `{"region": "ams"}`, format: varFileFormatHCL, detectedBy: "file extension"},
+		{name: "vars.tfvars", content: "\n  {\"region\": \"ams\"}", format: varFileFormatJSON, detectedBy: "content"},
+		{name: "vars", content: `region = "ams"`, format: varFileFormatHCL, detectedBy: "content"},
+		{name: "vars", content: "", format: varFileFormatHCL, detectedBy: "content"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, detectedBy := detectVarFileFormat(tc.name, []byte(tc.content))
+			require.Equal(t, tc.format, format)
+			require.Equal(t, tc.detectedBy, detectedBy)
+		})
+	}
+}
+
+func TestParser_VarFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	hclFile := writeFile("hcl.hcl", `region = "hcl"`)
+	jsonFile := writeFile("json.json", `{"region": "json"}`)
+	sniffedHCL := writeFile("sniffed-hcl.vars", `region = "sniffed-hcl"`)
+	sniffedJSON := writeFile("sniffed-json.vars", `{"region": "sniffed-json"}`)
+	jsonInHCL := writeFile("json-in-hcl.hcl", `{"region": "json"}`)
+	invalidJSON := writeFile("invalid.json", `{"region": `)
+
+	testCases := []struct {
+		name          string
+		fileOverrides []string
+		expectedValue cty.Value
+		expectedErr   string
+	}{
+		{
+			name:          "hcl by extension",
+			fileOverrides: []string{hclFile},
+			expectedValue: cty.StringVal("hcl"),
+		},
+		{
+			name:          "json by extension",
+			fileOverrides: []string{jsonFile},
+			expectedValue: cty.StringVal("json"),
+		},
+		{
+			name:          "hcl by content",
+			fileOverrides: []string{sniffedHCL},
+			expectedValue: cty.StringVal("sniffed-hcl"),
+		},
+		{
+			name:          "json by content",
+			fileOverrides: []string{sniffedJSON},
+			expectedValue: cty.StringVal("sniffed-json"),
+		},
+		// The files are merged in the order they are passed, so each mix
+		// has the last file take precedence.
+		{
+			name:          "mixed json and hcl",
+			fileOverrides: []string{sniffedHCL, jsonFile},
+			expectedValue: cty.StringVal("json"),
+		},
+		{
+			name:          "mixed hcl and json",
+			fileOverrides: []string{jsonFile, sniffedJSON, hclFile},
+			expectedValue: cty.StringVal("hcl"),
+		},
+		{
+			name:          "json in hcl file",
+			fileOverrides: []string{jsonFile, jsonInHCL},
+			expectedErr: `The variable file "` + jsonInHCL + `" could not be parsed as HCL, the format detected from its file extension. ` +
+				"Its content appears to be JSON; check the file extension matches its format.",
+		},
+		{
+			name:          "invalid json",
+			fileOverrides: []string{hclFile, invalidJSON},
+			expectedErr:   `The variable file "` + invalidJSON + `" could not be parsed as JSON, the format detected from its file extension.`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName:        "example",
+				RootVariableFiles: testRootVariableFiles(),
+				FileOverrides:     tc.fileOverrides,
+			})
+			require.NoError(t, err)
+
+			parsed, diags := parser.Parse()
+			if tc.expectedErr != "" {
+				require.True(t, diags.HasErrors())
+				require.Equal(t, "Failed to parse variable file", diags[0].Summary)
+				require.Equal(t, tc.expectedErr, diags[0].Detail)
+				return
+			}
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.Equal(t, tc.expectedValue, parsed.Vars["example"]["region"].Value)
+		})
+	}
+}
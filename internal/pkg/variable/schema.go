@@ -6,6 +6,10 @@ const (
 	variableAttributeType        = "type"
 	variableAttributeDefault     = "default"
 	variableAttributeDescription = "description"
+	variableAttributeSensitive   = "sensitive"
+
+	variableBlockValidation       = "validation"
+	variableAttributeErrorMessage = "error_message"
 )
 
 // variableFileSchema defines the hcl.BlockHeaderSchema for each root variable
@@ -26,5 +30,22 @@ var variableBlockSchema = &hcl.BodySchema{
 		{Name: variableAttributeDescription},
 		{Name: variableAttributeDefault},
 		{Name: variableAttributeType},
+		{Name: variableAttributeSensitive},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: variableBlockValidation},
+	},
+}
+
+// validationBlockSchema defines the hcl.BodySchema for a validation block
+// within a root variable block. Each attribute is a rule, other than the
+// error message.
+var validationBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: validationRuleMin},
+		{Name: validationRuleMax},
+		{Name: validationRuleRegex},
+		{Name: validationRuleEnum},
+		{Name: variableAttributeErrorMessage},
 	},
 }
@@ -0,0 +1,321 @@
+package variable
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// validationRule* are the rules which can be declared within a validation
+// block, each constraining the variable value.
+const (
+	validationRuleMin   = "min"
+	validationRuleMax   = "max"
+	validationRuleRegex = "regex"
+	validationRuleEnum  = "enum"
+)
+
+// Validation is a set of rules constraining the value of a variable, as
+// declared by one of its validation blocks. A value must satisfy every rule
+// which is set.
+type Validation struct {
+
+	// Min and Max are the inclusive bounds of a number value, or the length
+	// of a string or collection value. They are cty.NilVal when not set.
+	Min cty.Value
+	Max cty.Value
+
+	// Regex is the regular expression a string value must match. As with
+	// Terraform's regex function, the expression is not anchored, so
+	// matching the whole value requires using ^ and $.
+	Regex *regexp.Regexp
+
+	// Enum is the list of values the value must be equal to one of.
+	Enum []cty.Value
+
+	// ErrorMessage is an optional message included in the diagnostic when
+	// the value fails any of the rules.
+	ErrorMessage string
+
+	// DeclRange is the position of the validation block, which is used as
+	// the subject of the diagnostics of rules it fails.
+	DeclRange hcl.Range
+}
+
+// decodeValidationBlock decodes a validation block of a variable block,
+// checking its rules are well-formed.
+func decodeValidationBlock(block *hcl.Block) (*Validation, hcl.Diagnostics) {
+
+	content, diags := block.Body.Content(validationBlockSchema)
+	if content == nil {
+		return nil, diags
+	}
+
+	v := &Validation{DeclRange: block.DefRange}
+
+	for _, rule := range []string{validationRuleMin, validationRuleMax} {
+		attr, exists := content.Attributes[rule]
+		if !exists {
+			continue
+		}
+		val, valDiags := attr.Expr.Value(nil)
+		diags = safeDiagnosticsExtend(diags, valDiags)
+		if valDiags.HasErrors() {
+			continue
+		}
+
+		num, err := convert.Convert(val, cty.Number)
+		if err != nil || num.IsNull() {
+			diags = diags.Append(diagnosticInvalidValidationRule(rule,
+				fmt.Sprintf("The %s rule is expected to be a number, got %s.", rule, val.Type().FriendlyName()),
+				attr.Range.Ptr()))
+			continue
+		}
+		if rule == validationRuleMin {
+			v.Min = num
+		} else {
+			v.Max = num
+		}
+	}
+
+	if v.Min != cty.NilVal && v.Max != cty.NilVal && v.Max.LessThan(v.Min).True() {
+		diags = diags.Append(diagnosticInvalidValidationRule(validationRuleMax,
+			fmt.Sprintf("The max rule %s is less than the min rule %s.", formatValidationValue(v.Max), formatValidationValue(v.Min)),
+			content.Attributes[validationRuleMax].Range.Ptr()))
+	}
+
+	if attr, exists := content.Attributes[validationRuleRegex]; exists {
+		val, valDiags := attr.Expr.Value(nil)
+		diags = safeDiagnosticsExtend(diags, valDiags)
+
+		if !valDiags.HasErrors() {
+			if val.Type() != cty.String || val.IsNull() {
+				diags = diags.Append(diagnosticInvalidValidationRule(validationRuleRegex,
+					fmt.Sprintf("The regex rule is expected to be a string, got %s.", val.Type().FriendlyName()),
+					attr.Range.Ptr()))
+			} else if re, err := regexp.Compile(val.AsString()); err != nil {
+				diags = diags.Append(diagnosticInvalidValidationRule(validationRuleRegex,
+					fmt.Sprintf("The regex rule is not a valid regular expression: %s.", err),
+					attr.Range.Ptr()))
+			} else {
+				v.Regex = re
+			}
+		}
+	}
+
+	if attr, exists := content.Attributes[validationRuleEnum]; exists {
+		val, valDiags := attr.Expr.Value(nil)
+		diags = safeDiagnosticsExtend(diags, valDiags)
+
+		if !valDiags.HasErrors() {
+			typ := val.Type()
+			if val.IsNull() || !(typ.IsListType() || typ.IsTupleType() || typ.IsSetType()) || val.LengthInt() == 0 {
+				diags = diags.Append(diagnosticInvalidValidationRule(validationRuleEnum,
+					"The enum rule is expected to be a non-empty list of the allowed values.",
+					attr.Range.Ptr()))
+			} else {
+				v.Enum = val.AsValueSlice()
+			}
+		}
+	}
+
+	if attr, exists := content.Attributes[variableAttributeErrorMessage]; exists {
+		val, valDiags := attr.Expr.Value(nil)
+		diags = safeDiagnosticsExtend(diags, valDiags)
+
+		if val.Type() == cty.String && !val.IsNull() {
+			v.ErrorMessage = val.AsString()
+		} else {
+			diags = safeDiagnosticsAppend(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid type for error_message",
+				Detail: fmt.Sprintf("The error_message attribute is expected to be of type string, got %s",
+					val.Type().FriendlyName()),
+				Subject: attr.Range.Ptr(),
+			})
+		}
+	}
+
+	return v, diags
+}
+
+// validateVariables checks the value of each variable of every pack against
+// its validation rules, returning a diagnostic for every rule failed so they
+// can all be fixed at once. Null values are not validated.
+func (p *Parser) validateVariables() hcl.Diagnostics {
+
+	var diags hcl.Diagnostics
+
+	packNames := make([]string, 0, len(p.rootVars))
+	for packName := range p.rootVars {
+		packNames = append(packNames, packName)
+	}
+	sort.Strings(packNames)
+
+	for _, packName := range packNames {
+		varNames := make([]string, 0, len(p.rootVars[packName]))
+		for varName := range p.rootVars[packName] {
+			varNames = append(varNames, varName)
+		}
+		sort.Strings(varNames)
+
+		for _, varName := range varNames {
+			v := p.rootVars[packName][varName]
+			if v.Value == cty.NilVal || v.Value.IsNull() || !v.Value.IsWhollyKnown() {
+				continue
+			}
+
+			// Variables of dependencies are named as they are passed by the
+			// user, prefixed by their pack name.
+			name := varName
+			if packName != p.cfg.ParentName {
+				name = packName + "." + varName
+			}
+
+			for _, validation := range v.Validations {
+				for _, diag := range validation.check(name, v) {
+					p.setDiagnosticVariable(diag, name)
+					diags = diags.Append(diag)
+				}
+			}
+		}
+	}
+
+	return diags
+}
+
+// check returns a diagnostic for each of the rules the value of the variable
+// fails.
+func (val *Validation) check(name string, v *Variable) hcl.Diagnostics {
+
+	var diags hcl.Diagnostics
+	fail := func(rule, reason string) {
+		diags = diags.Append(val.diagnosticFailedRule(name, rule, reason, v))
+	}
+
+	if val.Min != cty.NilVal || val.Max != cty.NilVal {
+		subject, measured, ok := validationMeasure(v.Value)
+		if !ok {
+			rule := validationRuleMin
+			if val.Min == cty.NilVal {
+				rule = validationRuleMax
+			}
+			fail(rule, fmt.Sprintf("the rule can only be applied to numbers, strings and collections, not %s",
+				v.Value.Type().FriendlyName()))
+			return diags
+		}
+
+		// The value itself is hidden when sensitive, but its length is not.
+		got := formatValidationValue(measured)
+		if v.Value.Type() == cty.Number {
+			got = formatVariableValue(v)
+		}
+
+		switch {
+		case val.Min != cty.NilVal && measured.LessThan(val.Min).True():
+			fail(validationRuleMin, fmt.Sprintf("%s must be at least %s, got %s", subject, formatValidationValue(val.Min), got))
+		case val.Max != cty.NilVal && measured.GreaterThan(val.Max).True():
+			fail(validationRuleMax, fmt.Sprintf("%s must be at most %s, got %s", subject, formatValidationValue(val.Max), got))
+		}
+	}
+
+	if val.Regex != nil {
+		if v.Value.Type() != cty.String {
+			fail(validationRuleRegex, fmt.Sprintf("the rule can only be applied to strings, not %s",
+				v.Value.Type().FriendlyName()))
+		} else if !val.Regex.MatchString(v.Value.AsString()) {
+			fail(validationRuleRegex, fmt.Sprintf("the value must match the regular expression %q, got %s",
+				val.Regex.String(), formatVariableValue(v)))
+		}
+	}
+
+	if len(val.Enum) > 0 && !validationEnumContains(val.Enum, v.Value) {
+		allowed := make([]string, len(val.Enum))
+		for i, e := range val.Enum {
+			allowed[i] = formatValidationValue(e)
+		}
+		fail(validationRuleEnum, fmt.Sprintf("the value must be one of %s, got %s",
+			strings.Join(allowed, ", "), formatVariableValue(v)))
+	}
+
+	return diags
+}
+
+// validationMeasure returns what the min and max rules compare for the
+// value, which is the number itself, or the length of a string or
+// collection. The returned subject describes which it is.
+func validationMeasure(val cty.Value) (string, cty.Value, bool) {
+	typ := val.Type()
+	switch {
+	case typ == cty.Number:
+		return "the value", val, true
+	case typ == cty.String:
+		return "the length", cty.NumberIntVal(int64(utf8.RuneCountInString(val.AsString()))), true
+	case typ.IsCollectionType() || typ.IsTupleType():
+		return "the length", cty.NumberIntVal(int64(val.LengthInt())), true
+	}
+	return "", cty.NilVal, false
+}
+
+// validationEnumContains returns whether the value is equal to any of the
+// enum values, once they are converted to its type.
+func validationEnumContains(enum []cty.Value, val cty.Value) bool {
+	for _, e := range enum {
+		converted, err := convert.Convert(e, val.Type())
+		if err != nil {
+			continue
+		}
+		if eq := converted.Equals(val); eq.IsKnown() && eq.True() {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValidationValue formats the value as it would be written in HCL.
+func formatValidationValue(val cty.Value) string {
+	return strings.TrimSpace(string(hclwrite.TokensForValue(val).Bytes()))
+}
+
+// formatVariableValue formats the value of the variable for a diagnostic,
+// unless it is sensitive.
+func formatVariableValue(v *Variable) string {
+	if v.Sensitive {
+		return "a sensitive value"
+	}
+	return formatValidationValue(v.Value)
+}
+
+// diagnosticFailedRule returns the diagnostic of the variable value failing
+// the rule for the reason, including the error message of the validation.
+func (val *Validation) diagnosticFailedRule(name, rule, reason string, v *Variable) *hcl.Diagnostic {
+	detail := fmt.Sprintf("The value of variable %q failed the %s validation rule: %s.", name, rule, reason)
+	if v.Source.Origin != "" {
+		detail += fmt.Sprintf(" The value was set by %s.", v.Source.Origin)
+	}
+	if val.ErrorMessage != "" {
+		detail += " " + val.ErrorMessage
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value for variable",
+		Detail:   detail,
+		Subject:  val.DeclRange.Ptr(),
+	}
+}
+
+func diagnosticInvalidValidationRule(rule, detail string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("Invalid %s validation rule", rule),
+		Detail:   detail,
+		Subject:  sub,
+	}
+}
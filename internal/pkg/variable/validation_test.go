@@ -0,0 +1,224 @@
+package variable
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+// testValidationRootVariableFiles returns the root variable file for an
+// example pack declaring variables with validation rules.
+func testValidationRootVariableFiles() map[string]*pack.File {
+	return map[string]*pack.File{
+		"example": {
+			Name: "variables.hcl",
+			Path: "example/variables.hcl",
+			Content: []byte(`
+variable "replicas" {
+  type    = number
+  default = 3
+  validation {
+    min = 1
+    max = 10
+  }
+}
+
+variable "env" {
+  type    = string
+  default = "dev"
+  validation {
+    enum          = ["dev", "staging", "prod"]
+    error_message = "Use one of the supported environments."
+  }
+}
+
+variable "name" {
+  type    = string
+  default = "web"
+  validation {
+    regex = "^[a-z][a-z0-9-]*$"
+  }
+  validation {
+    max = 8
+  }
+}
+
+variable "datacenters" {
+  type    = list(string)
+  default = ["dc1"]
+  validation {
+    min = 1
+  }
+}
+
+variable "token" {
+  type      = number
+  default   = 1
+  sensitive = true
+  validation {
+    max = 5
+  }
+}
+`),
+		},
+	}
+}
+
+func parseValidationVariables(t *testing.T, overrides map[string]string) (*Parser, hcl.Diagnostics) {
+	t.Helper()
+
+	parser, err := NewParser(&ParserConfig{
+		ParentName:        "example",
+		RootVariableFiles: testValidationRootVariableFiles(),
+		CLIOverrides:      overrides,
+	})
+	require.NoError(t, err)
+
+	_, diags := parser.Parse()
+	return parser, diags
+}
+
+func TestParser_Validation(t *testing.T) {
+	testCases := []struct {
+		name           string
+		overrides      map[string]string
+		expectedDetail string
+	}{
+		{
+			name:      "numeric range",
+			overrides: map[string]string{"replicas": "10"},
+		},
+		{
+			name:           "numeric below min",
+			overrides:      map[string]string{"replicas": "0"},
+			expectedDetail: `The value of variable "replicas" failed the min validation rule: the value must be at least 1, got 0. The value was set by --var replicas.`,
+		},
+		{
+			name:           "numeric above max",
+			overrides:      map[string]string{"replicas": "11"},
+			expectedDetail: `The value of variable "replicas" failed the max validation rule: the value must be at most 10, got 11. The value was set by --var replicas.`,
+		},
+		{
+			name:           "sensitive above max",
+			overrides:      map[string]string{"token": "6"},
+			expectedDetail: `The value of variable "token" failed the max validation rule: the value must be at most 5, got a sensitive value. The value was set by --var token.`,
+		},
+		{
+			name:           "string length",
+			overrides:      map[string]string{"name": "frontend-web"},
+			expectedDetail: `The value of variable "name" failed the max validation rule: the length must be at most 8, got 12. The value was set by --var name.`,
+		},
+		{
+			name:           "collection length",
+			overrides:      map[string]string{"datacenters": "[]"},
+			expectedDetail: `The value of variable "datacenters" failed the min validation rule: the length must be at least 1, got 0. The value was set by --var datacenters.`,
+		},
+		{
+			name:      "regex",
+			overrides: map[string]string{"name": "api-2"},
+		},
+		{
+			name:           "regex mismatch",
+			overrides:      map[string]string{"name": "Api"},
+			expectedDetail: `The value of variable "name" failed the regex validation rule: the value must match the regular expression "^[a-z][a-z0-9-]*$", got "Api". The value was set by --var name.`,
+		},
+		{
+			name:      "enum",
+			overrides: map[string]string{"env": "prod"},
+		},
+		{
+			name:           "enum mismatch",
+			overrides:      map[string]string{"env": "qa"},
+			expectedDetail: `The value of variable "env" failed the enum validation rule: the value must be one of "dev", "staging", "prod", got "qa". The value was set by --var env. Use one of the supported environments.`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, diags := parseValidationVariables(t, tc.overrides)
+			if tc.expectedDetail == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+
+			require.Len(t, diags, 1)
+			require.Equal(t, "Invalid value for variable", diags[0].Summary)
+			require.Equal(t, tc.expectedDetail, diags[0].Detail)
+			require.NotEmpty(t, parser.DiagnosticVariable(diags[0]))
+		})
+	}
+}
+
+func TestParser_ValidationAllViolations(t *testing.T) {
+	parser, diags := parseValidationVariables(t, map[string]string{
+		"replicas": "20",
+		"env":      "qa",
+		"name":     "Frontend-Web",
+	})
+
+	// Every rule failed by every variable is reported, sorted by variable.
+	require.Len(t, diags, 4)
+	var variables []string
+	for _, diag := range diags {
+		variables = append(variables, parser.DiagnosticVariable(diag))
+	}
+	require.Equal(t, []string{"env", "name", "name", "replicas"}, variables)
+	require.Contains(t, diags[1].Detail, "regex validation rule")
+	require.Contains(t, diags[2].Detail, "max validation rule")
+}
+
+func TestParser_InvalidValidationRules(t *testing.T) {
+	testCases := []struct {
+		name            string
+		validation      string
+		expectedSummary string
+	}{
+		{
+			name:            "min not a number",
+			validation:      `min = "one"`,
+			expectedSummary: "Invalid min validation rule",
+		},
+		{
+			name:            "max less than min",
+			validation:      "min = 5\n    max = 1",
+			expectedSummary: "Invalid max validation rule",
+		},
+		{
+			name:            "invalid regex",
+			validation:      `regex = "[a-z"`,
+			expectedSummary: "Invalid regex validation rule",
+		},
+		{
+			name:            "empty enum",
+			validation:      `enum = []`,
+			expectedSummary: "Invalid enum validation rule",
+		},
+		{
+			name:            "unknown rule",
+			validation:      `length = 3`,
+			expectedSummary: "Unsupported argument",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewParser(&ParserConfig{
+				ParentName: "example",
+				RootVariableFiles: map[string]*pack.File{
+					"example": {
+						Name:    "variables.hcl",
+						Path:    "example/variables.hcl",
+						Content: []byte("variable \"name\" {\n  validation {\n    " + tc.validation + "\n  }\n}\n"),
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			_, diags := parser.Parse()
+			require.True(t, diags.HasErrors())
+			require.Equal(t, tc.expectedSummary, diags[0].Summary)
+		})
+	}
+}
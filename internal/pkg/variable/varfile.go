@@ -0,0 +1,91 @@
+package variable
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// VarFile returns an HCL variable file setting each of the variables declared
+// by the named pack, sorted by variable name. Each is preceded by comments
+// containing its description and type. Variables with a default are set to
+// it, while required variables are marked as such and commented out with a
+// placeholder value of their type, so the file can be loaded as is but must
+// be edited to set them.
+func (p *ParsedVariables) VarFile(packName string) []byte {
+
+	vars := p.Vars[packName]
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Variables of the %s pack.\n", packName)
+
+	for _, name := range names {
+		v := vars[name]
+		required := v.Value == cty.NilVal
+
+		buf.WriteString("\n")
+		if required {
+			buf.WriteString("# REQUIRED: this variable has no default, so uncomment it and set a value.\n")
+		}
+		for _, line := range strings.Split(strings.TrimSpace(v.Description), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&buf, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&buf, "# Type: %s\n", typeString(v))
+
+		value := v.Value
+		if required {
+			value = placeholderValue(v.Type)
+		}
+		attr := hclwrite.NewEmptyFile()
+		attr.Body().SetAttributeValue(name, value)
+		content := hclwrite.Format(attr.Bytes())
+
+		if required {
+			for _, line := range strings.SplitAfter(string(content), "\n") {
+				if line != "" {
+					buf.WriteString("# " + line)
+				}
+			}
+		} else {
+			buf.Write(content)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// placeholderValue returns the value shown for a required variable of the
+// type, which is the empty value of the type, or null when there is none.
+func placeholderValue(typ cty.Type) cty.Value {
+	switch {
+	case typ == cty.String:
+		return cty.StringVal("")
+	case typ == cty.Number:
+		return cty.Zero
+	case typ == cty.Bool:
+		return cty.False
+	case typ.IsListType(), typ.IsSetType(), typ.IsTupleType():
+		return cty.EmptyTupleVal
+	case typ.IsMapType():
+		return cty.EmptyObjectVal
+	case typ.IsObjectType():
+		attrs := make(map[string]cty.Value, len(typ.AttributeTypes()))
+		for name, attrType := range typ.AttributeTypes() {
+			attrs[name] = placeholderValue(attrType)
+		}
+		return cty.ObjectVal(attrs)
+	default:
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+}
@@ -0,0 +1,74 @@
+package variable
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedVariables_VarFile(t *testing.T) {
+	rootFiles := map[string]*pack.File{
+		"example": {
+			Name: "variables.hcl",
+			Path: "example/variables.hcl",
+			Content: []byte(`
+variable "job_name" {
+  description = "The name of the job"
+  type        = string
+}
+
+variable "count" {
+  description = "The number of instances"
+  default     = 2
+}
+
+variable "resources" {
+  type = object({
+    cpu    = number
+    memory = number
+  })
+}
+
+variable "datacenters" {
+  type    = list(string)
+  default = ["dc1"]
+}
+`),
+		},
+	}
+	parser, err := NewParser(&ParserConfig{ParentName: "example", RootVariableFiles: rootFiles})
+	require.NoError(t, err)
+
+	parsed, diags := parser.Parse()
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	varFile := parsed.VarFile("example")
+	require.Equal(t, `# Variables of the example pack.
+
+# The number of instances
+# Type: number
+count = 2
+
+# Type: list(string)
+datacenters = ["dc1"]
+
+# REQUIRED: this variable has no default, so uncomment it and set a value.
+# The name of the job
+# Type: string
+# job_name = ""
+
+# REQUIRED: this variable has no default, so uncomment it and set a value.
+# Type: object({cpu=number,memory=number})
+# resources = {
+#   cpu    = 0
+#   memory = 0
+# }
+`, string(varFile))
+
+	// The generated file can be loaded as a variable file of the pack.
+	parser, err = NewParser(&ParserConfig{ParentName: "example", RootVariableFiles: rootFiles})
+	require.NoError(t, err)
+	_, diags = parser.loadPackFile(&pack.File{Name: "overrides.hcl", Path: "overrides.hcl", Content: varFile})
+	require.False(t, diags.HasErrors(), diags.Error())
+}
@@ -31,6 +31,48 @@ type Variable struct {
 	// DeclRange is the position marker of the variable within the file it was
 	// read from. This is used for diagnostics.
 	DeclRange hcl.Range
+
+	// Sensitive indicates the variable value should not be displayed, such
+	// as when explaining the variable values.
+	Sensitive bool
+
+	// Source identifies where the current value was set. It is the zero
+	// value when the variable has no value.
+	Source Source
+
+	// Validations are the rules declared by the validation blocks of the
+	// variable, which its final value is checked against.
+	Validations []*Validation
+}
+
+// SourceKind identifies the kind of source a variable value was set from.
+type SourceKind string
+
+// SourceKind* are the kinds of source a variable value can be set from,
+// listed in increasing order of precedence.
+const (
+	SourceDefault SourceKind = "default"
+	SourceProfile SourceKind = "profile"
+	SourceEnv     SourceKind = "env"
+	SourceFile    SourceKind = "file"
+	SourceCLI     SourceKind = "cli"
+)
+
+// Source describes where a variable value was set.
+type Source struct {
+
+	// Kind is the kind of source the value was set from.
+	Kind SourceKind
+
+	// Origin identifies the specific source, such as the file path and line
+	// of the declaration or override, or the CLI flag.
+	Origin string
+}
+
+// rangeOrigin returns the origin of a value set at the passed range, which
+// is the file name and line number.
+func rangeOrigin(rng hcl.Range) string {
+	return fmt.Sprintf("%s:%d", rng.Filename, rng.Start.Line)
 }
 
 func (v *Variable) merge(new *Variable) hcl.Diagnostics {
@@ -38,6 +80,7 @@ func (v *Variable) merge(new *Variable) hcl.Diagnostics {
 
 	if new.Value != cty.NilVal {
 		v.Value = new.Value
+		v.Source = new.Source
 	}
 	if new.Type != cty.NilType {
 		v.Type = new.Type
@@ -112,10 +112,17 @@ func (r *Runner) multiRegionPlan(
 }
 
 func (r *Runner) outputPlannedJob(ui terminal.UI, job *v1client.Job, resp *v1client.JobPlanResponse) int {
+	return OutputPlan(ui, job, resp, r.cfg.PlanConfig.Diff, r.cfg.PlanConfig.Verbose)
+}
+
+// OutputPlan outputs the plan response of the job in the same way as the plan
+// command, including the job diff when diff is set, the scheduler dry-run,
+// and any warnings and preemptions. The plan exit code is returned.
+func OutputPlan(ui terminal.UI, job *v1client.Job, resp *v1client.JobPlanResponse, diff, verbose bool) int {
 
 	// Print the diff if not disabled
-	if r.cfg.PlanConfig.Diff {
-		formatJobDiff(*resp.Diff, r.cfg.PlanConfig.Verbose, ui)
+	if diff && resp.Diff != nil {
+		formatJobDiff(*resp.Diff, verbose, ui)
 	}
 
 	// Print the scheduler dry-run output
@@ -1,6 +1,11 @@
 package pack
 
-import "github.com/hashicorp/nomad-pack/sdk/helper"
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/sdk/helper"
+)
 
 // Dependency is a single dependency of a pack. A pack can have multiple and
 // each dependency represents an individual pack. A pack can be used as a
@@ -24,6 +29,15 @@ type Dependency struct {
 	Enabled *bool `hcl:"enabled,optional"`
 }
 
+// IsLocalSource returns whether the dependency source is a path on the local
+// filesystem, which is either absolute or relative to the depending pack and
+// starting with ./ or ../.
+func (d *Dependency) IsLocalSource() bool {
+	return filepath.IsAbs(d.Source) ||
+		strings.HasPrefix(d.Source, "./") ||
+		strings.HasPrefix(d.Source, "../")
+}
+
 // validate the Dependency object to ensure it meets requirements and doesn't
 // contain invalid or incorrect data.
 func (d *Dependency) validate() error {
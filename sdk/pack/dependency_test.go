@@ -64,3 +64,22 @@ func TestDependency_Validate(t *testing.T) {
 		assert.Equal(t, tc.expectedOutputDependency, tc.inputDependency, tc.name)
 	}
 }
+
+func TestDependency_IsLocalSource(t *testing.T) {
+	testCases := []struct {
+		source         string
+		expectedOutput bool
+	}{
+		{source: "", expectedOutput: false},
+		{source: "git://example.com/example", expectedOutput: false},
+		{source: "github.com/example/example", expectedOutput: false},
+		{source: "./example", expectedOutput: true},
+		{source: "../example", expectedOutput: true},
+		{source: "/opt/packs/example", expectedOutput: true},
+	}
+
+	for _, tc := range testCases {
+		d := &Dependency{Name: "example", Source: tc.source}
+		assert.Equal(t, tc.expectedOutput, d.IsLocalSource(), tc.source)
+	}
+}
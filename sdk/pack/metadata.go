@@ -2,15 +2,21 @@ package pack
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/suggest"
 )
 
 // Metadata is the contents of the Pack metadata.hcl file. It contains
 // high-level information about the pack which is useful for operators and is
 // also exposed as template variables during rendering.
 type Metadata struct {
-	App          *MetadataApp  `hcl:"app,block"`
-	Pack         *MetadataPack `hcl:"pack,block"`
-	Dependencies []*Dependency `hcl:"dependency,block"`
+	App          *MetadataApp        `hcl:"app,block"`
+	Pack         *MetadataPack       `hcl:"pack,block"`
+	Dependencies []*Dependency       `hcl:"dependency,block"`
+	Templates    []*MetadataTemplate `hcl:"template,block"`
 }
 
 // MetadataApp contains information regarding the application that the pack is
@@ -49,6 +55,24 @@ type MetadataPack struct {
 	Version string `hcl:"version"`
 }
 
+// MetadataTemplate contains annotations of an individual template of the pack.
+type MetadataTemplate struct {
+
+	// Name of the template file within the pack, such as
+	// templates/web.nomad.tpl.
+	Name string `hcl:"name,label"`
+
+	// OutputPath is the path, relative to the directory of the pack's
+	// renders, which the template is rendered to in place of the name derived
+	// from the template file name.
+	OutputPath string `hcl:"output_path,optional"`
+
+	// Executable marks the template as a script, so its render is written
+	// with the executable bit set regardless of the template file mode or
+	// content.
+	Executable bool `hcl:"executable,optional"`
+}
+
 // ConvertToMapInterface returns a map[string]interface{} representation of the
 // metadata object. The conversion doesn't take into account empty values and
 // will add them.
@@ -88,6 +112,62 @@ func (md *Metadata) AddToInterfaceMap(m map[string]interface{}) map[string]inter
 	return m
 }
 
+// ErrUnknownMetadataKey is returned by Metadata.Override when the key does
+// not name a metadata field which can be overridden.
+var ErrUnknownMetadataKey = errors.New("unknown metadata key")
+
+// metadataOverrideKeys are the keys of the metadata fields which can be
+// overridden, matching the fields exposed to templates under nomad_pack.
+// The pack name cannot be overridden, as renders are named for the pack.
+var metadataOverrideKeys = []string{
+	"app.author",
+	"app.url",
+	"pack.description",
+	"pack.url",
+	"pack.version",
+}
+
+// Override sets the metadata field named by key, in the form block.field
+// such as pack.version, to value. This allows the metadata to be changed
+// for a single render, such as to preview a version bump, without editing
+// the pack. An error wrapping ErrUnknownMetadataKey is returned if the key
+// does not exist.
+func (md *Metadata) Override(key, value string) error {
+	switch strings.TrimPrefix(key, "nomad_pack.") {
+	case "app.author":
+		md.App.Author = value
+	case "app.url":
+		md.App.URL = value
+	case "pack.description":
+		md.Pack.Description = value
+	case "pack.url":
+		md.Pack.URL = value
+	case "pack.version":
+		md.Pack.Version = value
+	case "pack.name":
+		return fmt.Errorf("%w: %q, the pack name cannot be overridden", ErrUnknownMetadataKey, key)
+	default:
+		// Suggest the same field within the other block, such as
+		// pack.version for app.version, as well as any close typo.
+		suggestion := ""
+		field := key[strings.LastIndex(key, ".")+1:]
+		for _, k := range metadataOverrideKeys {
+			if strings.HasSuffix(k, "."+field) {
+				suggestion = k
+				break
+			}
+		}
+		if suggestion == "" {
+			suggestion = suggest.Closest(key, metadataOverrideKeys)
+		}
+		if suggestion != "" {
+			return fmt.Errorf("%w: %q, did you mean %q?", ErrUnknownMetadataKey, key, suggestion)
+		}
+		return fmt.Errorf("%w: %q, valid keys are: %s", ErrUnknownMetadataKey, key, strings.Join(metadataOverrideKeys, ", "))
+	}
+	return nil
+}
+
 // Validate the entire Metadata object to ensure it meets requirements and
 // doesn't contain invalid or incorrect data.
 func (md *Metadata) Validate() error {
@@ -109,9 +189,41 @@ func (md *Metadata) Validate() error {
 			return err
 		}
 	}
+
+	seen := make(map[string]bool, len(md.Templates))
+	for _, tpl := range md.Templates {
+		if err := tpl.validate(); err != nil {
+			return err
+		}
+		if seen[tpl.Name] {
+			return fmt.Errorf("template %q is annotated more than once", tpl.Name)
+		}
+		seen[tpl.Name] = true
+	}
 	return nil
 }
 
+// OutputPath returns the output path annotated for the named template, or an
+// empty string if there is none.
+func (md *Metadata) OutputPath(name string) string {
+	for _, tpl := range md.Templates {
+		if tpl.Name == name {
+			return tpl.OutputPath
+		}
+	}
+	return ""
+}
+
+// Executable returns whether the named template is annotated as executable.
+func (md *Metadata) Executable(name string) bool {
+	for _, tpl := range md.Templates {
+		if tpl.Name == name {
+			return tpl.Executable
+		}
+	}
+	return false
+}
+
 // validate the MetadataApp object to ensure it meets requirements and doesn't
 // contain invalid or incorrect data.
 func (ma *MetadataApp) validate() error {
@@ -123,3 +235,17 @@ func (ma *MetadataApp) validate() error {
 func (mp *MetadataPack) validate() error {
 	return nil
 }
+
+// validate the MetadataTemplate object to ensure it meets requirements and
+// doesn't contain invalid or incorrect data. The output path must be a
+// relative path which stays within the directory of the pack's renders.
+func (mt *MetadataTemplate) validate() error {
+	if mt.OutputPath == "" {
+		return nil
+	}
+	cleaned := path.Clean(mt.OutputPath)
+	if path.IsAbs(mt.OutputPath) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("template %q output_path %q must be a relative path within the pack", mt.Name, mt.OutputPath)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package pack
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -101,6 +102,51 @@ func TestMetadata_Validate(t *testing.T) {
 			expectError:   true,
 			name:          "nil guard",
 		},
+		{
+			inputMetadata: &Metadata{
+				App:  &MetadataApp{},
+				Pack: &MetadataPack{Name: "Example"},
+				Templates: []*MetadataTemplate{
+					{Name: "templates/web.nomad.tpl", OutputPath: "jobs/web.nomad"},
+				},
+			},
+			expectError: false,
+			name:        "valid template output path",
+		},
+		{
+			inputMetadata: &Metadata{
+				App:  &MetadataApp{},
+				Pack: &MetadataPack{Name: "Example"},
+				Templates: []*MetadataTemplate{
+					{Name: "templates/web.nomad.tpl", OutputPath: "../web.nomad"},
+				},
+			},
+			expectError: true,
+			name:        "template output path outside pack",
+		},
+		{
+			inputMetadata: &Metadata{
+				App:  &MetadataApp{},
+				Pack: &MetadataPack{Name: "Example"},
+				Templates: []*MetadataTemplate{
+					{Name: "templates/web.nomad.tpl", OutputPath: "/web.nomad"},
+				},
+			},
+			expectError: true,
+			name:        "absolute template output path",
+		},
+		{
+			inputMetadata: &Metadata{
+				App:  &MetadataApp{},
+				Pack: &MetadataPack{Name: "Example"},
+				Templates: []*MetadataTemplate{
+					{Name: "templates/web.nomad.tpl", OutputPath: "a.nomad"},
+					{Name: "templates/web.nomad.tpl", OutputPath: "b.nomad"},
+				},
+			},
+			expectError: true,
+			name:        "template annotated twice",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -112,3 +158,35 @@ func TestMetadata_Validate(t *testing.T) {
 		}
 	}
 }
+
+func TestMetadata_Override(t *testing.T) {
+	md := &Metadata{
+		App:  &MetadataApp{URL: "https://example.com", Author: "test"},
+		Pack: &MetadataPack{Name: "example", URL: "https://example.com/example", Version: "1.0.0"},
+	}
+
+	assert.NoError(t, md.Override("pack.version", "2.0.0"))
+	assert.NoError(t, md.Override("nomad_pack.app.author", "someone"))
+	assert.Equal(t, "2.0.0", md.Pack.Version)
+	assert.Equal(t, "someone", md.App.Author)
+	assert.Equal(t, "2.0.0", md.ConvertToMapInterface()["nomad_pack"].(map[string]interface{})["pack"].(map[string]interface{})["version"])
+
+	testCases := []struct {
+		key         string
+		expectedErr string
+	}{
+		{key: "app.version", expectedErr: `unknown metadata key: "app.version", did you mean "pack.version"?`},
+		{key: "pack.verison", expectedErr: `unknown metadata key: "pack.verison", did you mean "pack.version"?`},
+		{key: "pack.name", expectedErr: `the pack name cannot be overridden`},
+		{key: "owner", expectedErr: `valid keys are: app.author, app.url, pack.description, pack.url, pack.version`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			err := md.Override(tc.key, "value")
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, ErrUnknownMetadataKey))
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+	assert.Equal(t, "example", md.Pack.Name)
+}
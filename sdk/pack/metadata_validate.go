@@ -0,0 +1,360 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/suggest"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ErrInvalidMetadata is wrapped by the MetadataError returned when a pack's
+// metadata file does not match the metadata schema.
+var ErrInvalidMetadata = errors.New("invalid pack metadata")
+
+// MetadataViolation is a single problem found when validating a pack's
+// metadata file.
+type MetadataViolation struct {
+
+	// Field is the path of the block or attribute within the metadata which
+	// has the problem, such as pack.version or dependency.redis.source.
+	Field string
+
+	// Detail describes the problem.
+	Detail string
+
+	// Suggestion describes how the problem can be fixed, and may be empty.
+	Suggestion string
+
+	// Subject is the range of the metadata file the problem relates to, if
+	// known.
+	Subject *hcl.Range
+}
+
+// String returns the violation prefixed with its position and field, and
+// followed by the suggestion if there is one.
+func (v *MetadataViolation) String() string {
+	var b strings.Builder
+	if v.Subject != nil {
+		fmt.Fprintf(&b, "%s: ", v.Subject)
+	}
+	if v.Field != "" {
+		fmt.Fprintf(&b, "%s: ", v.Field)
+	}
+	b.WriteString(v.Detail)
+	if v.Suggestion != "" {
+		b.WriteString(" " + v.Suggestion)
+	}
+	return b.String()
+}
+
+// MetadataError is returned by ValidateMetadataFile, holding every violation
+// found in the metadata file rather than only the first.
+type MetadataError struct {
+	File       string
+	Violations []*MetadataViolation
+}
+
+func (e *MetadataError) Error() string {
+	lines := make([]string, 0, len(e.Violations)+1)
+	lines = append(lines, fmt.Sprintf("%s: %s has %d problem(s):", ErrInvalidMetadata, e.File, len(e.Violations)))
+	for _, v := range e.Violations {
+		lines = append(lines, "  - "+v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns ErrInvalidMetadata, so the error can be identified using
+// errors.Is.
+func (e *MetadataError) Unwrap() error { return ErrInvalidMetadata }
+
+// metadataAttribute describes an attribute allowed within a metadata block.
+type metadataAttribute struct {
+	typ        cty.Type
+	required   bool
+	suggestion string
+}
+
+// metadataBlockSchema describes a block allowed within the metadata file.
+type metadataBlockSchema struct {
+	labeled    bool
+	required   bool
+	multiple   bool
+	attributes map[string]metadataAttribute
+	suggestion string
+
+	// misplaced holds suggestions for attributes which are commonly, but
+	// mistakenly, set within the block.
+	misplaced map[string]string
+}
+
+// metadataSchema is the schema of the metadata file, which must be kept in
+// line with the hcl tags of Metadata.
+var metadataSchema = map[string]*metadataBlockSchema{
+	"app": {
+		required: true,
+		attributes: map[string]metadataAttribute{
+			"url": {typ: cty.String, required: true,
+				suggestion: `Add url = "https://example.com" with the homepage of the application the pack deploys.`},
+			"author": {typ: cty.String, required: true,
+				suggestion: `Add author with the name of the author or maintainer of the pack.`},
+		},
+		suggestion: `Add an app block with the url and author of the application the pack deploys.`,
+		misplaced: map[string]string{
+			"version": `The app block does not support a version. Set the version of the pack using version within the pack block.`,
+			"name":    `The app block does not support a name. Set the name of the pack using name within the pack block.`,
+		},
+	},
+	"pack": {
+		required: true,
+		attributes: map[string]metadataAttribute{
+			"name": {typ: cty.String, required: true,
+				suggestion: `Add name with the name of the pack, which usually matches its directory.`},
+			"description": {typ: cty.String},
+			"url": {typ: cty.String, required: true,
+				suggestion: `Add url with the location of the pack, such as its registry repository.`},
+			"version": {typ: cty.String, required: true,
+				suggestion: `Add version = "0.0.1" with the version of the pack.`},
+		},
+		suggestion: `Add a pack block with the name, url and version of the pack.`,
+		misplaced: map[string]string{
+			"author": `The pack block does not support an author. Set the author using author within the app block.`,
+		},
+	},
+	"dependency": {
+		labeled:  true,
+		multiple: true,
+		attributes: map[string]metadataAttribute{
+			"source":  {typ: cty.String},
+			"enabled": {typ: cty.Bool},
+		},
+	},
+	"template": {
+		labeled:  true,
+		multiple: true,
+		attributes: map[string]metadataAttribute{
+			"output_path": {typ: cty.String},
+			"executable":  {typ: cty.Bool},
+		},
+	},
+}
+
+// ValidateMetadataFile validates the content of a pack's metadata file,
+// named filename, against the metadata schema. Unlike decoding the file,
+// every problem is reported, with the field and position it relates to and,
+// for common mistakes, a suggestion of how to fix it. A *MetadataError is
+// returned if any problems are found.
+func ValidateMetadataFile(filename string, src []byte) error {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+
+	var violations []*MetadataViolation
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagError {
+			violations = append(violations, &MetadataViolation{
+				Detail:  diagnosticDetail(diag),
+				Subject: diag.Subject,
+			})
+		}
+	}
+	if len(violations) > 0 {
+		return &MetadataError{File: filename, Violations: violations}
+	}
+
+	body := file.Body.(*hclsyntax.Body)
+
+	for _, name := range sortedAttributeNames(body.Attributes) {
+		attr := body.Attributes[name]
+		violations = append(violations, &MetadataViolation{
+			Field:      name,
+			Detail:     fmt.Sprintf("Unexpected attribute %q; the metadata file only contains blocks.", name),
+			Suggestion: closestSuggestion(name, sortedSchemaNames()),
+			Subject:    attr.SrcRange.Ptr(),
+		})
+	}
+
+	counts := make(map[string]int)
+	for _, block := range body.Blocks {
+		schema, ok := metadataSchema[block.Type]
+		if !ok {
+			violations = append(violations, &MetadataViolation{
+				Field:      block.Type,
+				Detail:     fmt.Sprintf("Unexpected block %q.", block.Type),
+				Suggestion: closestSuggestion(block.Type, sortedSchemaNames()),
+				Subject:    block.TypeRange.Ptr(),
+			})
+			continue
+		}
+
+		counts[block.Type]++
+		if counts[block.Type] > 1 && !schema.multiple {
+			violations = append(violations, &MetadataViolation{
+				Field:      block.Type,
+				Detail:     fmt.Sprintf("Duplicate %s block; only one is allowed.", block.Type),
+				Suggestion: "Merge the attributes into a single block.",
+				Subject:    block.TypeRange.Ptr(),
+			})
+			continue
+		}
+
+		violations = append(violations, validateMetadataBlock(block, schema)...)
+	}
+
+	for _, name := range sortedSchemaNames() {
+		if metadataSchema[name].required && counts[name] == 0 {
+			violations = append(violations, &MetadataViolation{
+				Field:      name,
+				Detail:     fmt.Sprintf("The %s block is required.", name),
+				Suggestion: metadataSchema[name].suggestion,
+				Subject:    body.SrcRange.Ptr(),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &MetadataError{File: filename, Violations: violations}
+	}
+	return nil
+}
+
+// validateMetadataBlock validates the labels, attributes and nested blocks of
+// the block against its schema.
+func validateMetadataBlock(block *hclsyntax.Block, schema *metadataBlockSchema) []*MetadataViolation {
+	var violations []*MetadataViolation
+
+	field := block.Type
+	switch {
+	case schema.labeled && len(block.Labels) != 1:
+		violations = append(violations, &MetadataViolation{
+			Field:      field,
+			Detail:     fmt.Sprintf("The %s block requires a single name label.", block.Type),
+			Suggestion: fmt.Sprintf(`Declare the block as %s "name" { ... }.`, block.Type),
+			Subject:    block.TypeRange.Ptr(),
+		})
+		return violations
+	case schema.labeled && strings.TrimSpace(block.Labels[0]) == "":
+		violations = append(violations, &MetadataViolation{
+			Field:   field,
+			Detail:  fmt.Sprintf("The name label of the %s block must not be empty.", block.Type),
+			Subject: block.LabelRanges[0].Ptr(),
+		})
+		return violations
+	case schema.labeled:
+		field += "." + block.Labels[0]
+	case len(block.Labels) > 0:
+		violations = append(violations, &MetadataViolation{
+			Field:      field,
+			Detail:     fmt.Sprintf("The %s block does not have labels.", block.Type),
+			Suggestion: fmt.Sprintf(`Declare the block as %s { ... }.`, block.Type),
+			Subject:    block.LabelRanges[0].Ptr(),
+		})
+	}
+
+	for _, nested := range block.Body.Blocks {
+		violations = append(violations, &MetadataViolation{
+			Field:   field + "." + nested.Type,
+			Detail:  fmt.Sprintf("Unexpected block %q; the %s block only contains attributes.", nested.Type, block.Type),
+			Subject: nested.TypeRange.Ptr(),
+		})
+	}
+
+	attrNames := make([]string, 0, len(schema.attributes))
+	for name := range schema.attributes {
+		attrNames = append(attrNames, name)
+	}
+	sort.Strings(attrNames)
+
+	for _, name := range sortedAttributeNames(block.Body.Attributes) {
+		attr := block.Body.Attributes[name]
+		attrSchema, ok := schema.attributes[name]
+		if !ok {
+			suggestion, misplaced := schema.misplaced[name]
+			if !misplaced {
+				suggestion = closestSuggestion(name, attrNames)
+			}
+			violations = append(violations, &MetadataViolation{
+				Field:      field + "." + name,
+				Detail:     fmt.Sprintf("Unexpected attribute %q in the %s block.", name, block.Type),
+				Suggestion: suggestion,
+				Subject:    attr.NameRange.Ptr(),
+			})
+			continue
+		}
+
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			violations = append(violations, &MetadataViolation{
+				Field:   field + "." + name,
+				Detail:  diagnosticDetail(diags[0]),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		if _, err := convert.Convert(val, attrSchema.typ); err != nil || val.IsNull() {
+			violations = append(violations, &MetadataViolation{
+				Field:   field + "." + name,
+				Detail:  fmt.Sprintf("The value must be a %s.", attrSchema.typ.FriendlyName()),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	for _, name := range attrNames {
+		attrSchema := schema.attributes[name]
+		if _, ok := block.Body.Attributes[name]; attrSchema.required && !ok {
+			violations = append(violations, &MetadataViolation{
+				Field:      field + "." + name,
+				Detail:     fmt.Sprintf("The %s attribute is required.", name),
+				Suggestion: attrSchema.suggestion,
+				Subject:    block.DefRange().Ptr(),
+			})
+		}
+	}
+
+	return violations
+}
+
+// diagnosticDetail returns the detail of the diagnostic, falling back to its
+// summary.
+func diagnosticDetail(diag *hcl.Diagnostic) string {
+	if diag.Detail != "" {
+		return diag.Detail
+	}
+	return diag.Summary + "."
+}
+
+// sortedSchemaNames returns the names of the blocks of the metadata schema.
+func sortedSchemaNames() []string {
+	names := make([]string, 0, len(metadataSchema))
+	for name := range metadataSchema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedAttributeNames returns the names of the attributes in source order,
+// so violations are reported in the order they appear in the file.
+func sortedAttributeNames(attrs hclsyntax.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return attrs[names[i]].SrcRange.Start.Byte < attrs[names[j]].SrcRange.Start.Byte
+	})
+	return names
+}
+
+// closestSuggestion returns a suggestion naming the candidate closest to
+// name, if one is close enough to likely be a typo.
+func closestSuggestion(name string, candidates []string) string {
+	if closest := suggest.Closest(name, candidates); closest != "" {
+		return fmt.Sprintf("Did you mean %q?", closest)
+	}
+	return ""
+}
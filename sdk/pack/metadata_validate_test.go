@@ -0,0 +1,153 @@
+package pack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetadataFile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		src            string
+		expectedFields []string
+		suggestion     string
+	}{
+		{
+			name: "valid metadata",
+			src: `
+app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+pack {
+  name    = "example"
+  url     = "https://github.com/hashicorp/nomad-pack-community-registry/example"
+  version = "0.0.1"
+}
+dependency "redis" {
+  source  = "git://example.com/redis"
+  enabled = false
+}
+template "example" {
+  output_path = "example.nomad"
+}
+template "run.sh" {
+  executable = true
+}
+`,
+		},
+		{
+			name: "all violations reported",
+			src: `
+app {
+  url     = "https://example.com"
+  version = "1.0.0"
+}
+pack {
+  name = "example"
+  url  = "https://example.com"
+}
+`,
+			expectedFields: []string{"app.version", "app.author", "pack.version"},
+			suggestion:     "The app block does not support a version. Set the version of the pack using version within the pack block.",
+		},
+		{
+			name:           "missing blocks",
+			src:            ``,
+			expectedFields: []string{"app", "pack"},
+			suggestion:     "Add an app block with the url and author of the application the pack deploys.",
+		},
+		{
+			name: "misspelled attribute",
+			src: `
+app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+pack {
+  name       = "example"
+  url        = "https://example.com"
+  version    = "0.0.1"
+  descripton = "An example."
+}
+`,
+			expectedFields: []string{"pack.descripton"},
+			suggestion:     `Did you mean "description"?`,
+		},
+		{
+			name: "malformed dependencies",
+			src: `
+app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+pack {
+  name    = "example"
+  url     = "https://example.com"
+  version = "0.0.1"
+}
+dependency {}
+dependency "redis" {
+  enabled = "sometimes"
+}
+dependency "" {}
+`,
+			expectedFields: []string{"dependency", "dependency.redis.enabled", "dependency"},
+			suggestion:     `Declare the block as dependency "name" { ... }.`,
+		},
+		{
+			name: "duplicate block",
+			src: `
+app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+pack {
+  name    = "example"
+  url     = "https://example.com"
+  version = "0.0.1"
+}
+`,
+			expectedFields: []string{"app"},
+			suggestion:     "Merge the attributes into a single block.",
+		},
+		{
+			name:           "syntax error",
+			src:            `app {`,
+			expectedFields: []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMetadataFile("metadata.hcl", []byte(tc.src))
+			if len(tc.expectedFields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var metaErr *MetadataError
+			assert.True(t, errors.As(err, &metaErr))
+			assert.True(t, errors.Is(err, ErrInvalidMetadata))
+			assert.Equal(t, "metadata.hcl", metaErr.File)
+
+			var fields []string
+			suggestions := make(map[string]bool)
+			for _, v := range metaErr.Violations {
+				fields = append(fields, v.Field)
+				suggestions[v.Suggestion] = true
+				assert.NotNil(t, v.Subject)
+			}
+			assert.Equal(t, tc.expectedFields, fields)
+			if tc.suggestion != "" {
+				assert.True(t, suggestions[tc.suggestion], "expected suggestion %q", tc.suggestion)
+			}
+		})
+	}
+}
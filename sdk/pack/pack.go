@@ -1,6 +1,20 @@
 package pack
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrOutputTemplateNotFound is returned when the requested output template
+// file does not exist within the pack.
+var ErrOutputTemplateNotFound = errors.New("output template file not found")
+
+// ErrProfileNotFound is returned when the requested profile is not defined
+// by the pack.
+var ErrProfileNotFound = errors.New("profile not found")
 
 // File is an individual file component of a Pack.
 type File struct {
@@ -14,6 +28,11 @@ type File struct {
 
 	// Content is the file contents as a byte array.
 	Content []byte
+
+	// Mode is the permission bits of the file as found on disk. It is used
+	// to carry the intended permissions of a template through to its
+	// rendered output.
+	Mode os.FileMode
 }
 
 // Pack is a single nomad-pack package and contains all the required information to
@@ -40,6 +59,21 @@ type Pack struct {
 	// print.
 	OutputTemplateFile *File
 
+	// OutputTemplateFiles contains all the output template files found in the
+	// root of the pack, keyed by their name. This includes the default output
+	// template, and allows alternative output templates to be selected.
+	OutputTemplateFiles map[string]*File
+
+	// ProfileFiles contains the variable files within the profiles directory
+	// of the pack, keyed by the profile name, which is the file name without
+	// its extension. Each profile is a preset of variable values, such as
+	// for an environment, which can be selected when rendering the pack.
+	ProfileFiles map[string]*File
+
+	// Path is the absolute path of the pack directory. It is empty when the
+	// pack was not loaded from a directory.
+	Path string
+
 	// dependencies are the packs that this pack depends on. There is no
 	// guarantee that this is populated. This is a private field so access can
 	// be controlled by the appropriate functions.
@@ -70,7 +104,7 @@ func (p *Pack) AddDependencies(packs ...*Pack) {
 func (p *Pack) Dependencies() []*Pack { return p.dependencies }
 
 // RootVariableFiles generates a mapping of all root variable files for the
-// pack and all dependencies.
+// pack and all dependencies, including those of dependencies transitively.
 func (p *Pack) RootVariableFiles() map[string]*File {
 
 	// Set up the base output that include the top level packs root variable
@@ -78,14 +112,67 @@ func (p *Pack) RootVariableFiles() map[string]*File {
 	out := map[string]*File{p.Name(): p.RootVariableFile}
 
 	// Iterate the dependency packs and add entries into the variable file
-	// mapping for each.
+	// mapping for each, along with their own dependencies.
 	for _, dep := range p.dependencies {
-		out[dep.Name()] = dep.RootVariableFile
+		for name, file := range dep.RootVariableFiles() {
+			out[name] = file
+		}
 	}
 
 	return out
 }
 
+// OutputTemplate returns the named output template file of the pack. If name
+// is empty, the default output template file is returned, which may be nil if
+// the pack does not have one.
+func (p *Pack) OutputTemplate(name string) (*File, error) {
+	if name == "" {
+		return p.OutputTemplateFile, nil
+	}
+
+	if file, ok := p.OutputTemplateFiles[name]; ok {
+		return file, nil
+	}
+
+	available := make([]string, 0, len(p.OutputTemplateFiles))
+	for fileName := range p.OutputTemplateFiles {
+		available = append(available, fileName)
+	}
+	sort.Strings(available)
+
+	if len(available) == 0 {
+		return nil, fmt.Errorf("%w: %q, the pack has no output templates", ErrOutputTemplateNotFound, name)
+	}
+	return nil, fmt.Errorf("%w: %q, available output templates are: %s",
+		ErrOutputTemplateNotFound, name, strings.Join(available, ", "))
+}
+
+// Profiles returns the names of the profiles defined by the pack, sorted
+// alphabetically.
+func (p *Pack) Profiles() []string {
+	names := make([]string, 0, len(p.ProfileFiles))
+	for name := range p.ProfileFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the variable file of the named profile. If the pack does
+// not define the profile, the error lists the profiles which are available.
+func (p *Pack) Profile(name string) (*File, error) {
+	if file, ok := p.ProfileFiles[name]; ok {
+		return file, nil
+	}
+
+	available := p.Profiles()
+	if len(available) == 0 {
+		return nil, fmt.Errorf("%w: %q, the pack does not define any profiles", ErrProfileNotFound, name)
+	}
+	return nil, fmt.Errorf("%w: %q, available profiles are: %s",
+		ErrProfileNotFound, name, strings.Join(available, ", "))
+}
+
 // Validate the pack for terminal problems that can easily be detected at this
 // stage. Anything that has potential to cause a panic should ideally be caught
 // here.
@@ -115,9 +115,115 @@ func TestPack_RootVariableFiles(t *testing.T) {
 			},
 			name: "multiple dependencies pack",
 		},
+		{
+			inputPack: &Pack{
+				Metadata: &Metadata{
+					Pack: &MetadataPack{
+						Name: "example",
+					},
+				},
+				RootVariableFile: &File{
+					Name: "variables.hcl",
+					Path: "/opt/packs/example/variables.hcl",
+				},
+				dependencies: []*Pack{
+					{
+						Metadata: &Metadata{
+							Pack: &MetadataPack{
+								Name: "dep1",
+							},
+						},
+						RootVariableFile: &File{
+							Name: "variables.hcl",
+							Path: "/opt/packs/dep1/variables.hcl",
+						},
+						dependencies: []*Pack{
+							{
+								Metadata: &Metadata{
+									Pack: &MetadataPack{
+										Name: "nested",
+									},
+								},
+								RootVariableFile: &File{
+									Name: "variables.hcl",
+									Path: "/opt/packs/nested/variables.hcl",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedOutput: map[string]*File{
+				"example": {
+					Name: "variables.hcl",
+					Path: "/opt/packs/example/variables.hcl",
+				},
+				"dep1": {
+					Name: "variables.hcl",
+					Path: "/opt/packs/dep1/variables.hcl",
+				},
+				"nested": {
+					Name: "variables.hcl",
+					Path: "/opt/packs/nested/variables.hcl",
+				},
+			},
+			name: "transitive dependencies pack",
+		},
 	}
 
 	for _, tc := range testCases {
 		assert.Equal(t, tc.expectedOutput, tc.inputPack.RootVariableFiles(), tc.name)
 	}
 }
+
+func TestPack_OutputTemplate(t *testing.T) {
+	defaultFile := &File{Name: "outputs.tpl"}
+	devFile := &File{Name: "outputs-dev.tpl"}
+
+	p := &Pack{
+		OutputTemplateFile: defaultFile,
+		OutputTemplateFiles: map[string]*File{
+			"outputs.tpl":     defaultFile,
+			"outputs-dev.tpl": devFile,
+		},
+	}
+
+	file, err := p.OutputTemplate("")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultFile, file)
+
+	file, err = p.OutputTemplate("outputs-dev.tpl")
+	assert.NoError(t, err)
+	assert.Equal(t, devFile, file)
+
+	_, err = p.OutputTemplate("outputs-prod.tpl")
+	assert.ErrorIs(t, err, ErrOutputTemplateNotFound)
+	assert.EqualError(t, err, `output template file not found: "outputs-prod.tpl", available output templates are: outputs-dev.tpl, outputs.tpl`)
+
+	_, err = (&Pack{}).OutputTemplate("outputs.tpl")
+	assert.ErrorIs(t, err, ErrOutputTemplateNotFound)
+}
+
+func TestPack_Profile(t *testing.T) {
+	prodFile := &File{Name: "profiles/prod.hcl"}
+	devFile := &File{Name: "profiles/dev.json"}
+
+	p := &Pack{
+		ProfileFiles: map[string]*File{
+			"prod": prodFile,
+			"dev":  devFile,
+		},
+	}
+	assert.Equal(t, []string{"dev", "prod"}, p.Profiles())
+
+	file, err := p.Profile("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, prodFile, file)
+
+	_, err = p.Profile("staging")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+	assert.EqualError(t, err, `profile not found: "staging", available profiles are: dev, prod`)
+
+	_, err = (&Pack{}).Profile("prod")
+	assert.EqualError(t, err, `profile not found: "prod", the pack does not define any profiles`)
+}
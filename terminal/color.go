@@ -0,0 +1,34 @@
+package terminal
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/mitchellh/go-glint"
+)
+
+// IsTerminal reports whether the file, such as os.Stdout, is connected to a
+// terminal. Output written to a file which is not a terminal, such as when
+// redirected or piped, should not include styling escape sequences.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// DisableColor disables the color and text styling of all output written by
+// the UI implementations, including input prompts and tables. Color is
+// disabled by default when stdout is not a terminal.
+func DisableColor() { color.NoColor = true }
+
+// ColorDisabled reports whether the color and text styling of output has been
+// disabled.
+func ColorDisabled() bool { return color.NoColor }
+
+// glintStyle applies the style options to the glint component, unless color
+// has been disabled, in which case the component is returned unstyled.
+func glintStyle(c glint.Component, opts ...glint.StyleOption) glint.Component {
+	if ColorDisabled() {
+		return c
+	}
+	return glint.Style(c, opts...)
+}
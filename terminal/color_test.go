@@ -0,0 +1,24 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/mitchellh/go-glint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisableColor(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	text := glint.Text("example")
+
+	color.NoColor = false
+	require.False(t, ColorDisabled())
+	require.NotEqual(t, text, glintStyle(text, glint.Bold()))
+
+	DisableColor()
+	require.True(t, ColorDisabled())
+	require.Equal(t, text, glintStyle(text, glint.Bold()))
+}
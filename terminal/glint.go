@@ -112,7 +112,7 @@ func (ui *glintUI) Output(msg string, raw ...interface{}) {
 		lines := strings.Split(msg, "\n")
 		if len(lines) > 0 {
 			ui.d.Append(glint.Finalize(
-				glint.Style(
+				glintStyle(
 					glint.Text("! "+lines[0]),
 					cs...,
 				),
@@ -166,7 +166,7 @@ func (ui *glintUI) Output(msg string, raw ...interface{}) {
 	}
 
 	ui.d.Append(glint.Finalize(
-		glint.Style(
+		glintStyle(
 			glint.Text(msg),
 			cs...,
 		),
@@ -243,7 +243,7 @@ func (ui *glintUI) AppendToRow(msg string, raw ...interface{}) {
 		cs = append(cs, glint.Color("lightYellow"))
 	}
 
-	ui.row = append(ui.row, glint.Style(
+	ui.row = append(ui.row, glintStyle(
 		glint.Text(msg),
 		cs...,
 	))
@@ -321,7 +321,7 @@ func (ui *glintUI) Table(tbl *Table, opts ...Option) {
 			entries[i] = ent.Value
 
 			color, ok := colorMapping[ent.Color]
-			if ok {
+			if ok && !ColorDisabled() {
 				colors[i] = tablewriter.Colors{color}
 			}
 		}
@@ -354,7 +354,7 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 	// function in ui.go
 	// Title the error output in red with the subject.
 	d.Append(glint.Layout(
-		glint.Style(
+		glintStyle(
 			glint.Text(fmt.Sprintf("! %s\n", strings.Title(sub))),
 			glint.Color("red"),
 		),
@@ -362,12 +362,12 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Add the error string as well as the error type to the output.
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("\tError:   "), glint.Bold()),
+		glintStyle(glint.Text("\tError:   "), glint.Bold()),
 		glint.Text(err.Error()),
 	).Row())
 
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("\tType:    "), glint.Bold()),
+		glintStyle(glint.Text("\tType:    "), glint.Bold()),
 		glint.Text(fmt.Sprintf("%T", err)),
 	).Row())
 
@@ -375,14 +375,14 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 	// this within the ctx loop.
 	if len(ctx) > 0 {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text("\tContext: "), glint.Bold()),
+			glintStyle(glint.Text("\tContext: "), glint.Bold()),
 		).Row())
 	}
 
 	// Iterate the addition context items and append these to the output.
 	for _, additionCTX := range ctx {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text(fmt.Sprintf("\t         - %s", additionCTX))),
+			glintStyle(glint.Text(fmt.Sprintf("\t         - %s", additionCTX))),
 		).Row())
 	}
 	// Add a new line
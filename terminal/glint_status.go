@@ -55,7 +55,7 @@ func (s *glintStatus) Step(status, msg string) {
 	s.msg = ""
 
 	// Add our final message
-	s.text = append(s.text, glint.Finalize(glint.Style(
+	s.text = append(s.text, glint.Finalize(glintStyle(
 		glint.Text(msg),
 		style...,
 	)))
@@ -49,7 +49,7 @@ func (t *glintTerm) Body(ctx context.Context) glint.Component {
 	for _, row := range t.output {
 		cs = append(cs, glint.Layout(
 			glint.Text(" │ "),
-			glint.Style(
+			glintStyle(
 				glint.Text(strings.TrimRightFunc(string(row), unicode.IsSpace)),
 				glint.Color("lightBlue"),
 			),
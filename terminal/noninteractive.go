@@ -181,7 +181,7 @@ func (ui *nonInteractiveUI) Table(tbl *Table, opts ...Option) {
 			entries[i] = ent.Value
 
 			color, ok := colorMapping[ent.Color]
-			if ok {
+			if ok && !ColorDisabled() {
 				colors[i] = tablewriter.Colors{color}
 			}
 		}
@@ -205,9 +205,16 @@ func (ui *nonInteractiveUI) Error(msg string) {
 // ErrorWithContext satisfies the ErrorWithContext function on the UI
 // interface.
 func (ui *nonInteractiveUI) ErrorWithContext(err error, sub string, ctx ...string) {
-	ui.Error(strings.Title(sub))
-	ui.Error("  Error: " + err.Error())
-	ui.Error("  Context:")
+	errorWithContext(ui.Error, err, sub, ctx...)
+}
+
+// errorWithContext outputs the error and its context as plain text using
+// the passed error output function, aligning the context entries on their
+// colons.
+func errorWithContext(outputError func(string), err error, sub string, ctx ...string) {
+	outputError(strings.Title(sub))
+	outputError("  Error: " + err.Error())
+	outputError("  Context:")
 	max := 0
 	for _, entry := range ctx {
 		if loc := strings.Index(entry, ":") + 1; loc > max {
@@ -216,7 +223,7 @@ func (ui *nonInteractiveUI) ErrorWithContext(err error, sub string, ctx ...strin
 	}
 	for _, entry := range ctx {
 		padding := max - strings.Index(entry, ":") + 1
-		ui.Error("  " + strings.Repeat(" ", padding) + entry)
+		outputError("  " + strings.Repeat(" ", padding) + entry)
 	}
 }
 
@@ -0,0 +1,74 @@
+package terminal
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ByteProgress displays the progress of writing a known number of bytes,
+// such as a large file, as a step within a StepGroup. The step is only
+// updated when the percentage written changes, so it can be updated after
+// every chunk without flooding the display.
+type ByteProgress struct {
+	mu      sync.Mutex
+	step    Step
+	name    string
+	total   int64
+	percent int
+}
+
+// NewByteProgress adds a step to the group displaying the progress of writing
+// total bytes to the named destination.
+func NewByteProgress(sg StepGroup, name string, total int64) *ByteProgress {
+	p := &ByteProgress{name: name, total: total}
+	p.step = sg.Add(p.message(0))
+	return p
+}
+
+// Update sets the number of bytes written so far.
+func (p *ByteProgress) Update(written int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent := 100
+	if p.total > 0 {
+		percent = int(written * 100 / p.total)
+	}
+	if percent == p.percent {
+		return
+	}
+	p.percent = percent
+	p.step.Update(p.message(written))
+}
+
+// Done marks the write as complete.
+func (p *ByteProgress) Done() {
+	p.step.Status(StatusOK)
+	p.step.Done()
+}
+
+// Abort marks the write as failed, leaving the progress made so far
+// displayed.
+func (p *ByteProgress) Abort() {
+	p.step.Abort()
+}
+
+// message returns the step message after written bytes have been written.
+func (p *ByteProgress) message(written int64) string {
+	return fmt.Sprintf("Writing %s: %s of %s", p.name, FormatBytes(written), FormatBytes(p.total))
+}
+
+// FormatBytes formats the number of bytes using the largest binary unit
+// which keeps the value at least 1, such as 1.5 MiB.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
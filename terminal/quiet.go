@@ -0,0 +1,148 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// quietUI is a UI which discards all output other than errors, which are
+// written to stderr as plain text. It is non-interactive, so prompts are
+// declined.
+type quietUI struct {
+	errors *nonInteractiveUI
+	stderr io.Writer
+}
+
+// QuietUI returns a UI which only outputs errors, writing them to stderr.
+// This is useful when the command is run from a script which only requires
+// its side effects, such as the files it writes.
+func QuietUI(ctx context.Context) UI {
+	return &quietUI{errors: &nonInteractiveUI{}, stderr: os.Stderr}
+}
+
+// Input implements UI
+func (ui *quietUI) Input(input *Input) (string, error) {
+	return "", ErrNonInteractive
+}
+
+// Interactive implements UI
+func (ui *quietUI) Interactive() bool {
+	return false
+}
+
+// Output implements UI. Only output with the error styles is written.
+func (ui *quietUI) Output(msg string, raw ...interface{}) {
+	if _, style, _ := Interpret(msg, raw...); style == ErrorStyle || style == ErrorBoldStyle {
+		ui.errors.Output(msg, append(raw, WithWriter(ui.stderr))...)
+	}
+}
+
+// AppendToRow implements UI
+func (ui *quietUI) AppendToRow(msg string, raw ...interface{}) {
+	if _, style, _ := Interpret(msg, raw...); style == ErrorStyle || style == ErrorBoldStyle {
+		ui.errors.AppendToRow(msg, append(raw, WithWriter(ui.stderr))...)
+	}
+}
+
+// NamedValues implements UI
+func (ui *quietUI) NamedValues(rows []NamedValue, opts ...Option) {}
+
+// OutputWriters implements UI. The writers are those of the process, so
+// output which is explicitly requested, such as a report, can still be
+// written.
+func (ui *quietUI) OutputWriters() (io.Writer, io.Writer, error) {
+	return os.Stdout, ui.stderr, nil
+}
+
+// Status implements UI
+func (ui *quietUI) Status() Status {
+	return &quietStatus{}
+}
+
+// StepGroup implements UI
+func (ui *quietUI) StepGroup() StepGroup {
+	return &quietStepGroup{}
+}
+
+// Table implements UI
+func (ui *quietUI) Table(tbl *Table, opts ...Option) {}
+
+// Debug implements UI
+func (ui *quietUI) Debug(msg string) {}
+
+// Error implements UI
+func (ui *quietUI) Error(msg string) {
+	ui.Output(msg, WithErrorStyle())
+}
+
+// ErrorWithContext satisfies the ErrorWithContext function on the UI
+// interface.
+func (ui *quietUI) ErrorWithContext(err error, sub string, ctx ...string) {
+	errorWithContext(ui.Error, err, sub, ctx...)
+}
+
+// Header implements UI
+func (ui *quietUI) Header(msg string) {}
+
+// Info implements UI
+func (ui *quietUI) Info(msg string) {}
+
+// Success implements UI
+func (ui *quietUI) Success(msg string) {}
+
+// Trace implements UI
+func (ui *quietUI) Trace(msg string) {}
+
+// Warning implements UI
+func (ui *quietUI) Warning(msg string) {}
+
+// WarningBold implements UI
+func (ui *quietUI) WarningBold(msg string) {}
+
+type quietStatus struct{}
+
+func (s *quietStatus) Update(msg string) {}
+
+func (s *quietStatus) Step(status, msg string) {}
+
+func (s *quietStatus) Close() error {
+	return nil
+}
+
+type quietStepGroup struct {
+	wg sync.WaitGroup
+}
+
+// Add implements StepGroup
+func (f *quietStepGroup) Add(str string, args ...interface{}) Step {
+	f.wg.Add(1)
+	return &quietStep{wg: &f.wg}
+}
+
+// Wait implements StepGroup
+func (f *quietStepGroup) Wait() {
+	f.wg.Wait()
+}
+
+type quietStep struct {
+	wg   *sync.WaitGroup
+	once sync.Once
+}
+
+func (f *quietStep) TermOutput() io.Writer {
+	return io.Discard
+}
+
+func (f *quietStep) Update(str string, args ...interface{}) {}
+
+func (f *quietStep) Status(status string) {}
+
+func (f *quietStep) Done() {
+	f.once.Do(f.wg.Done)
+}
+
+func (f *quietStep) Abort() {
+	f.Done()
+}
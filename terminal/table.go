@@ -60,7 +60,7 @@ func (u *basicUI) Table(tbl *Table, opts ...Option) {
 			entries[i] = ent.Value
 
 			color, ok := colorMapping[ent.Color]
-			if ok {
+			if ok && !ColorDisabled() {
 				colors[i] = tablewriter.Colors{color}
 			}
 		}
@@ -260,7 +260,7 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Title the error output in red with the subject.
 	d.Append(glint.Layout(
-		glint.Style(
+		glintStyle(
 			glint.Text(fmt.Sprintf("! %s\n", strings.Title(sub))),
 			glint.Color("red"),
 		),
@@ -268,12 +268,12 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Add the error string as well as the error type to the output.
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("\tError:   "), glint.Bold()),
+		glintStyle(glint.Text("\tError:   "), glint.Bold()),
 		glint.Text(err.Error()),
 	).Row())
 
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("\tType:    "), glint.Bold()),
+		glintStyle(glint.Text("\tType:    "), glint.Bold()),
 		glint.Text(fmt.Sprintf("%T", err)),
 	).Row())
 
@@ -281,14 +281,14 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 	// this within the ctx loop.
 	if len(ctx) > 0 {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text("\tContext: "), glint.Bold()),
+			glintStyle(glint.Text("\tContext: "), glint.Bold()),
 		).Row())
 	}
 
 	// Iterate the addition context items and append these to the output.
 	for _, additionCTX := range ctx {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text(fmt.Sprintf("\t         - %s", additionCTX))),
+			glintStyle(glint.Text(fmt.Sprintf("\t         - %s", additionCTX))),
 		).Row())
 	}
 
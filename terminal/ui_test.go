@@ -2,6 +2,9 @@ package terminal
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -82,3 +85,88 @@ two
 
 	require.Equal(expected, buf.String())
 }
+
+func TestQuietUI(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	ui := &quietUI{errors: &nonInteractiveUI{}, stderr: &buf}
+
+	ui.Output("example.nomad:", WithStyle(BoldStyle))
+	ui.Output("job \"example\" {}")
+	ui.Info("info")
+	ui.Warning("warning")
+	ui.Header("header")
+	ui.Table(&Table{})
+	ui.Error("failed to render")
+	ui.ErrorWithContext(errors.New("not found"), "failed to find pack", "Pack Name: example")
+
+	sg := ui.StepGroup()
+	sg.Add("step").Done()
+	sg.Wait()
+
+	expected := `! failed to render
+! Failed To Find Pack
+!   Error: not found
+!   Context:
+!     Pack Name: example
+`
+	require.Equal(expected, buf.String())
+	require.False(ui.Interactive())
+}
+
+func TestFormatBytes(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("0 B", FormatBytes(0))
+	require.Equal("1023 B", FormatBytes(1023))
+	require.Equal("1.0 KiB", FormatBytes(1024))
+	require.Equal("1.5 MiB", FormatBytes(3<<19))
+	require.Equal("2.0 GiB", FormatBytes(2<<30))
+}
+
+// recordingStep records the messages a Step is updated with.
+type recordingStep struct {
+	updates []string
+	status  string
+	done    bool
+}
+
+func (s *recordingStep) TermOutput() io.Writer { return io.Discard }
+func (s *recordingStep) Update(str string, args ...interface{}) {
+	s.updates = append(s.updates, fmt.Sprintf(str, args...))
+}
+func (s *recordingStep) Status(status string) { s.status = status }
+func (s *recordingStep) Done()                { s.done = true }
+func (s *recordingStep) Abort()               { s.status = StatusError; s.done = true }
+
+type recordingStepGroup struct {
+	step *recordingStep
+}
+
+func (g *recordingStepGroup) Add(str string, args ...interface{}) Step {
+	g.step.Update(str, args...)
+	return g.step
+}
+func (g *recordingStepGroup) Wait() {}
+
+func TestByteProgress(t *testing.T) {
+	require := require.New(t)
+
+	sg := &recordingStepGroup{step: &recordingStep{}}
+	p := NewByteProgress(sg, "example/large.nomad", 4<<20)
+
+	// Updates within the same percentage are not displayed.
+	p.Update(1 << 20)
+	p.Update(1<<20 + 1)
+	p.Update(4 << 20)
+	p.Done()
+
+	require.Equal([]string{
+		"Writing example/large.nomad: 0 B of 4.0 MiB",
+		"Writing example/large.nomad: 1.0 MiB of 4.0 MiB",
+		"Writing example/large.nomad: 4.0 MiB of 4.0 MiB",
+	}, sg.step.updates)
+	require.Equal(StatusOK, sg.step.status)
+	require.True(sg.step.done)
+}